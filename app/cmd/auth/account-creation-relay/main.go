@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	ydb_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/ydb"
+	ymq_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/ymq"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/bratushkadan/floral/internal/auth/setup"
+	"github.com/bratushkadan/floral/pkg/cfg"
+	"github.com/bratushkadan/floral/pkg/logging"
+	ydbpkg "github.com/bratushkadan/floral/pkg/ydb"
+	"github.com/bratushkadan/floral/pkg/ymq"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"go.uber.org/zap"
+)
+
+// Publish account creation notifications from the outbox to the account creations queue
+// until the process is stopped.
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	defer cancel()
+
+	authMethod := cfg.EnvDefault(setup.EnvKeyYdbAuthMethod, ydbpkg.YdbAuthMethodMetadata)
+
+	env := cfg.AssertEnv(
+		setup.EnvKeyYdbEndpoint,
+		setup.EnvKeyAwsAccessKeyId,
+		setup.EnvKeyAwsSecretAccessKey,
+		setup.EnvKeySqsQueueUrlAccountCreations,
+	)
+
+	logger, err := logging.NewZapConf("prod").Build()
+	if err != nil {
+		log.Fatalf("Error setting up zap: %v", err)
+	}
+
+	interval := 5 * time.Second
+	if v, ok := os.LookupEnv(setup.EnvKeyAccountCreationRelayInterval); ok {
+		interval, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("failed to parse account creation relay interval from env", zap.String("env_key", setup.EnvKeyAccountCreationRelayInterval), zap.Error(err))
+		}
+	}
+
+	db, err := ydb.Open(ctx, env[setup.EnvKeyYdbEndpoint], ydbpkg.GetYdbAuthOpts(authMethod)...)
+	if err != nil {
+		logger.Fatal("failed to setup ydb", zap.Error(err))
+	}
+	defer func() {
+		if err := db.Close(ctx); err != nil {
+			logger.Error("failed to close ydb", zap.Error(err))
+		}
+	}()
+
+	sqsClient, err := ymq.New(
+		ctx,
+		env[setup.EnvKeyAwsAccessKeyId],
+		env[setup.EnvKeyAwsSecretAccessKey],
+		env[setup.EnvKeySqsQueueUrlAccountCreations],
+		logger,
+	)
+	if err != nil {
+		logger.Fatal("failed to setup ymq", zap.Error(err))
+	}
+
+	svc, err := service.NewAuthBuilder().
+		AccountCreationNotificationProvider(&ymq_adapter.AccountCreation{
+			Sqs:         sqsClient,
+			SqsQueueUrl: env[setup.EnvKeySqsQueueUrlAccountCreations],
+		}).
+		AccountCreationOutbox(ydb_adapter.NewAccountCreationOutbox(ydb_adapter.AccountCreationOutboxConf{
+			DbDriver: db,
+			Logger:   logger,
+		})).
+		Logger(logger).
+		Build()
+	if err != nil {
+		logger.Fatal("failed to setup auth service", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		res, err := svc.RelayAccountCreationNotifications(ctx, domain.RelayAccountCreationNotificationsReq{})
+		if err != nil {
+			logger.Error("failed to relay account creation notifications", zap.Int("published", res.Published), zap.Int("failed", res.Failed), zap.Error(err))
+		} else if res.Published > 0 || res.Failed > 0 {
+			logger.Info("relayed account creation notifications", zap.Int("published", res.Published), zap.Int("failed", res.Failed))
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("stopped account creation relay")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -6,14 +6,22 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	http_adapter "github.com/bratushkadan/floral/internal/auth/adapters/primary/auth/http"
+	ydb_dynamodb_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/dynamodb"
+	email_resetter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/email/resetter"
 	ydb_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/ydb"
 	ymq_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/ymq"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
 	"github.com/bratushkadan/floral/internal/auth/infrastructure/authn"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/oidc"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/passkey"
 	"github.com/bratushkadan/floral/internal/auth/service"
 	"github.com/bratushkadan/floral/internal/auth/setup"
 	"github.com/bratushkadan/floral/pkg/auth"
@@ -68,10 +76,39 @@ func main() {
 		logger.Fatal("failed to set up password hasher", zap.Error(err))
 	}
 
-	tokenProvider, err := authn.NewTokenProviderBuilder().
+	tokenProviderBuilder := authn.NewTokenProviderBuilder().
 		PublicKey([]byte(env[setup.EnvKeyAuthTokenPublicKey])).
 		PrivateKey([]byte(env[setup.EnvKeyAuthTokenPrivateKey])).
-		Build()
+		KeyId(os.Getenv(setup.EnvKeyAuthTokenKeyId)).
+		Issuer(os.Getenv(setup.EnvKeyAuthTokenIssuer))
+	if v, ok := os.LookupEnv(setup.EnvKeyAuthTokenAudience); ok {
+		tokenProviderBuilder = tokenProviderBuilder.Audience(strings.Split(v, ",")...)
+	}
+	if v, ok := os.LookupEnv(setup.EnvKeyAuthTokenLegacyAcceptedUntil); ok {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			logger.Fatal("failed to parse legacy tokens accepted until time from env", zap.String("env_key", setup.EnvKeyAuthTokenLegacyAcceptedUntil), zap.Error(err))
+		}
+		tokenProviderBuilder = tokenProviderBuilder.LegacyTokensAcceptedUntil(until)
+	}
+	if v, ok := os.LookupEnv(setup.EnvKeyAuthTokenRetiredKeyIds); ok {
+		for _, keyId := range strings.Split(v, ",") {
+			envName := strings.ToUpper(keyId)
+			var notAfter time.Time
+			if v, ok := os.LookupEnv(fmt.Sprintf(setup.EnvKeyAuthTokenRetiredKeyNotAfterFmt, envName)); ok {
+				notAfter, err = time.Parse(time.RFC3339, v)
+				if err != nil {
+					logger.Fatal("failed to parse retired token key not after time from env", zap.String("key_id", keyId), zap.Error(err))
+				}
+			}
+			tokenProviderBuilder = tokenProviderBuilder.RetiredKey(
+				keyId,
+				[]byte(cfg.MustEnv(fmt.Sprintf(setup.EnvKeyAuthTokenRetiredKeyPublicKeyFmt, envName))),
+				notAfter,
+			)
+		}
+	}
+	tokenProvider, err := tokenProviderBuilder.Build()
 	if err != nil {
 		logger.Fatal("failed to setup token provider", zap.Error(err))
 	}
@@ -94,10 +131,18 @@ func main() {
 		PasswordHasher: passwordHasher,
 		Logger:         logger,
 	})
+	var refreshTokensDefaultLimit int
+	if v, ok := os.LookupEnv(setup.EnvKeyRefreshTokensDefaultLimit); ok {
+		refreshTokensDefaultLimit, err = strconv.Atoi(v)
+		if err != nil {
+			logger.Fatal("failed to parse refresh tokens default limit from env", zap.String("env_key", setup.EnvKeyRefreshTokensDefaultLimit), zap.Error(err))
+		}
+	}
 	refreshTokenAdapter := ydb_adapter.NewToken(ydb_adapter.TokenConf{
-		DbDriver: db,
-		IdHasher: tokenIdHasher,
-		Logger:   logger,
+		DbDriver:    db,
+		IdHasher:    tokenIdHasher,
+		Logger:      logger,
+		TokensLimit: refreshTokensDefaultLimit,
 	})
 
 	sqsClient, err := ymq.New(
@@ -115,13 +160,221 @@ func main() {
 		SqsQueueUrl: env[setup.EnvKeySqsQueueUrlAccountCreations],
 	}
 
-	svc, err := service.NewAuthBuilder().
+	authBuilder := service.NewAuthBuilder().
 		AccountProvider(accountAdapter).
 		RefreshTokenProvider(refreshTokenAdapter).
 		TokenProvider(tokenProvider).
 		AccountCreationNotificationProvider(&accountCreationNotificationAdapter).
-		Logger(logger).
-		Build()
+		LoginAttempts(ydb_adapter.NewLoginAttempts(ydb_adapter.LoginAttemptsConf{
+			DbDriver: db,
+			Logger:   logger,
+		})).
+		Logger(logger)
+
+	if policy, ok := os.LookupEnv(setup.EnvKeyRefreshTokensEvictionPolicy); ok {
+		authBuilder = authBuilder.RefreshTokensEvictionPolicy(domain.RefreshTokenEvictionPolicy(policy))
+	}
+	for _, accountType := range []domain.AccountType{domain.AccountTypeUser, domain.AccountTypeSeller, domain.AccountTypeAdmin, domain.AccountTypeService} {
+		envKey := fmt.Sprintf(setup.EnvKeyRefreshTokensLimitFmt, strings.ToUpper(accountType))
+		if v, ok := os.LookupEnv(envKey); ok {
+			limit, err := strconv.Atoi(v)
+			if err != nil {
+				logger.Fatal("failed to parse refresh tokens limit from env", zap.String("env_key", envKey), zap.Error(err))
+			}
+			authBuilder = authBuilder.RefreshTokensLimit(accountType, limit)
+		}
+	}
+
+	if _, ok := os.LookupEnv(setup.EnvKeySqsQueueUrlSecurityEvents); ok {
+		sqsQueueUrl := cfg.MustEnv(setup.EnvKeySqsQueueUrlSecurityEvents)
+		sqsClient, err := ymq.New(
+			ctx,
+			env[setup.EnvKeyAwsAccessKeyId],
+			env[setup.EnvKeyAwsSecretAccessKey],
+			sqsQueueUrl,
+			logger,
+		)
+		if err != nil {
+			logger.Fatal("failed to setup ymq sqs client for publishing security events", zap.Error(err))
+		}
+		authBuilder = authBuilder.SecurityEventNotificationProvider(&ymq_adapter.SecurityEvent{
+			Sqs:         sqsClient,
+			SqsQueueUrl: sqsQueueUrl,
+		})
+	}
+
+	if v, ok := os.LookupEnv(setup.EnvKeyAccountCreationOutboxEnabled); ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			logger.Fatal("failed to parse account creation outbox enabled from env", zap.String("env_key", setup.EnvKeyAccountCreationOutboxEnabled), zap.Error(err))
+		}
+		if enabled {
+			authBuilder = authBuilder.AccountCreationOutbox(ydb_adapter.NewAccountCreationOutbox(ydb_adapter.AccountCreationOutboxConf{
+				DbDriver: db,
+				Logger:   logger,
+			}))
+		}
+	}
+
+	if v, ok := os.LookupEnv(setup.EnvKeyAccountDeletionGracePeriod); ok {
+		gracePeriod, err := time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("failed to parse account deletion grace period from env", zap.String("env_key", setup.EnvKeyAccountDeletionGracePeriod), zap.Error(err))
+		}
+		authBuilder = authBuilder.AccountDeletionGracePeriod(gracePeriod)
+	}
+
+	if v, ok := os.LookupEnv(setup.EnvKeyRolePermissionsSource); ok {
+		switch domain.RolePermissionsSource(v) {
+		case domain.RolePermissionsSourceCode:
+		case domain.RolePermissionsSourceYdb:
+			authBuilder = authBuilder.RolePermissions(ydb_adapter.NewRolePermissions(ydb_adapter.RolePermissionsConf{
+				DbDriver: db,
+				Logger:   logger,
+			}))
+		default:
+			logger.Fatal("failed to setup role permissions", zap.String("env_key", setup.EnvKeyRolePermissionsSource), zap.Error(fmt.Errorf("%w: %s", domain.ErrUnknownRolePermissionsSource, v)))
+		}
+	}
+
+	if _, ok := os.LookupEnv(setup.EnvKeyYdbDocApiEndpoint); ok {
+		tokens, err := ydb_dynamodb_adapter.NewEmailConfirmationTokens(
+			ctx,
+			env[setup.EnvKeyAwsAccessKeyId],
+			env[setup.EnvKeyAwsSecretAccessKey],
+			cfg.MustEnv(setup.EnvKeyYdbDocApiEndpoint),
+			logger,
+		)
+		if err != nil {
+			logger.Fatal("failed to setup email confirmation tokens ydb dynamodb", zap.Error(err))
+		}
+		authBuilder = authBuilder.EmailConfirmationTokens(tokens)
+	}
+
+	_, passwordResetEnabled := os.LookupEnv(setup.EnvKeyPasswordResetUrl)
+	if passwordResetEnabled {
+		tokens, err := ydb_dynamodb_adapter.NewPasswordResetTokens(
+			ctx,
+			env[setup.EnvKeyAwsAccessKeyId],
+			env[setup.EnvKeyAwsSecretAccessKey],
+			cfg.MustEnv(setup.EnvKeyYdbDocApiEndpoint),
+			logger,
+		)
+		if err != nil {
+			logger.Fatal("failed to setup password reset tokens ydb dynamodb", zap.Error(err))
+		}
+
+		sender, err := email_resetter.NewBuilder().
+			SenderEmail(cfg.MustEnv(setup.EnvKeySenderEmail)).
+			SenderPassword(cfg.MustEnv(setup.EnvKeySenderPassword)).
+			ResetUrl(cfg.MustEnv(setup.EnvKeyPasswordResetUrl)).
+			Build()
+		if err != nil {
+			logger.Fatal("failed to setup password reset sender", zap.Error(err))
+		}
+
+		authBuilder = authBuilder.
+			PasswordResetTokens(tokens).
+			PasswordResetSender(sender)
+	}
+
+	_, passkeysEnabled := os.LookupEnv(setup.EnvKeyPasskeyRpId)
+	if passkeysEnabled {
+		rp, err := passkey.NewRelyingParty(passkey.RelyingPartyConf{
+			Id:          cfg.MustEnv(setup.EnvKeyPasskeyRpId),
+			DisplayName: "Floral",
+			Origins:     strings.Split(cfg.MustEnv(setup.EnvKeyPasskeyRpOrigins), ","),
+		})
+		if err != nil {
+			logger.Fatal("failed to setup passkey relying party", zap.Error(err))
+		}
+
+		authBuilder = authBuilder.
+			PasskeyCredentials(ydb_adapter.NewPasskeyCredentials(ydb_adapter.PasskeyCredentialsConf{
+				DbDriver: db,
+				Logger:   logger,
+			})).
+			PasskeyCeremonies(ydb_adapter.NewPasskeyCeremonies(ydb_adapter.PasskeyCeremoniesConf{
+				DbDriver: db,
+				Logger:   logger,
+			})).
+			PasskeyRelyingParty(rp)
+	}
+
+	_, oidcEnabled := os.LookupEnv(setup.EnvKeyOidcIssuer)
+	if oidcEnabled {
+		authBuilder = authBuilder.
+			OAuthClients(ydb_adapter.NewOAuthClients(ydb_adapter.OAuthClientsConf{
+				DbDriver:       db,
+				Logger:         logger,
+				PasswordHasher: passwordHasher,
+			})).
+			AuthorizationCodes(ydb_adapter.NewAuthorizationCodes(ydb_adapter.AuthorizationCodesConf{
+				DbDriver: db,
+				Logger:   logger,
+			})).
+			OidcIssuer(cfg.MustEnv(setup.EnvKeyOidcIssuer)).
+			OidcAuthorizationUrl(cfg.MustEnv(setup.EnvKeyOidcAuthorizationUrl))
+	}
+
+	_, identitiesEnabled := os.LookupEnv(setup.EnvKeyIdentityProviders)
+	if identitiesEnabled {
+		for _, name := range strings.Split(cfg.MustEnv(setup.EnvKeyIdentityProviders), ",") {
+			envName := strings.ToUpper(name)
+			prov, err := oidc.NewIdentityProvider(ctx, oidc.IdentityProviderConf{
+				IssuerUrl:    cfg.MustEnv(fmt.Sprintf(setup.EnvKeyIdentityProviderIssuerUrlFmt, envName)),
+				ClientId:     cfg.MustEnv(fmt.Sprintf(setup.EnvKeyIdentityProviderClientIdFmt, envName)),
+				ClientSecret: cfg.MustEnv(fmt.Sprintf(setup.EnvKeyIdentityProviderClientSecretFmt, envName)),
+				RedirectUrl:  cfg.MustEnv(setup.EnvKeyIdentityProviderRedirectUrl),
+			})
+			if err != nil {
+				logger.Fatal("failed to setup identity provider", zap.String("provider", name), zap.Error(err))
+			}
+			authBuilder = authBuilder.IdentityProvider(name, prov)
+		}
+
+		authBuilder = authBuilder.
+			Identities(ydb_adapter.NewIdentities(ydb_adapter.IdentitiesConf{
+				DbDriver: db,
+				Logger:   logger,
+			})).
+			IdentityLogins(ydb_adapter.NewIdentityLogins(ydb_adapter.IdentityLoginsConf{
+				DbDriver: db,
+				Logger:   logger,
+			}))
+	}
+
+	var revocationsRefreshInterval time.Duration
+	if v, ok := os.LookupEnv(setup.EnvKeyAccessTokenRevocationsRefreshInterval); ok {
+		revocationsRefreshInterval, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("failed to parse access token revocations refresh interval from env", zap.String("env_key", setup.EnvKeyAccessTokenRevocationsRefreshInterval), zap.Error(err))
+		}
+	}
+
+	accessTokenRevocations, err := ydb_adapter.NewAccessTokenRevocations(ctx, ydb_adapter.AccessTokenRevocationsConf{
+		DbDriver:        db,
+		Logger:          logger,
+		RefreshInterval: revocationsRefreshInterval,
+	})
+	if err != nil {
+		logger.Fatal("failed to setup access token revocations", zap.Error(err))
+	}
+	go accessTokenRevocations.Run(ctx)
+
+	authBuilder = authBuilder.
+		ApiKeys(ydb_adapter.NewApiKeys(ydb_adapter.ApiKeysConf{
+			DbDriver:       db,
+			Logger:         logger,
+			PasswordHasher: passwordHasher,
+		})).
+		AuditLog(ydb_adapter.NewAuditLog(ydb_adapter.AuditLogConf{
+			DbDriver: db,
+			Logger:   logger,
+		})).
+		AccessTokenRevocations(accessTokenRevocations)
+
+	svc, err := authBuilder.Build()
 	if err != nil {
 		logger.Fatal("failed to setup auth service", zap.Error(err))
 	}
@@ -148,17 +401,71 @@ func main() {
 	rUsers.Post("/:createAccount", http.HandlerFunc(httpAdapter.RegisterUserHandler))
 	rUsers.Post("/:createSellerAccount", http.HandlerFunc(httpAdapter.RegisterSellerHandler))
 	// Expose this endpoint ONLY internally
-	rUsers.Post("/:createAdminAccount", http.HandlerFunc(httpAdapter.RegisterAdminHandler))
-	// Expose this endpoint ONLY internally
 	rUsers.Post("/:activateAccounts", http.HandlerFunc(httpAdapter.ActivateAccountsHandler))
 	rUsers.Post("/:authenticate", http.HandlerFunc(httpAdapter.AuthenticateHandler))
+	rUsers.Post("/:verifyMfa", http.HandlerFunc(httpAdapter.VerifyMfaHandler))
 	rUsers.Post("/:replaceRefreshToken", http.HandlerFunc(httpAdapter.ReplaceRefreshTokenHandler))
 	rUsers.Post("/:createAccessToken", http.HandlerFunc(httpAdapter.CreateAccessToken))
+	rUsers.Post("/:logout", http.HandlerFunc(httpAdapter.LogoutHandler))
+	rUsers.Post("/:logoutAll", http.HandlerFunc(httpAdapter.LogoutAllHandler))
+	rUsers.Post("/:listSessions", http.HandlerFunc(httpAdapter.ListSessionsHandler))
+	rUsers.Post("/:revokeSession", http.HandlerFunc(httpAdapter.RevokeSessionHandler))
+	rUsers.Post("/:updatePassword", http.HandlerFunc(httpAdapter.UpdatePasswordHandler))
+	rUsers.Post("/:updateEmail", http.HandlerFunc(httpAdapter.UpdateEmailHandler))
+	rUsers.Post("/:enrollMfa", http.HandlerFunc(httpAdapter.EnrollMfaHandler))
+	rUsers.Post("/:confirmMfa", http.HandlerFunc(httpAdapter.ConfirmMfaHandler))
+	rUsers.Post("/:disableMfa", http.HandlerFunc(httpAdapter.DisableMfaHandler))
+	rUsers.Post("/:deleteAccount", http.HandlerFunc(httpAdapter.DeleteAccountHandler))
+	rUsers.Post("/:exportAccountData", http.HandlerFunc(httpAdapter.ExportAccountDataHandler))
+	if passwordResetEnabled {
+		rUsers.Post("/:requestPasswordReset", http.HandlerFunc(httpAdapter.RequestPasswordResetHandler))
+		rUsers.Post("/:resetPassword", http.HandlerFunc(httpAdapter.ResetPasswordHandler))
+	}
+	if passkeysEnabled {
+		rUsers.Post("/:beginPasskeyRegistration", http.HandlerFunc(httpAdapter.BeginPasskeyRegistrationHandler))
+		rUsers.Post("/:finishPasskeyRegistration", http.HandlerFunc(httpAdapter.FinishPasskeyRegistrationHandler))
+		rUsers.Post("/:beginPasskeyLogin", http.HandlerFunc(httpAdapter.BeginPasskeyLoginHandler))
+		rUsers.Post("/:authenticateWithPasskey", http.HandlerFunc(httpAdapter.AuthenticateWithPasskeyHandler))
+		rUsers.Post("/:listPasskeys", http.HandlerFunc(httpAdapter.ListPasskeysHandler))
+		rUsers.Post("/:deletePasskey", http.HandlerFunc(httpAdapter.DeletePasskeyHandler))
+	}
+
+	if identitiesEnabled {
+		rUsers.Post("/:beginIdentityLogin", http.HandlerFunc(httpAdapter.BeginIdentityLoginHandler))
+		rUsers.Post("/:authenticateWithIdentity", http.HandlerFunc(httpAdapter.AuthenticateWithIdentityHandler))
+		rUsers.Post("/:listIdentities", http.HandlerFunc(httpAdapter.ListIdentitiesHandler))
+	}
+
+	if oidcEnabled {
+		rUsers.Post("/:authorize", http.HandlerFunc(httpAdapter.AuthorizeHandler))
+		rUsers.Post("/:createOAuthClient", http.HandlerFunc(httpAdapter.CreateOAuthClientHandler))
+		rUsers.Post("/:deleteOAuthClient", http.HandlerFunc(httpAdapter.DeleteOAuthClientHandler))
+
+		r.Get(http_adapter.OidcConfigurationPath, http.HandlerFunc(httpAdapter.OpenIdConfigurationHandler))
+		r.Post(http_adapter.OidcTokenPath, http.HandlerFunc(httpAdapter.TokenHandler))
+		r.Get(http_adapter.OidcUserInfoPath, http.HandlerFunc(httpAdapter.UserInfoHandler))
+		r.Post(http_adapter.OidcUserInfoPath, http.HandlerFunc(httpAdapter.UserInfoHandler))
+	}
+	r.Get(http_adapter.OidcJwksPath, http.HandlerFunc(httpAdapter.JwksHandler))
+
+	// Admin account management
+	rUsers.Get("/{id}", http.HandlerFunc(httpAdapter.GetAccountHandler))
+	rUsers.Get("/", http.HandlerFunc(httpAdapter.ListAccountsHandler))
+	rUsers.Post("/:suspendAccount", http.HandlerFunc(httpAdapter.SuspendAccountHandler))
+	rUsers.Post("/:unsuspendAccount", http.HandlerFunc(httpAdapter.UnsuspendAccountHandler))
+	rUsers.Post("/:unlockAccount", http.HandlerFunc(httpAdapter.UnlockAccountHandler))
+	rUsers.Post("/:updateAccountType", http.HandlerFunc(httpAdapter.UpdateAccountTypeHandler))
 
-	// Get
-	// rUsers.Get("/{id}")
-	// List
-	// rUsers.Get("/")
+	// Service accounts
+	rUsers.Post("/:createServiceAccount", http.HandlerFunc(httpAdapter.CreateServiceAccountHandler))
+	rUsers.Post("/:createApiKey", http.HandlerFunc(httpAdapter.CreateApiKeyHandler))
+	rUsers.Post("/:listApiKeys", http.HandlerFunc(httpAdapter.ListApiKeysHandler))
+	rUsers.Post("/:rotateApiKey", http.HandlerFunc(httpAdapter.RotateApiKeyHandler))
+	rUsers.Post("/:revokeApiKey", http.HandlerFunc(httpAdapter.RevokeApiKeyHandler))
+	rUsers.Post("/:exchangeApiKey", http.HandlerFunc(httpAdapter.ExchangeApiKeyHandler))
+	rUsers.Post("/:impersonate", http.HandlerFunc(httpAdapter.ImpersonateHandler))
+	rUsers.Post("/:listAuditEvents", http.HandlerFunc(httpAdapter.ListAuditEventsHandler))
+	rUsers.Post("/:revokeAccessTokens", http.HandlerFunc(httpAdapter.RevokeAccessTokensHandler))
 
 	r.Get("/ready", xhttp.HandleReadiness(ctx))
 	r.Get("/health", xhttp.HandleReadiness(ctx))
@@ -169,6 +476,7 @@ func main() {
 	r.Mount("/api", rApi)
 	rApi.Mount("/v1", rV1)
 	rV1.Mount("/users", rUsers)
+	rV1.Post("/tokens:introspect", http.HandlerFunc(httpAdapter.IntrospectTokenHandler))
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%s", Port),
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	ydb_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/ydb"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/bratushkadan/floral/internal/auth/setup"
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/bratushkadan/floral/pkg/cfg"
+	"github.com/bratushkadan/floral/pkg/logging"
+	"github.com/bratushkadan/floral/pkg/resource/idhash"
+	ydbpkg "github.com/bratushkadan/floral/pkg/ydb"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"go.uber.org/zap"
+)
+
+// Create the admin account or rotate its password if it already exists.
+// The account is activated right away. Meant to be run from CI to seed
+// environments, so that admin account creation is never exposed over HTTP.
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	defer cancel()
+
+	authMethod := cfg.EnvDefault(setup.EnvKeyYdbAuthMethod, ydbpkg.YdbAuthMethodMetadata)
+
+	env := cfg.AssertEnv(
+		setup.EnvKeyYdbEndpoint,
+		setup.EnvKeyAccountIdHashSalt,
+		setup.EnvKeyTokenIdHashSalt,
+		setup.EnvKeyPasswordHashSalt,
+		setup.EnvKeyAdminName,
+		setup.EnvKeyAdminEmail,
+		setup.EnvKeyAdminPassword,
+	)
+
+	logger, err := logging.NewZapConf("prod").Build()
+	if err != nil {
+		log.Fatalf("Error setting up zap: %v", err)
+	}
+
+	accountIdHasher, err := idhash.New(env[setup.EnvKeyAccountIdHashSalt], idhash.WithPrefix("ie"))
+	if err != nil {
+		logger.Fatal("failed to set up account id hasher")
+	}
+	tokenIdHasher, err := idhash.New(env[setup.EnvKeyTokenIdHashSalt])
+	if err != nil {
+		logger.Fatal("failed to set up token id hasher")
+	}
+	passwordHasher, err := auth.NewPasswordHasher(env[setup.EnvKeyPasswordHashSalt])
+	if err != nil {
+		logger.Fatal("failed to set up password hasher", zap.Error(err))
+	}
+
+	db, err := ydb.Open(ctx, env[setup.EnvKeyYdbEndpoint], ydbpkg.GetYdbAuthOpts(authMethod)...)
+	if err != nil {
+		logger.Fatal("failed to setup ydb", zap.Error(err))
+	}
+	defer func() {
+		if err := db.Close(ctx); err != nil {
+			logger.Error("failed to close ydb", zap.Error(err))
+		}
+	}()
+
+	svc, err := service.NewAuthBuilder().
+		AccountProvider(ydb_adapter.NewAccount(ydb_adapter.AccountConf{
+			DbDriver:       db,
+			IdHasher:       accountIdHasher,
+			PasswordHasher: passwordHasher,
+			Logger:         logger,
+		})).
+		RefreshTokenProvider(ydb_adapter.NewToken(ydb_adapter.TokenConf{
+			DbDriver: db,
+			IdHasher: tokenIdHasher,
+			Logger:   logger,
+		})).
+		Logger(logger).
+		Build()
+	if err != nil {
+		logger.Fatal("failed to setup auth service", zap.Error(err))
+	}
+
+	res, err := svc.CreateAdmin(ctx, domain.CreateAdminReq{
+		Name:     env[setup.EnvKeyAdminName],
+		Email:    env[setup.EnvKeyAdminEmail],
+		Password: env[setup.EnvKeyAdminPassword],
+	})
+	if err != nil {
+		logger.Fatal("failed to bootstrap admin account", zap.Error(err))
+	}
+	if res.Created {
+		logger.Info("created admin account", zap.String("id", res.Id), zap.String("email", res.Email))
+		return
+	}
+	logger.Info("rotated admin account password", zap.String("id", res.Id), zap.String("email", res.Email))
+}
@@ -241,6 +241,24 @@ func runAccountTests(ctx context.Context, accountIdHasher idhash.IdHasher, logge
 		zap.Any("expires_at", createAccountTokenRes.ExpiresAt),
 	)
 
+	logger.Info("replace already replaced refresh token to trigger reuse detection")
+	_, err = svc.ReplaceRefreshToken(ctx, domain.ReplaceRefreshTokenReq{
+		RefreshToken: authenticateRes.RefreshToken,
+	})
+	if !errors.Is(err, domain.ErrRefreshTokenReused) {
+		return fmt.Errorf("expected replacing already replaced refresh token to fail with reuse error, got: %v", err)
+	}
+	logger.Info("refresh token reuse detected - as expected")
+
+	logger.Info("create access token with the refresh token from the revoked family")
+	_, err = svc.CreateAccessToken(ctx, domain.CreateAccessTokenReq{
+		RefreshToken: replaceRefreshTokenRes.RefreshToken,
+	})
+	if !errors.Is(err, domain.ErrTokenRevoked) {
+		return fmt.Errorf("expected create access token with the refresh token from the revoked family to fail, got: %v", err)
+	}
+	logger.Info("failed to create access token with the refresh token from the revoked family - as expected")
+
 	logger.Info("authenticate multiple times to issue multiple refresh tokens and check whether older tokens are deleted")
 	n := 6
 	authMultTimesResponses := make([]domain.AuthenticateRes, 0, n)
@@ -297,6 +315,23 @@ func runAccountTests(ctx context.Context, accountIdHasher idhash.IdHasher, logge
 		return errors.New("refresh token must have been deleted, but it hasn't")
 	}
 
+	logger.Info("log out of session")
+	logoutToken := authMultTimesResponses[len(authMultTimesResponses)-1].RefreshToken
+	if _, err := svc.Logout(ctx, domain.LogoutReq{RefreshToken: logoutToken}); err != nil {
+		return fmt.Errorf("failed to log out: %v", err)
+	}
+	if _, err := svc.CreateAccessToken(ctx, domain.CreateAccessTokenReq{RefreshToken: logoutToken}); !errors.Is(err, domain.ErrTokenRevoked) {
+		return fmt.Errorf("expected create access token with the logged out refresh token to fail, got: %v", err)
+	}
+	logger.Info("logged out of session")
+
+	accountRefreshTokensRes, err = refreshTokenAdapter.List(ctx, domain.RefreshTokenListDTOInput{
+		AccountId: resp.Id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list refresh tokens: %v", err)
+	}
+
 	logger.Info("delete refresh tokens by account id")
 	deleteAccountTokensRes, err := refreshTokenAdapter.DeleteByAccountId(ctx, domain.RefreshTokenDeleteByAccountIdDTOInput{
 		Id: accByEmail.Id,
@@ -331,13 +366,6 @@ func runAccountTests(ctx context.Context, accountIdHasher idhash.IdHasher, logge
 	}
 	logger.Info("created admin", zap.Any("admin", createAdminRes))
 
-	logger.Info("activate admin account")
-	_, err = svc.ActivateAccounts(ctx, domain.ActivateAccountsReq{Emails: []string{createAdminRes.Email}})
-	if err != nil {
-		return fmt.Errorf("failed to active admin account: %v", err)
-	}
-	logger.Info("activated admin account")
-
 	logger.Info("authneticate admin")
 	authneticateAdminRes, err := svc.Authenticate(ctx, domain.AuthenticateReq{
 		Email:    adminEmail,
@@ -357,6 +385,27 @@ func runAccountTests(ctx context.Context, accountIdHasher idhash.IdHasher, logge
 	}
 	logger.Info("created access token for admin")
 
+	logger.Info("list admin sessions")
+	listSessionsRes, err := svc.ListSessions(ctx, domain.ListSessionsReq{
+		AccessToken: accessTokenRes.AccessToken,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list admin sessions: %v", err)
+	}
+	if len(listSessionsRes.Sessions) != 1 {
+		return fmt.Errorf("expected admin to have exactly 1 session, got %d", len(listSessionsRes.Sessions))
+	}
+	logger.Info("listed admin sessions", zap.Any("sessions", listSessionsRes.Sessions))
+
+	logger.Info("revoke session that does not belong to admin")
+	if _, err := svc.RevokeSession(ctx, domain.RevokeSessionReq{
+		AccessToken: accessTokenRes.AccessToken,
+		SessionId:   deletedRefToken.Id,
+	}); !errors.Is(err, domain.ErrSessionNotFound) {
+		return fmt.Errorf("expected revoking foreign session to fail with session not found error, got: %v", err)
+	}
+	logger.Info("failed to revoke session that does not belong to admin - as expected")
+
 	sellerEmail := fmt.Sprintf("seller-%d@seller.com", time.Now().Unix())
 	sellerPassword := uuid.New().String()[:24]
 	logger.Info("create seller using admin's access token")
@@ -367,7 +416,7 @@ func runAccountTests(ctx context.Context, accountIdHasher idhash.IdHasher, logge
 		AccessToken: accessTokenRes.AccessToken,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create seller using admin's access token", err)
+		return fmt.Errorf("failed to create seller using admin's access token: %v", err)
 	}
 	logger.Info("created seller using admin's access token", zap.Any("seller", createSellerRes))
 
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	ydb_dynamodb_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/dynamodb"
+	ydb_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/ydb"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/bratushkadan/floral/internal/auth/setup"
+	"github.com/bratushkadan/floral/pkg/cfg"
+	"github.com/bratushkadan/floral/pkg/logging"
+	"github.com/bratushkadan/floral/pkg/resource/idhash"
+	ydbpkg "github.com/bratushkadan/floral/pkg/ydb"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"go.uber.org/zap"
+)
+
+// Purge accounts deleted more than the deletion grace period ago.
+// Meant to be run periodically.
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	defer cancel()
+
+	authMethod := cfg.EnvDefault(setup.EnvKeyYdbAuthMethod, ydbpkg.YdbAuthMethodMetadata)
+
+	env := cfg.AssertEnv(
+		setup.EnvKeyYdbEndpoint,
+		setup.EnvKeyYdbDocApiEndpoint,
+		setup.EnvKeyAwsAccessKeyId,
+		setup.EnvKeyAwsSecretAccessKey,
+		setup.EnvKeyAccountIdHashSalt,
+	)
+
+	logger, err := logging.NewZapConf("prod").Build()
+	if err != nil {
+		log.Fatalf("Error setting up zap: %v", err)
+	}
+
+	accountIdHasher, err := idhash.New(env[setup.EnvKeyAccountIdHashSalt], idhash.WithPrefix("ie"))
+	if err != nil {
+		logger.Fatal("failed to set up account id hasher")
+	}
+
+	db, err := ydb.Open(ctx, env[setup.EnvKeyYdbEndpoint], ydbpkg.GetYdbAuthOpts(authMethod)...)
+	if err != nil {
+		logger.Fatal("failed to setup ydb", zap.Error(err))
+	}
+	defer func() {
+		if err := db.Close(ctx); err != nil {
+			logger.Error("failed to close ydb", zap.Error(err))
+		}
+	}()
+
+	emailConfirmationTokens, err := ydb_dynamodb_adapter.NewEmailConfirmationTokens(
+		ctx,
+		env[setup.EnvKeyAwsAccessKeyId],
+		env[setup.EnvKeyAwsSecretAccessKey],
+		env[setup.EnvKeyYdbDocApiEndpoint],
+		logger,
+	)
+	if err != nil {
+		logger.Fatal("failed to setup email confirmation tokens ydb dynamodb", zap.Error(err))
+	}
+
+	authBuilder := service.NewAuthBuilder().
+		AccountProvider(ydb_adapter.NewAccount(ydb_adapter.AccountConf{
+			DbDriver: db,
+			IdHasher: accountIdHasher,
+			Logger:   logger,
+		})).
+		EmailConfirmationTokens(emailConfirmationTokens).
+		Logger(logger)
+
+	if v, ok := os.LookupEnv(setup.EnvKeyAccountDeletionGracePeriod); ok {
+		gracePeriod, err := time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("failed to parse account deletion grace period from env", zap.String("env_key", setup.EnvKeyAccountDeletionGracePeriod), zap.Error(err))
+		}
+		authBuilder = authBuilder.AccountDeletionGracePeriod(gracePeriod)
+	}
+
+	svc, err := authBuilder.Build()
+	if err != nil {
+		logger.Fatal("failed to setup auth service", zap.Error(err))
+	}
+
+	res, err := svc.PurgeDeletedAccounts(ctx, domain.PurgeDeletedAccountsReq{})
+	if err != nil {
+		logger.Fatal("failed to purge deleted accounts", zap.Int("purged_accounts", res.PurgedAccounts), zap.Error(err))
+	}
+	logger.Info("purged deleted accounts", zap.Int("purged_accounts", res.PurgedAccounts))
+}
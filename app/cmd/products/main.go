@@ -7,13 +7,16 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/bratushkadan/floral/internal/auth/middleware"
 	"github.com/bratushkadan/floral/internal/products/presentation"
 	oapi_codegen "github.com/bratushkadan/floral/internal/products/presentation/generated"
 	"github.com/bratushkadan/floral/pkg/cfg"
@@ -26,6 +29,15 @@ var (
 	Port = cfg.EnvDefault("PORT", "8080")
 )
 
+const (
+	// Access tokens are verified with the account service key set, or with the public key if set.
+	EnvKeyAuthJwksUrl        = "APP_AUTH_JWKS_URL"
+	EnvKeyAuthTokenPublicKey = "APP_AUTH_TOKEN_PUBLIC_KEY"
+	EnvKeyAuthTokenIssuer    = "APP_AUTH_TOKEN_ISSUER"
+	// Comma separated, tokens must be intended for any of the audiences.
+	EnvKeyAuthTokenAudience = "APP_AUTH_TOKEN_AUDIENCE"
+)
+
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
 	defer cancel()
@@ -52,7 +64,21 @@ func main() {
 	r.GET("/ready", readinessHandler)
 	r.GET("/health", readinessHandler)
 
-	apiImpl := &presentation.ApiImpl{Logger: logger}
+	authConf := middleware.AuthConf{
+		PublicKey: []byte(os.Getenv(EnvKeyAuthTokenPublicKey)),
+		JwksUrl:   os.Getenv(EnvKeyAuthJwksUrl),
+		Issuer:    os.Getenv(EnvKeyAuthTokenIssuer),
+		Logger:    logger,
+	}
+	if v, ok := os.LookupEnv(EnvKeyAuthTokenAudience); ok {
+		authConf.Audience = strings.Split(v, ",")
+	}
+	authMiddleware, err := middleware.NewAuth(authConf)
+	if err != nil {
+		logger.Fatal("failed to setup auth middleware", zap.Error(err))
+	}
+
+	apiImpl := &presentation.ApiImpl{Logger: logger, Auth: authMiddleware}
 
 	oapi_codegen.RegisterHandlersWithOptions(r, apiImpl, oapi_codegen.GinServerOptions{
 		ErrorHandler: apiImpl.ErrorHandler,
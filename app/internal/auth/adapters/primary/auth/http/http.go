@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/bratushkadan/floral/internal/auth/core/domain"
 	"github.com/bratushkadan/floral/pkg/shared/api"
+	"github.com/bratushkadan/floral/pkg/xhttp"
 	"github.com/bratushkadan/floral/pkg/yc/serverless/ymq"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 )
@@ -76,6 +79,142 @@ var (
 		Code:    10,
 		Message: "refresh token to replace not found",
 	}
+	ErrHttpRefreshTokenReused = HttpError{
+		Code:    11,
+		Message: "refresh token has already been used, all sessions of the token were revoked",
+	}
+	ErrHttpSessionNotFound = HttpError{
+		Code:    12,
+		Message: "session not found",
+	}
+	ErrHttpRefreshTokensLimitReached = HttpError{
+		Code:    13,
+		Message: "active sessions limit reached, revoke some of the sessions to sign in",
+	}
+	ErrHttpInvalidPasswordResetToken = HttpError{
+		Code:    14,
+		Message: "invalid password reset token",
+	}
+	ErrHttpPasswordResetTokenExpired = HttpError{
+		Code:    15,
+		Message: "password reset token expired",
+	}
+	ErrHttpAccountNotFound = HttpError{
+		Code:    16,
+		Message: "account not found",
+	}
+	ErrHttpAccountSuspended = HttpError{
+		Code:    17,
+		Message: "account suspended",
+	}
+	ErrHttpInvalidAccountType = HttpError{
+		Code:    18,
+		Message: "invalid account type",
+	}
+	ErrHttpTooManyLoginAttempts = HttpError{
+		Code:    19,
+		Message: "too many failed login attempts, try again later",
+	}
+	ErrHttpInvalidMfaCode = HttpError{
+		Code:    20,
+		Message: "invalid mfa code",
+	}
+	ErrHttpInvalidMfaChallengeToken = HttpError{
+		Code:    21,
+		Message: "invalid mfa challenge token",
+	}
+	ErrHttpMfaAlreadyEnabled = HttpError{
+		Code:    22,
+		Message: "mfa is already enabled",
+	}
+	ErrHttpMfaNotEnrolled = HttpError{
+		Code:    23,
+		Message: "mfa enrollment has not been started",
+	}
+	ErrHttpMfaNotEnabled = HttpError{
+		Code:    24,
+		Message: "mfa is not enabled",
+	}
+	ErrHttpInvalidPasskeyCeremony = HttpError{
+		Code:    25,
+		Message: "invalid or expired passkey ceremony",
+	}
+	ErrHttpPasskeyVerificationFailed = HttpError{
+		Code:    26,
+		Message: "passkey verification failed",
+	}
+	ErrHttpPasskeyNotFound = HttpError{
+		Code:    27,
+		Message: "passkey not found",
+	}
+	ErrHttpInvalidOAuthClient = HttpError{
+		Code:    28,
+		Message: "invalid oauth client",
+	}
+	ErrHttpInvalidRedirectUri = HttpError{
+		Code:    29,
+		Message: "invalid redirect uri",
+	}
+	ErrHttpInvalidAuthorizationRequest = HttpError{
+		Code:    30,
+		Message: "invalid authorization request",
+	}
+	ErrHttpOAuthClientNotFound = HttpError{
+		Code:    31,
+		Message: "oauth client not found",
+	}
+	ErrHttpIdentityProviderNotFound = HttpError{
+		Code:    32,
+		Message: "identity provider not found",
+	}
+	ErrHttpInvalidIdentityLogin = HttpError{
+		Code:    33,
+		Message: "invalid or expired identity provider login",
+	}
+	ErrHttpIdentityVerificationFailed = HttpError{
+		Code:    34,
+		Message: "identity provider verification failed",
+	}
+	ErrHttpIdentityEmailNotVerified = HttpError{
+		Code:    35,
+		Message: "identity provider email is not verified",
+	}
+	ErrHttpInvalidServiceAccountName = HttpError{
+		Code:    36,
+		Message: "invalid service account name, use lowercase letters, digits and hyphens",
+	}
+	ErrHttpServiceAccountExists = HttpError{
+		Code:    37,
+		Message: "service account with the name already exists",
+	}
+	ErrHttpInvalidApiKey = HttpError{
+		Code:    38,
+		Message: "invalid or expired api key",
+	}
+	ErrHttpApiKeyNotFound = HttpError{
+		Code:    39,
+		Message: "api key not found",
+	}
+	ErrHttpInvalidScope = HttpError{
+		Code:    40,
+		Message: "invalid scope",
+	}
+	ErrHttpImpersonationNotAllowed = HttpError{
+		Code:    41,
+		Message: "account can't be impersonated",
+	}
+	ErrHttpImpersonationRefused = HttpError{
+		Code:    42,
+		Message: "operation is not allowed with an impersonation token",
+	}
+	ErrHttpInvalidAuditEventType = HttpError{
+		Code:    43,
+		Message: "invalid audit event type",
+	}
+	ErrHttpInvalidPageToken = HttpError{
+		Code:    44,
+		Message: "invalid page token",
+	}
 )
 
 type Http struct {
@@ -179,68 +318,6 @@ func (f *Http) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-type RegisterAdminHandlerReq struct {
-	Name     string `json:"name" validate:"required,min=2,max=40"`
-	Password string `json:"password" validate:"required,min=8,max=24"`
-	Email    string `json:"email" validate:"required,email"`
-}
-type RegisterAdminHandlerRes struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
-}
-
-func (f *Http) RegisterAdminHandler(w http.ResponseWriter, r *http.Request) {
-	var reqData RegisterAdminHandlerReq
-	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
-		f.l.Info("failed to decode request body for handler RegisterAdminHandler", zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
-		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
-			f.l.Error("failed to encode error response", zap.Error(err))
-		}
-		return
-	}
-	if err := f.validateJson.Struct(reqData); err != nil {
-		f.l.Info("invalid request struct", zap.String("handler", "RegisterAdminHandler"), zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
-		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
-			f.l.Error("failed to encode error response", zap.Error(err))
-		}
-		return
-	}
-
-	user, err := f.svc.CreateAdmin(r.Context(), domain.CreateAdminReq{
-		Name:     reqData.Name,
-		Password: reqData.Password,
-		Email:    reqData.Email,
-	})
-	if err != nil {
-		if errors.Is(err, domain.ErrEmailIsInUse) {
-			w.WriteHeader(http.StatusConflict)
-			if err := json.NewEncoder(w).Encode(NewHttpErrors(NewErrHttpEmailIsInUse(reqData.Email))); err != nil {
-				f.l.Error("failed to encode error response", zap.Error(err))
-			}
-			return
-		}
-		f.l.Error("unexpected error occurred in handler RegisterAdminHandler", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
-			f.l.Error("unexpected error occurred in handler AuthenticateHandler", zap.Error(err))
-		}
-		return
-	}
-
-	if err := json.NewEncoder(w).Encode(&RegisterAdminHandlerRes{
-		Id:   user.Id,
-		Name: user.Name,
-	}); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
-			f.l.Error("unexpected error occurred in handler AuthenticateHandler", zap.Error(err))
-		}
-		return
-	}
-}
-
 type RegisterSellerHandlerReq struct {
 	Seller struct {
 		Name     string `json:"name" validate:"required,min=2,max=40"`
@@ -398,6 +475,8 @@ type AuthenticateHandlerReq struct {
 type AuthenticateHandlerRes struct {
 	RefreshToken string    `json:"refresh_token"`
 	ExpiresAt    time.Time `json:"expires_at"`
+	// Set instead of the refresh token for accounts with MFA enabled.
+	MfaChallengeToken string `json:"mfa_challenge_token,omitempty"`
 }
 
 func (f *Http) AuthenticateHandler(w http.ResponseWriter, r *http.Request) {
@@ -420,8 +499,10 @@ func (f *Http) AuthenticateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	res, err := f.svc.Authenticate(r.Context(), domain.AuthenticateReq{
-		Email:    reqData.Email,
-		Password: reqData.Password,
+		Email:     reqData.Email,
+		Password:  reqData.Password,
+		UserAgent: r.UserAgent(),
+		Ip:        xhttp.ClientIp(r),
 	})
 	if err != nil {
 		if errors.Is(err, domain.ErrInvalidCredentials) {
@@ -438,6 +519,27 @@ func (f *Http) AuthenticateHandler(w http.ResponseWriter, r *http.Request) {
 			}
 			return
 		}
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountSuspended)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrRefreshTokensLimitReached) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpRefreshTokensLimitReached)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrTooManyLoginAttempts) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpTooManyLoginAttempts)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
 		f.l.Error("unexpected error occurred in handler AuthenticateHandler", zap.Error(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
@@ -447,8 +549,9 @@ func (f *Http) AuthenticateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewEncoder(w).Encode(&AuthenticateHandlerRes{
-		RefreshToken: res.RefreshToken,
-		ExpiresAt:    res.ExpiresAt,
+		RefreshToken:      res.RefreshToken,
+		ExpiresAt:         res.ExpiresAt,
+		MfaChallengeToken: res.MfaChallengeToken,
 	}); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
@@ -487,6 +590,8 @@ func (f *Http) ReplaceRefreshTokenHandler(w http.ResponseWriter, r *http.Request
 
 	res, err := f.svc.ReplaceRefreshToken(r.Context(), domain.ReplaceRefreshTokenReq{
 		RefreshToken: reqData.RefreshToken,
+		UserAgent:    r.UserAgent(),
+		Ip:           xhttp.ClientIp(r),
 	})
 	if err != nil {
 		if errors.Is(err, domain.ErrInvalidRefreshToken) {
@@ -496,6 +601,13 @@ func (f *Http) ReplaceRefreshTokenHandler(w http.ResponseWriter, r *http.Request
 			}
 			return
 		}
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpRefreshTokenReused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
 		if errors.Is(err, domain.ErrRefreshTokenToReplaceNotFound) {
 			w.WriteHeader(http.StatusNotFound)
 			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpRefreshTokenToReplaceNotFound)); err != nil {
@@ -554,6 +666,20 @@ func (f *Http) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
 		RefreshToken: reqData.RefreshToken,
 	})
 	if err != nil {
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountSuspended)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrTokenRevoked) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidRefreshToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
 		if errors.Is(err, domain.ErrInvalidAccessToken) {
 			w.WriteHeader(http.StatusUnauthorized)
 			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidRefreshToken)); err != nil {
@@ -582,3 +708,3221 @@ func (f *Http) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+type LogoutHandlerReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+type LogoutHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData LogoutHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler LogoutHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "LogoutHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.Logout(r.Context(), domain.LogoutReq{
+		RefreshToken: reqData.RefreshToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidRefreshToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler LogoutHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&LogoutHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type LogoutAllHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+type LogoutAllHandlerRes struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+func (f *Http) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData LogoutAllHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler LogoutAllHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "LogoutAllHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.LogoutAll(r.Context(), domain.LogoutAllReq{
+		AccessToken: reqData.AccessToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler LogoutAllHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&LogoutAllHandlerRes{
+		RevokedSessions: res.RevokedSessions,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ListSessionsHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+type ListSessionsHandlerRes struct {
+	Sessions []ListSessionsHandlerResSession `json:"sessions"`
+}
+type ListSessionsHandlerResSession struct {
+	Id         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func (f *Http) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ListSessionsHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ListSessionsHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ListSessionsHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ListSessions(r.Context(), domain.ListSessionsReq{
+		AccessToken: reqData.AccessToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ListSessionsHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	sessions := make([]ListSessionsHandlerResSession, 0, len(res.Sessions))
+	for _, s := range res.Sessions {
+		sessions = append(sessions, ListSessionsHandlerResSession{
+			Id:         s.Id,
+			UserAgent:  s.UserAgent,
+			Ip:         s.Ip,
+			CreatedAt:  s.CreatedAt,
+			ExpiresAt:  s.ExpiresAt,
+			LastUsedAt: s.LastUsedAt,
+		})
+	}
+
+	if err := json.NewEncoder(w).Encode(&ListSessionsHandlerRes{
+		Sessions: sessions,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type RevokeSessionHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	SessionId   string `json:"session_id" validate:"required"`
+}
+type RevokeSessionHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData RevokeSessionHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler RevokeSessionHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "RevokeSessionHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.RevokeSession(r.Context(), domain.RevokeSessionReq{
+		AccessToken: reqData.AccessToken,
+		SessionId:   reqData.SessionId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpSessionNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler RevokeSessionHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&RevokeSessionHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type RequestPasswordResetHandlerReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+type RequestPasswordResetHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData RequestPasswordResetHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler RequestPasswordResetHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "RequestPasswordResetHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	if _, err := f.svc.RequestPasswordReset(r.Context(), domain.RequestPasswordResetReq{
+		Email: reqData.Email,
+	}); err != nil {
+		f.l.Error("unexpected error occurred in handler RequestPasswordResetHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&RequestPasswordResetHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ResetPasswordHandlerReq struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=24"`
+}
+type ResetPasswordHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ResetPasswordHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ResetPasswordHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ResetPasswordHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.ResetPassword(r.Context(), domain.ResetPasswordReq{
+		Token:       reqData.Token,
+		NewPassword: reqData.NewPassword,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPasswordResetToken) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidPasswordResetToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPasswordResetTokenExpired) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpPasswordResetTokenExpired)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPasswordTooLong) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ResetPasswordHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&ResetPasswordHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type UpdatePasswordHandlerReq struct {
+	AccessToken  string `json:"access_token" validate:"required"`
+	RefreshToken string `json:"refresh_token"`
+	Password     string `json:"password" validate:"required"`
+	NewPassword  string `json:"new_password" validate:"required,min=8,max=24"`
+}
+type UpdatePasswordHandlerRes struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+func (f *Http) UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData UpdatePasswordHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler UpdatePasswordHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "UpdatePasswordHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.UpdatePassword(r.Context(), domain.UpdatePasswordReq{
+		AccessToken:  reqData.AccessToken,
+		RefreshToken: reqData.RefreshToken,
+		Password:     reqData.Password,
+		NewPassword:  reqData.NewPassword,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidRefreshToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidCredentials)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPasswordTooLong) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler UpdatePasswordHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&UpdatePasswordHandlerRes{
+		RevokedSessions: res.RevokedSessions,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type UpdateEmailHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Password    string `json:"password" validate:"required"`
+	NewEmail    string `json:"new_email" validate:"required,email"`
+}
+type UpdateEmailHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) UpdateEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData UpdateEmailHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler UpdateEmailHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "UpdateEmailHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.UpdateEmail(r.Context(), domain.UpdateEmailReq{
+		AccessToken: reqData.AccessToken,
+		Password:    reqData.Password,
+		NewEmail:    reqData.NewEmail,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidCredentials)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidEmail) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrEmailIsInUse) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(NewErrHttpEmailIsInUse(reqData.NewEmail))); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler UpdateEmailHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&UpdateEmailHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type DeleteAccountHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id"`
+}
+type DeleteAccountHandlerRes struct {
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+func (f *Http) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData DeleteAccountHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler DeleteAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "DeleteAccountHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.DeleteAccount(r.Context(), domain.DeleteAccountReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler DeleteAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&DeleteAccountHandlerRes{
+		PurgeAt: res.PurgeAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ExportAccountDataHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id"`
+}
+type ExportAccountDataHandlerRes struct {
+	domain.ExportAccountDataRes
+}
+
+func (f *Http) ExportAccountDataHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ExportAccountDataHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ExportAccountDataHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ExportAccountDataHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ExportAccountData(r.Context(), domain.ExportAccountDataReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ExportAccountDataHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&ExportAccountDataHandlerRes{
+		ExportAccountDataRes: res,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+// List accounts. Filters and pagination are passed as query parameters:
+// type, activated, created_after, created_before (RFC 3339), page_size and page_token.
+func (f *Http) ListAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := xhttp.BearerToken(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	req := domain.ListAccountsReq{
+		AccessToken: accessToken,
+		PageToken:   r.URL.Query().Get("page_token"),
+	}
+	if err := parseListAccountsQuery(r, &req); err != nil {
+		f.l.Info("invalid query parameters", zap.String("handler", "ListAccountsHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ListAccounts(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ListAccountsHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&res); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+func parseListAccountsQuery(r *http.Request, req *domain.ListAccountsReq) error {
+	q := r.URL.Query()
+
+	if v := q.Get("type"); v != "" {
+		if err := domain.ValidateAccountType(v); err != nil {
+			return err
+		}
+		req.Type = v
+	}
+	if v := q.Get("activated"); v != "" {
+		activated, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse activated: %w", err)
+		}
+		req.Activated = &activated
+	}
+	if v := q.Get("created_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("failed to parse created_after: %w", err)
+		}
+		req.CreatedAfter = t
+	}
+	if v := q.Get("created_before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("failed to parse created_before: %w", err)
+		}
+		req.CreatedBefore = t
+	}
+	if v := q.Get("page_size"); v != "" {
+		pageSize, err := strconv.Atoi(v)
+		if err != nil || pageSize < 0 {
+			return fmt.Errorf("invalid page_size %q", v)
+		}
+		req.PageSize = pageSize
+	}
+
+	return nil
+}
+
+func (f *Http) GetAccountHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := xhttp.BearerToken(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.GetAccount(r.Context(), domain.GetAccountReq{
+		AccessToken: accessToken,
+		AccountId:   chi.URLParam(r, "id"),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler GetAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&res); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type SuspendAccountHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id" validate:"required"`
+}
+type SuspendAccountHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) SuspendAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData SuspendAccountHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler SuspendAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "SuspendAccountHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.SuspendAccount(r.Context(), domain.SuspendAccountReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler SuspendAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&SuspendAccountHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type UnsuspendAccountHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id" validate:"required"`
+}
+type UnsuspendAccountHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) UnsuspendAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData UnsuspendAccountHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler UnsuspendAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "UnsuspendAccountHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.UnsuspendAccount(r.Context(), domain.UnsuspendAccountReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler UnsuspendAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&UnsuspendAccountHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type UpdateAccountTypeHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id" validate:"required"`
+	Type        string `json:"type" validate:"required,oneof=user seller admin"`
+}
+type UpdateAccountTypeHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) UpdateAccountTypeHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData UpdateAccountTypeHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler UpdateAccountTypeHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "UpdateAccountTypeHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.UpdateAccountType(r.Context(), domain.UpdateAccountTypeReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+		Type:        reqData.Type,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidAccountType) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccountType)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler UpdateAccountTypeHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&UpdateAccountTypeHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type UnlockAccountHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id" validate:"required"`
+}
+type UnlockAccountHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) UnlockAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData UnlockAccountHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler UnlockAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "UnlockAccountHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.UnlockAccount(r.Context(), domain.UnlockAccountReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler UnlockAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&UnlockAccountHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type VerifyMfaHandlerReq struct {
+	MfaChallengeToken string `json:"mfa_challenge_token" validate:"required"`
+	Code              string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode      string `json:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
+type VerifyMfaHandlerRes struct {
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (f *Http) VerifyMfaHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData VerifyMfaHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler VerifyMfaHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "VerifyMfaHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.VerifyMfa(r.Context(), domain.VerifyMfaReq{
+		MfaChallengeToken: reqData.MfaChallengeToken,
+		Code:              reqData.Code,
+		RecoveryCode:      reqData.RecoveryCode,
+		UserAgent:         r.UserAgent(),
+		Ip:                xhttp.ClientIp(r),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidMfaChallengeToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidMfaChallengeToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidMfaCode) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidMfaCode)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrMfaNotEnabled) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpMfaNotEnabled)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrTooManyLoginAttempts) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpTooManyLoginAttempts)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountSuspended)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrRefreshTokensLimitReached) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpRefreshTokensLimitReached)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler VerifyMfaHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&VerifyMfaHandlerRes{
+		RefreshToken: res.RefreshToken,
+		ExpiresAt:    res.ExpiresAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type EnrollMfaHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+type EnrollMfaHandlerRes struct {
+	TotpSecret string `json:"totp_secret"`
+	TotpUri    string `json:"totp_uri"`
+}
+
+func (f *Http) EnrollMfaHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData EnrollMfaHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler EnrollMfaHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "EnrollMfaHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.EnrollMfa(r.Context(), domain.EnrollMfaReq{
+		AccessToken: reqData.AccessToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrMfaAlreadyEnabled) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpMfaAlreadyEnabled)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler EnrollMfaHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&EnrollMfaHandlerRes{
+		TotpSecret: res.TotpSecret,
+		TotpUri:    res.TotpUri,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ConfirmMfaHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Code        string `json:"code" validate:"required,numeric,len=6"`
+}
+type ConfirmMfaHandlerRes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (f *Http) ConfirmMfaHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ConfirmMfaHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ConfirmMfaHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ConfirmMfaHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ConfirmMfa(r.Context(), domain.ConfirmMfaReq{
+		AccessToken: reqData.AccessToken,
+		Code:        reqData.Code,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidMfaCode) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidMfaCode)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrMfaAlreadyEnabled) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpMfaAlreadyEnabled)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrMfaNotEnrolled) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpMfaNotEnrolled)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ConfirmMfaHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&ConfirmMfaHandlerRes{
+		RecoveryCodes: res.RecoveryCodes,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type DisableMfaHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Password    string `json:"password" validate:"required,max=24"`
+	Code        string `json:"code" validate:"required,numeric,len=6"`
+}
+type DisableMfaHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) DisableMfaHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData DisableMfaHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler DisableMfaHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "DisableMfaHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.DisableMfa(r.Context(), domain.DisableMfaReq{
+		AccessToken: reqData.AccessToken,
+		Password:    reqData.Password,
+		Code:        reqData.Code,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidCredentials)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidMfaCode) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidMfaCode)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrMfaNotEnabled) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpMfaNotEnabled)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler DisableMfaHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&DisableMfaHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type BeginPasskeyRegistrationHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+type BeginPasskeyRegistrationHandlerRes struct {
+	CeremonyId string `json:"ceremony_id"`
+	// PublicKeyCredentialCreationOptions for navigator.credentials.create().
+	Options   json.RawMessage `json:"options"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+func (f *Http) BeginPasskeyRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData BeginPasskeyRegistrationHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler BeginPasskeyRegistrationHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "BeginPasskeyRegistrationHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.BeginPasskeyRegistration(r.Context(), domain.BeginPasskeyRegistrationReq{
+		AccessToken: reqData.AccessToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler BeginPasskeyRegistrationHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&BeginPasskeyRegistrationHandlerRes{
+		CeremonyId: res.CeremonyId,
+		Options:    res.Options,
+		ExpiresAt:  res.ExpiresAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type FinishPasskeyRegistrationHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	CeremonyId  string `json:"ceremony_id" validate:"required"`
+	Name        string `json:"name" validate:"max=64"`
+	// Response of navigator.credentials.create().
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+type FinishPasskeyRegistrationHandlerRes struct {
+	Passkey PasskeyHandlerRes `json:"passkey"`
+}
+
+func (f *Http) FinishPasskeyRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData FinishPasskeyRegistrationHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler FinishPasskeyRegistrationHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "FinishPasskeyRegistrationHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.FinishPasskeyRegistration(r.Context(), domain.FinishPasskeyRegistrationReq{
+		AccessToken: reqData.AccessToken,
+		CeremonyId:  reqData.CeremonyId,
+		Name:        reqData.Name,
+		Credential:  reqData.Credential,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidPasskeyCeremony) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidPasskeyCeremony)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPasskeyVerificationFailed) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpPasskeyVerificationFailed)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler FinishPasskeyRegistrationHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&FinishPasskeyRegistrationHandlerRes{
+		Passkey: newPasskeyHandlerRes(res.Passkey),
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type PasskeyHandlerRes struct {
+	Id         string    `json:"id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func newPasskeyHandlerRes(p domain.Passkey) PasskeyHandlerRes {
+	return PasskeyHandlerRes{
+		Id:         p.Id,
+		Name:       p.Name,
+		CreatedAt:  p.CreatedAt,
+		LastUsedAt: p.LastUsedAt,
+	}
+}
+
+type BeginPasskeyLoginHandlerRes struct {
+	CeremonyId string `json:"ceremony_id"`
+	// PublicKeyCredentialRequestOptions for navigator.credentials.get().
+	Options   json.RawMessage `json:"options"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+func (f *Http) BeginPasskeyLoginHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := f.svc.BeginPasskeyLogin(r.Context(), domain.BeginPasskeyLoginReq{})
+	if err != nil {
+		f.l.Error("unexpected error occurred in handler BeginPasskeyLoginHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&BeginPasskeyLoginHandlerRes{
+		CeremonyId: res.CeremonyId,
+		Options:    res.Options,
+		ExpiresAt:  res.ExpiresAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type AuthenticateWithPasskeyHandlerReq struct {
+	CeremonyId string `json:"ceremony_id" validate:"required"`
+	// Response of navigator.credentials.get().
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+type AuthenticateWithPasskeyHandlerRes struct {
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (f *Http) AuthenticateWithPasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData AuthenticateWithPasskeyHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler AuthenticateWithPasskeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "AuthenticateWithPasskeyHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.Authenticate(r.Context(), domain.AuthenticateReq{
+		Passkey: &domain.PasskeyAssertion{
+			CeremonyId: reqData.CeremonyId,
+			Credential: reqData.Credential,
+		},
+		UserAgent: r.UserAgent(),
+		Ip:        xhttp.ClientIp(r),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPasskeyCeremony) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidPasskeyCeremony)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPasskeyVerificationFailed) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpPasskeyVerificationFailed)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountSuspended)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrRefreshTokensLimitReached) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpRefreshTokensLimitReached)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrTooManyLoginAttempts) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpTooManyLoginAttempts)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler AuthenticateWithPasskeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&AuthenticateWithPasskeyHandlerRes{
+		RefreshToken: res.RefreshToken,
+		ExpiresAt:    res.ExpiresAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ListPasskeysHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+type ListPasskeysHandlerRes struct {
+	Passkeys []PasskeyHandlerRes `json:"passkeys"`
+}
+
+func (f *Http) ListPasskeysHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ListPasskeysHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ListPasskeysHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ListPasskeysHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ListPasskeys(r.Context(), domain.ListPasskeysReq{
+		AccessToken: reqData.AccessToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ListPasskeysHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	passkeys := make([]PasskeyHandlerRes, 0, len(res.Passkeys))
+	for _, p := range res.Passkeys {
+		passkeys = append(passkeys, newPasskeyHandlerRes(p))
+	}
+
+	if err := json.NewEncoder(w).Encode(&ListPasskeysHandlerRes{
+		Passkeys: passkeys,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type DeletePasskeyHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	PasskeyId   string `json:"passkey_id" validate:"required"`
+}
+type DeletePasskeyHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) DeletePasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData DeletePasskeyHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler DeletePasskeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "DeletePasskeyHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.DeletePasskey(r.Context(), domain.DeletePasskeyReq{
+		AccessToken: reqData.AccessToken,
+		PasskeyId:   reqData.PasskeyId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPasskeyNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpPasskeyNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler DeletePasskeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&DeletePasskeyHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type AuthorizeHandlerReq struct {
+	AccessToken         string `json:"access_token" validate:"required"`
+	ClientId            string `json:"client_id" validate:"required"`
+	RedirectUri         string `json:"redirect_uri" validate:"required"`
+	ResponseType        string `json:"response_type" validate:"required"`
+	Scope               string `json:"scope" validate:"required"`
+	State               string `json:"state" validate:"max=512"`
+	Nonce               string `json:"nonce" validate:"max=512"`
+	CodeChallenge       string `json:"code_challenge" validate:"required"`
+	CodeChallengeMethod string `json:"code_challenge_method" validate:"required"`
+}
+type AuthorizeHandlerRes struct {
+	// Redirect uri with the authorization code and state to send the user agent to.
+	RedirectUri string `json:"redirect_uri"`
+}
+
+func (f *Http) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData AuthorizeHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler AuthorizeHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "AuthorizeHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.Authorize(r.Context(), domain.AuthorizeReq{
+		AccessToken:         reqData.AccessToken,
+		ClientId:            reqData.ClientId,
+		RedirectUri:         reqData.RedirectUri,
+		ResponseType:        reqData.ResponseType,
+		Scope:               reqData.Scope,
+		State:               reqData.State,
+		Nonce:               reqData.Nonce,
+		CodeChallenge:       reqData.CodeChallenge,
+		CodeChallengeMethod: reqData.CodeChallengeMethod,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationRefused) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationRefused)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountSuspended)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidOAuthClient) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidOAuthClient)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRedirectUri) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidRedirectUri)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidAuthorizationRequest) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAuthorizationRequest)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler AuthorizeHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&AuthorizeHandlerRes{
+		RedirectUri: res.RedirectUri,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type CreateOAuthClientHandlerReq struct {
+	AccessToken  string   `json:"access_token" validate:"required"`
+	Name         string   `json:"name" validate:"required,max=64"`
+	RedirectUris []string `json:"redirect_uris" validate:"required,min=1,max=10,dive,required,max=2048"`
+	Confidential bool     `json:"confidential"`
+}
+type CreateOAuthClientHandlerRes struct {
+	ClientId string `json:"client_id"`
+	// Shown once, empty for public clients.
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+func (f *Http) CreateOAuthClientHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData CreateOAuthClientHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler CreateOAuthClientHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "CreateOAuthClientHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.CreateOAuthClient(r.Context(), domain.CreateOAuthClientReq{
+		AccessToken:  reqData.AccessToken,
+		Name:         reqData.Name,
+		RedirectUris: reqData.RedirectUris,
+		Confidential: reqData.Confidential,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRedirectUri) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidRedirectUri)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler CreateOAuthClientHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&CreateOAuthClientHandlerRes{
+		ClientId:     res.ClientId,
+		ClientSecret: res.ClientSecret,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type DeleteOAuthClientHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	ClientId    string `json:"client_id" validate:"required"`
+}
+type DeleteOAuthClientHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) DeleteOAuthClientHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData DeleteOAuthClientHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler DeleteOAuthClientHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "DeleteOAuthClientHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.DeleteOAuthClient(r.Context(), domain.DeleteOAuthClientReq{
+		AccessToken: reqData.AccessToken,
+		ClientId:    reqData.ClientId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrOAuthClientNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpOAuthClientNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler DeleteOAuthClientHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&DeleteOAuthClientHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type BeginIdentityLoginHandlerReq struct {
+	// Name of the identity provider, e.g. "google".
+	Provider string `json:"provider" validate:"required"`
+}
+type BeginIdentityLoginHandlerRes struct {
+	// Url of the identity provider the user is redirected to.
+	AuthorizationUrl string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+func (f *Http) BeginIdentityLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData BeginIdentityLoginHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler BeginIdentityLoginHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "BeginIdentityLoginHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.BeginIdentityLogin(r.Context(), domain.BeginIdentityLoginReq{
+		Provider: reqData.Provider,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrIdentityProviderNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpIdentityProviderNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler BeginIdentityLoginHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&BeginIdentityLoginHandlerRes{
+		AuthorizationUrl: res.AuthorizationUrl,
+		State:            res.State,
+		ExpiresAt:        res.ExpiresAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type AuthenticateWithIdentityHandlerReq struct {
+	// State of the login started with :beginIdentityLogin.
+	State string `json:"state" validate:"required"`
+	// Authorization code the identity provider redirected back with.
+	Code string `json:"code" validate:"required"`
+}
+type AuthenticateWithIdentityHandlerRes struct {
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	// Set instead of the refresh token for accounts with MFA enabled.
+	MfaChallengeToken string `json:"mfa_challenge_token,omitempty"`
+}
+
+func (f *Http) AuthenticateWithIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData AuthenticateWithIdentityHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler AuthenticateWithIdentityHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "AuthenticateWithIdentityHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.Authenticate(r.Context(), domain.AuthenticateReq{
+		Identity: &domain.IdentityAssertion{
+			State: reqData.State,
+			Code:  reqData.Code,
+		},
+		UserAgent: r.UserAgent(),
+		Ip:        xhttp.ClientIp(r),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidIdentityLogin) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidIdentityLogin)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrIdentityVerificationFailed) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpIdentityVerificationFailed)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrIdentityEmailNotVerified) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpIdentityEmailNotVerified)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrAccountNotActivated) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpEmailIsNotConfirmed)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountSuspended)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrRefreshTokensLimitReached) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpRefreshTokensLimitReached)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrTooManyLoginAttempts) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpTooManyLoginAttempts)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler AuthenticateWithIdentityHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&AuthenticateWithIdentityHandlerRes{
+		RefreshToken:      res.RefreshToken,
+		ExpiresAt:         res.ExpiresAt,
+		MfaChallengeToken: res.MfaChallengeToken,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type LinkedIdentityHandlerRes struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListIdentitiesHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+type ListIdentitiesHandlerRes struct {
+	Identities []LinkedIdentityHandlerRes `json:"identities"`
+}
+
+func (f *Http) ListIdentitiesHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ListIdentitiesHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ListIdentitiesHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ListIdentitiesHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ListIdentities(r.Context(), domain.ListIdentitiesReq{
+		AccessToken: reqData.AccessToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ListIdentitiesHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	identities := make([]LinkedIdentityHandlerRes, 0, len(res.Identities))
+	for _, identity := range res.Identities {
+		identities = append(identities, LinkedIdentityHandlerRes{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	if err := json.NewEncoder(w).Encode(&ListIdentitiesHandlerRes{
+		Identities: identities,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type CreateServiceAccountHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Name        string `json:"name" validate:"required,max=63"`
+}
+type CreateServiceAccountHandlerRes struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (f *Http) CreateServiceAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData CreateServiceAccountHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler CreateServiceAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "CreateServiceAccountHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.CreateServiceAccount(r.Context(), domain.CreateServiceAccountReq{
+		AccessToken: reqData.AccessToken,
+		Name:        reqData.Name,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidServiceAccountName) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidServiceAccountName)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrServiceAccountExists) {
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpServiceAccountExists)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler CreateServiceAccountHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&CreateServiceAccountHandlerRes{
+		Id:   res.Id,
+		Name: res.Name,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type CreateApiKeyHandlerReq struct {
+	AccessToken string   `json:"access_token" validate:"required"`
+	AccountId   string   `json:"account_id" validate:"required"`
+	Name        string   `json:"name" validate:"required,max=64"`
+	Scopes      []string `json:"scopes" validate:"max=32"`
+}
+type CreateApiKeyHandlerRes struct {
+	Id string `json:"id"`
+	// Shown once.
+	ApiKey string   `json:"api_key"`
+	Scopes []string `json:"scopes"`
+}
+
+func (f *Http) CreateApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData CreateApiKeyHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler CreateApiKeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "CreateApiKeyHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.CreateApiKey(r.Context(), domain.CreateApiKeyReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+		Name:        reqData.Name,
+		Scopes:      reqData.Scopes,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidAccountType) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccountType)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidScope) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidScope)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler CreateApiKeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&CreateApiKeyHandlerRes{
+		Id:     res.Id,
+		ApiKey: res.ApiKey,
+		Scopes: res.Scopes,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ApiKeyHandlerRes struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type ListApiKeysHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id" validate:"required"`
+}
+type ListApiKeysHandlerRes struct {
+	ApiKeys []ApiKeyHandlerRes `json:"api_keys"`
+}
+
+func (f *Http) ListApiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ListApiKeysHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ListApiKeysHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ListApiKeysHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ListApiKeys(r.Context(), domain.ListApiKeysReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidAccountType) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccountType)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ListApiKeysHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	apiKeys := make([]ApiKeyHandlerRes, 0, len(res.ApiKeys))
+	for _, key := range res.ApiKeys {
+		apiKey := ApiKeyHandlerRes{
+			Id:        key.Id,
+			Name:      key.Name,
+			Scopes:    key.Scopes,
+			CreatedAt: key.CreatedAt,
+			ExpiresAt: key.ExpiresAt,
+		}
+		if !key.LastUsedAt.IsZero() {
+			apiKey.LastUsedAt = &key.LastUsedAt
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err := json.NewEncoder(w).Encode(&ListApiKeysHandlerRes{
+		ApiKeys: apiKeys,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type RotateApiKeyHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	ApiKeyId    string `json:"api_key_id" validate:"required"`
+}
+type RotateApiKeyHandlerRes struct {
+	Id string `json:"id"`
+	// Shown once.
+	ApiKey            string    `json:"api_key"`
+	PreviousExpiresAt time.Time `json:"previous_expires_at"`
+}
+
+func (f *Http) RotateApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData RotateApiKeyHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler RotateApiKeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "RotateApiKeyHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.RotateApiKey(r.Context(), domain.RotateApiKeyReq{
+		AccessToken: reqData.AccessToken,
+		ApiKeyId:    reqData.ApiKeyId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrApiKeyNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpApiKeyNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler RotateApiKeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&RotateApiKeyHandlerRes{
+		Id:                res.Id,
+		ApiKey:            res.ApiKey,
+		PreviousExpiresAt: res.PreviousExpiresAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type RevokeApiKeyHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	ApiKeyId    string `json:"api_key_id" validate:"required"`
+}
+type RevokeApiKeyHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) RevokeApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData RevokeApiKeyHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler RevokeApiKeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "RevokeApiKeyHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.RevokeApiKey(r.Context(), domain.RevokeApiKeyReq{
+		AccessToken: reqData.AccessToken,
+		ApiKeyId:    reqData.ApiKeyId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrApiKeyNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpApiKeyNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler RevokeApiKeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&RevokeApiKeyHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ExchangeApiKeyHandlerReq struct {
+	ApiKey string `json:"api_key" validate:"required"`
+	// Space separated subset of the api key scopes, all of them are granted if omitted.
+	Scope string `json:"scope" validate:"max=2048"`
+}
+type ExchangeApiKeyHandlerRes struct {
+	AccessToken string    `json:"access_token"`
+	Scope       string    `json:"scope"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (f *Http) ExchangeApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ExchangeApiKeyHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ExchangeApiKeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ExchangeApiKeyHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ExchangeApiKey(r.Context(), domain.ExchangeApiKeyReq{
+		ApiKey: reqData.ApiKey,
+		Scope:  reqData.Scope,
+		Ip:     xhttp.ClientIp(r),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidApiKey) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidApiKey)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidScope) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidScope)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountSuspended)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrTooManyLoginAttempts) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpTooManyLoginAttempts)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ExchangeApiKeyHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&ExchangeApiKeyHandlerRes{
+		AccessToken: res.AccessToken,
+		Scope:       res.Scope,
+		ExpiresAt:   res.ExpiresAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ImpersonateHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id" validate:"required"`
+	Reason      string `json:"reason" validate:"required,max=256"`
+}
+type ImpersonateHandlerRes struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (f *Http) ImpersonateHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ImpersonateHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ImpersonateHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ImpersonateHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.Impersonate(r.Context(), domain.ImpersonateReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+		Reason:      reqData.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrImpersonationNotAllowed) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpImpersonationNotAllowed)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountSuspended)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ImpersonateHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(ImpersonateHandlerRes{
+		AccessToken: res.AccessToken,
+		ExpiresAt:   res.ExpiresAt,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type ListAuditEventsHandlerReq struct {
+	AccessToken string    `json:"access_token" validate:"required"`
+	AccountId   string    `json:"account_id"`
+	Type        string    `json:"type"`
+	Since       time.Time `json:"since"`
+	Until       time.Time `json:"until"`
+	PageSize    int       `json:"page_size" validate:"min=0,max=100"`
+	PageToken   string    `json:"page_token"`
+}
+type ListAuditEventsHandlerRes struct {
+	Events        []domain.AuditEventInfo `json:"events"`
+	NextPageToken string                  `json:"next_page_token"`
+}
+
+func (f *Http) ListAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData ListAuditEventsHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler ListAuditEventsHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "ListAuditEventsHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := f.svc.ListAuditEvents(r.Context(), domain.ListAuditEventsReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+		Type:        reqData.Type,
+		Since:       reqData.Since,
+		Until:       reqData.Until,
+		PageSize:    reqData.PageSize,
+		PageToken:   reqData.PageToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidAuditEventType) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAuditEventType)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidPageToken) {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidPageToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler ListAuditEventsHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(ListAuditEventsHandlerRes{
+		Events:        res.Events,
+		NextPageToken: res.NextPageToken,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
+
+type RevokeAccessTokensHandlerReq struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	AccountId   string `json:"account_id" validate:"required"`
+}
+type RevokeAccessTokensHandlerRes struct {
+	Ok bool `json:"ok"`
+}
+
+func (f *Http) RevokeAccessTokensHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData RevokeAccessTokensHandlerReq
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		f.l.Info("failed to decode request body for handler RevokeAccessTokensHandler", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+	if err := f.validateJson.Struct(reqData); err != nil {
+		f.l.Info("invalid request struct", zap.String("handler", "RevokeAccessTokensHandler"), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpBadRequestBody)); err != nil {
+			f.l.Error("failed to encode error response", zap.Error(err))
+		}
+		return
+	}
+
+	_, err := f.svc.RevokeAccessTokens(r.Context(), domain.RevokeAccessTokensReq{
+		AccessToken: reqData.AccessToken,
+		AccountId:   reqData.AccountId,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInvalidAccessToken)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccessDenied)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpAccountNotFound)); err != nil {
+				f.l.Error("failed to encode error response", zap.Error(err))
+			}
+			return
+		}
+		f.l.Error("unexpected error occurred in handler RevokeAccessTokensHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&RevokeAccessTokensHandlerRes{Ok: true}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+}
@@ -0,0 +1,93 @@
+package http_adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"go.uber.org/zap"
+)
+
+const oauthErrorInsufficientScope = "insufficient_scope"
+
+// Claims of the token introspection response (RFC 7662 section 2.2).
+type IntrospectTokenHandlerRes struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+	// Admin acting on behalf of the subject of impersonation tokens (RFC 8693 section 4.1).
+	Act *IntrospectTokenHandlerResActor `json:"act,omitempty"`
+}
+type IntrospectTokenHandlerResActor struct {
+	Sub string `json:"sub"`
+}
+
+// Token introspection endpoint (RFC 7662) accepting form encoded token and token_type_hint parameters.
+// Callers authenticate with an access token of a service account as a bearer token.
+func (f *Http) IntrospectTokenHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || accessToken == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		f.l.Info("failed to parse form for handler IntrospectTokenHandler", zap.Error(err))
+		f.writeOAuthError(w, http.StatusBadRequest, oauthErrorInvalidRequest, "malformed request body")
+		return
+	}
+	if r.PostForm.Get("token") == "" {
+		f.writeOAuthError(w, http.StatusBadRequest, oauthErrorInvalidRequest, "token is required")
+		return
+	}
+
+	res, err := f.svc.IntrospectToken(r.Context(), domain.IntrospectTokenReq{
+		AccessToken:   accessToken,
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: r.PostForm.Get("token_type_hint"),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="`+oauthErrorInvalidToken+`"`)
+			f.writeOAuthError(w, http.StatusUnauthorized, oauthErrorInvalidToken, "")
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="`+oauthErrorInsufficientScope+`", scope="`+domain.PermissionTokensIntrospect+`"`)
+			f.writeOAuthError(w, http.StatusForbidden, oauthErrorInsufficientScope, "")
+			return
+		}
+		f.l.Error("unexpected error occurred in handler IntrospectTokenHandler", zap.Error(err))
+		f.writeOAuthError(w, http.StatusInternalServerError, oauthErrorServerError, "")
+		return
+	}
+
+	resBody := IntrospectTokenHandlerRes{Active: res.Active}
+	if res.Active {
+		resBody.TokenType = res.TokenType
+		resBody.Scope = strings.Join(res.Permissions, " ")
+		resBody.Sub = res.SubjectId
+		resBody.Exp = res.ExpiresAt.Unix()
+		resBody.Jti = res.Id
+		if !res.IssuedAt.IsZero() {
+			resBody.Iat = res.IssuedAt.Unix()
+		}
+		if res.ActorId != "" {
+			resBody.Act = &IntrospectTokenHandlerResActor{Sub: res.ActorId}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&resBody); err != nil {
+		f.l.Error("failed to encode token introspection response", zap.Error(err))
+	}
+}
@@ -0,0 +1,243 @@
+package http_adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"go.uber.org/zap"
+)
+
+// Paths of the OpenID Connect provider endpoints relative to the issuer.
+const (
+	OidcConfigurationPath = "/.well-known/openid-configuration"
+	OidcJwksPath          = "/.well-known/jwks.json"
+	OidcTokenPath         = "/oauth2/token"
+	OidcUserInfoPath      = "/oauth2/userinfo"
+)
+
+// Error response of the token endpoint (RFC 6749 section 5.2).
+// OpenID Connect endpoints don't use HttpErrors for the standard clients to understand them.
+type OAuthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+const (
+	oauthErrorInvalidRequest       = "invalid_request"
+	oauthErrorInvalidClient        = "invalid_client"
+	oauthErrorInvalidGrant         = "invalid_grant"
+	oauthErrorUnsupportedGrantType = "unsupported_grant_type"
+	oauthErrorInvalidToken         = "invalid_token"
+	oauthErrorServerError          = "server_error"
+)
+
+type OpenIdConfigurationHandlerRes struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksUri                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+func (f *Http) OpenIdConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := f.svc.GetOpenIdConfiguration(r.Context(), domain.GetOpenIdConfigurationReq{})
+	if err != nil {
+		f.l.Error("unexpected error occurred in handler OpenIdConfigurationHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	issuer := strings.TrimSuffix(res.Issuer, "/")
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&OpenIdConfigurationHandlerRes{
+		Issuer:                            res.Issuer,
+		AuthorizationEndpoint:             res.AuthorizationEndpoint,
+		TokenEndpoint:                     issuer + OidcTokenPath,
+		UserInfoEndpoint:                  issuer + OidcUserInfoPath,
+		JwksUri:                           issuer + OidcJwksPath,
+		ScopesSupported:                   res.ScopesSupported,
+		ResponseTypesSupported:            []string{domain.OidcResponseTypeCode},
+		GrantTypesSupported:               []string{domain.OidcGrantTypeAuthorizationCode},
+		SubjectTypesSupported:             []string{domain.OidcSubjectTypePublic},
+		IdTokenSigningAlgValuesSupported:  []string{domain.OidcIdTokenSigningAlgorithmEs256},
+		TokenEndpointAuthMethodsSupported: []string{domain.OidcClientAuthMethodSecretBasic, domain.OidcClientAuthMethodSecretPost, domain.OidcClientAuthMethodNone},
+		CodeChallengeMethodsSupported:     []string{domain.OidcCodeChallengeMethodS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "name", "email", "email_verified"},
+	}); err != nil {
+		f.l.Error("failed to encode openid configuration response", zap.Error(err))
+	}
+}
+
+func (f *Http) JwksHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := f.svc.GetJwks(r.Context(), domain.GetJwksReq{})
+	if err != nil {
+		f.l.Error("unexpected error occurred in handler JwksHandler", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewHttpErrors(ErrHttpInternalServerError)); err != nil {
+			f.l.Error("failed to encode internal server error response", zap.Error(err))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	if _, err := w.Write(res.Jwks); err != nil {
+		f.l.Error("failed to write jwks response", zap.Error(err))
+	}
+}
+
+type TokenHandlerRes struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// Access token lifetime in seconds.
+	ExpiresIn int64  `json:"expires_in"`
+	IdToken   string `json:"id_token"`
+	Scope     string `json:"scope"`
+}
+
+// Token endpoint (RFC 6749 section 3.2) accepting form encoded authorization code grants.
+// Clients authenticate with HTTP Basic auth or client_id and client_secret form parameters.
+func (f *Http) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		f.l.Info("failed to parse form for handler TokenHandler", zap.Error(err))
+		f.writeOAuthError(w, http.StatusBadRequest, oauthErrorInvalidRequest, "malformed request body")
+		return
+	}
+
+	clientId, clientSecret, basicAuth := r.BasicAuth()
+	if basicAuth {
+		// Basic auth credentials are form encoded (RFC 6749 section 2.3.1).
+		var errId, errSecret error
+		clientId, errId = url.QueryUnescape(clientId)
+		clientSecret, errSecret = url.QueryUnescape(clientSecret)
+		if errId != nil || errSecret != nil {
+			f.writeOAuthError(w, http.StatusBadRequest, oauthErrorInvalidRequest, "malformed client credentials")
+			return
+		}
+	} else {
+		clientId = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+
+	if clientId == "" || r.PostForm.Get("code") == "" || r.PostForm.Get("code_verifier") == "" {
+		f.writeOAuthError(w, http.StatusBadRequest, oauthErrorInvalidRequest, "client_id, code and code_verifier are required")
+		return
+	}
+
+	res, err := f.svc.ExchangeAuthorizationCode(r.Context(), domain.ExchangeAuthorizationCodeReq{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectUri:  r.PostForm.Get("redirect_uri"),
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUnsupportedGrantType) {
+			f.writeOAuthError(w, http.StatusBadRequest, oauthErrorUnsupportedGrantType, "")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidOAuthClient) {
+			if basicAuth {
+				w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+			}
+			f.writeOAuthError(w, http.StatusUnauthorized, oauthErrorInvalidClient, "")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidGrant) {
+			f.writeOAuthError(w, http.StatusBadRequest, oauthErrorInvalidGrant, "")
+			return
+		}
+		f.l.Error("unexpected error occurred in handler TokenHandler", zap.Error(err))
+		f.writeOAuthError(w, http.StatusInternalServerError, oauthErrorServerError, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&TokenHandlerRes{
+		AccessToken: res.AccessToken,
+		TokenType:   domain.OidcTokenTypeBearer,
+		ExpiresIn:   int64(time.Until(res.ExpiresAt).Seconds()),
+		IdToken:     res.IdToken,
+		Scope:       res.Scope,
+	}); err != nil {
+		f.l.Error("failed to encode token response", zap.Error(err))
+	}
+}
+
+// Claims not granted to the client are omitted.
+type UserInfoHandlerRes struct {
+	Sub           string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// UserInfo endpoint taking the access token as a bearer token (RFC 6750).
+func (f *Http) UserInfoHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || accessToken == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	res, err := f.svc.GetUserInfo(r.Context(), domain.GetUserInfoReq{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="`+oauthErrorInvalidToken+`"`)
+			f.writeOAuthError(w, http.StatusUnauthorized, oauthErrorInvalidToken, "")
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="`+oauthErrorInsufficientScope+`", scope="`+domain.OidcScopeOpenId+`"`)
+			f.writeOAuthError(w, http.StatusForbidden, oauthErrorInsufficientScope, "")
+			return
+		}
+		f.l.Error("unexpected error occurred in handler UserInfoHandler", zap.Error(err))
+		f.writeOAuthError(w, http.StatusInternalServerError, oauthErrorServerError, "")
+		return
+	}
+
+	resBody := UserInfoHandlerRes{
+		Sub:   res.SubjectId,
+		Name:  res.Name,
+		Email: res.Email,
+	}
+	if res.Email != "" {
+		resBody.EmailVerified = &res.EmailVerified
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&resBody); err != nil {
+		f.l.Error("failed to encode user info response", zap.Error(err))
+	}
+}
+
+func (f *Http) writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(&OAuthError{Error: code, ErrorDescription: description}); err != nil {
+		f.l.Error("failed to encode oauth error response", zap.Error(err))
+	}
+}
@@ -0,0 +1,73 @@
+package ydb_dynamodb_adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	ydb_dynamodb "github.com/bratushkadan/floral/pkg/ydb/dynamodb"
+	"go.uber.org/zap"
+)
+
+const (
+	tablePasswordResetTokens = "password_reset_tokens"
+)
+
+var _ domain.PasswordResetTokens = (*PasswordResetTokens)(nil)
+
+type PasswordResetTokens struct {
+	cl *dynamodb.Client
+	l  *zap.Logger
+}
+
+func NewPasswordResetTokens(ctx context.Context, accessKeyId, secretAccessKey string, ydbDocApiEndpoint string, logger *zap.Logger) (*PasswordResetTokens, error) {
+	client, err := ydb_dynamodb.New(ctx, accessKeyId, secretAccessKey, ydbDocApiEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup dynamodb password reset tokens: %v", err)
+	}
+	return &PasswordResetTokens{cl: client, l: logger}, nil
+}
+
+func (db *PasswordResetTokens) InsertToken(ctx context.Context, record domain.PasswordResetRecord) error {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal password reset token record: %v", err)
+	}
+
+	if _, err := db.cl.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tablePasswordResetTokens),
+		Item:      item,
+	}); err != nil {
+		return fmt.Errorf("failed to put password reset token record: %v", err)
+	}
+
+	db.l.Info("inserted password reset token", zap.String("email", record.Email), zap.String("purpose", record.Purpose))
+	return nil
+}
+
+func (db *PasswordResetTokens) ConsumeToken(ctx context.Context, token string) (*domain.PasswordResetRecord, error) {
+	output, err := db.cl.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(tablePasswordResetTokens),
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{Value: token},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete password reset token record: %v", err)
+	}
+
+	if len(output.Attributes) == 0 {
+		return nil, nil
+	}
+
+	var record domain.PasswordResetRecord
+	if err := attributevalue.UnmarshalMap(output.Attributes, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response from dynamodb: %v", err)
+	}
+	return &record, nil
+}
@@ -122,3 +122,25 @@ func (db *EmailConfirmationTokens) FindTokenRecord(ctx context.Context, token st
 	}
 	return &unmarshaledItem, nil
 }
+
+func (db *EmailConfirmationTokens) DeleteTokensEmail(ctx context.Context, email string) error {
+	records, err := db.ListTokensEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to list email confirmation tokens to delete: %v", err)
+	}
+
+	for _, record := range records {
+		if _, err := db.cl.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(tableEmailConfirmationTokens),
+			Key: map[string]types.AttributeValue{
+				"email": &types.AttributeValueMemberS{Value: record.Email},
+				"token": &types.AttributeValueMemberS{Value: record.Token},
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to delete email confirmation token: %v", err)
+		}
+	}
+
+	db.l.Info("deleted email tokens", zap.String("email", email), zap.Int("count", len(records)))
+	return nil
+}
@@ -0,0 +1,92 @@
+package email_resetter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/email"
+)
+
+type Email struct {
+	ResetSendTimeout time.Duration
+
+	p        *email.YandexMailProvider
+	resetUrl *url.URL
+}
+
+var _ domain.PasswordResetSender = (*Email)(nil)
+
+type EmailBuilder struct {
+	e *Email
+
+	senderEmail    string
+	senderPassword string
+
+	resetUrl *string
+}
+
+func NewBuilder() *EmailBuilder {
+	return &EmailBuilder{
+		e: &Email{},
+	}
+}
+
+func (b *EmailBuilder) SenderEmail(email string) *EmailBuilder {
+	b.senderEmail = email
+	return b
+}
+func (b *EmailBuilder) SenderPassword(password string) *EmailBuilder {
+	b.senderPassword = password
+	return b
+}
+
+// Set url of the page the account owner sets the new password at.
+// Reset token is appended to the url as the "token" query parameter.
+func (b *EmailBuilder) ResetUrl(url string) *EmailBuilder {
+	b.resetUrl = &url
+	return b
+}
+
+// Set password reset email sending timeout.
+// 5 seconds by default.
+func (b *EmailBuilder) ResetSendTimeout(d time.Duration) *EmailBuilder {
+	b.e.ResetSendTimeout = d
+	return b
+}
+
+func (b *EmailBuilder) Build() (*Email, error) {
+	if b.resetUrl == nil {
+		return nil, errors.New("password reset url must be set")
+	}
+	u, err := url.Parse(*b.resetUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse password reset url: %v", err)
+	}
+	b.e.resetUrl = u
+
+	b.e.p = email.NewYandexMailProvider(b.senderEmail, b.senderPassword)
+	if b.e.ResetSendTimeout == 0 {
+		b.e.ResetSendTimeout = 5 * time.Second
+	}
+
+	return b.e, nil
+}
+
+func (s Email) Send(ctx context.Context, in domain.PasswordResetSenderSendDTOInput) error {
+	u := *s.resetUrl
+	q := u.Query()
+	q.Add("token", in.ResetToken)
+	u.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithTimeout(ctx, s.ResetSendTimeout)
+	defer cancel()
+	return s.p.SendMail(ctx, email.EmailContents{
+		To:      in.RecipientEmail,
+		Subject: "Password reset",
+		Body:    fmt.Sprintf("Follow the link to set a new password for your account: %s\nIgnore this email if you did not request a password reset.", u.String()),
+	})
+}
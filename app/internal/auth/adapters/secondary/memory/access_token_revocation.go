@@ -0,0 +1,42 @@
+package memory_adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// In-memory access token revocations. Meant for tests and single instance setups.
+type AccessTokenRevocations struct {
+	mu          sync.Mutex
+	revocations []domain.AccessTokenRevocation
+}
+
+var _ domain.AccessTokenRevocations = (*AccessTokenRevocations)(nil)
+
+func NewAccessTokenRevocations() *AccessTokenRevocations {
+	return &AccessTokenRevocations{}
+}
+
+func (r *AccessTokenRevocations) Revoke(_ context.Context, revocation domain.AccessTokenRevocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revocations = append(r.revocations, revocation)
+	return nil
+}
+
+func (r *AccessTokenRevocations) Revoked(_ context.Context, token domain.AccessToken) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, revocation := range r.revocations {
+		if now.Before(revocation.ExpiresAt) && revocation.Applies(token) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,80 @@
+package memory_adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// In-memory account creation outbox. Meant for tests and single instance setups.
+type AccountCreationOutbox struct {
+	mu       sync.Mutex
+	messages map[string]domain.AccountCreationOutboxMessage
+}
+
+var _ domain.AccountCreationOutbox = (*AccountCreationOutbox)(nil)
+
+func NewAccountCreationOutbox() *AccountCreationOutbox {
+	return &AccountCreationOutbox{messages: make(map[string]domain.AccountCreationOutboxMessage)}
+}
+
+// Enqueue is performed by the account provider in the same transaction as the account creation.
+func (o *AccountCreationOutbox) Enqueue(_ context.Context, id string, email string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	o.messages[id] = domain.AccountCreationOutboxMessage{
+		Id:            id,
+		Email:         email,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+	return nil
+}
+
+func (o *AccountCreationOutbox) ListDue(_ context.Context, in domain.AccountCreationOutboxListDueDTOInput) ([]domain.AccountCreationOutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	out := make([]domain.AccountCreationOutboxMessage, 0)
+	for _, msg := range o.messages {
+		if msg.NextAttemptAt.After(in.Now) {
+			continue
+		}
+		out = append(out, msg)
+	}
+
+	slices.SortFunc(out, func(a, b domain.AccountCreationOutboxMessage) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+	if in.Limit > 0 && len(out) > in.Limit {
+		out = out[:in.Limit]
+	}
+	return out, nil
+}
+
+func (o *AccountCreationOutbox) Delete(_ context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.messages, id)
+	return nil
+}
+
+func (o *AccountCreationOutbox) Retry(_ context.Context, in domain.AccountCreationOutboxRetryDTOInput) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	msg, ok := o.messages[in.Id]
+	if !ok {
+		return nil
+	}
+	msg.Attempts = in.Attempts
+	msg.NextAttemptAt = in.NextAttemptAt
+	o.messages[in.Id] = msg
+	return nil
+}
@@ -0,0 +1,112 @@
+package memory_adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// In-memory api keys storage. Meant for tests and single instance setups.
+// Api key secrets are kept in plain text.
+type ApiKeys struct {
+	mu      sync.Mutex
+	keys    map[string]domain.ApiKey
+	secrets map[string]string
+}
+
+var _ domain.ApiKeys = (*ApiKeys)(nil)
+
+func NewApiKeys() *ApiKeys {
+	return &ApiKeys{
+		keys:    make(map[string]domain.ApiKey),
+		secrets: make(map[string]string),
+	}
+}
+
+func (a *ApiKeys) Add(_ context.Context, in domain.ApiKeysAddDTOInput) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.keys[in.Id] = domain.ApiKey{
+		Id:        in.Id,
+		AccountId: in.AccountId,
+		Name:      in.Name,
+		Scopes:    slices.Clone(in.Scopes),
+		CreatedAt: in.CreatedAt,
+	}
+	a.secrets[in.Id] = in.Secret
+	return nil
+}
+
+func (a *ApiKeys) Get(_ context.Context, id string) (*domain.ApiKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key, ok := a.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	key.Scopes = slices.Clone(key.Scopes)
+	return &key, nil
+}
+
+func (a *ApiKeys) CheckSecret(_ context.Context, in domain.ApiKeysCheckSecretDTOInput) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	secret, ok := a.secrets[in.Id]
+	return ok && secret == in.Secret, nil
+}
+
+func (a *ApiKeys) List(_ context.Context, accountId string) ([]domain.ApiKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var keys []domain.ApiKey
+	for _, key := range a.keys {
+		if key.AccountId == accountId {
+			key.Scopes = slices.Clone(key.Scopes)
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b domain.ApiKey) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return keys, nil
+}
+
+func (a *ApiKeys) Expire(_ context.Context, in domain.ApiKeysExpireDTOInput) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if key, ok := a.keys[in.Id]; ok {
+		key.ExpiresAt = in.ExpiresAt
+		a.keys[in.Id] = key
+	}
+	return nil
+}
+
+func (a *ApiKeys) Touch(_ context.Context, in domain.ApiKeysTouchDTOInput) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if key, ok := a.keys[in.Id]; ok {
+		key.LastUsedAt = in.LastUsedAt
+		a.keys[in.Id] = key
+	}
+	return nil
+}
+
+func (a *ApiKeys) Delete(_ context.Context, id string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.keys[id]; !ok {
+		return false, nil
+	}
+	delete(a.keys, id)
+	delete(a.secrets, id)
+	return true, nil
+}
@@ -0,0 +1,78 @@
+package memory_adapter
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// In-memory audit log. Meant for tests and single instance setups.
+type AuditLog struct {
+	mu     sync.Mutex
+	events []domain.AuditEvent
+}
+
+var _ domain.AuditLog = (*AuditLog)(nil)
+
+func NewAuditLog() *AuditLog {
+	return &AuditLog{}
+}
+
+func (a *AuditLog) Append(_ context.Context, event domain.AuditEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	event.Details = maps.Clone(event.Details)
+	a.events = append(a.events, event)
+	return nil
+}
+
+func (a *AuditLog) List(_ context.Context, in domain.AuditLogListDTOInput) ([]domain.AuditEvent, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]domain.AuditEvent, 0)
+	for _, event := range a.events {
+		if in.AccountId != "" && event.AccountId != in.AccountId {
+			continue
+		}
+		if in.Type != "" && event.Type != in.Type {
+			continue
+		}
+		if !in.Since.IsZero() && event.CreatedAt.Before(in.Since) {
+			continue
+		}
+		if !in.Until.IsZero() && !event.CreatedAt.Before(in.Until) {
+			continue
+		}
+		if in.Before != nil && !auditEventBefore(event, *in.Before) {
+			continue
+		}
+		event.Details = maps.Clone(event.Details)
+		out = append(out, event)
+	}
+
+	// Newest first.
+	slices.SortFunc(out, func(a, b domain.AuditEvent) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.Id, a.Id)
+	})
+	if in.Limit > 0 && len(out) > in.Limit {
+		out = out[:in.Limit]
+	}
+	return out, nil
+}
+
+// Reports whether the event is older than the cursor position.
+func auditEventBefore(event domain.AuditEvent, cursor domain.AuditLogCursor) bool {
+	if !event.CreatedAt.Equal(cursor.CreatedAt) {
+		return event.CreatedAt.Before(cursor.CreatedAt)
+	}
+	return event.Id < cursor.Id
+}
@@ -0,0 +1,97 @@
+package memory_adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+type identityKey struct {
+	provider string
+	subject  string
+}
+
+// In-memory identities storage. Meant for tests and single instance setups.
+type Identities struct {
+	mu         sync.Mutex
+	identities map[identityKey]domain.Identity
+}
+
+var _ domain.Identities = (*Identities)(nil)
+
+func NewIdentities() *Identities {
+	return &Identities{
+		identities: make(map[identityKey]domain.Identity),
+	}
+}
+
+func (a *Identities) Find(_ context.Context, provider, subject string) (*domain.Identity, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	identity, ok := a.identities[identityKey{provider: provider, subject: subject}]
+	if !ok {
+		return nil, nil
+	}
+	return &identity, nil
+}
+
+func (a *Identities) Add(_ context.Context, identity domain.Identity) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.identities[identityKey{provider: identity.Provider, subject: identity.Subject}] = identity
+	return nil
+}
+
+func (a *Identities) List(_ context.Context, accountId string) ([]domain.Identity, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]domain.Identity, 0)
+	for _, identity := range a.identities {
+		if identity.AccountId == accountId {
+			out = append(out, identity)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Identity) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return out, nil
+}
+
+// In-memory identity logins storage. Meant for tests and single instance setups.
+type IdentityLogins struct {
+	mu     sync.Mutex
+	logins map[string]domain.IdentityLogin
+}
+
+var _ domain.IdentityLogins = (*IdentityLogins)(nil)
+
+func NewIdentityLogins() *IdentityLogins {
+	return &IdentityLogins{
+		logins: make(map[string]domain.IdentityLogin),
+	}
+}
+
+func (a *IdentityLogins) Insert(_ context.Context, login domain.IdentityLogin) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.logins[login.State] = login
+	return nil
+}
+
+func (a *IdentityLogins) Consume(_ context.Context, state string) (*domain.IdentityLogin, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out, ok := a.logins[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(a.logins, state)
+	return &out, nil
+}
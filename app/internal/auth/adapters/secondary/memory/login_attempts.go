@@ -0,0 +1,56 @@
+package memory_adapter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// In-memory failed login attempts tracker. Meant for tests and single instance setups.
+type LoginAttempts struct {
+	mu      sync.Mutex
+	records map[string]domain.LoginAttemptsRecord
+}
+
+var _ domain.LoginAttempts = (*LoginAttempts)(nil)
+
+func NewLoginAttempts() *LoginAttempts {
+	return &LoginAttempts{
+		records: make(map[string]domain.LoginAttemptsRecord),
+	}
+}
+
+func (a *LoginAttempts) Get(_ context.Context, key string) (*domain.LoginAttemptsRecord, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record, ok := a.records[key]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (a *LoginAttempts) RegisterFailure(_ context.Context, in domain.LoginAttemptsRegisterFailureDTOInput) (domain.LoginAttemptsRecord, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record, ok := a.records[in.Key]
+	if !ok || record.LastFailureAt.Before(in.At.Add(-in.ResetAfter)) {
+		record = domain.LoginAttemptsRecord{Key: in.Key}
+	}
+	record.Failures++
+	record.LastFailureAt = in.At
+
+	a.records[in.Key] = record
+	return record, nil
+}
+
+func (a *LoginAttempts) Reset(_ context.Context, key string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.records, key)
+	return nil
+}
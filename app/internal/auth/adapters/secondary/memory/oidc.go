@@ -0,0 +1,109 @@
+package memory_adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// In-memory OAuth clients registry. Meant for tests and single instance setups.
+// Client secrets are kept in plain text.
+type OAuthClients struct {
+	mu      sync.Mutex
+	clients map[string]domain.OAuthClient
+	secrets map[string]string
+}
+
+var _ domain.OAuthClients = (*OAuthClients)(nil)
+
+func NewOAuthClients() *OAuthClients {
+	return &OAuthClients{
+		clients: make(map[string]domain.OAuthClient),
+		secrets: make(map[string]string),
+	}
+}
+
+func (a *OAuthClients) Add(_ context.Context, in domain.OAuthClientsAddDTOInput) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.clients[in.Id] = domain.OAuthClient{
+		Id:           in.Id,
+		Name:         in.Name,
+		Confidential: in.Secret != "",
+		RedirectUris: slices.Clone(in.RedirectUris),
+		CreatedAt:    in.CreatedAt,
+	}
+	if in.Secret != "" {
+		a.secrets[in.Id] = in.Secret
+	}
+	return nil
+}
+
+func (a *OAuthClients) Get(_ context.Context, id string) (*domain.OAuthClient, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	client, ok := a.clients[id]
+	if !ok {
+		return nil, nil
+	}
+	client.RedirectUris = slices.Clone(client.RedirectUris)
+	return &client, nil
+}
+
+func (a *OAuthClients) CheckSecret(_ context.Context, in domain.OAuthClientsCheckSecretDTOInput) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	secret, ok := a.secrets[in.Id]
+	return ok && secret == in.Secret, nil
+}
+
+func (a *OAuthClients) Delete(_ context.Context, id string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.clients[id]; !ok {
+		return false, nil
+	}
+	delete(a.clients, id)
+	delete(a.secrets, id)
+	return true, nil
+}
+
+// In-memory authorization codes storage. Meant for tests and single instance setups.
+type AuthorizationCodes struct {
+	mu    sync.Mutex
+	codes map[string]domain.AuthorizationCode
+}
+
+var _ domain.AuthorizationCodes = (*AuthorizationCodes)(nil)
+
+func NewAuthorizationCodes() *AuthorizationCodes {
+	return &AuthorizationCodes{
+		codes: make(map[string]domain.AuthorizationCode),
+	}
+}
+
+func (a *AuthorizationCodes) Insert(_ context.Context, code domain.AuthorizationCode) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.codes[code.Code] = code
+	return nil
+}
+
+func (a *AuthorizationCodes) Consume(_ context.Context, code string) (*domain.AuthorizationCode, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out, ok := a.codes[code]
+	if !ok {
+		return nil, nil
+	}
+	delete(a.codes, code)
+	return &out, nil
+}
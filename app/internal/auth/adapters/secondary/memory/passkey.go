@@ -0,0 +1,102 @@
+package memory_adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// In-memory passkey credentials storage. Meant for tests and single instance setups.
+type PasskeyCredentials struct {
+	mu          sync.Mutex
+	credentials map[string][]domain.PasskeyCredential
+}
+
+var _ domain.PasskeyCredentials = (*PasskeyCredentials)(nil)
+
+func NewPasskeyCredentials() *PasskeyCredentials {
+	return &PasskeyCredentials{
+		credentials: make(map[string][]domain.PasskeyCredential),
+	}
+}
+
+func (a *PasskeyCredentials) Add(_ context.Context, cred domain.PasskeyCredential) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.credentials[cred.AccountId] = append(a.credentials[cred.AccountId], cred)
+	return nil
+}
+
+func (a *PasskeyCredentials) List(_ context.Context, in domain.PasskeyCredentialsListDTOInput) ([]domain.PasskeyCredential, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return slices.Clone(a.credentials[in.AccountId]), nil
+}
+
+func (a *PasskeyCredentials) Touch(_ context.Context, in domain.PasskeyCredentialsTouchDTOInput) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	creds := a.credentials[in.AccountId]
+	if i := a.index(in.AccountId, in.Id); i >= 0 {
+		creds[i].Data = in.Data
+		creds[i].LastUsedAt = in.LastUsedAt
+	}
+	return nil
+}
+
+func (a *PasskeyCredentials) Delete(_ context.Context, in domain.PasskeyCredentialsDeleteDTOInput) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i := a.index(in.AccountId, in.Id)
+	if i < 0 {
+		return false, nil
+	}
+	a.credentials[in.AccountId] = slices.Delete(a.credentials[in.AccountId], i, i+1)
+	return true, nil
+}
+
+func (a *PasskeyCredentials) index(accountId, id string) int {
+	return slices.IndexFunc(a.credentials[accountId], func(c domain.PasskeyCredential) bool {
+		return c.Id == id
+	})
+}
+
+// In-memory passkey ceremonies storage. Meant for tests and single instance setups.
+type PasskeyCeremonies struct {
+	mu         sync.Mutex
+	ceremonies map[string]domain.PasskeyCeremony
+}
+
+var _ domain.PasskeyCeremonies = (*PasskeyCeremonies)(nil)
+
+func NewPasskeyCeremonies() *PasskeyCeremonies {
+	return &PasskeyCeremonies{
+		ceremonies: make(map[string]domain.PasskeyCeremony),
+	}
+}
+
+func (a *PasskeyCeremonies) Insert(_ context.Context, ceremony domain.PasskeyCeremony) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.ceremonies[ceremony.Id] = ceremony
+	return nil
+}
+
+func (a *PasskeyCeremonies) Consume(_ context.Context, id string) (*domain.PasskeyCeremony, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ceremony, ok := a.ceremonies[id]
+	if !ok {
+		return nil, nil
+	}
+	delete(a.ceremonies, id)
+	return &ceremony, nil
+}
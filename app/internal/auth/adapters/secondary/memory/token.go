@@ -0,0 +1,215 @@
+package memory_adapter
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// In-memory refresh tokens storage. Meant for tests and single instance setups.
+type RefreshTokens struct {
+	mu     sync.Mutex
+	lastId int
+	tokens map[string]refreshToken
+
+	tokensLimit    int
+	evictionPolicy domain.RefreshTokenEvictionPolicy
+}
+
+type refreshToken struct {
+	domain.RefreshTokenListDTOOutputToken
+	accountId  string
+	familyId   string
+	replacedAt time.Time
+}
+
+var _ domain.RefreshTokenProvider = (*RefreshTokens)(nil)
+
+// Zero limit and empty policy stand for 5 tokens per account and evicting the oldest ones.
+func NewRefreshTokens(tokensLimit int, evictionPolicy domain.RefreshTokenEvictionPolicy) *RefreshTokens {
+	if tokensLimit <= 0 {
+		tokensLimit = 5
+	}
+	if evictionPolicy == "" {
+		evictionPolicy = domain.RefreshTokenEvictionPolicyEvictOldest
+	}
+	return &RefreshTokens{
+		tokens:         make(map[string]refreshToken),
+		tokensLimit:    tokensLimit,
+		evictionPolicy: evictionPolicy,
+	}
+}
+
+// Account tokens not replaced yet, the newest first.
+func (a *RefreshTokens) active(accountId string) []refreshToken {
+	var tokens []refreshToken
+	for _, t := range a.tokens {
+		if t.accountId == accountId && t.replacedAt.IsZero() {
+			tokens = append(tokens, t)
+		}
+	}
+	slices.SortFunc(tokens, func(a, b refreshToken) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		ai, _ := strconv.Atoi(a.Id)
+		bi, _ := strconv.Atoi(b.Id)
+		return bi - ai
+	})
+	return tokens
+}
+
+func (a *RefreshTokens) limit(limit int) int {
+	if limit <= 0 {
+		return a.tokensLimit
+	}
+	return limit
+}
+
+func (a *RefreshTokens) add(t refreshToken) refreshToken {
+	a.lastId++
+	t.Id = strconv.Itoa(a.lastId)
+	t.LastUsedAt = t.CreatedAt
+	a.tokens[t.Id] = t
+	return t
+}
+
+func (a *RefreshTokens) List(_ context.Context, in domain.RefreshTokenListDTOInput) (domain.RefreshTokenListDTOOutput, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tokens := a.active(in.AccountId)
+	out := make([]domain.RefreshTokenListDTOOutputToken, 0, len(tokens))
+	for _, t := range tokens[:min(len(tokens), a.limit(in.Limit))] {
+		out = append(out, t.RefreshTokenListDTOOutputToken)
+	}
+	return domain.RefreshTokenListDTOOutput{Tokens: out}, nil
+}
+
+func (a *RefreshTokens) Add(_ context.Context, in domain.RefreshTokenAddDTOInput) (domain.RefreshTokenAddDTOOutput, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limit := a.limit(in.Limit)
+	policy := in.EvictionPolicy
+	if policy == "" {
+		policy = a.evictionPolicy
+	}
+
+	tokens := a.active(in.AccountId)
+	if len(tokens) >= limit {
+		if policy == domain.RefreshTokenEvictionPolicyRejectNew {
+			return domain.RefreshTokenAddDTOOutput{}, domain.ErrRefreshTokensLimitReached
+		}
+		for _, t := range tokens[limit-1:] {
+			delete(a.tokens, t.Id)
+		}
+	}
+
+	t := a.add(refreshToken{
+		RefreshTokenListDTOOutputToken: domain.RefreshTokenListDTOOutputToken{
+			CreatedAt: in.CreatedAt,
+			ExpiresAt: in.ExpiresAt,
+			UserAgent: in.UserAgent,
+			Ip:        in.Ip,
+		},
+		accountId: in.AccountId,
+		familyId:  in.FamilyId,
+	})
+	return domain.RefreshTokenAddDTOOutput{
+		Id:        t.Id,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.ExpiresAt,
+	}, nil
+}
+
+// Replaced token is kept with the time it has been replaced at.
+func (a *RefreshTokens) Replace(_ context.Context, in domain.RefreshTokenReplaceDTOInput) (domain.RefreshTokenReplaceDTOOutput, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	old, ok := a.tokens[in.Id]
+	if !ok {
+		return domain.RefreshTokenReplaceDTOOutput{}, nil
+	}
+	if !old.replacedAt.IsZero() {
+		return domain.RefreshTokenReplaceDTOOutput{ReplacedAt: old.replacedAt}, nil
+	}
+	old.replacedAt = in.CreatedAt
+	a.tokens[old.Id] = old
+
+	t := a.add(refreshToken{
+		RefreshTokenListDTOOutputToken: domain.RefreshTokenListDTOOutputToken{
+			CreatedAt: in.CreatedAt,
+			ExpiresAt: in.ExpiresAt,
+			UserAgent: in.UserAgent,
+			Ip:        in.Ip,
+		},
+		accountId: old.accountId,
+		familyId:  old.familyId,
+	})
+	return domain.RefreshTokenReplaceDTOOutput{
+		Id:        t.Id,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.ExpiresAt,
+	}, nil
+}
+
+func (a *RefreshTokens) Delete(_ context.Context, in domain.RefreshTokenDeleteDTOInput) (domain.RefreshTokenDeleteDTOOutput, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.tokens[in.Id]; !ok {
+		return domain.RefreshTokenDeleteDTOOutput{}, nil
+	}
+	delete(a.tokens, in.Id)
+	return domain.RefreshTokenDeleteDTOOutput{Id: in.Id}, nil
+}
+
+func (a *RefreshTokens) DeleteByAccountId(_ context.Context, in domain.RefreshTokenDeleteByAccountIdDTOInput) (domain.RefreshTokenDeleteByAccountIdDTOOutput, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return domain.RefreshTokenDeleteByAccountIdDTOOutput{
+		Ids: a.deleteFunc(func(t refreshToken) bool {
+			return t.accountId == in.Id && t.Id != in.ExceptId
+		}),
+	}, nil
+}
+
+func (a *RefreshTokens) DeleteByFamilyId(_ context.Context, in domain.RefreshTokenDeleteByFamilyIdDTOInput) (domain.RefreshTokenDeleteByFamilyIdDTOOutput, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return domain.RefreshTokenDeleteByFamilyIdDTOOutput{
+		Ids: a.deleteFunc(func(t refreshToken) bool {
+			return t.familyId == in.FamilyId
+		}),
+	}, nil
+}
+
+func (a *RefreshTokens) deleteFunc(del func(refreshToken) bool) []string {
+	ids := make([]string, 0)
+	for id, t := range a.tokens {
+		if del(t) {
+			delete(a.tokens, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (a *RefreshTokens) Touch(_ context.Context, in domain.RefreshTokenTouchDTOInput) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if t, ok := a.tokens[in.Id]; ok {
+		t.LastUsedAt = in.LastUsedAt
+		a.tokens[in.Id] = t
+	}
+	return nil
+}
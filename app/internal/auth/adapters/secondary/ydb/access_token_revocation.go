@@ -0,0 +1,274 @@
+package ydb_adapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+)
+
+const defaultAccessTokenRevocationsRefreshInterval = 10 * time.Second
+
+// Access token deny-list stored in YDB. Rows are removed by TTL once the tokens they revoke expire.
+// Every instance loads the whole deny-list in memory on start and reloads it in the background with Run,
+// so tokens revoked by other instances are rejected after the refresh interval at most.
+// Lookups never wait for YDB.
+type AccessTokenRevocations struct {
+	db              *ydb.Driver
+	l               *zap.Logger
+	refreshInterval time.Duration
+
+	mu       sync.RWMutex
+	accounts map[string]domain.AccessTokenRevocation
+	tokens   map[string]domain.AccessTokenRevocation
+	// Revocations made by this instance that a running reload may miss, oldest first.
+	recent []recentAccessTokenRevocation
+}
+
+type recentAccessTokenRevocation struct {
+	revocation domain.AccessTokenRevocation
+	revokedAt  time.Time
+}
+
+var _ domain.AccessTokenRevocations = (*AccessTokenRevocations)(nil)
+
+type AccessTokenRevocationsConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+	// How often the deny-list is reloaded. Defaults to 10 seconds.
+	RefreshInterval time.Duration
+}
+
+// Loads the deny-list, fails if it can't be loaded so that the service doesn't start
+// rejecting every access token.
+func NewAccessTokenRevocations(ctx context.Context, conf AccessTokenRevocationsConf) (*AccessTokenRevocations, error) {
+	adapter := &AccessTokenRevocations{
+		db:              conf.DbDriver,
+		l:               conf.Logger,
+		refreshInterval: conf.RefreshInterval,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+	if conf.RefreshInterval == 0 {
+		adapter.refreshInterval = defaultAccessTokenRevocationsRefreshInterval
+	}
+
+	if err := adapter.reload(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load access token revocations: %w", err)
+	}
+
+	return adapter, nil
+}
+
+// Reload the deny-list every refresh interval until the context is done.
+func (a *AccessTokenRevocations) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := a.reload(ctx); err != nil {
+			// Keep checking against the previously loaded deny-list while YDB is unavailable.
+			a.l.Error("failed to reload access token revocations", zap.Error(err))
+		}
+	}
+}
+
+func (a *AccessTokenRevocations) reload(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, a.refreshInterval)
+	defer cancel()
+
+	startedAt := time.Now()
+	revocations, err := a.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	accounts := make(map[string]domain.AccessTokenRevocation)
+	tokens := make(map[string]domain.AccessTokenRevocation)
+	for _, revocation := range revocations {
+		addAccessTokenRevocation(accounts, tokens, revocation)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Revocations stored before the reload started are loaded, the rest are kept until the next reload.
+	recent := a.recent[:0]
+	for _, r := range a.recent {
+		if r.revokedAt.Before(startedAt) {
+			continue
+		}
+		addAccessTokenRevocation(accounts, tokens, r.revocation)
+		recent = append(recent, r)
+	}
+	a.recent = recent
+	a.accounts, a.tokens = accounts, tokens
+	return nil
+}
+
+// Account revocations replace the previous ones of the account since they revoke more tokens.
+func accessTokenRevocationId(revocation domain.AccessTokenRevocation) string {
+	if revocation.TokenId != "" {
+		return "token:" + revocation.TokenId
+	}
+	return "account:" + revocation.AccountId
+}
+
+var queryRevokeAccessTokens = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $token_id AS Optional<Utf8>;
+DECLARE $account_id AS Optional<Utf8>;
+DECLARE $revoked_at AS Timestamp;
+DECLARE $expires_at AS Timestamp;
+
+UPSERT INTO {{table.access_token_revocations}} ( id, token_id, account_id, revoked_at, expires_at )
+VALUES ( $id, $token_id, $account_id, $revoked_at, $expires_at );
+`,
+	"{{table.access_token_revocations}}", tableAccessTokenRevocations,
+)
+
+func (a *AccessTokenRevocations) Revoke(ctx context.Context, revocation domain.AccessTokenRevocation) error {
+	tokenId := types.NullValue(types.TypeUTF8)
+	if revocation.TokenId != "" {
+		tokenId = types.OptionalValue(types.UTF8Value(revocation.TokenId))
+	}
+	accountId := types.NullValue(types.TypeUTF8)
+	if revocation.AccountId != "" {
+		accountId = types.OptionalValue(types.UTF8Value(revocation.AccountId))
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryRevokeAccessTokens, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(accessTokenRevocationId(revocation))),
+			table.ValueParam("$token_id", tokenId),
+			table.ValueParam("$account_id", accountId),
+			table.ValueParam("$revoked_at", types.TimestampValueFromTime(revocation.RevokedAt)),
+			table.ValueParam("$expires_at", types.TimestampValueFromTime(revocation.ExpiresAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction revoke access tokens: %w", err)
+	}
+
+	// Rejected by this instance right away.
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pruneRecent(now)
+	a.recent = append(a.recent, recentAccessTokenRevocation{revocation: revocation, revokedAt: now})
+	addAccessTokenRevocation(a.accounts, a.tokens, revocation)
+
+	return nil
+}
+
+// Reloads time out after the refresh interval, so none started before the cutoff is still running:
+// older revocations are either loaded or kept in the current deny-list if the reloads have failed.
+func (a *AccessTokenRevocations) pruneRecent(now time.Time) {
+	cutoff := now.Add(-2 * a.refreshInterval)
+	i := 0
+	for i < len(a.recent) && a.recent[i].revokedAt.Before(cutoff) {
+		i++
+	}
+	a.recent = a.recent[i:]
+}
+
+func (a *AccessTokenRevocations) Revoked(_ context.Context, token domain.AccessToken) (bool, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	now := time.Now()
+	for _, revocation := range []domain.AccessTokenRevocation{a.tokens[token.Id], a.accounts[token.SubjectId]} {
+		if now.Before(revocation.ExpiresAt) && revocation.Applies(token) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Account revocations are replaced by the later ones since they revoke more tokens.
+func addAccessTokenRevocation(accounts, tokens map[string]domain.AccessTokenRevocation, revocation domain.AccessTokenRevocation) {
+	if revocation.TokenId != "" {
+		tokens[revocation.TokenId] = revocation
+		return
+	}
+	if prev, ok := accounts[revocation.AccountId]; !ok || prev.RevokedAt.Before(revocation.RevokedAt) {
+		accounts[revocation.AccountId] = revocation
+	}
+}
+
+var queryListAccessTokenRevocations = template.ReplaceAllPairs(`
+DECLARE $now AS Timestamp;
+
+SELECT
+    token_id,
+    account_id,
+    revoked_at,
+    expires_at
+FROM
+    {{table.access_token_revocations}}
+WHERE
+    expires_at > $now;
+`,
+	"{{table.access_token_revocations}}", tableAccessTokenRevocations,
+)
+
+func (a *AccessTokenRevocations) load(ctx context.Context) ([]domain.AccessTokenRevocation, error) {
+	var out []domain.AccessTokenRevocation
+
+	// Scan query since the deny-list may exceed the row limit of data queries.
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		out = make([]domain.AccessTokenRevocation, 0)
+
+		res, err := s.StreamExecuteScanQuery(ctx, queryListAccessTokenRevocations, table.NewQueryParameters(
+			table.ValueParam("$now", types.TimestampValueFromTime(time.Now())),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var revocation domain.AccessTokenRevocation
+				if err := res.ScanNamed(
+					named.OptionalWithDefault("token_id", &revocation.TokenId),
+					named.OptionalWithDefault("account_id", &revocation.AccountId),
+					named.Required("revoked_at", &revocation.RevokedAt),
+					named.Required("expires_at", &revocation.ExpiresAt),
+				); err != nil {
+					return err
+				}
+				out = append(out, revocation)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query list access token revocations: %w", err)
+	}
+
+	return out, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/bratushkadan/floral/internal/auth/core/domain"
 	"github.com/bratushkadan/floral/pkg/auth"
 	"github.com/bratushkadan/floral/pkg/resource/idhash"
+	"github.com/bratushkadan/floral/pkg/template"
 	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
 	"github.com/ydb-platform/ydb-go-sdk/v3"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
@@ -53,8 +54,9 @@ DECLARE $password AS Utf8;
 DECLARE $email AS Utf8;
 DECLARE $type AS String;
 DECLARE $created_at AS Datetime;
-UPSERT INTO %s ( name, password, email, type, created_at )
-VALUES ( $name, $password, $email, $type, $created_at )
+DECLARE $activated_at AS Optional<Datetime>;
+UPSERT INTO %s ( name, password, email, type, created_at, activated_at )
+VALUES ( $name, $password, $email, $type, $created_at, $activated_at )
 RETURNING id, name, email, type
 `, tableAccounts)
 
@@ -66,13 +68,20 @@ func (a *Account) CreateAccount(ctx context.Context, in domain.CreateAccountDTOI
 		return domain.CreateAccountDTOOutput{}, fmt.Errorf("failed to hash account password: %v", err)
 	}
 
+	now := time.Now()
+	activatedAt := types.NullValue(types.TypeDatetime)
+	if in.Activated {
+		activatedAt = types.OptionalValue(types.DatetimeValueFromTime(now))
+	}
+
 	err = a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
 		res, err := tx.Execute(ctx, queryCreateAccount, table.NewQueryParameters(
 			table.ValueParam("$name", types.UTF8Value(in.Name)),
 			table.ValueParam("$email", types.UTF8Value(in.Email)),
 			table.ValueParam("$password", types.UTF8Value(hashedPass)),
 			table.ValueParam("$type", types.StringValueFromString(in.Type)),
-			table.ValueParam("$created_at", types.DatetimeValueFromTime(time.Now())),
+			table.ValueParam("$created_at", types.DatetimeValueFromTime(now)),
+			table.ValueParam("$activated_at", activatedAt),
 		))
 		if err != nil {
 			return err
@@ -106,6 +115,15 @@ func (a *Account) CreateAccount(ctx context.Context, in domain.CreateAccountDTOI
 				out.Id = idStr
 			}
 		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+
+		if in.CreationNotificationId != "" {
+			if err := enqueueAccountCreationNotification(ctx, tx, in.CreationNotificationId, in.Email, now); err != nil {
+				return fmt.Errorf("failed to enqueue account creation notification: %w", err)
+			}
+		}
 
 		return nil
 		// return res.Close() // <---- If I do not require RETURNING values when executing query
@@ -126,11 +144,15 @@ SELECT
   name,
   email,
   type,
-  (activated_at IS NOT NULL) AS activated
+  (activated_at IS NOT NULL) AS activated,
+  created_at,
+  activated_at,
+  pending_email,
+  suspended_at
 FROM
   %s
 WHERE
-  id = $id;
+  id = $id AND deleted_at IS NULL;
 `, tableAccounts)
 
 func (a *Account) FindAccount(ctx context.Context, in domain.FindAccountDTOInput) (*domain.FindAccountDTOOutput, error) {
@@ -160,6 +182,10 @@ func (a *Account) FindAccount(ctx context.Context, in domain.FindAccountDTOInput
 					named.Required("email", &account.Email),
 					named.Required("type", &account.Type),
 					named.Required("activated", &account.Activated),
+					named.Required("created_at", &account.CreatedAt),
+					named.OptionalWithDefault("activated_at", &account.ActivatedAt),
+					named.OptionalWithDefault("pending_email", &account.PendingEmail),
+					named.OptionalWithDefault("suspended_at", &account.SuspendedAt),
 				); err != nil {
 					return err
 				}
@@ -188,7 +214,7 @@ FROM
 VIEW
   %s 
 WHERE
-  email = $email;
+  email = $email AND deleted_at IS NULL;
 `, tableAccounts, tableAccountsIndexEmailUnique)
 
 func (a *Account) FindAccountByEmail(ctx context.Context, in domain.FindAccountByEmailDTOInput) (*domain.FindAccountByEmailDTOOutput, error) {
@@ -241,13 +267,16 @@ DECLARE $email AS Utf8;
 SELECT
   id,
   password,
-  (activated_at IS NOT NULL) AS activated
+  type,
+  (activated_at IS NOT NULL) AS activated,
+  (suspended_at IS NOT NULL) AS suspended,
+  (mfa_enabled_at IS NOT NULL) AS mfa_enabled
 FROM
   %s
 VIEW
   %s 
 WHERE
-  email = $email;
+  email = $email AND deleted_at IS NULL;
 `, tableAccounts, tableAccountsIndexEmailUnique)
 
 func (a *Account) CheckAccountCredentials(ctx context.Context, in domain.CheckAccountCredentialsDTOInput) (domain.CheckAccountCredentialsDTOOutput, error) {
@@ -271,7 +300,10 @@ func (a *Account) CheckAccountCredentials(ctx context.Context, in domain.CheckAc
 				if err := res.ScanNamed(
 					named.Required("id", &intId),
 					named.Required("password", &password),
+					named.Required("type", &out.AccountType),
 					named.Required("activated", &out.Activated),
+					named.Required("suspended", &out.Suspended),
+					named.Required("mfa_enabled", &out.MfaEnabled),
 				); err != nil {
 					return err
 				}
@@ -326,7 +358,43 @@ ON
 --   activated_at = $activated_at
 -- WHERE
 --   email IN $emails;
-`, tableAccounts, tableAccountsIndexEmailUnique, tableAccounts, tableAccounts)
+
+-- Apply confirmed pending emails unless the email has been taken in the meantime.
+-- A single account takes the email even if several have it pending, so the email stays unique.
+
+$pending = (
+    SELECT
+      Unwrap(pending_email) AS email,
+      MIN(id) AS id
+    FROM
+      %s VIEW %s
+    WHERE
+      pending_email IN $emails AND deleted_at IS NULL
+    GROUP BY
+      pending_email
+);
+
+$to_change_email = (
+    SELECT
+      p.id AS id,
+      p.email AS email,
+      CAST(NULL AS Utf8) AS pending_email
+    FROM
+      $pending AS p
+    LEFT ONLY JOIN
+      %s VIEW %s AS taken
+    ON
+      p.email = taken.email
+);
+
+UPDATE
+  %s
+ON
+  SELECT * FROM $to_change_email;
+`,
+	tableAccounts, tableAccountsIndexEmailUnique, tableAccounts, tableAccounts,
+	tableAccounts, tableAccountsIndexPendingEmail, tableAccounts, tableAccountsIndexEmailUnique, tableAccounts,
+)
 
 func (a *Account) ActivateAccountsByEmail(ctx context.Context, in domain.ActivateAccountsByEmailDTOInput) error {
 	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
@@ -353,3 +421,524 @@ func (a *Account) ActivateAccountsByEmail(ctx context.Context, in domain.Activat
 
 	return nil
 }
+
+var queryUpdatePassword = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $password AS Utf8;
+
+UPDATE
+  %s
+SET
+  password = $password
+WHERE
+  id = $id;
+`, tableAccounts)
+
+func (a *Account) UpdatePassword(ctx context.Context, in domain.UpdatePasswordDTOInput) error {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	hashedPass, err := a.ph.Hash(in.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash account password: %v", err)
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryUpdatePassword, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$password", types.UTF8Value(hashedPass)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to run update account password ydb query: %w", err)
+	}
+
+	return nil
+}
+
+var queryFindPendingEmailConflicts = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $pending_email AS Utf8;
+
+SELECT
+  COUNT(*) AS conflicts
+FROM
+  %s
+VIEW
+  %s
+WHERE
+  pending_email = $pending_email AND id != $id AND deleted_at IS NULL;
+`, tableAccounts, tableAccountsIndexPendingEmail)
+
+var queryUpdateEmail = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $pending_email AS Utf8;
+
+UPDATE
+  %s
+SET
+  pending_email = $pending_email
+WHERE
+  id = $id;
+`, tableAccounts)
+
+// Returns domain.ErrEmailIsInUse if another account has the email pending,
+// since only one of the accounts could take it on confirmation.
+func (a *Account) UpdateEmail(ctx context.Context, in domain.UpdateEmailDTOInput) error {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$id", types.Int64Value(intId)),
+		table.ValueParam("$pending_email", types.UTF8Value(in.Email)),
+	)
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryFindPendingEmailConflicts, params)
+		if err != nil {
+			return err
+		}
+		var conflicts uint64
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				if err := res.ScanNamed(named.Required("conflicts", &conflicts)); err != nil {
+					_ = res.Close()
+					return err
+				}
+			}
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		if conflicts > 0 {
+			return domain.ErrEmailIsInUse
+		}
+
+		res, err = tx.Execute(ctx, queryUpdateEmail, params)
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to run update account email ydb query: %w", err)
+	}
+
+	return nil
+}
+
+var queryDeleteAccount = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $deleted_at AS Datetime;
+
+UPDATE
+  %s
+SET
+  deleted_at = $deleted_at
+WHERE
+  id = $id AND deleted_at IS NULL;
+`, tableAccounts)
+
+func (a *Account) DeleteAccount(ctx context.Context, in domain.DeleteAccountDTOInput) error {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryDeleteAccount, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$deleted_at", types.DatetimeValueFromTime(in.DeletedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to run delete account ydb query: %w", err)
+	}
+
+	return nil
+}
+
+var queryListDeletedAccounts = fmt.Sprintf(`
+DECLARE $deleted_before AS Datetime;
+DECLARE $limit AS Uint64;
+
+SELECT
+  id,
+  email,
+  deleted_at
+FROM
+  %s
+VIEW
+  %s
+WHERE
+  deleted_at < $deleted_before
+ORDER BY
+  deleted_at
+LIMIT $limit;
+`, tableAccounts, tableAccountsIndexDeletedAt)
+
+func (a *Account) ListDeletedAccounts(ctx context.Context, in domain.ListDeletedAccountsDTOInput) (domain.ListDeletedAccountsDTOOutput, error) {
+	out := domain.ListDeletedAccountsDTOOutput{
+		Accounts: make([]domain.ListDeletedAccountsDTOOutputAccount, 0),
+	}
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		_, res, err := s.Execute(ctx, readTx, queryListDeletedAccounts, table.NewQueryParameters(
+			table.ValueParam("$deleted_before", types.DatetimeValueFromTime(in.DeletedBefore)),
+			table.ValueParam("$limit", types.Uint64Value(uint64(in.Limit))),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var intId int64
+				var account domain.ListDeletedAccountsDTOOutputAccount
+				if err := res.ScanNamed(
+					named.Required("id", &intId),
+					named.Required("email", &account.Email),
+					named.OptionalWithDefault("deleted_at", &account.DeletedAt),
+				); err != nil {
+					return err
+				}
+
+				id, err := a.idHasher.EncodeInt64(intId)
+				if err != nil {
+					return fmt.Errorf("failed to encode account int id: %w", err)
+				}
+				account.Id = id
+				out.Accounts = append(out.Accounts, account)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return domain.ListDeletedAccountsDTOOutput{}, fmt.Errorf("failed to run list deleted accounts ydb query: %w", err)
+	}
+
+	return out, nil
+}
+
+var queryPurgeAccount = template.ReplaceAllPairs(`
+DECLARE $id AS Int64;
+DECLARE $account_id AS Utf8;
+
+$refresh_tokens_to_delete = (
+    SELECT
+        id
+    FROM
+        {{table.refresh_tokens}}
+    VIEW
+        {{index.account_id}}
+    WHERE
+        account_id = $account_id
+);
+
+DELETE FROM
+    {{table.refresh_tokens}}
+ON SELECT * FROM
+    $refresh_tokens_to_delete;
+
+DELETE FROM
+    {{table.passkey_credentials}}
+WHERE
+    account_id = $account_id;
+
+$identities_to_delete = (
+    SELECT
+        provider,
+        subject
+    FROM
+        {{table.identities}}
+    VIEW
+        {{index.identities_account_id}}
+    WHERE
+        account_id = $account_id
+);
+
+DELETE FROM
+    {{table.identities}}
+ON SELECT * FROM
+    $identities_to_delete;
+
+$api_keys_to_delete = (
+    SELECT
+        id
+    FROM
+        {{table.api_keys}}
+    VIEW
+        {{index.api_keys_account_id}}
+    WHERE
+        account_id = $account_id
+);
+
+DELETE FROM
+    {{table.api_keys}}
+ON SELECT * FROM
+    $api_keys_to_delete;
+
+DELETE FROM
+    {{table.accounts}}
+WHERE
+    id = $id AND deleted_at IS NOT NULL;
+`,
+	"{{table.accounts}}", tableAccounts,
+	"{{table.refresh_tokens}}", tableRefreshTokens,
+	"{{table.passkey_credentials}}", tablePasskeyCredentials,
+	"{{table.identities}}", tableIdentities,
+	"{{index.account_id}}", tableRefreshTokensIndexAccountId,
+	"{{index.identities_account_id}}", tableIdentitiesIndexAccountId,
+	"{{table.api_keys}}", tableApiKeys,
+	"{{index.api_keys_account_id}}", tableApiKeysIndexAccountId,
+)
+
+// Only accounts marked deleted are purged.
+func (a *Account) PurgeAccount(ctx context.Context, in domain.PurgeAccountDTOInput) error {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryPurgeAccount, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$account_id", types.UTF8Value(in.Id)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to run purge account ydb query: %w", err)
+	}
+
+	return nil
+}
+
+var queryListAccounts = fmt.Sprintf(`
+DECLARE $type AS Optional<String>;
+DECLARE $activated AS Optional<Bool>;
+DECLARE $created_after AS Optional<Datetime>;
+DECLARE $created_before AS Optional<Datetime>;
+DECLARE $after_id AS Int64;
+DECLARE $limit AS Uint64;
+
+SELECT
+  id,
+  name,
+  email,
+  type,
+  created_at,
+  activated_at,
+  suspended_at
+FROM
+  %s
+WHERE
+  id > $after_id
+  AND deleted_at IS NULL
+  AND ($type IS NULL OR type = $type)
+  AND ($activated IS NULL OR (activated_at IS NOT NULL) = $activated)
+  AND ($created_after IS NULL OR created_at >= $created_after)
+  AND ($created_before IS NULL OR created_at < $created_before)
+ORDER BY
+  id
+LIMIT $limit;
+`, tableAccounts)
+
+func (a *Account) ListAccounts(ctx context.Context, in domain.ListAccountsDTOInput) (domain.ListAccountsDTOOutput, error) {
+	var afterId int64
+	if in.AfterId != "" {
+		intId, err := a.idHasher.DecodeInt64(in.AfterId)
+		if err != nil {
+			return domain.ListAccountsDTOOutput{}, err
+		}
+		afterId = intId
+	}
+
+	accountType := types.NullValue(types.TypeString)
+	if in.Type != "" {
+		accountType = types.OptionalValue(types.StringValueFromString(in.Type))
+	}
+	activated := types.NullValue(types.TypeBool)
+	if in.Activated != nil {
+		activated = types.OptionalValue(types.BoolValue(*in.Activated))
+	}
+	createdAfter := types.NullValue(types.TypeDatetime)
+	if !in.CreatedAfter.IsZero() {
+		createdAfter = types.OptionalValue(types.DatetimeValueFromTime(in.CreatedAfter))
+	}
+	createdBefore := types.NullValue(types.TypeDatetime)
+	if !in.CreatedBefore.IsZero() {
+		createdBefore = types.OptionalValue(types.DatetimeValueFromTime(in.CreatedBefore))
+	}
+
+	out := domain.ListAccountsDTOOutput{
+		Accounts: make([]domain.ListAccountsDTOOutputAccount, 0),
+	}
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		_, res, err := s.Execute(ctx, readTx, queryListAccounts, table.NewQueryParameters(
+			table.ValueParam("$type", accountType),
+			table.ValueParam("$activated", activated),
+			table.ValueParam("$created_after", createdAfter),
+			table.ValueParam("$created_before", createdBefore),
+			table.ValueParam("$after_id", types.Int64Value(afterId)),
+			table.ValueParam("$limit", types.Uint64Value(uint64(in.Limit))),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var intId int64
+				var account domain.ListAccountsDTOOutputAccount
+				if err := res.ScanNamed(
+					named.Required("id", &intId),
+					named.Required("name", &account.Name),
+					named.Required("email", &account.Email),
+					named.Required("type", &account.Type),
+					named.Required("created_at", &account.CreatedAt),
+					named.OptionalWithDefault("activated_at", &account.ActivatedAt),
+					named.OptionalWithDefault("suspended_at", &account.SuspendedAt),
+				); err != nil {
+					return err
+				}
+
+				id, err := a.idHasher.EncodeInt64(intId)
+				if err != nil {
+					return fmt.Errorf("failed to encode account int id: %w", err)
+				}
+				account.Id = id
+				out.Accounts = append(out.Accounts, account)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return domain.ListAccountsDTOOutput{}, fmt.Errorf("failed to run list accounts ydb query: %w", err)
+	}
+
+	return out, nil
+}
+
+var querySuspendAccount = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $suspended_at AS Optional<Datetime>;
+
+UPDATE
+  %s
+SET
+  suspended_at = $suspended_at
+WHERE
+  id = $id;
+`, tableAccounts)
+
+func (a *Account) setAccountSuspendedAt(ctx context.Context, id string, suspendedAt types.Value) error {
+	intId, err := a.idHasher.DecodeInt64(id)
+	if err != nil {
+		return err
+	}
+
+	return a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, querySuspendAccount, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$suspended_at", suspendedAt),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	})
+}
+
+func (a *Account) SuspendAccount(ctx context.Context, in domain.SuspendAccountDTOInput) error {
+	if err := a.setAccountSuspendedAt(ctx, in.Id, types.OptionalValue(types.DatetimeValueFromTime(in.SuspendedAt))); err != nil {
+		return fmt.Errorf("failed to run suspend account ydb query: %w", err)
+	}
+	return nil
+}
+
+func (a *Account) UnsuspendAccount(ctx context.Context, in domain.UnsuspendAccountDTOInput) error {
+	if err := a.setAccountSuspendedAt(ctx, in.Id, types.NullValue(types.TypeDatetime)); err != nil {
+		return fmt.Errorf("failed to run unsuspend account ydb query: %w", err)
+	}
+	return nil
+}
+
+var queryUpdateAccountType = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $type AS String;
+
+UPDATE
+  %s
+SET
+  type = $type
+WHERE
+  id = $id;
+`, tableAccounts)
+
+func (a *Account) UpdateAccountType(ctx context.Context, in domain.UpdateAccountTypeDTOInput) error {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryUpdateAccountType, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$type", types.StringValueFromString(in.Type)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to run update account type ydb query: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package ydb_adapter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+)
+
+// Account creation notifications are enqueued by the Account adapter
+// in the same transaction as the account itself.
+type AccountCreationOutbox struct {
+	db *ydb.Driver
+	l  *zap.Logger
+}
+
+var _ domain.AccountCreationOutbox = (*AccountCreationOutbox)(nil)
+
+type AccountCreationOutboxConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+}
+
+func NewAccountCreationOutbox(conf AccountCreationOutboxConf) *AccountCreationOutbox {
+	adapter := &AccountCreationOutbox{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryEnqueueAccountCreationNotification = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $email AS Utf8;
+DECLARE $created_at AS Timestamp;
+
+INSERT INTO {{table.account_creation_outbox}} ( id, email, attempts, created_at, next_attempt_at )
+VALUES ( $id, $email, 0, $created_at, $created_at );
+`,
+	"{{table.account_creation_outbox}}", tableAccountCreationOutbox,
+)
+
+func enqueueAccountCreationNotification(ctx context.Context, tx table.TransactionActor, id string, email string, createdAt time.Time) error {
+	res, err := tx.Execute(ctx, queryEnqueueAccountCreationNotification, table.NewQueryParameters(
+		table.ValueParam("$id", types.UTF8Value(id)),
+		table.ValueParam("$email", types.UTF8Value(email)),
+		table.ValueParam("$created_at", types.TimestampValueFromTime(createdAt)),
+	))
+	if err != nil {
+		return err
+	}
+	if err := res.Close(); err != nil {
+		return err
+	}
+
+	return res.Err()
+}
+
+var queryListDueAccountCreationNotifications = template.ReplaceAllPairs(`
+DECLARE $now AS Timestamp;
+DECLARE $limit AS Uint64;
+
+SELECT
+    id,
+    email,
+    attempts,
+    created_at,
+    next_attempt_at
+FROM
+    {{table.account_creation_outbox}}
+VIEW
+    {{index.next_attempt_at}}
+WHERE
+    next_attempt_at <= $now
+ORDER BY
+    next_attempt_at
+LIMIT $limit;
+`,
+	"{{table.account_creation_outbox}}", tableAccountCreationOutbox,
+	"{{index.next_attempt_at}}", tableAccountCreationOutboxIndexNextAttemptAt,
+)
+
+func (o *AccountCreationOutbox) ListDue(ctx context.Context, in domain.AccountCreationOutboxListDueDTOInput) ([]domain.AccountCreationOutboxMessage, error) {
+	out := make([]domain.AccountCreationOutboxMessage, 0)
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := o.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		out = out[:0]
+
+		_, res, err := s.Execute(ctx, readTx, queryListDueAccountCreationNotifications, table.NewQueryParameters(
+			table.ValueParam("$now", types.TimestampValueFromTime(in.Now)),
+			table.ValueParam("$limit", types.Uint64Value(uint64(in.Limit))),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var (
+					msg      domain.AccountCreationOutboxMessage
+					attempts uint32
+				)
+				if err := res.ScanNamed(
+					named.Required("id", &msg.Id),
+					named.Required("email", &msg.Email),
+					named.Required("attempts", &attempts),
+					named.Required("created_at", &msg.CreatedAt),
+					named.Required("next_attempt_at", &msg.NextAttemptAt),
+				); err != nil {
+					return err
+				}
+				msg.Attempts = int(attempts)
+				out = append(out, msg)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query list due account creation notifications: %w", err)
+	}
+
+	return out, nil
+}
+
+var queryDeleteAccountCreationNotification = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+
+DELETE FROM {{table.account_creation_outbox}}
+WHERE id = $id;
+`,
+	"{{table.account_creation_outbox}}", tableAccountCreationOutbox,
+)
+
+func (o *AccountCreationOutbox) Delete(ctx context.Context, id string) error {
+	if err := o.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryDeleteAccountCreationNotification, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(id)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction delete account creation notification: %w", err)
+	}
+
+	return nil
+}
+
+var queryRetryAccountCreationNotification = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $attempts AS Uint32;
+DECLARE $next_attempt_at AS Timestamp;
+DECLARE $last_error AS Utf8;
+
+UPDATE {{table.account_creation_outbox}}
+SET
+    attempts = $attempts,
+    next_attempt_at = $next_attempt_at,
+    last_error = $last_error
+WHERE id = $id;
+`,
+	"{{table.account_creation_outbox}}", tableAccountCreationOutbox,
+)
+
+func (o *AccountCreationOutbox) Retry(ctx context.Context, in domain.AccountCreationOutboxRetryDTOInput) error {
+	if err := o.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryRetryAccountCreationNotification, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(in.Id)),
+			table.ValueParam("$attempts", types.Uint32Value(uint32(in.Attempts))),
+			table.ValueParam("$next_attempt_at", types.TimestampValueFromTime(in.NextAttemptAt)),
+			table.ValueParam("$last_error", types.UTF8Value(in.LastError)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction retry account creation notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,371 @@
+package ydb_adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+var queryFindAccountMfa = fmt.Sprintf(`
+DECLARE $id AS Int64;
+SELECT
+  mfa_totp_secret,
+  mfa_enabled_at,
+  mfa_totp_last_counter,
+  mfa_recovery_codes
+FROM
+  %s
+WHERE
+  id = $id AND deleted_at IS NULL;
+`, tableAccounts)
+
+func (a *Account) FindAccountMfa(ctx context.Context, in domain.FindAccountMfaDTOInput) (*domain.FindAccountMfaDTOOutput, error) {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var out *domain.FindAccountMfaDTOOutput
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		_, res, err := s.Execute(ctx, readTx, queryFindAccountMfa, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var (
+					mfa           domain.FindAccountMfaDTOOutput
+					recoveryCodes string
+				)
+				if err := res.ScanNamed(
+					named.OptionalWithDefault("mfa_totp_secret", &mfa.TotpSecret),
+					named.OptionalWithDefault("mfa_enabled_at", &mfa.EnabledAt),
+					named.OptionalWithDefault("mfa_totp_last_counter", &mfa.TotpLastCounter),
+					named.OptionalWithDefault("mfa_recovery_codes", &recoveryCodes),
+				); err != nil {
+					return err
+				}
+
+				hashes, err := decodeRecoveryCodeHashes(recoveryCodes)
+				if err != nil {
+					return err
+				}
+				mfa.RecoveryCodesLeft = len(hashes)
+
+				out = &mfa
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to run find account mfa ydb query: %w", err)
+	}
+
+	return out, nil
+}
+
+var querySetMfaTotpSecret = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $mfa_totp_secret AS Utf8;
+
+UPDATE
+  %s
+SET
+  mfa_totp_secret = $mfa_totp_secret,
+  mfa_enabled_at = NULL,
+  mfa_totp_last_counter = NULL,
+  mfa_recovery_codes = NULL
+WHERE
+  id = $id;
+`, tableAccounts)
+
+func (a *Account) SetMfaTotpSecret(ctx context.Context, in domain.SetMfaTotpSecretDTOInput) error {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, querySetMfaTotpSecret, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$mfa_totp_secret", types.UTF8Value(in.TotpSecret)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to run set account mfa totp secret ydb query: %w", err)
+	}
+
+	return nil
+}
+
+var queryEnableMfa = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $mfa_enabled_at AS Datetime;
+DECLARE $mfa_totp_last_counter AS Uint64;
+DECLARE $mfa_recovery_codes AS Json;
+
+UPDATE
+  %s
+SET
+  mfa_enabled_at = $mfa_enabled_at,
+  mfa_totp_last_counter = $mfa_totp_last_counter,
+  mfa_recovery_codes = $mfa_recovery_codes
+WHERE
+  id = $id AND mfa_totp_secret IS NOT NULL;
+`, tableAccounts)
+
+func (a *Account) EnableMfa(ctx context.Context, in domain.EnableMfaDTOInput) error {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	hashes := make([]string, 0, len(in.RecoveryCodes))
+	for _, code := range in.RecoveryCodes {
+		hash, err := a.ph.Hash(code)
+		if err != nil {
+			return fmt.Errorf("failed to hash mfa recovery code: %v", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	recoveryCodes, err := json.Marshal(hashes)
+	if err != nil {
+		return fmt.Errorf("failed to encode mfa recovery code hashes: %v", err)
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryEnableMfa, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$mfa_enabled_at", types.DatetimeValueFromTime(in.EnabledAt)),
+			table.ValueParam("$mfa_totp_last_counter", types.Uint64Value(in.TotpLastCounter)),
+			table.ValueParam("$mfa_recovery_codes", types.JSONValueFromBytes(recoveryCodes)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to run enable account mfa ydb query: %w", err)
+	}
+
+	return nil
+}
+
+var queryDisableMfa = fmt.Sprintf(`
+DECLARE $id AS Int64;
+
+UPDATE
+  %s
+SET
+  mfa_totp_secret = NULL,
+  mfa_enabled_at = NULL,
+  mfa_totp_last_counter = NULL,
+  mfa_recovery_codes = NULL
+WHERE
+  id = $id;
+`, tableAccounts)
+
+func (a *Account) DisableMfa(ctx context.Context, in domain.DisableMfaDTOInput) error {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryDisableMfa, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to run disable account mfa ydb query: %w", err)
+	}
+
+	return nil
+}
+
+var queryUseMfaTotpCounter = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $counter AS Uint64;
+
+UPDATE
+  %s
+SET
+  mfa_totp_last_counter = $counter
+WHERE
+  id = $id AND mfa_enabled_at IS NOT NULL AND (mfa_totp_last_counter IS NULL OR mfa_totp_last_counter < $counter)
+RETURNING id;
+`, tableAccounts)
+
+func (a *Account) UseMfaTotpCounter(ctx context.Context, in domain.UseMfaTotpCounterDTOInput) (bool, error) {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return false, err
+	}
+
+	var used bool
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		used = false
+
+		res, err := tx.Execute(ctx, queryUseMfaTotpCounter, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$counter", types.Uint64Value(in.Counter)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				used = true
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return false, fmt.Errorf("failed to run use account mfa totp counter ydb query: %w", err)
+	}
+
+	return used, nil
+}
+
+var querySelectMfaRecoveryCodes = fmt.Sprintf(`
+DECLARE $id AS Int64;
+SELECT
+  mfa_recovery_codes
+FROM
+  %s
+WHERE
+  id = $id AND mfa_enabled_at IS NOT NULL;
+`, tableAccounts)
+
+var queryUpdateMfaRecoveryCodes = fmt.Sprintf(`
+DECLARE $id AS Int64;
+DECLARE $mfa_recovery_codes AS Json;
+
+UPDATE
+  %s
+SET
+  mfa_recovery_codes = $mfa_recovery_codes
+WHERE
+  id = $id;
+`, tableAccounts)
+
+func (a *Account) UseMfaRecoveryCode(ctx context.Context, in domain.UseMfaRecoveryCodeDTOInput) (bool, error) {
+	intId, err := a.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return false, err
+	}
+
+	var used bool
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		used = false
+
+		res, err := tx.Execute(ctx, querySelectMfaRecoveryCodes, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+		))
+		if err != nil {
+			return err
+		}
+
+		var recoveryCodes string
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				if err := res.ScanNamed(
+					named.OptionalWithDefault("mfa_recovery_codes", &recoveryCodes),
+				); err != nil {
+					_ = res.Close()
+					return err
+				}
+			}
+		}
+		if err := res.Err(); err != nil {
+			_ = res.Close()
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		hashes, err := decodeRecoveryCodeHashes(recoveryCodes)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(hashes, func(hash string) bool {
+			return a.ph.Check(in.Code, hash)
+		})
+		if i == -1 {
+			return nil
+		}
+
+		remaining, err := json.Marshal(append(hashes[:i:i], hashes[i+1:]...))
+		if err != nil {
+			return fmt.Errorf("failed to encode mfa recovery code hashes: %v", err)
+		}
+
+		res, err = tx.Execute(ctx, queryUpdateMfaRecoveryCodes, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$mfa_recovery_codes", types.JSONValueFromBytes(remaining)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+
+		used = true
+		return nil
+	}); err != nil {
+		return false, fmt.Errorf("failed to run use account mfa recovery code ydb query: %w", err)
+	}
+
+	return used, nil
+}
+
+func decodeRecoveryCodeHashes(recoveryCodes string) ([]string, error) {
+	if recoveryCodes == "" {
+		return nil, nil
+	}
+	var hashes []string
+	if err := json.Unmarshal([]byte(recoveryCodes), &hashes); err != nil {
+		return nil, fmt.Errorf("failed to decode mfa recovery code hashes: %w", err)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,360 @@
+package ydb_adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+)
+
+type ApiKeys struct {
+	db *ydb.Driver
+	l  *zap.Logger
+	ph *auth.PasswordHasher
+}
+
+var _ domain.ApiKeys = (*ApiKeys)(nil)
+
+type ApiKeysConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+	// Hashes api key secrets.
+	PasswordHasher *auth.PasswordHasher
+}
+
+func NewApiKeys(conf ApiKeysConf) *ApiKeys {
+	adapter := &ApiKeys{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+		ph: conf.PasswordHasher,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryAddApiKey = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $account_id AS Utf8;
+DECLARE $name AS Utf8;
+DECLARE $secret_hash AS Utf8;
+DECLARE $scopes AS Json;
+DECLARE $created_at AS Timestamp;
+
+INSERT INTO {{table.api_keys}} ( id, account_id, name, secret_hash, scopes, created_at )
+VALUES ( $id, $account_id, $name, $secret_hash, $scopes, $created_at );
+`,
+	"{{table.api_keys}}", tableApiKeys,
+)
+
+func (a *ApiKeys) Add(ctx context.Context, in domain.ApiKeysAddDTOInput) error {
+	secretHash, err := a.ph.Hash(in.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to hash api key secret: %v", err)
+	}
+	scopes, err := json.Marshal(in.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to encode api key scopes: %v", err)
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryAddApiKey, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(in.Id)),
+			table.ValueParam("$account_id", types.UTF8Value(in.AccountId)),
+			table.ValueParam("$name", types.UTF8Value(in.Name)),
+			table.ValueParam("$secret_hash", types.UTF8Value(secretHash)),
+			table.ValueParam("$scopes", types.JSONValueFromBytes(scopes)),
+			table.ValueParam("$created_at", types.TimestampValueFromTime(in.CreatedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction add api key: %w", err)
+	}
+
+	return nil
+}
+
+var queryGetApiKey = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+
+SELECT
+    id,
+    account_id,
+    name,
+    secret_hash,
+    scopes,
+    created_at,
+    expires_at,
+    last_used_at
+FROM
+    {{table.api_keys}}
+WHERE
+    id = $id;
+`,
+	"{{table.api_keys}}", tableApiKeys,
+)
+
+func (a *ApiKeys) get(ctx context.Context, id string) (key *domain.ApiKey, secretHash string, err error) {
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		key = nil
+
+		_, res, err := s.Execute(ctx, readTx, queryGetApiKey, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(id)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				out, hash, err := scanApiKey(res)
+				if err != nil {
+					return err
+				}
+				key, secretHash = &out, hash
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, "", fmt.Errorf("failed to execute query get api key: %w", err)
+	}
+
+	return key, secretHash, nil
+}
+
+func (a *ApiKeys) Get(ctx context.Context, id string) (*domain.ApiKey, error) {
+	key, _, err := a.get(ctx, id)
+	return key, err
+}
+
+func (a *ApiKeys) CheckSecret(ctx context.Context, in domain.ApiKeysCheckSecretDTOInput) (bool, error) {
+	key, secretHash, err := a.get(ctx, in.Id)
+	if err != nil {
+		return false, err
+	}
+	if key == nil {
+		return false, nil
+	}
+	return a.ph.Check(in.Secret, secretHash), nil
+}
+
+var queryListApiKeys = template.ReplaceAllPairs(`
+DECLARE $account_id AS Utf8;
+
+SELECT
+    id,
+    account_id,
+    name,
+    secret_hash,
+    scopes,
+    created_at,
+    expires_at,
+    last_used_at
+FROM
+    {{table.api_keys}}
+VIEW
+    {{index.account_id}}
+WHERE
+    account_id = $account_id
+ORDER BY
+    created_at;
+`,
+	"{{table.api_keys}}", tableApiKeys,
+	"{{index.account_id}}", tableApiKeysIndexAccountId,
+)
+
+func (a *ApiKeys) List(ctx context.Context, accountId string) ([]domain.ApiKey, error) {
+	out := make([]domain.ApiKey, 0)
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		out = out[:0]
+
+		_, res, err := s.Execute(ctx, readTx, queryListApiKeys, table.NewQueryParameters(
+			table.ValueParam("$account_id", types.UTF8Value(accountId)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				key, _, err := scanApiKey(res)
+				if err != nil {
+					return err
+				}
+				out = append(out, key)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query list api keys: %w", err)
+	}
+
+	return out, nil
+}
+
+func scanApiKey(res interface {
+	ScanNamed(...named.Value) error
+}) (domain.ApiKey, string, error) {
+	var (
+		key                domain.ApiKey
+		secretHash, scopes string
+	)
+	if err := res.ScanNamed(
+		named.Required("id", &key.Id),
+		named.Required("account_id", &key.AccountId),
+		named.Required("name", &key.Name),
+		named.Required("secret_hash", &secretHash),
+		named.Required("scopes", &scopes),
+		named.Required("created_at", &key.CreatedAt),
+		named.OptionalWithDefault("expires_at", &key.ExpiresAt),
+		named.OptionalWithDefault("last_used_at", &key.LastUsedAt),
+	); err != nil {
+		return domain.ApiKey{}, "", err
+	}
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return domain.ApiKey{}, "", fmt.Errorf("failed to decode api key scopes: %w", err)
+	}
+	return key, secretHash, nil
+}
+
+var queryExpireApiKey = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $expires_at AS Timestamp;
+
+UPDATE
+    {{table.api_keys}}
+SET
+    expires_at = $expires_at
+WHERE
+    id = $id;
+`,
+	"{{table.api_keys}}", tableApiKeys,
+)
+
+func (a *ApiKeys) Expire(ctx context.Context, in domain.ApiKeysExpireDTOInput) error {
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryExpireApiKey, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(in.Id)),
+			table.ValueParam("$expires_at", types.TimestampValueFromTime(in.ExpiresAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction expire api key: %w", err)
+	}
+
+	return nil
+}
+
+var queryTouchApiKey = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $last_used_at AS Timestamp;
+
+UPDATE
+    {{table.api_keys}}
+SET
+    last_used_at = $last_used_at
+WHERE
+    id = $id;
+`,
+	"{{table.api_keys}}", tableApiKeys,
+)
+
+func (a *ApiKeys) Touch(ctx context.Context, in domain.ApiKeysTouchDTOInput) error {
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryTouchApiKey, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(in.Id)),
+			table.ValueParam("$last_used_at", types.TimestampValueFromTime(in.LastUsedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction touch api key: %w", err)
+	}
+
+	return nil
+}
+
+var queryDeleteApiKey = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+
+DELETE FROM
+    {{table.api_keys}}
+WHERE
+    id = $id
+RETURNING id;
+`,
+	"{{table.api_keys}}", tableApiKeys,
+)
+
+func (a *ApiKeys) Delete(ctx context.Context, id string) (bool, error) {
+	var deleted bool
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryDeleteApiKey, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(id)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := res.Close(); err != nil {
+				a.l.Error("failed to close ydb result", zap.Error(err))
+			}
+		}()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				deleted = true
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return false, fmt.Errorf("failed to execute query transaction delete api key: %w", err)
+	}
+
+	return deleted, nil
+}
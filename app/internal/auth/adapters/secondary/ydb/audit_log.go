@@ -0,0 +1,236 @@
+package ydb_adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+)
+
+// Append-only audit trail. Events are never updated nor deleted by the service.
+type AuditLog struct {
+	db *ydb.Driver
+	l  *zap.Logger
+}
+
+var _ domain.AuditLog = (*AuditLog)(nil)
+
+type AuditLogConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+}
+
+func NewAuditLog(conf AuditLogConf) *AuditLog {
+	adapter := &AuditLog{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryAppendAuditEvent = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $type AS Utf8;
+DECLARE $account_id AS Utf8;
+DECLARE $actor_id AS Optional<Utf8>;
+DECLARE $details AS Json;
+DECLARE $created_at AS Timestamp;
+
+INSERT INTO {{table.audit_log}} ( id, type, account_id, actor_id, details, created_at )
+VALUES ( $id, $type, $account_id, $actor_id, $details, $created_at );
+`,
+	"{{table.audit_log}}", tableAuditLog,
+)
+
+func (a *AuditLog) Append(ctx context.Context, event domain.AuditEvent) error {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event details: %v", err)
+	}
+
+	actorId := types.NullValue(types.TypeUTF8)
+	if event.ActorId != "" {
+		actorId = types.OptionalValue(types.UTF8Value(event.ActorId))
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryAppendAuditEvent, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(event.Id)),
+			table.ValueParam("$type", types.UTF8Value(event.Type)),
+			table.ValueParam("$account_id", types.UTF8Value(event.AccountId)),
+			table.ValueParam("$actor_id", actorId),
+			table.ValueParam("$details", types.JSONValueFromBytes(details)),
+			table.ValueParam("$created_at", types.TimestampValueFromTime(event.CreatedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction append audit event: %w", err)
+	}
+
+	return nil
+}
+
+const queryListAuditEventsFilters = `
+WHERE
+    ($type IS NULL OR type = $type)
+    AND ($since IS NULL OR created_at >= $since)
+    AND ($until IS NULL OR created_at < $until)
+    AND (
+        $before_created_at IS NULL
+        OR created_at < $before_created_at
+        OR (created_at = $before_created_at AND id < $before_id)
+    )`
+
+const queryListAuditEventsDeclare = `
+DECLARE $type AS Optional<Utf8>;
+DECLARE $since AS Optional<Timestamp>;
+DECLARE $until AS Optional<Timestamp>;
+DECLARE $before_created_at AS Optional<Timestamp>;
+DECLARE $before_id AS Utf8;
+DECLARE $limit AS Uint64;
+`
+
+var queryListAuditEvents = template.ReplaceAllPairs(queryListAuditEventsDeclare+`
+SELECT
+    id,
+    type,
+    account_id,
+    actor_id,
+    details,
+    created_at
+FROM
+    {{table.audit_log}}
+VIEW
+    {{index.created_at}}
+{{filters}}
+ORDER BY
+    created_at DESC,
+    id DESC
+LIMIT $limit;
+`,
+	"{{table.audit_log}}", tableAuditLog,
+	"{{index.created_at}}", tableAuditLogIndexCreatedAt,
+	"{{filters}}", queryListAuditEventsFilters,
+)
+
+var queryListAccountAuditEvents = template.ReplaceAllPairs(queryListAuditEventsDeclare+`DECLARE $account_id AS Utf8;
+
+SELECT
+    id,
+    type,
+    account_id,
+    actor_id,
+    details,
+    created_at
+FROM
+    {{table.audit_log}}
+VIEW
+    {{index.account_id}}
+{{filters}}
+    AND account_id = $account_id
+ORDER BY
+    created_at DESC,
+    id DESC
+LIMIT $limit;
+`,
+	"{{table.audit_log}}", tableAuditLog,
+	"{{index.account_id}}", tableAuditLogIndexAccountId,
+	"{{filters}}", queryListAuditEventsFilters,
+)
+
+func (a *AuditLog) List(ctx context.Context, in domain.AuditLogListDTOInput) ([]domain.AuditEvent, error) {
+	eventType := types.NullValue(types.TypeUTF8)
+	if in.Type != "" {
+		eventType = types.OptionalValue(types.UTF8Value(in.Type))
+	}
+	since := types.NullValue(types.TypeTimestamp)
+	if !in.Since.IsZero() {
+		since = types.OptionalValue(types.TimestampValueFromTime(in.Since))
+	}
+	until := types.NullValue(types.TypeTimestamp)
+	if !in.Until.IsZero() {
+		until = types.OptionalValue(types.TimestampValueFromTime(in.Until))
+	}
+	beforeCreatedAt := types.NullValue(types.TypeTimestamp)
+	var beforeId string
+	if in.Before != nil {
+		beforeCreatedAt = types.OptionalValue(types.TimestampValueFromTime(in.Before.CreatedAt))
+		beforeId = in.Before.Id
+	}
+
+	query := queryListAuditEvents
+	params := []table.ParameterOption{
+		table.ValueParam("$type", eventType),
+		table.ValueParam("$since", since),
+		table.ValueParam("$until", until),
+		table.ValueParam("$before_created_at", beforeCreatedAt),
+		table.ValueParam("$before_id", types.UTF8Value(beforeId)),
+		table.ValueParam("$limit", types.Uint64Value(uint64(in.Limit))),
+	}
+	if in.AccountId != "" {
+		query = queryListAccountAuditEvents
+		params = append(params, table.ValueParam("$account_id", types.UTF8Value(in.AccountId)))
+	}
+
+	out := make([]domain.AuditEvent, 0)
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		out = out[:0]
+
+		_, res, err := s.Execute(ctx, readTx, query, table.NewQueryParameters(params...))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var (
+					event   domain.AuditEvent
+					details string
+				)
+				if err := res.ScanNamed(
+					named.Required("id", &event.Id),
+					named.Required("type", &event.Type),
+					named.Required("account_id", &event.AccountId),
+					named.OptionalWithDefault("actor_id", &event.ActorId),
+					named.Required("details", &details),
+					named.Required("created_at", &event.CreatedAt),
+				); err != nil {
+					return err
+				}
+				if err := json.Unmarshal([]byte(details), &event.Details); err != nil {
+					return fmt.Errorf("failed to decode audit event details: %w", err)
+				}
+				out = append(out, event)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query list audit events: %w", err)
+	}
+
+	return out, nil
+}
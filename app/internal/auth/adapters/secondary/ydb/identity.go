@@ -0,0 +1,321 @@
+package ydb_adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+)
+
+type Identities struct {
+	db *ydb.Driver
+	l  *zap.Logger
+}
+
+var _ domain.Identities = (*Identities)(nil)
+
+type IdentitiesConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+}
+
+func NewIdentities(conf IdentitiesConf) *Identities {
+	adapter := &Identities{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryFindIdentity = template.ReplaceAllPairs(`
+DECLARE $provider AS Utf8;
+DECLARE $subject AS Utf8;
+
+SELECT
+    provider,
+    subject,
+    account_id,
+    email,
+    created_at
+FROM
+    {{table.identities}}
+WHERE
+    provider = $provider AND subject = $subject;
+`,
+	"{{table.identities}}", tableIdentities,
+)
+
+func (a *Identities) Find(ctx context.Context, provider, subject string) (*domain.Identity, error) {
+	var out *domain.Identity
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		out = nil
+
+		_, res, err := s.Execute(ctx, readTx, queryFindIdentity, table.NewQueryParameters(
+			table.ValueParam("$provider", types.UTF8Value(provider)),
+			table.ValueParam("$subject", types.UTF8Value(subject)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				identity, err := scanIdentity(res)
+				if err != nil {
+					return err
+				}
+				out = &identity
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query find identity: %w", err)
+	}
+
+	return out, nil
+}
+
+var queryAddIdentity = template.ReplaceAllPairs(`
+DECLARE $provider AS Utf8;
+DECLARE $subject AS Utf8;
+DECLARE $account_id AS Utf8;
+DECLARE $email AS Utf8;
+DECLARE $created_at AS Timestamp;
+
+INSERT INTO {{table.identities}} ( provider, subject, account_id, email, created_at )
+VALUES ( $provider, $subject, $account_id, $email, $created_at );
+`,
+	"{{table.identities}}", tableIdentities,
+)
+
+func (a *Identities) Add(ctx context.Context, identity domain.Identity) error {
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryAddIdentity, table.NewQueryParameters(
+			table.ValueParam("$provider", types.UTF8Value(identity.Provider)),
+			table.ValueParam("$subject", types.UTF8Value(identity.Subject)),
+			table.ValueParam("$account_id", types.UTF8Value(identity.AccountId)),
+			table.ValueParam("$email", types.UTF8Value(identity.Email)),
+			table.ValueParam("$created_at", types.TimestampValueFromTime(identity.CreatedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction add identity: %w", err)
+	}
+
+	return nil
+}
+
+var queryListIdentities = template.ReplaceAllPairs(`
+DECLARE $account_id AS Utf8;
+
+SELECT
+    provider,
+    subject,
+    account_id,
+    email,
+    created_at
+FROM
+    {{table.identities}}
+VIEW
+    {{index.account_id}}
+WHERE
+    account_id = $account_id
+ORDER BY
+    created_at;
+`,
+	"{{table.identities}}", tableIdentities,
+	"{{index.account_id}}", tableIdentitiesIndexAccountId,
+)
+
+func (a *Identities) List(ctx context.Context, accountId string) ([]domain.Identity, error) {
+	out := make([]domain.Identity, 0)
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		out = out[:0]
+
+		_, res, err := s.Execute(ctx, readTx, queryListIdentities, table.NewQueryParameters(
+			table.ValueParam("$account_id", types.UTF8Value(accountId)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				identity, err := scanIdentity(res)
+				if err != nil {
+					return err
+				}
+				out = append(out, identity)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query list identities: %w", err)
+	}
+
+	return out, nil
+}
+
+func scanIdentity(res interface {
+	ScanNamed(...named.Value) error
+}) (domain.Identity, error) {
+	var identity domain.Identity
+	err := res.ScanNamed(
+		named.Required("provider", &identity.Provider),
+		named.Required("subject", &identity.Subject),
+		named.Required("account_id", &identity.AccountId),
+		named.Required("email", &identity.Email),
+		named.Required("created_at", &identity.CreatedAt),
+	)
+	return identity, err
+}
+
+type IdentityLogins struct {
+	db *ydb.Driver
+	l  *zap.Logger
+}
+
+var _ domain.IdentityLogins = (*IdentityLogins)(nil)
+
+type IdentityLoginsConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+}
+
+func NewIdentityLogins(conf IdentityLoginsConf) *IdentityLogins {
+	adapter := &IdentityLogins{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryInsertIdentityLogin = template.ReplaceAllPairs(`
+DECLARE $state AS Utf8;
+DECLARE $provider AS Utf8;
+DECLARE $nonce AS Utf8;
+DECLARE $code_verifier AS Utf8;
+DECLARE $expires_at AS Timestamp;
+
+INSERT INTO {{table.identity_logins}} ( state, provider, nonce, code_verifier, expires_at )
+VALUES ( $state, $provider, $nonce, $code_verifier, $expires_at );
+`,
+	"{{table.identity_logins}}", tableIdentityLogins,
+)
+
+func (a *IdentityLogins) Insert(ctx context.Context, login domain.IdentityLogin) error {
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryInsertIdentityLogin, table.NewQueryParameters(
+			table.ValueParam("$state", types.UTF8Value(login.State)),
+			table.ValueParam("$provider", types.UTF8Value(login.Provider)),
+			table.ValueParam("$nonce", types.UTF8Value(login.Nonce)),
+			table.ValueParam("$code_verifier", types.UTF8Value(login.CodeVerifier)),
+			table.ValueParam("$expires_at", types.TimestampValueFromTime(login.ExpiresAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction insert identity login: %w", err)
+	}
+
+	return nil
+}
+
+var queryConsumeIdentityLogin = template.ReplaceAllPairs(`
+DECLARE $state AS Utf8;
+
+DELETE FROM
+    {{table.identity_logins}}
+WHERE
+    state = $state
+RETURNING
+    state,
+    provider,
+    nonce,
+    code_verifier,
+    expires_at;
+`,
+	"{{table.identity_logins}}", tableIdentityLogins,
+)
+
+func (a *IdentityLogins) Consume(ctx context.Context, state string) (*domain.IdentityLogin, error) {
+	var out *domain.IdentityLogin
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryConsumeIdentityLogin, table.NewQueryParameters(
+			table.ValueParam("$state", types.UTF8Value(state)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := res.Close(); err != nil {
+				a.l.Error("failed to close ydb result", zap.Error(err))
+			}
+		}()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var login domain.IdentityLogin
+				if err := res.ScanNamed(
+					named.Required("state", &login.State),
+					named.Required("provider", &login.Provider),
+					named.Required("nonce", &login.Nonce),
+					named.Required("code_verifier", &login.CodeVerifier),
+					named.Required("expires_at", &login.ExpiresAt),
+				); err != nil {
+					return err
+				}
+				out = &login
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query transaction consume identity login: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,199 @@
+package ydb_adapter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+)
+
+type LoginAttempts struct {
+	db *ydb.Driver
+	l  *zap.Logger
+}
+
+var _ domain.LoginAttempts = (*LoginAttempts)(nil)
+
+type LoginAttemptsConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+}
+
+func NewLoginAttempts(conf LoginAttemptsConf) *LoginAttempts {
+	adapter := &LoginAttempts{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryGetLoginAttempts = template.ReplaceAllPairs(`
+DECLARE $key AS Utf8;
+
+SELECT
+    key,
+    failures,
+    last_failure_at
+FROM
+    {{table.login_attempts}}
+WHERE
+    key = $key;
+`,
+	"{{table.login_attempts}}", tableLoginAttempts,
+)
+
+func (a *LoginAttempts) Get(ctx context.Context, key string) (*domain.LoginAttemptsRecord, error) {
+	var out *domain.LoginAttemptsRecord
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		_, res, err := s.Execute(ctx, readTx, queryGetLoginAttempts, table.NewQueryParameters(
+			table.ValueParam("$key", types.UTF8Value(key)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				record, err := scanLoginAttemptsRecord(res)
+				if err != nil {
+					return err
+				}
+				out = &record
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query get login attempts: %w", err)
+	}
+
+	return out, nil
+}
+
+var queryRegisterLoginFailure = template.ReplaceAllPairs(`
+DECLARE $key AS Utf8;
+DECLARE $at AS Timestamp;
+DECLARE $reset_before AS Timestamp;
+
+$failures = (
+    SELECT
+        failures
+    FROM
+        {{table.login_attempts}}
+    WHERE
+        key = $key AND last_failure_at >= $reset_before
+);
+
+UPSERT INTO {{table.login_attempts}} ( key, failures, last_failure_at )
+VALUES ( $key, COALESCE($failures, 0u) + 1u, $at )
+RETURNING key, failures, last_failure_at;
+`,
+	"{{table.login_attempts}}", tableLoginAttempts,
+)
+
+func (a *LoginAttempts) RegisterFailure(ctx context.Context, in domain.LoginAttemptsRegisterFailureDTOInput) (domain.LoginAttemptsRecord, error) {
+	var out domain.LoginAttemptsRecord
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryRegisterLoginFailure, table.NewQueryParameters(
+			table.ValueParam("$key", types.UTF8Value(in.Key)),
+			table.ValueParam("$at", types.TimestampValueFromTime(in.At)),
+			table.ValueParam("$reset_before", types.TimestampValueFromTime(in.At.Add(-in.ResetAfter))),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := res.Close(); err != nil {
+				a.l.Error("failed to close ydb result", zap.Error(err))
+			}
+		}()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				out, err = scanLoginAttemptsRecord(res)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return domain.LoginAttemptsRecord{}, fmt.Errorf("failed to execute query transaction register login failure: %w", err)
+	}
+
+	return out, nil
+}
+
+var queryResetLoginAttempts = template.ReplaceAllPairs(`
+DECLARE $key AS Utf8;
+
+DELETE FROM
+    {{table.login_attempts}}
+WHERE
+    key = $key;
+`,
+	"{{table.login_attempts}}", tableLoginAttempts,
+)
+
+func (a *LoginAttempts) Reset(ctx context.Context, key string) error {
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryResetLoginAttempts, table.NewQueryParameters(
+			table.ValueParam("$key", types.UTF8Value(key)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction reset login attempts: %w", err)
+	}
+
+	return nil
+}
+
+type namedScanner interface {
+	ScanNamed(...named.Value) error
+}
+
+func scanLoginAttemptsRecord(res namedScanner) (domain.LoginAttemptsRecord, error) {
+	var (
+		record        domain.LoginAttemptsRecord
+		failures      uint32
+		lastFailureAt time.Time
+	)
+	if err := res.ScanNamed(
+		named.Required("key", &record.Key),
+		named.Required("failures", &failures),
+		named.Required("last_failure_at", &lastFailureAt),
+	); err != nil {
+		return domain.LoginAttemptsRecord{}, err
+	}
+	record.Failures = int(failures)
+	record.LastFailureAt = lastFailureAt
+	return record, nil
+}
@@ -0,0 +1,362 @@
+package ydb_adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+)
+
+type OAuthClients struct {
+	db *ydb.Driver
+	l  *zap.Logger
+	ph *auth.PasswordHasher
+}
+
+var _ domain.OAuthClients = (*OAuthClients)(nil)
+
+type OAuthClientsConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+	// Hashes client secrets.
+	PasswordHasher *auth.PasswordHasher
+}
+
+func NewOAuthClients(conf OAuthClientsConf) *OAuthClients {
+	adapter := &OAuthClients{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+		ph: conf.PasswordHasher,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryAddOAuthClient = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $name AS Utf8;
+DECLARE $secret_hash AS Optional<Utf8>;
+DECLARE $redirect_uris AS Json;
+DECLARE $created_at AS Timestamp;
+
+INSERT INTO {{table.oauth_clients}} ( id, name, secret_hash, redirect_uris, created_at )
+VALUES ( $id, $name, $secret_hash, $redirect_uris, $created_at );
+`,
+	"{{table.oauth_clients}}", tableOAuthClients,
+)
+
+func (a *OAuthClients) Add(ctx context.Context, in domain.OAuthClientsAddDTOInput) error {
+	secretHash := types.NullValue(types.TypeUTF8)
+	if in.Secret != "" {
+		hash, err := a.ph.Hash(in.Secret)
+		if err != nil {
+			return fmt.Errorf("failed to hash oauth client secret: %v", err)
+		}
+		secretHash = types.OptionalValue(types.UTF8Value(hash))
+	}
+	redirectUris, err := json.Marshal(in.RedirectUris)
+	if err != nil {
+		return fmt.Errorf("failed to encode oauth client redirect uris: %v", err)
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryAddOAuthClient, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(in.Id)),
+			table.ValueParam("$name", types.UTF8Value(in.Name)),
+			table.ValueParam("$secret_hash", secretHash),
+			table.ValueParam("$redirect_uris", types.JSONValueFromBytes(redirectUris)),
+			table.ValueParam("$created_at", types.TimestampValueFromTime(in.CreatedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction add oauth client: %w", err)
+	}
+
+	return nil
+}
+
+var queryGetOAuthClient = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+
+SELECT
+    id,
+    name,
+    secret_hash,
+    redirect_uris,
+    created_at
+FROM
+    {{table.oauth_clients}}
+WHERE
+    id = $id;
+`,
+	"{{table.oauth_clients}}", tableOAuthClients,
+)
+
+func (a *OAuthClients) get(ctx context.Context, id string) (client *domain.OAuthClient, secretHash string, err error) {
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		client = nil
+
+		_, res, err := s.Execute(ctx, readTx, queryGetOAuthClient, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(id)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var (
+					out          domain.OAuthClient
+					redirectUris string
+				)
+				if err := res.ScanNamed(
+					named.Required("id", &out.Id),
+					named.Required("name", &out.Name),
+					named.OptionalWithDefault("secret_hash", &secretHash),
+					named.Required("redirect_uris", &redirectUris),
+					named.Required("created_at", &out.CreatedAt),
+				); err != nil {
+					return err
+				}
+				if err := json.Unmarshal([]byte(redirectUris), &out.RedirectUris); err != nil {
+					return fmt.Errorf("failed to decode oauth client redirect uris: %w", err)
+				}
+				out.Confidential = secretHash != ""
+				client = &out
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, "", fmt.Errorf("failed to execute query get oauth client: %w", err)
+	}
+
+	return client, secretHash, nil
+}
+
+func (a *OAuthClients) Get(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	client, _, err := a.get(ctx, id)
+	return client, err
+}
+
+func (a *OAuthClients) CheckSecret(ctx context.Context, in domain.OAuthClientsCheckSecretDTOInput) (bool, error) {
+	client, secretHash, err := a.get(ctx, in.Id)
+	if err != nil {
+		return false, err
+	}
+	if client == nil || secretHash == "" {
+		return false, nil
+	}
+	return a.ph.Check(in.Secret, secretHash), nil
+}
+
+var queryDeleteOAuthClient = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+
+DELETE FROM
+    {{table.oauth_clients}}
+WHERE
+    id = $id
+RETURNING id;
+`,
+	"{{table.oauth_clients}}", tableOAuthClients,
+)
+
+func (a *OAuthClients) Delete(ctx context.Context, id string) (bool, error) {
+	var deleted bool
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryDeleteOAuthClient, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(id)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := res.Close(); err != nil {
+				a.l.Error("failed to close ydb result", zap.Error(err))
+			}
+		}()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				deleted = true
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return false, fmt.Errorf("failed to execute query transaction delete oauth client: %w", err)
+	}
+
+	return deleted, nil
+}
+
+type AuthorizationCodes struct {
+	db *ydb.Driver
+	l  *zap.Logger
+}
+
+var _ domain.AuthorizationCodes = (*AuthorizationCodes)(nil)
+
+type AuthorizationCodesConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+}
+
+func NewAuthorizationCodes(conf AuthorizationCodesConf) *AuthorizationCodes {
+	adapter := &AuthorizationCodes{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryInsertAuthorizationCode = template.ReplaceAllPairs(`
+DECLARE $code AS Utf8;
+DECLARE $client_id AS Utf8;
+DECLARE $account_id AS Utf8;
+DECLARE $redirect_uri AS Utf8;
+DECLARE $scope AS Utf8;
+DECLARE $nonce AS Optional<Utf8>;
+DECLARE $code_challenge AS Utf8;
+DECLARE $expires_at AS Timestamp;
+
+INSERT INTO {{table.authorization_codes}} ( code, client_id, account_id, redirect_uri, scope, nonce, code_challenge, expires_at )
+VALUES ( $code, $client_id, $account_id, $redirect_uri, $scope, $nonce, $code_challenge, $expires_at );
+`,
+	"{{table.authorization_codes}}", tableAuthorizationCodes,
+)
+
+func (a *AuthorizationCodes) Insert(ctx context.Context, code domain.AuthorizationCode) error {
+	nonce := types.NullValue(types.TypeUTF8)
+	if code.Nonce != "" {
+		nonce = types.OptionalValue(types.UTF8Value(code.Nonce))
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryInsertAuthorizationCode, table.NewQueryParameters(
+			table.ValueParam("$code", types.UTF8Value(code.Code)),
+			table.ValueParam("$client_id", types.UTF8Value(code.ClientId)),
+			table.ValueParam("$account_id", types.UTF8Value(code.AccountId)),
+			table.ValueParam("$redirect_uri", types.UTF8Value(code.RedirectUri)),
+			table.ValueParam("$scope", types.UTF8Value(code.Scope)),
+			table.ValueParam("$nonce", nonce),
+			table.ValueParam("$code_challenge", types.UTF8Value(code.CodeChallenge)),
+			table.ValueParam("$expires_at", types.TimestampValueFromTime(code.ExpiresAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction insert authorization code: %w", err)
+	}
+
+	return nil
+}
+
+var queryConsumeAuthorizationCode = template.ReplaceAllPairs(`
+DECLARE $code AS Utf8;
+
+DELETE FROM
+    {{table.authorization_codes}}
+WHERE
+    code = $code
+RETURNING
+    code,
+    client_id,
+    account_id,
+    redirect_uri,
+    scope,
+    nonce,
+    code_challenge,
+    expires_at;
+`,
+	"{{table.authorization_codes}}", tableAuthorizationCodes,
+)
+
+func (a *AuthorizationCodes) Consume(ctx context.Context, code string) (*domain.AuthorizationCode, error) {
+	var out *domain.AuthorizationCode
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryConsumeAuthorizationCode, table.NewQueryParameters(
+			table.ValueParam("$code", types.UTF8Value(code)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := res.Close(); err != nil {
+				a.l.Error("failed to close ydb result", zap.Error(err))
+			}
+		}()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var (
+					authzCode domain.AuthorizationCode
+					expiresAt time.Time
+				)
+				if err := res.ScanNamed(
+					named.Required("code", &authzCode.Code),
+					named.Required("client_id", &authzCode.ClientId),
+					named.Required("account_id", &authzCode.AccountId),
+					named.Required("redirect_uri", &authzCode.RedirectUri),
+					named.Required("scope", &authzCode.Scope),
+					named.OptionalWithDefault("nonce", &authzCode.Nonce),
+					named.Required("code_challenge", &authzCode.CodeChallenge),
+					named.Required("expires_at", &expiresAt),
+				); err != nil {
+					return err
+				}
+				authzCode.ExpiresAt = expiresAt
+				out = &authzCode
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query transaction consume authorization code: %w", err)
+	}
+
+	return out, nil
+}
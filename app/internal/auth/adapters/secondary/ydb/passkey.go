@@ -0,0 +1,360 @@
+package ydb_adapter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+)
+
+type PasskeyCredentials struct {
+	db *ydb.Driver
+	l  *zap.Logger
+}
+
+var _ domain.PasskeyCredentials = (*PasskeyCredentials)(nil)
+
+type PasskeyCredentialsConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+}
+
+func NewPasskeyCredentials(conf PasskeyCredentialsConf) *PasskeyCredentials {
+	adapter := &PasskeyCredentials{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryAddPasskeyCredential = template.ReplaceAllPairs(`
+DECLARE $account_id AS Utf8;
+DECLARE $id AS Utf8;
+DECLARE $name AS Utf8;
+DECLARE $data AS Json;
+DECLARE $created_at AS Timestamp;
+
+INSERT INTO {{table.passkey_credentials}} ( account_id, id, name, data, created_at )
+VALUES ( $account_id, $id, $name, $data, $created_at );
+`,
+	"{{table.passkey_credentials}}", tablePasskeyCredentials,
+)
+
+func (a *PasskeyCredentials) Add(ctx context.Context, cred domain.PasskeyCredential) error {
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryAddPasskeyCredential, table.NewQueryParameters(
+			table.ValueParam("$account_id", types.UTF8Value(cred.AccountId)),
+			table.ValueParam("$id", types.UTF8Value(cred.Id)),
+			table.ValueParam("$name", types.UTF8Value(cred.Name)),
+			table.ValueParam("$data", types.JSONValueFromBytes(cred.Data)),
+			table.ValueParam("$created_at", types.TimestampValueFromTime(cred.CreatedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction add passkey credential: %w", err)
+	}
+
+	return nil
+}
+
+var queryListPasskeyCredentials = template.ReplaceAllPairs(`
+DECLARE $account_id AS Utf8;
+
+SELECT
+    account_id,
+    id,
+    name,
+    data,
+    created_at,
+    last_used_at
+FROM
+    {{table.passkey_credentials}}
+WHERE
+    account_id = $account_id
+ORDER BY
+    created_at;
+`,
+	"{{table.passkey_credentials}}", tablePasskeyCredentials,
+)
+
+func (a *PasskeyCredentials) List(ctx context.Context, in domain.PasskeyCredentialsListDTOInput) ([]domain.PasskeyCredential, error) {
+	out := make([]domain.PasskeyCredential, 0)
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		_, res, err := s.Execute(ctx, readTx, queryListPasskeyCredentials, table.NewQueryParameters(
+			table.ValueParam("$account_id", types.UTF8Value(in.AccountId)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var (
+					cred domain.PasskeyCredential
+					data string
+				)
+				if err := res.ScanNamed(
+					named.Required("account_id", &cred.AccountId),
+					named.Required("id", &cred.Id),
+					named.Required("name", &cred.Name),
+					named.Required("data", &data),
+					named.Required("created_at", &cred.CreatedAt),
+					named.OptionalWithDefault("last_used_at", &cred.LastUsedAt),
+				); err != nil {
+					return err
+				}
+				cred.Data = []byte(data)
+				out = append(out, cred)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query list passkey credentials: %w", err)
+	}
+
+	return out, nil
+}
+
+var queryTouchPasskeyCredential = template.ReplaceAllPairs(`
+DECLARE $account_id AS Utf8;
+DECLARE $id AS Utf8;
+DECLARE $data AS Json;
+DECLARE $last_used_at AS Timestamp;
+
+UPDATE
+    {{table.passkey_credentials}}
+SET
+    data = $data,
+    last_used_at = $last_used_at
+WHERE
+    account_id = $account_id AND id = $id;
+`,
+	"{{table.passkey_credentials}}", tablePasskeyCredentials,
+)
+
+func (a *PasskeyCredentials) Touch(ctx context.Context, in domain.PasskeyCredentialsTouchDTOInput) error {
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryTouchPasskeyCredential, table.NewQueryParameters(
+			table.ValueParam("$account_id", types.UTF8Value(in.AccountId)),
+			table.ValueParam("$id", types.UTF8Value(in.Id)),
+			table.ValueParam("$data", types.JSONValueFromBytes(in.Data)),
+			table.ValueParam("$last_used_at", types.TimestampValueFromTime(in.LastUsedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction touch passkey credential: %w", err)
+	}
+
+	return nil
+}
+
+var queryDeletePasskeyCredential = template.ReplaceAllPairs(`
+DECLARE $account_id AS Utf8;
+DECLARE $id AS Utf8;
+
+DELETE FROM
+    {{table.passkey_credentials}}
+WHERE
+    account_id = $account_id AND id = $id
+RETURNING id;
+`,
+	"{{table.passkey_credentials}}", tablePasskeyCredentials,
+)
+
+func (a *PasskeyCredentials) Delete(ctx context.Context, in domain.PasskeyCredentialsDeleteDTOInput) (bool, error) {
+	var deleted bool
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryDeletePasskeyCredential, table.NewQueryParameters(
+			table.ValueParam("$account_id", types.UTF8Value(in.AccountId)),
+			table.ValueParam("$id", types.UTF8Value(in.Id)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := res.Close(); err != nil {
+				a.l.Error("failed to close ydb result", zap.Error(err))
+			}
+		}()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				deleted = true
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return false, fmt.Errorf("failed to execute query transaction delete passkey credential: %w", err)
+	}
+
+	return deleted, nil
+}
+
+type PasskeyCeremonies struct {
+	db *ydb.Driver
+	l  *zap.Logger
+}
+
+var _ domain.PasskeyCeremonies = (*PasskeyCeremonies)(nil)
+
+type PasskeyCeremoniesConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+}
+
+func NewPasskeyCeremonies(conf PasskeyCeremoniesConf) *PasskeyCeremonies {
+	adapter := &PasskeyCeremonies{
+		db: conf.DbDriver,
+		l:  conf.Logger,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+
+	return adapter
+}
+
+var queryInsertPasskeyCeremony = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+DECLARE $kind AS Utf8;
+DECLARE $account_id AS Optional<Utf8>;
+DECLARE $session AS Json;
+DECLARE $expires_at AS Timestamp;
+
+INSERT INTO {{table.passkey_ceremonies}} ( id, kind, account_id, session, expires_at )
+VALUES ( $id, $kind, $account_id, $session, $expires_at );
+`,
+	"{{table.passkey_ceremonies}}", tablePasskeyCeremonies,
+)
+
+func (a *PasskeyCeremonies) Insert(ctx context.Context, ceremony domain.PasskeyCeremony) error {
+	accountId := types.NullValue(types.TypeUTF8)
+	if ceremony.AccountId != "" {
+		accountId = types.OptionalValue(types.UTF8Value(ceremony.AccountId))
+	}
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryInsertPasskeyCeremony, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(ceremony.Id)),
+			table.ValueParam("$kind", types.UTF8Value(ceremony.Kind)),
+			table.ValueParam("$account_id", accountId),
+			table.ValueParam("$session", types.JSONValueFromBytes(ceremony.Session)),
+			table.ValueParam("$expires_at", types.TimestampValueFromTime(ceremony.ExpiresAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction insert passkey ceremony: %w", err)
+	}
+
+	return nil
+}
+
+var queryConsumePasskeyCeremony = template.ReplaceAllPairs(`
+DECLARE $id AS Utf8;
+
+DELETE FROM
+    {{table.passkey_ceremonies}}
+WHERE
+    id = $id
+RETURNING
+    id,
+    kind,
+    account_id,
+    session,
+    expires_at;
+`,
+	"{{table.passkey_ceremonies}}", tablePasskeyCeremonies,
+)
+
+func (a *PasskeyCeremonies) Consume(ctx context.Context, id string) (*domain.PasskeyCeremony, error) {
+	var out *domain.PasskeyCeremony
+
+	if err := a.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryConsumePasskeyCeremony, table.NewQueryParameters(
+			table.ValueParam("$id", types.UTF8Value(id)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := res.Close(); err != nil {
+				a.l.Error("failed to close ydb result", zap.Error(err))
+			}
+		}()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var (
+					ceremony  domain.PasskeyCeremony
+					session   string
+					expiresAt time.Time
+				)
+				if err := res.ScanNamed(
+					named.Required("id", &ceremony.Id),
+					named.Required("kind", &ceremony.Kind),
+					named.OptionalWithDefault("account_id", &ceremony.AccountId),
+					named.Required("session", &session),
+					named.Required("expires_at", &expiresAt),
+				); err != nil {
+					return err
+				}
+				ceremony.Session = []byte(session)
+				ceremony.ExpiresAt = expiresAt
+				out = &ceremony
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query transaction consume passkey ceremony: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package ydb_adapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/template"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/named"
+	"go.uber.org/zap"
+)
+
+const defaultRolePermissionsCacheDuration = time.Minute
+
+// Permission sets stored in YDB. Loaded all at once and cached since they are read on every access token creation.
+type RolePermissions struct {
+	db       *ydb.Driver
+	l        *zap.Logger
+	cacheTtl time.Duration
+
+	mu          sync.Mutex
+	permissions map[domain.AccountType][]domain.Permission
+	loadedAt    time.Time
+}
+
+var _ domain.RolePermissions = (*RolePermissions)(nil)
+
+type RolePermissionsConf struct {
+	DbDriver *ydb.Driver
+	Logger   *zap.Logger
+	// How long permission sets are cached for. Defaults to 1 minute.
+	CacheDuration time.Duration
+}
+
+func NewRolePermissions(conf RolePermissionsConf) *RolePermissions {
+	adapter := &RolePermissions{
+		db:       conf.DbDriver,
+		l:        conf.Logger,
+		cacheTtl: conf.CacheDuration,
+	}
+
+	if conf.Logger == nil {
+		adapter.l = zap.NewNop()
+	}
+	if conf.CacheDuration == 0 {
+		adapter.cacheTtl = defaultRolePermissionsCacheDuration
+	}
+
+	return adapter
+}
+
+var queryListRolePermissions = template.ReplaceAllPairs(`
+SELECT
+    account_type,
+    permission
+FROM
+    {{table.role_permissions}};
+`,
+	"{{table.role_permissions}}", tableRolePermissions,
+)
+
+func (a *RolePermissions) Permissions(ctx context.Context, accountType domain.AccountType) ([]domain.Permission, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.permissions == nil || time.Since(a.loadedAt) > a.cacheTtl {
+		permissions, err := a.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		a.permissions, a.loadedAt = permissions, time.Now()
+	}
+
+	return append([]domain.Permission(nil), a.permissions[accountType]...), nil
+}
+
+func (a *RolePermissions) load(ctx context.Context) (map[domain.AccountType][]domain.Permission, error) {
+	var out map[domain.AccountType][]domain.Permission
+
+	readTx := table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx())
+
+	if err := a.db.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		out = make(map[domain.AccountType][]domain.Permission)
+
+		_, res, err := s.Execute(ctx, readTx, queryListRolePermissions, table.NewQueryParameters())
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var accountType, permission string
+				if err := res.ScanNamed(
+					named.Required("account_type", &accountType),
+					named.Required("permission", &permission),
+				); err != nil {
+					return err
+				}
+				out[accountType] = append(out[accountType], permission)
+			}
+		}
+
+		return res.Err()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute query list role permissions: %w", err)
+	}
+
+	return out, nil
+}
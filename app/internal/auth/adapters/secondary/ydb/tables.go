@@ -4,7 +4,36 @@ package ydb_adapter
 const (
 	tableAccounts      = "accounts"
 	tableRefreshTokens = "refresh_tokens"
+	tableLoginAttempts = "login_attempts"
+
+	tablePasskeyCredentials = "passkey_credentials"
+	tablePasskeyCeremonies  = "passkey_ceremonies"
+
+	tableOAuthClients       = "oauth_clients"
+	tableAuthorizationCodes = "authorization_codes"
+
+	tableIdentities     = "identities"
+	tableIdentityLogins = "identity_logins"
+
+	tableApiKeys = "api_keys"
+
+	tableRolePermissions = "role_permissions"
+
+	tableAuditLog = "audit_log"
+
+	tableAccountCreationOutbox = "account_creation_outbox"
+
+	tableAccessTokenRevocations = "access_token_revocations"
 
 	tableAccountsIndexEmailUnique    = "idx_email_uniq"
+	tableAccountsIndexPendingEmail   = "idx_pending_email"
+	tableAccountsIndexDeletedAt      = "idx_deleted_at"
 	tableRefreshTokensIndexAccountId = "idx_account_id"
+	tableRefreshTokensIndexFamilyId  = "idx_family_id"
+	tableIdentitiesIndexAccountId    = "idx_account_id"
+	tableApiKeysIndexAccountId       = "idx_account_id"
+	tableAuditLogIndexAccountId      = "idx_account_id"
+	tableAuditLogIndexCreatedAt      = "idx_created_at"
+
+	tableAccountCreationOutboxIndexNextAttemptAt = "idx_next_attempt_at"
 )
@@ -2,9 +2,10 @@ package ydb_adapter
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bratushkadan/floral/internal/auth/core/domain"
 	"github.com/bratushkadan/floral/pkg/resource/idhash"
@@ -20,6 +21,9 @@ type Token struct {
 	db       *ydb.Driver
 	l        *zap.Logger
 	idHasher idhash.IdHasher
+
+	tokensLimit    int
+	evictionPolicy domain.RefreshTokenEvictionPolicy
 }
 
 var _ domain.RefreshTokenProvider = (*Token)(nil)
@@ -28,44 +32,68 @@ type TokenConf struct {
 	DbDriver *ydb.Driver
 	Logger   *zap.Logger
 	IdHasher idhash.IdHasher
+
+	// Amount of refresh tokens stored per account unless specified per call.
+	// DefaultRefreshTokensLimit by default.
+	TokensLimit int
+	// Policy applied once the amount of stored account refresh tokens reaches the limit
+	// unless specified per call. Oldest tokens are evicted by default.
+	EvictionPolicy domain.RefreshTokenEvictionPolicy
 }
 
+const DefaultRefreshTokensLimit = 5
+
 func NewToken(conf TokenConf) *Token {
 	adapter := &Token{
-		db:       conf.DbDriver,
-		idHasher: conf.IdHasher,
-		l:        conf.Logger,
+		db:             conf.DbDriver,
+		idHasher:       conf.IdHasher,
+		l:              conf.Logger,
+		tokensLimit:    conf.TokensLimit,
+		evictionPolicy: conf.EvictionPolicy,
 	}
 
 	if conf.Logger == nil {
 		adapter.l = zap.NewNop()
 	}
+	if conf.TokensLimit <= 0 {
+		adapter.tokensLimit = DefaultRefreshTokensLimit
+	}
+	if conf.EvictionPolicy == "" {
+		adapter.evictionPolicy = domain.RefreshTokenEvictionPolicyEvictOldest
+	}
 
 	return adapter
 }
 
-// TODO: move to config
-const RefreshTokensIssuedLimitation = 5
+func (p *Token) limit(limit int) int {
+	if limit <= 0 {
+		return p.tokensLimit
+	}
+	return limit
+}
 
 var queryListRefreshTokens = template.ReplaceAllPairs(`
 DECLARE $account_id AS Utf8;
+DECLARE $limit AS Uint64;
 
 SELECT 
     id,
     created_at,
-    expires_at
+    expires_at,
+    user_agent,
+    ip,
+    last_used_at
 FROM
     {{table.refresh_tokens}}
 VIEW
     {{index.account_id}}
 WHERE
-    account_id = $account_id
+    account_id = $account_id AND replaced_at IS NULL
 ORDER BY created_at DESC
-LIMIT {{tokens_count_limitation}};
+LIMIT $limit;
 `,
 	"{{table.refresh_tokens}}", tableRefreshTokens,
 	"{{index.account_id}}", tableRefreshTokensIndexAccountId,
-	"{{tokens_count_limitation}}", strconv.Itoa(RefreshTokensIssuedLimitation),
 )
 
 func (p *Token) List(ctx context.Context, in domain.RefreshTokenListDTOInput) (domain.RefreshTokenListDTOOutput, error) {
@@ -74,6 +102,7 @@ func (p *Token) List(ctx context.Context, in domain.RefreshTokenListDTOInput) (d
 	if err := p.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
 		res, err := tx.Execute(ctx, queryListRefreshTokens, table.NewQueryParameters(
 			table.ValueParam("$account_id", types.UTF8Value(in.AccountId)),
+			table.ValueParam("$limit", types.Uint64Value(uint64(p.limit(in.Limit)))),
 		))
 		if err != nil {
 			return err
@@ -95,6 +124,9 @@ func (p *Token) List(ctx context.Context, in domain.RefreshTokenListDTOInput) (d
 					named.Required("id", &intId),
 					named.Required("created_at", &outToken.CreatedAt),
 					named.Required("expires_at", &outToken.ExpiresAt),
+					named.OptionalWithDefault("user_agent", &outToken.UserAgent),
+					named.OptionalWithDefault("ip", &outToken.Ip),
+					named.OptionalWithDefault("last_used_at", &outToken.LastUsedAt),
 				); err != nil {
 					return err
 				}
@@ -118,10 +150,31 @@ func (p *Token) List(ctx context.Context, in domain.RefreshTokenListDTOInput) (d
 	}, nil
 }
 
+var queryCountRefreshTokens = template.ReplaceAllPairs(`
+DECLARE $account_id AS Utf8;
+DECLARE $now AS Datetime;
+
+SELECT
+    COUNT(*) AS count
+FROM
+    {{table.refresh_tokens}}
+VIEW
+    {{index.account_id}}
+WHERE
+    account_id = $account_id AND expires_at > $now AND replaced_at IS NULL;
+`,
+	"{{table.refresh_tokens}}", tableRefreshTokens,
+	"{{index.account_id}}", tableRefreshTokensIndexAccountId,
+)
+
 var queryAddRefreshToken = template.ReplaceAllPairs(`
 DECLARE $account_id AS Utf8;
+DECLARE $remaining_tokens_count AS Uint64;
+DECLARE $family_id AS Utf8;
 DECLARE $created_at AS Datetime;
 DECLARE $expires_at AS Datetime;
+DECLARE $user_agent AS Utf8;
+DECLARE $ip AS Utf8;
 
 $to_delete = (
     SELECT
@@ -131,10 +184,10 @@ $to_delete = (
     VIEW
         {{index.account_id}}
     WHERE
-        account_id = $account_id
+        account_id = $account_id AND replaced_at IS NULL
     ORDER BY created_at DESC
     LIMIT 10000
-    OFFSET {{remaining_tokens_count}}
+    OFFSET $remaining_tokens_count
 );
 
 DELETE FROM
@@ -143,14 +196,22 @@ ON SELECT * FROM $to_delete;
 
 INSERT INTO {{table.refresh_tokens}} (
     account_id,
+    family_id,
     created_at,
-    expires_at
+    expires_at,
+    user_agent,
+    ip,
+    last_used_at
 )
 VALUES 
 (
     $account_id,
+    $family_id,
     $created_at,
-    $expires_at
+    $expires_at,
+    $user_agent,
+    $ip,
+    $created_at
 )
 RETURNING
     id,
@@ -160,18 +221,39 @@ RETURNING
 `,
 	"{{table.refresh_tokens}}", tableRefreshTokens,
 	"{{index.account_id}}", tableRefreshTokensIndexAccountId,
-	"{{remaining_tokens_count}}", strconv.Itoa(RefreshTokensIssuedLimitation-1),
 )
 
-// TODO: describe the limitation on the amount of issued/stored refresh tokens in the API.
+// Add refresh token keeping at most limit tokens per account.
+// Depending on the eviction policy, either the oldest tokens are deleted
+// or domain.ErrRefreshTokensLimitReached is returned once the limit is reached.
 func (p *Token) Add(ctx context.Context, in domain.RefreshTokenAddDTOInput) (domain.RefreshTokenAddDTOOutput, error) {
 	var out domain.RefreshTokenAddDTOOutput
 
+	limit := p.limit(in.Limit)
+	policy := in.EvictionPolicy
+	if policy == "" {
+		policy = p.evictionPolicy
+	}
+
 	if err := p.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		if policy == domain.RefreshTokenEvictionPolicyRejectNew {
+			count, err := p.count(ctx, tx, in.AccountId, in.CreatedAt)
+			if err != nil {
+				return err
+			}
+			if count >= uint64(limit) {
+				return domain.ErrRefreshTokensLimitReached
+			}
+		}
+
 		res, err := tx.Execute(ctx, queryAddRefreshToken, table.NewQueryParameters(
 			table.ValueParam("$account_id", types.UTF8Value(in.AccountId)),
+			table.ValueParam("$remaining_tokens_count", types.Uint64Value(uint64(limit-1))),
+			table.ValueParam("$family_id", types.UTF8Value(in.FamilyId)),
 			table.ValueParam("$created_at", types.DatetimeValueFromTime(in.CreatedAt)),
 			table.ValueParam("$expires_at", types.DatetimeValueFromTime(in.ExpiresAt)),
+			table.ValueParam("$user_agent", types.UTF8Value(in.UserAgent)),
+			table.ValueParam("$ip", types.UTF8Value(in.Ip)),
 		))
 		if err != nil {
 			return err
@@ -205,47 +287,107 @@ func (p *Token) Add(ctx context.Context, in domain.RefreshTokenAddDTOInput) (dom
 
 		return nil
 	}); err != nil {
+		if errors.Is(err, domain.ErrRefreshTokensLimitReached) {
+			return domain.RefreshTokenAddDTOOutput{}, domain.ErrRefreshTokensLimitReached
+		}
 		return domain.RefreshTokenAddDTOOutput{}, fmt.Errorf("failed to execute query transaction add refresh token: %w", err)
 	}
 
 	return out, nil
 }
 
+func (p *Token) count(ctx context.Context, tx table.TransactionActor, accountId string, now time.Time) (uint64, error) {
+	res, err := tx.Execute(ctx, queryCountRefreshTokens, table.NewQueryParameters(
+		table.ValueParam("$account_id", types.UTF8Value(accountId)),
+		table.ValueParam("$now", types.DatetimeValueFromTime(now)),
+	))
+	if err != nil {
+		return 0, err
+	}
+	if err := res.Err(); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := res.Close(); err != nil {
+			p.l.Error("failed to close ydb result", zap.Error(err))
+		}
+	}()
+
+	var count uint64
+	for res.NextResultSet(ctx) {
+		for res.NextRow() {
+			if err := res.ScanNamed(
+				named.Required("count", &count),
+			); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return count, nil
+}
+
+var querySelectRefreshTokenReplacedAt = template.ReplaceAllPairs(`
+DECLARE $id AS Int64;
+
+SELECT
+    replaced_at
+FROM
+    {{table.refresh_tokens}}
+WHERE
+    id = $id;
+`,
+	"{{table.refresh_tokens}}", tableRefreshTokens,
+)
+
 var queryReplaceRefreshToken = template.ReplaceAllPairs(`
 DECLARE $id AS Int64;
 DECLARE $created_at AS Datetime;
 DECLARE $expires_at AS Datetime;
+DECLARE $user_agent AS Utf8;
+DECLARE $ip AS Utf8;
 
-$to_delete = (
+$to_replace = (
     SELECT
         id,
-        account_id
+        account_id,
+        family_id
     FROM
         {{table.refresh_tokens}}
-    WHERE id = $id
+    WHERE id = $id AND replaced_at IS NULL
 );
 
 INSERT INTO {{table.refresh_tokens}} (
     account_id,
+    family_id,
     created_at,
-    expires_at
+    expires_at,
+    user_agent,
+    ip,
+    last_used_at
 )
 SELECT
     account_id,
+    family_id,
     $created_at AS created_at,
-    $expires_at AS expires_at
-FROM $to_delete
+    $expires_at AS expires_at,
+    $user_agent AS user_agent,
+    $ip AS ip,
+    $created_at AS last_used_at
+FROM $to_replace
 RETURNING
     id,
     created_at,
     expires_at;
 
-DELETE FROM {{table.refresh_tokens}}
-ON SELECT id FROM $to_delete;
+UPDATE {{table.refresh_tokens}}
+ON SELECT id, $created_at AS replaced_at FROM $to_replace;
 `,
 	"{{table.refresh_tokens}}", tableRefreshTokens,
 )
 
+// Replaced token is kept with replaced_at set until it expires,
+// so that presenting it again is told apart from presenting a revoked token.
 func (p *Token) Replace(ctx context.Context, in domain.RefreshTokenReplaceDTOInput) (domain.RefreshTokenReplaceDTOOutput, error) {
 	intId, err := p.idHasher.DecodeInt64(in.Id)
 	if err != nil {
@@ -255,10 +397,24 @@ func (p *Token) Replace(ctx context.Context, in domain.RefreshTokenReplaceDTOInp
 	var out domain.RefreshTokenReplaceDTOOutput
 
 	if err := p.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		found, replacedAt, err := p.replacedAt(ctx, tx, intId)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+		if !replacedAt.IsZero() {
+			out.ReplacedAt = replacedAt
+			return nil
+		}
+
 		res, err := tx.Execute(ctx, queryReplaceRefreshToken, table.NewQueryParameters(
 			table.ValueParam("$id", types.Int64Value(intId)),
 			table.ValueParam("$created_at", types.DatetimeValueFromTime(in.CreatedAt)),
 			table.ValueParam("$expires_at", types.DatetimeValueFromTime(in.ExpiresAt)),
+			table.ValueParam("$user_agent", types.UTF8Value(in.UserAgent)),
+			table.ValueParam("$ip", types.UTF8Value(in.Ip)),
 		))
 		if err != nil {
 			return err
@@ -298,6 +454,39 @@ func (p *Token) Replace(ctx context.Context, in domain.RefreshTokenReplaceDTOInp
 	return out, nil
 }
 
+// Reports whether the token is stored and when it has been replaced, zero time if it hasn't.
+func (p *Token) replacedAt(ctx context.Context, tx table.TransactionActor, id int64) (bool, time.Time, error) {
+	res, err := tx.Execute(ctx, querySelectRefreshTokenReplacedAt, table.NewQueryParameters(
+		table.ValueParam("$id", types.Int64Value(id)),
+	))
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if err := res.Err(); err != nil {
+		return false, time.Time{}, err
+	}
+	defer func() {
+		if err := res.Close(); err != nil {
+			p.l.Error("failed to close ydb result", zap.Error(err))
+		}
+	}()
+
+	var found bool
+	var replacedAt time.Time
+	for res.NextResultSet(ctx) {
+		for res.NextRow() {
+			found = true
+			if err := res.ScanNamed(
+				named.OptionalWithDefault("replaced_at", &replacedAt),
+			); err != nil {
+				return false, time.Time{}, err
+			}
+		}
+	}
+
+	return found, replacedAt, nil
+}
+
 var queryDeleteRefreshToken = strings.ReplaceAll(`
 DECLARE $id AS Int64;
 
@@ -360,6 +549,7 @@ func (p *Token) Delete(ctx context.Context, in domain.RefreshTokenDeleteDTOInput
 
 var queryDeleteRefreshTokensByAccountId = template.ReplaceAllPairs(`
 DECLARE $account_id AS Utf8;
+DECLARE $except_id AS Optional<Int64>;
 
 $to_delete = (
     SELECT
@@ -369,7 +559,7 @@ $to_delete = (
     VIEW
         {{index.account_id}}
     WHERE
-        account_id = $account_id
+        account_id = $account_id AND ($except_id IS NULL OR id != $except_id)
 );
 
 DELETE FROM
@@ -385,9 +575,19 @@ RETURNING id;
 func (p *Token) DeleteByAccountId(ctx context.Context, in domain.RefreshTokenDeleteByAccountIdDTOInput) (domain.RefreshTokenDeleteByAccountIdDTOOutput, error) {
 	outIds := make([]string, 0)
 
+	exceptId := types.NullValue(types.TypeInt64)
+	if in.ExceptId != "" {
+		intId, err := p.idHasher.DecodeInt64(in.ExceptId)
+		if err != nil {
+			return domain.RefreshTokenDeleteByAccountIdDTOOutput{}, err
+		}
+		exceptId = types.OptionalValue(types.Int64Value(intId))
+	}
+
 	if err := p.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
 		res, err := tx.Execute(ctx, queryDeleteRefreshTokensByAccountId, table.NewQueryParameters(
 			table.ValueParam("$account_id", types.UTF8Value(in.Id)),
+			table.ValueParam("$except_id", exceptId),
 		))
 		if err != nil {
 			return err
@@ -428,3 +628,116 @@ func (p *Token) DeleteByAccountId(ctx context.Context, in domain.RefreshTokenDel
 		Ids: outIds,
 	}, nil
 }
+
+var queryDeleteRefreshTokensByFamilyId = template.ReplaceAllPairs(`
+DECLARE $family_id AS Utf8;
+
+$to_delete = (
+    SELECT
+        id
+    FROM
+        {{table.refresh_tokens}}
+    VIEW
+        {{index.family_id}}
+    WHERE
+        family_id = $family_id
+);
+
+DELETE FROM
+    {{table.refresh_tokens}}
+ON SELECT * FROM
+    $to_delete
+RETURNING id;
+`,
+	"{{table.refresh_tokens}}", tableRefreshTokens,
+	"{{index.family_id}}", tableRefreshTokensIndexFamilyId,
+)
+
+// Revokes every refresh token that descends from the same Add call.
+func (p *Token) DeleteByFamilyId(ctx context.Context, in domain.RefreshTokenDeleteByFamilyIdDTOInput) (domain.RefreshTokenDeleteByFamilyIdDTOOutput, error) {
+	outIds := make([]string, 0)
+
+	if err := p.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryDeleteRefreshTokensByFamilyId, table.NewQueryParameters(
+			table.ValueParam("$family_id", types.UTF8Value(in.FamilyId)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := res.Close(); err != nil {
+				p.l.Error("failed to close ydb result", zap.Error(err))
+			}
+		}()
+
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				var id int64
+				if err := res.ScanNamed(
+					named.Required("id", &id),
+				); err != nil {
+					return err
+				}
+
+				idStr, err := p.idHasher.EncodeInt64(id)
+				if err != nil {
+					return fmt.Errorf("failed to hash encode int64 id %d: %v", id, err)
+				}
+
+				outIds = append(outIds, idStr)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return domain.RefreshTokenDeleteByFamilyIdDTOOutput{}, fmt.Errorf("failed to execute query transaction delete refresh tokens by family id: %w", err)
+	}
+
+	return domain.RefreshTokenDeleteByFamilyIdDTOOutput{
+		Ids: outIds,
+	}, nil
+}
+
+var queryTouchRefreshToken = template.ReplaceAllPairs(`
+DECLARE $id AS Int64;
+DECLARE $last_used_at AS Datetime;
+
+UPDATE
+    {{table.refresh_tokens}}
+SET
+    last_used_at = $last_used_at
+WHERE
+    id = $id;
+`,
+	"{{table.refresh_tokens}}", tableRefreshTokens,
+)
+
+// Record the time the refresh token was last used to create an access token.
+func (p *Token) Touch(ctx context.Context, in domain.RefreshTokenTouchDTOInput) error {
+	intId, err := p.idHasher.DecodeInt64(in.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := p.db.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, queryTouchRefreshToken, table.NewQueryParameters(
+			table.ValueParam("$id", types.Int64Value(intId)),
+			table.ValueParam("$last_used_at", types.DatetimeValueFromTime(in.LastUsedAt)),
+		))
+		if err != nil {
+			return err
+		}
+		if err := res.Close(); err != nil {
+			return err
+		}
+
+		return res.Err()
+	}); err != nil {
+		return fmt.Errorf("failed to execute query transaction touch refresh token: %w", err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
@@ -20,7 +21,7 @@ var _ domain.AccountCreationNotifications = (*AccountCreation)(nil)
 
 func (q *AccountCreation) Send(ctx context.Context, in domain.SendAccountCreationNotificationDTOInput) (domain.SendAccountCreationNotificationDTOOutput, error) {
 	msg := api.AccountCreationMessage{
-		Id:    "",
+		Id:    in.Id,
 		Email: in.Email,
 	}
 	emailConfirmationMsg, err := json.Marshal(&msg)
@@ -28,9 +29,16 @@ func (q *AccountCreation) Send(ctx context.Context, in domain.SendAccountCreatio
 		return domain.SendAccountCreationNotificationDTOOutput{}, fmt.Errorf("failed to serialize account creation message: %v", err)
 	}
 
-	_, err = q.Sqs.SendMessage(ctx, &sqs.SendMessageInput{
+	sendIn := &sqs.SendMessageInput{
 		MessageBody: aws.String(string(emailConfirmationMsg)),
 		QueueUrl:    aws.String(q.SqsQueueUrl),
-	})
+	}
+	// Messages relayed from the outbox more than once are deduplicated by FIFO queues.
+	if in.Id != "" && strings.HasSuffix(q.SqsQueueUrl, ".fifo") {
+		sendIn.MessageDeduplicationId = aws.String(in.Id)
+		sendIn.MessageGroupId = aws.String(in.Id)
+	}
+
+	_, err = q.Sqs.SendMessage(ctx, sendIn)
 	return domain.SendAccountCreationNotificationDTOOutput{}, err
 }
@@ -0,0 +1,37 @@
+package ymq_adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/shared/api"
+)
+
+type SecurityEvent struct {
+	Sqs         *sqs.Client
+	SqsQueueUrl string
+}
+
+var _ domain.SecurityEventNotifications = (*SecurityEvent)(nil)
+
+func (q *SecurityEvent) Send(ctx context.Context, in domain.SendSecurityEventNotificationDTOInput) (domain.SendSecurityEventNotificationDTOOutput, error) {
+	msg := api.SecurityEventMessage{
+		Type:      in.Type,
+		AccountId: in.AccountId,
+		Details:   in.Details,
+	}
+	securityEventMsg, err := json.Marshal(&msg)
+	if err != nil {
+		return domain.SendSecurityEventNotificationDTOOutput{}, fmt.Errorf("failed to serialize security event message: %v", err)
+	}
+
+	_, err = q.Sqs.SendMessage(ctx, &sqs.SendMessageInput{
+		MessageBody: aws.String(string(securityEventMsg)),
+		QueueUrl:    aws.String(q.SqsQueueUrl),
+	})
+	return domain.SendSecurityEventNotificationDTOOutput{}, err
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Deny-list of access tokens revoked before they expire. Checked on every access token
+// decoding, so lookups are expected to be served from memory.
+type AccessTokenRevocations interface {
+	Revoke(context.Context, AccessTokenRevocation) error
+	Revoked(context.Context, AccessToken) (bool, error)
+}
+
+// Revokes either a single token by its id or every token of the account issued before RevokedAt.
+type AccessTokenRevocation struct {
+	TokenId   string
+	AccountId string
+	RevokedAt time.Time
+	// The revocation is forgotten once every token it applies to has expired.
+	ExpiresAt time.Time
+}
+
+// Reports whether the revocation applies to the token.
+func (r AccessTokenRevocation) Applies(token AccessToken) bool {
+	if r.TokenId != "" {
+		return token.Id == r.TokenId
+	}
+	// Issued at is truncated to seconds, so tokens issued in the second of the revocation are revoked too.
+	return token.SubjectId == r.AccountId && token.IssuedAt.Before(r.RevokedAt)
+}
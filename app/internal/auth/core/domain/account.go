@@ -13,6 +13,12 @@ var (
 	ErrEmailIsInUse        = errors.New("email is in use")
 	ErrAccountNotActivated = errors.New("account not activated")
 	ErrPermissionDenied    = errors.New("permission denied")
+	ErrAccountSuspended    = errors.New("account suspended")
+	ErrInvalidAccountType  = errors.New("invalid account type")
+	// Admins and service accounts can't be impersonated.
+	ErrImpersonationNotAllowed = errors.New("account can't be impersonated")
+	// Impersonation tokens only let support staff see what the account owner sees.
+	ErrImpersonationRefused = errors.New("operation is not allowed with an impersonation token")
 )
 
 var (
@@ -25,6 +31,8 @@ const (
 	AccountTypeUser   AccountType = "user"
 	AccountTypeSeller AccountType = "seller"
 	AccountTypeAdmin  AccountType = "admin"
+	// Machine-to-machine account authenticating with api keys only.
+	AccountTypeService AccountType = "service"
 )
 
 type Account struct {
@@ -47,15 +55,31 @@ func (a Account) Type() AccountType {
 	return a.accountType
 }
 
+func ValidateAccountType(accountType string) error {
+	switch accountType {
+	case AccountTypeUser, AccountTypeSeller, AccountTypeAdmin, AccountTypeService:
+		return nil
+	}
+	return ErrInvalidAccountType
+}
+
 func (a Account) validateEmail() error {
-	if regexDomain.MatchString(a.email) {
+	return ValidateEmail(a.email)
+}
+
+func ValidateEmail(email string) error {
+	if regexDomain.MatchString(email) {
 		return nil
 	}
 	return ErrInvalidEmail
 }
 
 func (a Account) validatePassword() error {
-	if utf8.RuneCountInString(a.password) > 24 {
+	return ValidatePassword(a.password)
+}
+
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) > 24 {
 		// Hashed sequences of byte length > 72 by bcrypt are not valid.
 		return ErrPasswordTooLong
 	}
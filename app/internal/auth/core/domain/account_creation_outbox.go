@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Account creation notifications written in the same transaction as the account
+// by the account provider and published to the account creations queue by the relay.
+type AccountCreationOutbox interface {
+	// Messages due for publishing, oldest first.
+	ListDue(context.Context, AccountCreationOutboxListDueDTOInput) ([]AccountCreationOutboxMessage, error)
+	// Remove the published message.
+	Delete(ctx context.Context, id string) error
+	// Schedule the next publishing attempt after a failed one.
+	Retry(context.Context, AccountCreationOutboxRetryDTOInput) error
+}
+
+type AccountCreationOutboxMessage struct {
+	// Idempotency key the message is published with.
+	Id            string
+	Email         string
+	Attempts      int
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+}
+
+type AccountCreationOutboxListDueDTOInput struct {
+	Now   time.Time
+	Limit int
+}
+
+type AccountCreationOutboxRetryDTOInput struct {
+	Id            string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+}
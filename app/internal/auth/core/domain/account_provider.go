@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type CheckUserCredentialsDTOOutput struct {
 	UserId   string
@@ -14,6 +17,41 @@ type AccountProvider interface {
 	FindAccountByEmail(context.Context, FindAccountByEmailDTOInput) (*FindAccountByEmailDTOOutput, error)
 	CheckAccountCredentials(context.Context, CheckAccountCredentialsDTOInput) (CheckAccountCredentialsDTOOutput, error)
 	ActivateAccountsByEmail(context.Context, ActivateAccountsByEmailDTOInput) error
+	// Hash and set new account password.
+	UpdatePassword(context.Context, UpdatePasswordDTOInput) error
+	// Set pending account email. The account email is changed
+	// once the pending email is confirmed, see ActivateAccountsByEmail.
+	// Returns ErrEmailIsInUse if another account has the email pending.
+	UpdateEmail(context.Context, UpdateEmailDTOInput) error
+
+	// Mark account deleted. Deleted accounts are not found nor can be authenticated,
+	// but the data is kept until the account is purged.
+	DeleteAccount(context.Context, DeleteAccountDTOInput) error
+	ListDeletedAccounts(context.Context, ListDeletedAccountsDTOInput) (ListDeletedAccountsDTOOutput, error)
+	// Delete account data along with the account refresh tokens.
+	PurgeAccount(context.Context, PurgeAccountDTOInput) error
+
+	// List accounts ordered by id. Deleted accounts are not listed.
+	ListAccounts(context.Context, ListAccountsDTOInput) (ListAccountsDTOOutput, error)
+	// Suspended accounts can neither authenticate nor create access tokens.
+	SuspendAccount(context.Context, SuspendAccountDTOInput) error
+	UnsuspendAccount(context.Context, UnsuspendAccountDTOInput) error
+	UpdateAccountType(context.Context, UpdateAccountTypeDTOInput) error
+
+	// Returns nil if the account does not exist.
+	FindAccountMfa(context.Context, FindAccountMfaDTOInput) (*FindAccountMfaDTOOutput, error)
+	// Store TOTP secret of a pending enrollment. MFA stays disabled until EnableMfa is called.
+	SetMfaTotpSecret(context.Context, SetMfaTotpSecretDTOInput) error
+	// Enable MFA with the stored TOTP secret, hash and store recovery codes.
+	EnableMfa(context.Context, EnableMfaDTOInput) error
+	// Disable MFA and remove the TOTP secret along with recovery codes.
+	DisableMfa(context.Context, DisableMfaDTOInput) error
+	// Record the counter of the accepted TOTP code. Returns false if a code
+	// of the same or a later period has already been accepted, so that codes can't be replayed.
+	UseMfaTotpCounter(context.Context, UseMfaTotpCounterDTOInput) (bool, error)
+	// Check the recovery code against the stored hashes and remove the matching one.
+	// Returns false if no recovery code matches.
+	UseMfaRecoveryCode(context.Context, UseMfaRecoveryCodeDTOInput) (bool, error)
 }
 
 type CreateAccountDTOInput struct {
@@ -21,6 +59,10 @@ type CreateAccountDTOInput struct {
 	Password string
 	Email    string
 	Type     string
+	// Create the account already activated, skipping email confirmation.
+	Activated bool
+	// Enqueue account creation notification with the id to the outbox in the same transaction if set.
+	CreationNotificationId string
 }
 type CreateAccountDTOOutput struct {
 	Id    string
@@ -37,6 +79,14 @@ type FindAccountDTOOutput struct {
 	Email     string
 	Type      string
 	Activated bool
+
+	CreatedAt time.Time
+	// Zero if the account has not been activated.
+	ActivatedAt time.Time
+	// Email waiting for confirmation to replace the account email.
+	PendingEmail string
+	// Zero if the account is not suspended.
+	SuspendedAt time.Time
 }
 
 type FindAccountByEmailDTOInput struct {
@@ -54,11 +104,122 @@ type CheckAccountCredentialsDTOInput struct {
 	Password string
 }
 type CheckAccountCredentialsDTOOutput struct {
-	Ok        bool
-	Activated bool
-	AccountId string
+	Ok          bool
+	Activated   bool
+	Suspended   bool
+	MfaEnabled  bool
+	AccountId   string
+	AccountType AccountType
 }
 
 type ActivateAccountsByEmailDTOInput struct {
 	Emails []string
 }
+
+type UpdatePasswordDTOInput struct {
+	Id       string
+	Password string
+}
+
+type UpdateEmailDTOInput struct {
+	Id    string
+	Email string
+}
+
+type DeleteAccountDTOInput struct {
+	Id        string
+	DeletedAt time.Time
+}
+
+type ListDeletedAccountsDTOInput struct {
+	DeletedBefore time.Time
+	Limit         int
+}
+type ListDeletedAccountsDTOOutput struct {
+	Accounts []ListDeletedAccountsDTOOutputAccount
+}
+type ListDeletedAccountsDTOOutputAccount struct {
+	Id        string
+	Email     string
+	DeletedAt time.Time
+}
+
+type PurgeAccountDTOInput struct {
+	Id string
+}
+
+type ListAccountsDTOInput struct {
+	// Optional filters.
+	Type          AccountType
+	Activated     *bool
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// Id of the last account of the previous page, the first page is listed if not set.
+	AfterId string
+	Limit   int
+}
+type ListAccountsDTOOutput struct {
+	Accounts []ListAccountsDTOOutputAccount
+}
+type ListAccountsDTOOutputAccount struct {
+	Id          string
+	Name        string
+	Email       string
+	Type        AccountType
+	CreatedAt   time.Time
+	ActivatedAt time.Time
+	SuspendedAt time.Time
+}
+
+type SuspendAccountDTOInput struct {
+	Id          string
+	SuspendedAt time.Time
+}
+
+type UnsuspendAccountDTOInput struct {
+	Id string
+}
+
+type UpdateAccountTypeDTOInput struct {
+	Id   string
+	Type AccountType
+}
+
+type FindAccountMfaDTOInput struct {
+	Id string
+}
+type FindAccountMfaDTOOutput struct {
+	// Empty if MFA enrollment has not been started.
+	TotpSecret string
+	// Zero if MFA is not enabled.
+	EnabledAt         time.Time
+	TotpLastCounter   uint64
+	RecoveryCodesLeft int
+}
+
+type SetMfaTotpSecretDTOInput struct {
+	Id         string
+	TotpSecret string
+}
+
+type EnableMfaDTOInput struct {
+	Id              string
+	EnabledAt       time.Time
+	TotpLastCounter uint64
+	RecoveryCodes   []string
+}
+
+type DisableMfaDTOInput struct {
+	Id string
+}
+
+type UseMfaTotpCounterDTOInput struct {
+	Id      string
+	Counter uint64
+}
+
+type UseMfaRecoveryCodeDTOInput struct {
+	Id   string
+	Code string
+}
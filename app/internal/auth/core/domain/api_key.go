@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidServiceAccountName = errors.New("invalid service account name")
+	ErrServiceAccountExists      = errors.New("service account exists")
+	ErrInvalidApiKey             = errors.New("invalid or expired api key")
+	ErrApiKeyNotFound            = errors.New("api key not found")
+	ErrInvalidScope              = errors.New("invalid scope")
+)
+
+const (
+	// Makes leaked keys recognizable by secret scanners.
+	ApiKeyPrefix = "flk"
+	// Service accounts have no mailbox, the synthetic email only keeps the account name unique.
+	ServiceAccountEmailDomain = "service.invalid"
+)
+
+var (
+	regexServiceAccountName = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	regexScope              = regexp.MustCompile(`^[a-z0-9_.:-]{1,64}$`)
+)
+
+func ValidateServiceAccountName(name string) error {
+	if regexServiceAccountName.MatchString(name) {
+		return nil
+	}
+	return ErrInvalidServiceAccountName
+}
+
+func ServiceAccountEmail(name string) string {
+	return name + "@" + ServiceAccountEmailDomain
+}
+
+// Scopes are opaque to the auth service, resource servers define them, i.e. "products:write".
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !regexScope.MatchString(scope) {
+			return ErrInvalidScope
+		}
+	}
+	return nil
+}
+
+// Api key in the form of "flk_<id>_<secret>".
+func FormatApiKey(id, secret string) string {
+	return ApiKeyPrefix + "_" + id + "_" + secret
+}
+
+// Returns false if the key is not in the format of FormatApiKey.
+func ParseApiKey(key string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(key, ApiKeyPrefix+"_")
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// Long-lived credential of a service account exchanged for access tokens.
+type ApiKey struct {
+	Id        string
+	AccountId string
+	Name      string
+	// Scopes access tokens issued for the key may carry.
+	Scopes    []string
+	CreatedAt time.Time
+	// Zero if the key never expires. Rotated keys expire after the grace period.
+	ExpiresAt time.Time
+	// Zero if the key has never been used.
+	LastUsedAt time.Time
+}
+
+func (k ApiKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && now.After(k.ExpiresAt)
+}
+
+type ApiKeys interface {
+	Add(context.Context, ApiKeysAddDTOInput) error
+	// Returns nil key if there's no key with the id.
+	Get(ctx context.Context, id string) (*ApiKey, error)
+	// Reports whether the secret is the one of the key.
+	CheckSecret(context.Context, ApiKeysCheckSecretDTOInput) (bool, error)
+	List(ctx context.Context, accountId string) ([]ApiKey, error)
+	Expire(context.Context, ApiKeysExpireDTOInput) error
+	Touch(context.Context, ApiKeysTouchDTOInput) error
+	// Reports whether the key has been deleted.
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+type ApiKeysAddDTOInput struct {
+	Id        string
+	AccountId string
+	Name      string
+	// Stored hashed.
+	Secret    string
+	Scopes    []string
+	CreatedAt time.Time
+}
+
+type ApiKeysCheckSecretDTOInput struct {
+	Id     string
+	Secret string
+}
+
+type ApiKeysExpireDTOInput struct {
+	Id        string
+	ExpiresAt time.Time
+}
+
+type ApiKeysTouchDTOInput struct {
+	Id         string
+	LastUsedAt time.Time
+}
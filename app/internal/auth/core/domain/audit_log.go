@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidAuditEventType = errors.New("invalid audit event type")
+	ErrInvalidPageToken      = errors.New("invalid page token")
+)
+
+type AuditEventType = string
+
+const (
+	// Account logged in and started a session with any of the authentication methods.
+	AuditEventAuthentication AuditEventType = "authentication"
+	// Refresh token was rotated with ReplaceRefreshToken.
+	AuditEventRefreshTokenRotation AuditEventType = "refresh_token_rotation"
+	// Already rotated refresh token was presented again, the whole rotation family got revoked.
+	AuditEventRefreshTokenReuse   AuditEventType = "refresh_token_reuse"
+	AuditEventAccessTokenCreation AuditEventType = "access_token_creation"
+	AuditEventSellerCreation      AuditEventType = "seller_creation"
+	AuditEventAdminCreation       AuditEventType = "admin_creation"
+	// Password of the existing admin was rotated by CreateAdmin.
+	AuditEventAdminPasswordRotation AuditEventType = "admin_password_rotation"
+	AuditEventAccountActivation     AuditEventType = "account_activation"
+	// Admin minted an access token acting on behalf of another account.
+	AuditEventImpersonation AuditEventType = "impersonation"
+	// Access tokens of the account were revoked before they expire.
+	AuditEventAccessTokenRevocation AuditEventType = "access_token_revocation"
+)
+
+var auditEventTypes = []AuditEventType{
+	AuditEventAuthentication,
+	AuditEventRefreshTokenRotation,
+	AuditEventRefreshTokenReuse,
+	AuditEventAccessTokenCreation,
+	AuditEventSellerCreation,
+	AuditEventAdminCreation,
+	AuditEventAdminPasswordRotation,
+	AuditEventAccountActivation,
+	AuditEventImpersonation,
+	AuditEventAccessTokenRevocation,
+}
+
+func ValidateAuditEventType(eventType AuditEventType) error {
+	if !slices.Contains(auditEventTypes, eventType) {
+		return ErrInvalidAuditEventType
+	}
+	return nil
+}
+
+// Security relevant event recorded to the append-only audit trail.
+type AuditEvent struct {
+	Id   string
+	Type AuditEventType
+	// Account the event happened to.
+	AccountId string
+	// Account that performed the operation if it's not the account itself, i.e. an admin.
+	ActorId   string
+	Details   map[string]string
+	CreatedAt time.Time
+}
+
+type AuditLog interface {
+	Append(context.Context, AuditEvent) error
+	// Lists events from the newest to the oldest.
+	List(context.Context, AuditLogListDTOInput) ([]AuditEvent, error)
+}
+
+type AuditLogListDTOInput struct {
+	// Optional filters.
+	AccountId string
+	Type      AuditEventType
+	Since     time.Time
+	Until     time.Time
+
+	// Last event of the previous page, the first page is listed if not set.
+	Before *AuditLogCursor
+	Limit  int
+}
+
+// Position of the event in the audit log, events are ordered by creation time and id.
+type AuditLogCursor struct {
+	CreatedAt time.Time
+	Id        string
+}
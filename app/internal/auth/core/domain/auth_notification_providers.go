@@ -8,6 +8,8 @@ type AccountCreationNotifications interface {
 }
 
 type SendAccountCreationNotificationDTOInput struct {
+	// Idempotency key of notifications relayed from the outbox, consumers may receive the message more than once.
+	Id    string
 	Email string
 }
 type SendAccountCreationNotificationDTOOutput struct {
@@ -35,3 +37,22 @@ type SendEmailConfirmationNotificationsDTOOutput struct {
 // 	Email string
 // }
 // type RcvProcessEmailConfirmationNotificationsDTOOutput struct{}
+
+type SecurityEventType = string
+
+const (
+	// Already rotated refresh token was presented again, the whole rotation family got revoked.
+	SecurityEventRefreshTokenReuse SecurityEventType = "refresh_token_reuse"
+)
+
+type SecurityEventNotifications interface {
+	Send(context.Context, SendSecurityEventNotificationDTOInput) (SendSecurityEventNotificationDTOOutput, error)
+}
+
+type SendSecurityEventNotificationDTOInput struct {
+	Type      SecurityEventType
+	AccountId string
+	Details   map[string]string
+}
+type SendSecurityEventNotificationDTOOutput struct {
+}
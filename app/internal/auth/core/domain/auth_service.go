@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"time"
 )
@@ -16,13 +17,18 @@ var (
 	ErrTokenParseFailed              = errors.New("token parse failed")
 	ErrTokenExpired                  = errors.New("token expired")
 	ErrTokenRevoked                  = errors.New("token revoked")
+	ErrTokenRevocationCheckFailed    = errors.New("failed to check token revocation")
 	ErrRefreshTokenToReplaceNotFound = errors.New("refresh token to replace not found")
+	ErrRefreshTokenReused            = errors.New("refresh token reuse detected")
+	ErrSessionNotFound               = errors.New("session not found")
+	ErrRefreshTokensLimitReached     = errors.New("refresh tokens limit reached")
 )
 
 type AuthService interface {
 	CreateUser(context.Context, CreateUserReq) (CreateUserRes, error)
 	CreateSeller(context.Context, CreateSellerReq) (CreateSellerRes, error)
-	// DO NOT expose this method externally.
+	// Create an activated admin account or rotate the password of an existing one.
+	// DO NOT expose this method externally, see cmd/auth/admin-bootstrap.
 	CreateAdmin(context.Context, CreateAdminReq) (CreateAdminRes, error)
 	ActivateAccounts(context.Context, ActivateAccountsReq) (ActivateAccountsRes, error)
 
@@ -30,6 +36,119 @@ type AuthService interface {
 	ReplaceRefreshToken(context.Context, ReplaceRefreshTokenReq) (ReplaceRefreshTokenRes, error)
 
 	CreateAccessToken(context.Context, CreateAccessTokenReq) (CreateAccessTokenRes, error)
+
+	// Revoke the session of the refresh token.
+	Logout(context.Context, LogoutReq) (LogoutRes, error)
+	// Revoke all sessions of the access token owner. Impersonation tokens are refused.
+	LogoutAll(context.Context, LogoutAllReq) (LogoutAllRes, error)
+
+	// List sessions (issued refresh tokens) of the access token owner.
+	ListSessions(context.Context, ListSessionsReq) (ListSessionsRes, error)
+	// Revoke a single session of the access token owner.
+	RevokeSession(context.Context, RevokeSessionReq) (RevokeSessionRes, error)
+
+	// Send password reset link to the account email.
+	// Succeeds for unknown emails as well not to disclose registered emails.
+	RequestPasswordReset(context.Context, RequestPasswordResetReq) (RequestPasswordResetRes, error)
+	// Set new account password by the password reset token and revoke all account sessions.
+	ResetPassword(context.Context, ResetPasswordReq) (ResetPasswordRes, error)
+
+	// Change password of the access token owner and revoke all sessions but the current one.
+	UpdatePassword(context.Context, UpdatePasswordReq) (UpdatePasswordRes, error)
+	// Request changing email of the access token owner.
+	// The email is changed once the new email address is confirmed.
+	UpdateEmail(context.Context, UpdateEmailReq) (UpdateEmailRes, error)
+
+	// Delete the account of the access token owner or any account if the owner is an admin.
+	// Account data is purged after the deletion grace period.
+	DeleteAccount(context.Context, DeleteAccountReq) (DeleteAccountRes, error)
+	// DO NOT expose this method externally.
+	PurgeDeletedAccounts(context.Context, PurgeDeletedAccountsReq) (PurgeDeletedAccountsRes, error)
+	// DO NOT expose this method externally.
+	// Publish account creation notifications of the outbox that are due, failed ones are retried later.
+	RelayAccountCreationNotifications(context.Context, RelayAccountCreationNotificationsReq) (RelayAccountCreationNotificationsRes, error)
+	// Export data stored on the account of the access token owner or any account if the owner is an admin.
+	ExportAccountData(context.Context, ExportAccountDataReq) (ExportAccountDataRes, error)
+
+	// Admin only. List accounts page by page.
+	ListAccounts(context.Context, ListAccountsReq) (ListAccountsRes, error)
+	// Admin only.
+	GetAccount(context.Context, GetAccountReq) (GetAccountRes, error)
+	// Admin only. Suspended accounts can neither authenticate nor create access tokens.
+	SuspendAccount(context.Context, SuspendAccountReq) (SuspendAccountRes, error)
+	// Admin only.
+	UnsuspendAccount(context.Context, UnsuspendAccountReq) (UnsuspendAccountRes, error)
+	// Admin only. Promote or demote account.
+	UpdateAccountType(context.Context, UpdateAccountTypeReq) (UpdateAccountTypeRes, error)
+	// Admin only. Lift the lockout caused by failed authentication attempts for the account email.
+	UnlockAccount(context.Context, UnlockAccountReq) (UnlockAccountRes, error)
+
+	// Exchange the MFA challenge token issued by Authenticate and a TOTP or recovery code for a refresh token.
+	VerifyMfa(context.Context, VerifyMfaReq) (VerifyMfaRes, error)
+	// Generate TOTP secret for the access token owner. MFA is enabled once confirmed with ConfirmMfa.
+	EnrollMfa(context.Context, EnrollMfaReq) (EnrollMfaRes, error)
+	// Enable MFA with the first TOTP code and issue recovery codes.
+	ConfirmMfa(context.Context, ConfirmMfaReq) (ConfirmMfaRes, error)
+	DisableMfa(context.Context, DisableMfaReq) (DisableMfaRes, error)
+
+	// Start registration of a passkey for the access token owner.
+	BeginPasskeyRegistration(context.Context, BeginPasskeyRegistrationReq) (BeginPasskeyRegistrationRes, error)
+	// Verify the credential created by the authenticator and store it.
+	FinishPasskeyRegistration(context.Context, FinishPasskeyRegistrationReq) (FinishPasskeyRegistrationRes, error)
+	// Start passwordless login. The assertion is passed to Authenticate to get a refresh token.
+	BeginPasskeyLogin(context.Context, BeginPasskeyLoginReq) (BeginPasskeyLoginRes, error)
+	// List passkeys of the access token owner.
+	ListPasskeys(context.Context, ListPasskeysReq) (ListPasskeysRes, error)
+	DeletePasskey(context.Context, DeletePasskeyReq) (DeletePasskeyRes, error)
+
+	// Start login with the external identity provider. The user is redirected to the authorization url
+	// and the state and the code the provider redirects back with are passed to Authenticate.
+	BeginIdentityLogin(context.Context, BeginIdentityLoginReq) (BeginIdentityLoginRes, error)
+	// List external identities linked to the account of the access token owner.
+	ListIdentities(context.Context, ListIdentitiesReq) (ListIdentitiesRes, error)
+
+	// OpenID Connect provider metadata served at /.well-known/openid-configuration.
+	GetOpenIdConfiguration(context.Context, GetOpenIdConfigurationReq) (GetOpenIdConfigurationRes, error)
+	// Keys ID and access tokens signatures are verified with.
+	GetJwks(context.Context, GetJwksReq) (GetJwksRes, error)
+	// Grant the client an authorization code on behalf of the access token owner
+	// (authorization code flow with PKCE). Called by the login page the client redirects to.
+	Authorize(context.Context, AuthorizeReq) (AuthorizeRes, error)
+	// Exchange the authorization code for access and ID tokens.
+	ExchangeAuthorizationCode(context.Context, ExchangeAuthorizationCodeReq) (ExchangeAuthorizationCodeRes, error)
+	// Claims about the access token owner.
+	GetUserInfo(context.Context, GetUserInfoReq) (GetUserInfoRes, error)
+	// Admin only. Register a client that delegates login to the auth service.
+	CreateOAuthClient(context.Context, CreateOAuthClientReq) (CreateOAuthClientRes, error)
+	// Admin only.
+	DeleteOAuthClient(context.Context, DeleteOAuthClientReq) (DeleteOAuthClientRes, error)
+
+	// Admin only. Create a machine-to-machine account authenticating with api keys.
+	CreateServiceAccount(context.Context, CreateServiceAccountReq) (CreateServiceAccountRes, error)
+	// Admin only. Issue an api key for the service account. The key is returned once.
+	CreateApiKey(context.Context, CreateApiKeyReq) (CreateApiKeyRes, error)
+	// Admin only.
+	ListApiKeys(context.Context, ListApiKeysReq) (ListApiKeysRes, error)
+	// Admin only. Issue a new key with the same scopes, the old one expires after the rotation grace period.
+	RotateApiKey(context.Context, RotateApiKeyReq) (RotateApiKeyRes, error)
+	// Admin only.
+	RevokeApiKey(context.Context, RevokeApiKeyReq) (RevokeApiKeyRes, error)
+	// Exchange the api key of a service account for an access token.
+	ExchangeApiKey(context.Context, ExchangeApiKeyReq) (ExchangeApiKeyRes, error)
+
+	// Admin only. Mint a short-lived access token acting on behalf of the account for a support session.
+	// No refresh token is issued and every impersonation is recorded to the audit log.
+	Impersonate(context.Context, ImpersonateReq) (ImpersonateRes, error)
+
+	// Admin only. List audit log events from the newest to the oldest.
+	ListAuditEvents(context.Context, ListAuditEventsReq) (ListAuditEventsRes, error)
+
+	// Admin only. Revoke every access token issued to the account so far. Refresh tokens are kept,
+	// so suspend the account or revoke its sessions to prevent issuing new ones.
+	RevokeAccessTokens(context.Context, RevokeAccessTokensReq) (RevokeAccessTokensRes, error)
+
+	// Service accounts only. Report whether a refresh or access token is valid and not revoked (RFC 7662).
+	IntrospectToken(context.Context, IntrospectTokenReq) (IntrospectTokenRes, error)
 }
 
 type CreateUserReq struct {
@@ -65,6 +184,8 @@ type CreateAdminRes struct {
 	Id    string `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	// False if the password of an existing admin account was rotated.
+	Created bool `json:"created"`
 }
 
 type ActivateAccountsReq struct {
@@ -76,14 +197,43 @@ type ActivateAccountsRes struct {
 type AuthenticateReq struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Authenticate with the passkey instead of the email and password.
+	Passkey *PasskeyAssertion `json:"passkey"`
+	// Authenticate with the external identity provider instead of the email and password.
+	Identity *IdentityAssertion `json:"identity"`
+
+	// Session metadata.
+	UserAgent string `json:"-"`
+	Ip        string `json:"-"`
 }
 type AuthenticateRes struct {
 	RefreshToken string `json:"refresh_token"`
 	ExpiresAt    time.Time
+	// Issued instead of the refresh token for accounts with MFA enabled,
+	// exchanged for the refresh token with VerifyMfa. ExpiresAt is the challenge token expiration then.
+	MfaChallengeToken string `json:"mfa_challenge_token"`
+}
+
+type PasskeyAssertion struct {
+	// Id of the ceremony started with BeginPasskeyLogin.
+	CeremonyId string `json:"ceremony_id"`
+	// Response of navigator.credentials.get().
+	Credential json.RawMessage `json:"credential"`
+}
+
+type IdentityAssertion struct {
+	// State of the login started with BeginIdentityLogin.
+	State string `json:"state"`
+	// Authorization code the identity provider redirected back with.
+	Code string `json:"code"`
 }
 
 type ReplaceRefreshTokenReq struct {
 	RefreshToken string `json:"refresh_token"`
+
+	// Session metadata.
+	UserAgent string `json:"-"`
+	Ip        string `json:"-"`
 }
 type ReplaceRefreshTokenRes struct {
 	RefreshToken string `json:"refresh_token"`
@@ -97,3 +247,524 @@ type CreateAccessTokenRes struct {
 	AccessToken string    `json:"access_token"`
 	ExpiresAt   time.Time `json:"expires_at"`
 }
+
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+type LogoutRes struct {
+}
+
+type LogoutAllReq struct {
+	AccessToken string `json:"access_token"`
+}
+type LogoutAllRes struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+type Session struct {
+	Id         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+type ListSessionsReq struct {
+	AccessToken string `json:"access_token"`
+}
+type ListSessionsRes struct {
+	Sessions []Session `json:"sessions"`
+}
+
+type RevokeSessionReq struct {
+	AccessToken string `json:"access_token"`
+	SessionId   string `json:"session_id"`
+}
+type RevokeSessionRes struct {
+}
+
+type RequestPasswordResetReq struct {
+	Email string `json:"email"`
+}
+type RequestPasswordResetRes struct {
+}
+
+type ResetPasswordReq struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+type ResetPasswordRes struct {
+}
+
+type UpdatePasswordReq struct {
+	AccessToken string `json:"access_token"`
+	// Optional. Refresh token of the current session that is kept after the password change.
+	RefreshToken string `json:"refresh_token"`
+	Password     string `json:"password"`
+	NewPassword  string `json:"new_password"`
+}
+type UpdatePasswordRes struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+type UpdateEmailReq struct {
+	AccessToken string `json:"access_token"`
+	Password    string `json:"password"`
+	NewEmail    string `json:"new_email"`
+}
+type UpdateEmailRes struct {
+}
+
+type DeleteAccountReq struct {
+	AccessToken string `json:"access_token"`
+	// Optional. Account of the access token owner is deleted if not set.
+	AccountId string `json:"account_id"`
+}
+type DeleteAccountRes struct {
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+type PurgeDeletedAccountsReq struct {
+}
+type PurgeDeletedAccountsRes struct {
+	PurgedAccounts int `json:"purged_accounts"`
+}
+
+type RelayAccountCreationNotificationsReq struct {
+}
+type RelayAccountCreationNotificationsRes struct {
+	Published int `json:"published"`
+	Failed    int `json:"failed"`
+}
+
+type ExportAccountDataReq struct {
+	AccessToken string `json:"access_token"`
+	// Optional. Data of the access token owner is exported if not set.
+	AccountId string `json:"account_id"`
+}
+type ExportAccountDataRes struct {
+	Account            ExportedAccount             `json:"account"`
+	Sessions           []Session                   `json:"sessions"`
+	EmailConfirmations []ExportedEmailConfirmation `json:"email_confirmations"`
+	Passkeys           []Passkey                   `json:"passkeys,omitempty"`
+	Identities         []LinkedIdentity            `json:"identities,omitempty"`
+}
+
+type ExportedAccount struct {
+	Id           string     `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Type         string     `json:"type"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ActivatedAt  *time.Time `json:"activated_at"`
+	PendingEmail string     `json:"pending_email,omitempty"`
+}
+
+// Email confirmation sent to the account. The confirmation token itself is not exported.
+type ExportedEmailConfirmation struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AccountInfo struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	Type        string     `json:"type"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ActivatedAt *time.Time `json:"activated_at"`
+	SuspendedAt *time.Time `json:"suspended_at"`
+}
+
+type ListAccountsReq struct {
+	AccessToken string `json:"access_token"`
+
+	// Optional filters.
+	Type          AccountType `json:"type"`
+	Activated     *bool       `json:"activated"`
+	CreatedAfter  time.Time   `json:"created_after"`
+	CreatedBefore time.Time   `json:"created_before"`
+
+	PageSize  int    `json:"page_size"`
+	PageToken string `json:"page_token"`
+}
+type ListAccountsRes struct {
+	Accounts []AccountInfo `json:"accounts"`
+	// Empty if there are no more accounts to list.
+	NextPageToken string `json:"next_page_token"`
+}
+
+type GetAccountReq struct {
+	AccessToken string `json:"access_token"`
+	AccountId   string `json:"account_id"`
+}
+type GetAccountRes struct {
+	Account AccountInfo `json:"account"`
+}
+
+type SuspendAccountReq struct {
+	AccessToken string `json:"access_token"`
+	AccountId   string `json:"account_id"`
+}
+type SuspendAccountRes struct {
+}
+
+type UnsuspendAccountReq struct {
+	AccessToken string `json:"access_token"`
+	AccountId   string `json:"account_id"`
+}
+type UnsuspendAccountRes struct {
+}
+
+type UpdateAccountTypeReq struct {
+	AccessToken string      `json:"access_token"`
+	AccountId   string      `json:"account_id"`
+	Type        AccountType `json:"type"`
+}
+type UpdateAccountTypeRes struct {
+}
+
+type UnlockAccountReq struct {
+	AccessToken string `json:"access_token"`
+	AccountId   string `json:"account_id"`
+}
+type UnlockAccountRes struct {
+}
+
+type VerifyMfaReq struct {
+	MfaChallengeToken string `json:"mfa_challenge_token"`
+	// Either of the TOTP code or a recovery code.
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+
+	// Session metadata.
+	UserAgent string `json:"-"`
+	Ip        string `json:"-"`
+}
+type VerifyMfaRes struct {
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type EnrollMfaReq struct {
+	AccessToken string `json:"access_token"`
+}
+type EnrollMfaRes struct {
+	TotpSecret string `json:"totp_secret"`
+	// otpauth:// key URI to be rendered as a QR code.
+	TotpUri string `json:"totp_uri"`
+}
+
+type ConfirmMfaReq struct {
+	AccessToken string `json:"access_token"`
+	Code        string `json:"code"`
+}
+type ConfirmMfaRes struct {
+	// Shown once, only hashes are stored.
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableMfaReq struct {
+	AccessToken string `json:"access_token"`
+	Password    string `json:"password"`
+	Code        string `json:"code"`
+}
+type DisableMfaRes struct {
+}
+
+type BeginPasskeyRegistrationReq struct {
+	AccessToken string `json:"access_token"`
+}
+type BeginPasskeyRegistrationRes struct {
+	CeremonyId string `json:"ceremony_id"`
+	// PublicKeyCredentialCreationOptions for navigator.credentials.create().
+	Options   json.RawMessage `json:"options"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+type FinishPasskeyRegistrationReq struct {
+	AccessToken string `json:"access_token"`
+	CeremonyId  string `json:"ceremony_id"`
+	// Name to tell passkeys of the account apart.
+	Name string `json:"name"`
+	// Response of navigator.credentials.create().
+	Credential json.RawMessage `json:"credential"`
+}
+type FinishPasskeyRegistrationRes struct {
+	Passkey Passkey `json:"passkey"`
+}
+
+type BeginPasskeyLoginReq struct {
+}
+type BeginPasskeyLoginRes struct {
+	CeremonyId string `json:"ceremony_id"`
+	// PublicKeyCredentialRequestOptions for navigator.credentials.get().
+	Options   json.RawMessage `json:"options"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+type Passkey struct {
+	Id         string    `json:"id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+type ListPasskeysReq struct {
+	AccessToken string `json:"access_token"`
+}
+type ListPasskeysRes struct {
+	Passkeys []Passkey `json:"passkeys"`
+}
+
+type DeletePasskeyReq struct {
+	AccessToken string `json:"access_token"`
+	PasskeyId   string `json:"passkey_id"`
+}
+type DeletePasskeyRes struct {
+}
+
+type BeginIdentityLoginReq struct {
+	// Name of the identity provider, e.g. "google".
+	Provider string `json:"provider"`
+}
+type BeginIdentityLoginRes struct {
+	// Url of the identity provider the user is redirected to.
+	AuthorizationUrl string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type LinkedIdentity struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListIdentitiesReq struct {
+	AccessToken string `json:"access_token"`
+}
+type ListIdentitiesRes struct {
+	Identities []LinkedIdentity `json:"identities"`
+}
+
+type GetOpenIdConfigurationReq struct {
+}
+type GetOpenIdConfigurationRes struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	ScopesSupported       []string `json:"scopes_supported"`
+}
+
+type GetJwksReq struct {
+}
+type GetJwksRes struct {
+	Jwks json.RawMessage `json:"jwks"`
+}
+
+type AuthorizeReq struct {
+	AccessToken  string `json:"access_token"`
+	ClientId     string `json:"client_id"`
+	RedirectUri  string `json:"redirect_uri"`
+	ResponseType string `json:"response_type"`
+	// Space separated requested scopes, must include openid.
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	Nonce               string `json:"nonce"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+type AuthorizeRes struct {
+	// Redirect uri with the authorization code and state appended.
+	RedirectUri string `json:"redirect_uri"`
+}
+
+type ExchangeAuthorizationCodeReq struct {
+	GrantType   string `json:"grant_type"`
+	Code        string `json:"code"`
+	RedirectUri string `json:"redirect_uri"`
+	ClientId    string `json:"client_id"`
+	// Empty for public clients.
+	ClientSecret string `json:"client_secret"`
+	CodeVerifier string `json:"code_verifier"`
+}
+type ExchangeAuthorizationCodeRes struct {
+	AccessToken string    `json:"access_token"`
+	IdToken     string    `json:"id_token"`
+	Scope       string    `json:"scope"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type GetUserInfoReq struct {
+	AccessToken string `json:"access_token"`
+}
+type GetUserInfoRes struct {
+	SubjectId string `json:"sub"`
+	// Set if "profile" scope is granted.
+	Name string `json:"name"`
+	// Email claims are set if "email" scope is granted.
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+type CreateOAuthClientReq struct {
+	AccessToken  string   `json:"access_token"`
+	Name         string   `json:"name"`
+	RedirectUris []string `json:"redirect_uris"`
+	// Confidential clients are issued a client secret.
+	Confidential bool `json:"confidential"`
+}
+type CreateOAuthClientRes struct {
+	ClientId string `json:"client_id"`
+	// Shown once, only the hash is stored.
+	ClientSecret string `json:"client_secret"`
+}
+
+type DeleteOAuthClientReq struct {
+	AccessToken string `json:"access_token"`
+	ClientId    string `json:"client_id"`
+}
+type DeleteOAuthClientRes struct {
+}
+
+type CreateServiceAccountReq struct {
+	AccessToken string `json:"access_token"`
+	// Lowercase letters, digits and hyphens, i.e. "products-service".
+	Name string `json:"name"`
+}
+type CreateServiceAccountRes struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type CreateApiKeyReq struct {
+	AccessToken string `json:"access_token"`
+	// Id of the service account.
+	AccountId string   `json:"account_id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+}
+type CreateApiKeyRes struct {
+	Id string `json:"id"`
+	// Shown once, only the hash of the secret is stored.
+	ApiKey string   `json:"api_key"`
+	Scopes []string `json:"scopes"`
+}
+
+type ApiKeyInfo struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	// Nil if the key never expires.
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+}
+
+type ListApiKeysReq struct {
+	AccessToken string `json:"access_token"`
+	AccountId   string `json:"account_id"`
+}
+type ListApiKeysRes struct {
+	ApiKeys []ApiKeyInfo `json:"api_keys"`
+}
+
+type RotateApiKeyReq struct {
+	AccessToken string `json:"access_token"`
+	ApiKeyId    string `json:"api_key_id"`
+}
+type RotateApiKeyRes struct {
+	Id string `json:"id"`
+	// Shown once, only the hash of the secret is stored.
+	ApiKey string `json:"api_key"`
+	// The rotated key keeps working until then.
+	PreviousExpiresAt time.Time `json:"previous_expires_at"`
+}
+
+type RevokeApiKeyReq struct {
+	AccessToken string `json:"access_token"`
+	ApiKeyId    string `json:"api_key_id"`
+}
+type RevokeApiKeyRes struct {
+}
+
+type ExchangeApiKeyReq struct {
+	ApiKey string `json:"api_key"`
+	// Space separated subset of the key scopes. All key scopes are granted if empty.
+	Scope string `json:"scope"`
+	// Client ip failed attempts are throttled by.
+	Ip string `json:"ip"`
+}
+type ExchangeApiKeyRes struct {
+	AccessToken string `json:"access_token"`
+	// Space separated granted scopes.
+	Scope     string    `json:"scope"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ImpersonateReq struct {
+	AccessToken string `json:"access_token"`
+	AccountId   string `json:"account_id"`
+	// Why the support session is needed, i.e. a support ticket reference.
+	Reason string `json:"reason"`
+}
+type ImpersonateRes struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type ListAuditEventsReq struct {
+	AccessToken string `json:"access_token"`
+
+	// Optional filters.
+	AccountId string         `json:"account_id"`
+	Type      AuditEventType `json:"type"`
+	Since     time.Time      `json:"since"`
+	Until     time.Time      `json:"until"`
+
+	PageSize  int    `json:"page_size"`
+	PageToken string `json:"page_token"`
+}
+type ListAuditEventsRes struct {
+	Events []AuditEventInfo `json:"events"`
+	// Empty if there are no more events to list.
+	NextPageToken string `json:"next_page_token"`
+}
+type AuditEventInfo struct {
+	Id        string            `json:"id"`
+	Type      AuditEventType    `json:"type"`
+	AccountId string            `json:"account_id"`
+	ActorId   string            `json:"actor_id,omitempty"`
+	Details   map[string]string `json:"details"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type RevokeAccessTokensReq struct {
+	AccessToken string `json:"access_token"`
+	AccountId   string `json:"account_id"`
+}
+type RevokeAccessTokensRes struct {
+}
+
+type IntrospectTokenReq struct {
+	// Access token of the service account, it must be granted PermissionTokensIntrospect.
+	AccessToken string `json:"access_token"`
+	// Refresh or access token to introspect.
+	Token string `json:"token"`
+	// TokenTypeHintAccessToken or TokenTypeHintRefreshToken. The hinted token type is tried first.
+	TokenTypeHint string `json:"token_type_hint"`
+}
+type IntrospectTokenRes struct {
+	// The rest of the fields are set for active tokens only.
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type"`
+	Id        string `json:"id"`
+	SubjectId string `json:"subject_id"`
+	// Set for access tokens only.
+	SubjectType string       `json:"subject_type"`
+	Permissions []Permission `json:"permissions"`
+	ActorId     string       `json:"actor_id"`
+	IssuedAt    time.Time    `json:"issued_at"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+}
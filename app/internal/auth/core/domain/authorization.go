@@ -0,0 +1,33 @@
+package domain
+
+import "context"
+
+// Privileged operation an account may be authorized to perform.
+type Action string
+
+const (
+	ActionCreateSeller      Action = "accounts.create_seller"
+	ActionListAccounts      Action = "accounts.list"
+	ActionGetAccount        Action = "accounts.get"
+	ActionSuspendAccount    Action = "accounts.suspend"
+	ActionUnlockAccount     Action = "accounts.unlock"
+	ActionUpdateAccountType Action = "accounts.update_type"
+	// Delete accounts other than the access token owner's one.
+	ActionDeleteAnyAccount Action = "accounts.delete_any"
+	// Export data of accounts other than the access token owner's one.
+	ActionExportAnyAccountData Action = "accounts.export_any"
+	// Register and delete OpenID Connect clients.
+	ActionManageOAuthClients Action = "oauth_clients.manage"
+	// Create service accounts and issue, rotate and revoke their api keys.
+	ActionManageServiceAccounts Action = "service_accounts.manage"
+	// Mint access tokens acting on behalf of other accounts for support sessions.
+	ActionImpersonate  Action = "accounts.impersonate"
+	ActionReadAuditLog Action = "audit_log.read"
+	// Revoke access tokens of other accounts before they expire.
+	ActionRevokeAccessTokens Action = "accounts.revoke_access_tokens"
+)
+
+type Authorizer interface {
+	// Returns ErrPermissionDenied if the access token owner is not allowed to perform the action.
+	Authorize(context.Context, AccessToken, Action) error
+}
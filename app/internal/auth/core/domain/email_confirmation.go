@@ -21,6 +21,7 @@ type EmailConfirmationTokens interface {
 	InsertToken(ctx context.Context, email, token string) error
 	ListTokensEmail(context context.Context, email string) ([]EmailConfirmationRecord, error)
 	FindTokenRecord(context context.Context, token string) (*EmailConfirmationRecord, error)
+	DeleteTokensEmail(context context.Context, email string) error
 }
 
 type EmailConfirmationSender interface {
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdentityProviderNotFound   = errors.New("identity provider not found")
+	ErrInvalidIdentityLogin       = errors.New("invalid or expired identity provider login")
+	ErrIdentityVerificationFailed = errors.New("identity provider verification failed")
+	ErrIdentityEmailNotVerified   = errors.New("identity provider email not verified")
+)
+
+// Identity of the user asserted by an external identity provider.
+type ExternalIdentity struct {
+	// Subject identifier, unique within the provider.
+	Subject string
+	Email   string
+	// Whether the provider has verified the user owns the email.
+	EmailVerified bool
+	Name          string
+}
+
+// External OpenID Connect provider ("Sign in with Google/Yandex") users may log in with.
+type IdentityProvider interface {
+	// Url of the provider's authorization endpoint to redirect the user to.
+	// The provider redirects back with the state and the authorization code.
+	AuthCodeUrl(state, nonce, codeVerifier string) string
+	// Exchange the authorization code for the ID token and verify it.
+	// Returns ErrIdentityVerificationFailed if the code or the ID token is not valid.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (ExternalIdentity, error)
+}
+
+// Link of an external identity to an account.
+type Identity struct {
+	// Name of the identity provider the identity was asserted by.
+	Provider  string
+	Subject   string
+	AccountId string
+	// Email reported by the provider at the time of linking.
+	Email     string
+	CreatedAt time.Time
+}
+
+type Identities interface {
+	// Returns nil identity if the external identity is not linked to any account.
+	Find(ctx context.Context, provider, subject string) (*Identity, error)
+	Add(context.Context, Identity) error
+	List(ctx context.Context, accountId string) ([]Identity, error)
+}
+
+// Server side state of a login with an identity provider started and not yet finished.
+type IdentityLogin struct {
+	// Passed to the provider as the OAuth state.
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+type IdentityLogins interface {
+	Insert(context.Context, IdentityLogin) error
+	// Delete the login so that it can't be finished twice and return it.
+	// Returns nil login if there's no login with the state.
+	Consume(ctx context.Context, state string) (*IdentityLogin, error)
+}
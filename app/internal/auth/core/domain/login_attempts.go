@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+)
+
+// Failed login attempts are tracked separately per email and per client ip.
+type LoginAttemptsKeyKind = string
+
+const (
+	LoginAttemptsKeyKindEmail LoginAttemptsKeyKind = "email"
+	LoginAttemptsKeyKindIp    LoginAttemptsKeyKind = "ip"
+)
+
+func LoginAttemptsKey(kind LoginAttemptsKeyKind, value string) string {
+	return kind + ":" + value
+}
+
+type LoginAttemptsRecord struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+}
+
+type LoginAttempts interface {
+	// Returns nil record if no failed attempts were registered for the key.
+	Get(ctx context.Context, key string) (*LoginAttemptsRecord, error)
+	// Increment failed attempts count of the key and return the updated record.
+	// The count starts over if the last failure happened longer than ResetAfter ago.
+	RegisterFailure(context.Context, LoginAttemptsRegisterFailureDTOInput) (LoginAttemptsRecord, error)
+	Reset(ctx context.Context, key string) error
+}
+
+type LoginAttemptsRegisterFailureDTOInput struct {
+	Key        string
+	At         time.Time
+	ResetAfter time.Duration
+}
+
+// Throttling of login attempts for a single key.
+//
+// The first FreeAttempts failures are not throttled. Each failure after that delays
+// the next attempt by BaseDelay doubled for every extra failure, up to MaxDelay.
+// Once LockoutThreshold failures are reached the key is locked out for LockoutDuration.
+type LoginThrottlingPolicy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// Failures are forgotten once no failed attempts happen for this long.
+	ResetAfter time.Duration
+}
+
+func (p LoginThrottlingPolicy) Validate() error {
+	if p.FreeAttempts < 0 || p.LockoutThreshold <= p.FreeAttempts {
+		return errors.New("login throttling lockout threshold must be greater than free attempts")
+	}
+	if p.BaseDelay <= 0 || p.MaxDelay < p.BaseDelay {
+		return errors.New("login throttling max delay must not be less than positive base delay")
+	}
+	if p.LockoutDuration <= 0 || p.ResetAfter < p.LockoutDuration {
+		return errors.New("login throttling reset period must not be less than positive lockout duration")
+	}
+	return nil
+}
+
+// Time before which login attempts for the key of the record are rejected.
+func (p LoginThrottlingPolicy) BlockedUntil(record LoginAttemptsRecord) time.Time {
+	if record.Failures >= p.LockoutThreshold {
+		return record.LastFailureAt.Add(p.LockoutDuration)
+	}
+	if record.Failures <= p.FreeAttempts {
+		return time.Time{}
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < record.Failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return record.LastFailureAt.Add(min(delay, p.MaxDelay))
+}
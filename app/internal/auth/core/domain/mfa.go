@@ -0,0 +1,14 @@
+package domain
+
+import "errors"
+
+var (
+	ErrMfaNotEnrolled           = errors.New("mfa enrollment has not been started")
+	ErrMfaAlreadyEnabled        = errors.New("mfa is already enabled")
+	ErrMfaNotEnabled            = errors.New("mfa is not enabled")
+	ErrInvalidMfaCode           = errors.New("invalid mfa code")
+	ErrInvalidMfaChallengeToken = errors.New("invalid mfa challenge token")
+)
+
+// Amount of single-use recovery codes issued on MFA enrollment.
+const MfaRecoveryCodesCount = 10
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidOAuthClient          = errors.New("invalid oauth client")
+	ErrInvalidRedirectUri          = errors.New("invalid redirect uri")
+	ErrInvalidAuthorizationRequest = errors.New("invalid authorization request")
+	ErrInvalidGrant                = errors.New("invalid or expired authorization grant")
+	ErrUnsupportedGrantType        = errors.New("unsupported grant type")
+	ErrOAuthClientNotFound         = errors.New("oauth client not found")
+)
+
+const (
+	OidcScopeOpenId  = "openid"
+	OidcScopeProfile = "profile"
+	OidcScopeEmail   = "email"
+
+	OidcResponseTypeCode             = "code"
+	OidcGrantTypeAuthorizationCode   = "authorization_code"
+	OidcCodeChallengeMethodS256      = "S256"
+	OidcTokenTypeBearer              = "Bearer"
+	OidcSubjectTypePublic            = "public"
+	OidcClientAuthMethodNone         = "none"
+	OidcClientAuthMethodSecretBasic  = "client_secret_basic"
+	OidcClientAuthMethodSecretPost   = "client_secret_post"
+	OidcIdTokenSigningAlgorithmEs256 = "ES256"
+)
+
+// Relying party registered to delegate login to the auth service.
+type OAuthClient struct {
+	Id   string
+	Name string
+	// Confidential clients authenticate at the token endpoint with the client secret,
+	// public ones (SPAs, mobile apps) rely on PKCE only.
+	Confidential bool
+	// Exact redirect uris authorization codes may be sent to.
+	RedirectUris []string
+	CreatedAt    time.Time
+}
+
+type OAuthClients interface {
+	Add(context.Context, OAuthClientsAddDTOInput) error
+	// Returns nil client if there's no client with the id.
+	Get(ctx context.Context, id string) (*OAuthClient, error)
+	// Reports whether the secret is the one of the confidential client.
+	CheckSecret(context.Context, OAuthClientsCheckSecretDTOInput) (bool, error)
+	// Reports whether the client has been deleted.
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+type OAuthClientsAddDTOInput struct {
+	Id   string
+	Name string
+	// Empty for public clients. Stored hashed.
+	Secret       string
+	RedirectUris []string
+	CreatedAt    time.Time
+}
+
+type OAuthClientsCheckSecretDTOInput struct {
+	Id     string
+	Secret string
+}
+
+// Authorization granted by the account to the client, exchanged for tokens at the token endpoint.
+type AuthorizationCode struct {
+	Code        string
+	ClientId    string
+	AccountId   string
+	RedirectUri string
+	// Space separated granted scopes.
+	Scope string
+	Nonce string
+	// Base64url encoded SHA-256 of the PKCE code verifier.
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
+
+type AuthorizationCodes interface {
+	Insert(context.Context, AuthorizationCode) error
+	// Delete the code so that it can't be exchanged twice and return it.
+	// Returns nil code if there's no such code.
+	Consume(ctx context.Context, code string) (*AuthorizationCode, error)
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidPasskeyCeremony    = errors.New("invalid or expired passkey ceremony")
+	ErrPasskeyVerificationFailed = errors.New("passkey verification failed")
+	ErrPasskeyNotFound           = errors.New("passkey not found")
+)
+
+// WebAuthn credential registered by an account.
+type PasskeyCredential struct {
+	// Base64url encoded WebAuthn credential id.
+	Id        string
+	AccountId string
+	Name      string
+	// Public key, signature counter and flags of the credential.
+	// Opaque to everything but the PasskeyRelyingParty that created it.
+	Data       []byte
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+type PasskeyCredentials interface {
+	Add(context.Context, PasskeyCredential) error
+	List(context.Context, PasskeyCredentialsListDTOInput) ([]PasskeyCredential, error)
+	// Store credential data updated by a successful assertion.
+	Touch(context.Context, PasskeyCredentialsTouchDTOInput) error
+	// Reports whether the credential has been deleted.
+	Delete(context.Context, PasskeyCredentialsDeleteDTOInput) (bool, error)
+}
+
+type PasskeyCredentialsListDTOInput struct {
+	AccountId string
+}
+
+type PasskeyCredentialsTouchDTOInput struct {
+	AccountId  string
+	Id         string
+	Data       []byte
+	LastUsedAt time.Time
+}
+
+type PasskeyCredentialsDeleteDTOInput struct {
+	AccountId string
+	Id        string
+}
+
+type PasskeyCeremonyKind = string
+
+const (
+	PasskeyCeremonyKindRegistration PasskeyCeremonyKind = "registration"
+	PasskeyCeremonyKindLogin        PasskeyCeremonyKind = "login"
+)
+
+// Server side state of a registration or login started and not yet finished.
+type PasskeyCeremony struct {
+	Id   string
+	Kind PasskeyCeremonyKind
+	// Empty for login ceremonies, the account is resolved from the assertion.
+	AccountId string
+	// Challenge and options the response is verified against.
+	// Opaque to everything but the PasskeyRelyingParty that created it.
+	Session   []byte
+	ExpiresAt time.Time
+}
+
+type PasskeyCeremonies interface {
+	Insert(context.Context, PasskeyCeremony) error
+	// Delete the ceremony so that it can't be finished twice and return it.
+	// Returns nil ceremony if there's no ceremony with the id.
+	Consume(ctx context.Context, id string) (*PasskeyCeremony, error)
+}
+
+type PasskeyUser struct {
+	AccountId   string
+	Name        string
+	Email       string
+	Credentials []PasskeyCredential
+}
+
+// WebAuthn relying party verifying the ceremonies.
+type PasskeyRelyingParty interface {
+	// Returns PublicKeyCredentialCreationOptions to be passed to navigator.credentials.create().
+	BeginRegistration(PasskeyUser) (options json.RawMessage, session []byte, err error)
+	// Verify the attestation response of navigator.credentials.create().
+	// Returns ErrPasskeyVerificationFailed if the response is not valid.
+	FinishRegistration(user PasskeyUser, session []byte, response json.RawMessage) (PasskeyCredential, error)
+	// Returns PublicKeyCredentialRequestOptions to be passed to navigator.credentials.get().
+	// Only discoverable credentials may be used, the account is resolved from the user handle.
+	BeginLogin() (options json.RawMessage, session []byte, err error)
+	// Verify the assertion response of navigator.credentials.get() and return the used credential
+	// with its data updated. findUser is called with the account id the credential belongs to.
+	// Returns ErrPasskeyVerificationFailed if the response is not valid.
+	FinishLogin(session []byte, response json.RawMessage, findUser func(accountId string) (PasskeyUser, error)) (PasskeyCredential, error)
+}
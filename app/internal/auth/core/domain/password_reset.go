@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidPasswordResetToken = errors.New("invalid password reset token")
+	ErrPasswordResetTokenExpired = errors.New("password reset token expired")
+)
+
+// Purpose of a one-time token sent to the account email.
+// Tokens issued for one purpose must not be accepted for another.
+type OneTimeTokenPurpose = string
+
+const (
+	OneTimeTokenPurposePasswordReset OneTimeTokenPurpose = "password_reset"
+)
+
+type PasswordResetRecord struct {
+	Email     string              `dynamodbav:"email" json:"email"`
+	Token     string              `dynamodbav:"token" json:"token"`
+	Purpose   OneTimeTokenPurpose `dynamodbav:"purpose" json:"purpose"`
+	ExpiresAt time.Time           `dynamodbav:"expires_at,unixtime" json:"expires_at"`
+}
+
+type PasswordResetTokens interface {
+	InsertToken(context.Context, PasswordResetRecord) error
+	// Find the token record and delete it so that the token can't be used twice.
+	// Returns nil record if the token does not exist.
+	ConsumeToken(ctx context.Context, token string) (*PasswordResetRecord, error)
+}
+
+type PasswordResetSender interface {
+	Send(context.Context, PasswordResetSenderSendDTOInput) error
+}
+
+type PasswordResetSenderSendDTOInput struct {
+	RecipientEmail string
+	ResetToken     string
+}
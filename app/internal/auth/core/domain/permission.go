@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrUnknownRolePermissionsSource = errors.New("unknown role permissions source")
+
+// Operation on resources of downstream services access tokens may grant, i.e. "products:write".
+// Api key scopes are permissions as well.
+type Permission = string
+
+const (
+	PermissionProductsRead  Permission = "products:read"
+	PermissionProductsWrite Permission = "products:write"
+	PermissionOrdersRead    Permission = "orders:read"
+	PermissionOrdersWrite   Permission = "orders:write"
+	// Read and write resources of any account, not just the access token owner's ones.
+	PermissionProductsManage Permission = "products:manage"
+	PermissionOrdersManage   Permission = "orders:manage"
+	// Introspect tokens issued by the auth service, granted to service accounts by api key scopes.
+	PermissionTokensIntrospect Permission = "tokens:introspect"
+)
+
+// Permission sets granted to accounts (roles) by the account type.
+type RolePermissions interface {
+	// Returns no permissions for account types without a permission set.
+	Permissions(context.Context, AccountType) ([]Permission, error)
+}
+
+type RolePermissionsSource string
+
+const (
+	// Permission sets defined in code, see service.DefaultRolePermissions.
+	RolePermissionsSourceCode RolePermissionsSource = "code"
+	// Permission sets stored in the role_permissions YDB table.
+	RolePermissionsSourceYdb RolePermissionsSource = "ydb"
+)
@@ -11,24 +11,49 @@ type RefreshTokenProvider interface {
 	Replace(context.Context, RefreshTokenReplaceDTOInput) (RefreshTokenReplaceDTOOutput, error)
 	Delete(context.Context, RefreshTokenDeleteDTOInput) (RefreshTokenDeleteDTOOutput, error)
 	DeleteByAccountId(context.Context, RefreshTokenDeleteByAccountIdDTOInput) (RefreshTokenDeleteByAccountIdDTOOutput, error)
+	DeleteByFamilyId(context.Context, RefreshTokenDeleteByFamilyIdDTOInput) (RefreshTokenDeleteByFamilyIdDTOOutput, error)
+	Touch(context.Context, RefreshTokenTouchDTOInput) error
 }
 
+type RefreshTokenEvictionPolicy string
+
+const (
+	// Delete the oldest account refresh tokens to fit the newly added one.
+	RefreshTokenEvictionPolicyEvictOldest RefreshTokenEvictionPolicy = "evict_oldest"
+	// Refuse adding refresh tokens until the account revokes some of its tokens.
+	RefreshTokenEvictionPolicyRejectNew RefreshTokenEvictionPolicy = "reject_new"
+)
+
 type RefreshTokenListDTOInput struct {
 	AccountId string
+	// Maximum amount of tokens to list, provider's default limit is used if not set.
+	Limit int
 }
 type RefreshTokenListDTOOutput struct {
 	Tokens []RefreshTokenListDTOOutputToken `json:"tokens"`
 }
 type RefreshTokenListDTOOutputToken struct {
-	Id        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	ExpiresAt time.Time `json:"expires_at"`
+	Id         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip"`
+	LastUsedAt time.Time `json:"last_used_at"`
 }
 
 type RefreshTokenAddDTOInput struct {
 	AccountId string
+	// Rotation family shared by all tokens replaced starting from this one.
+	FamilyId  string
 	CreatedAt time.Time
 	ExpiresAt time.Time
+	UserAgent string
+	Ip        string
+
+	// Maximum amount of tokens stored for the account, provider's default limit is used if not set.
+	Limit int
+	// Provider's default policy is used if not set.
+	EvictionPolicy RefreshTokenEvictionPolicy
 }
 type RefreshTokenAddDTOOutput struct {
 	Id        string    `json:"id"`
@@ -40,11 +65,17 @@ type RefreshTokenReplaceDTOInput struct {
 	Id        string
 	CreatedAt time.Time
 	ExpiresAt time.Time
+	UserAgent string
+	Ip        string
 }
 type RefreshTokenReplaceDTOOutput struct {
+	// Empty if the token to replace is not stored or has already been replaced.
 	Id        string    `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
+	// Set instead of Id if the token to replace has already been replaced.
+	// Replaced tokens are kept until they expire to tell reuse apart from revocation.
+	ReplacedAt time.Time `json:"replaced_at"`
 }
 
 type RefreshTokenDeleteDTOInput struct {
@@ -56,7 +87,21 @@ type RefreshTokenDeleteDTOOutput struct {
 
 type RefreshTokenDeleteByAccountIdDTOInput struct {
 	Id string
+	// Optional. Id of the refresh token to keep.
+	ExceptId string
 }
 type RefreshTokenDeleteByAccountIdDTOOutput struct {
 	Ids []string
 }
+
+type RefreshTokenDeleteByFamilyIdDTOInput struct {
+	FamilyId string
+}
+type RefreshTokenDeleteByFamilyIdDTOOutput struct {
+	Ids []string
+}
+
+type RefreshTokenTouchDTOInput struct {
+	Id         string
+	LastUsedAt time.Time
+}
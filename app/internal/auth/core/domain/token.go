@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"slices"
 	"time"
 )
 
@@ -9,16 +10,68 @@ type TokenType string
 var (
 	TokenTypeRefresh TokenType = "refresh"
 	TokenTypeAccess  TokenType = "access"
+	// Issued on authentication of accounts with MFA enabled instead of a refresh token.
+	TokenTypeMfaChallenge TokenType = "mfa_challenge"
+)
+
+// Token type hints of token introspection (RFC 7662 section 2.1).
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
 )
 
 type RefreshToken struct {
 	Id        string    `json:"id"`
+	FamilyId  string    `json:"family_id"`
 	SubjectId string    `json:"subject_id"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type AccessToken struct {
-	SubjectId   string    `json:"subject_id"`
-	SubjectType string    `json:"subject_type"`
-	ExpiresAt   time.Time `json:"expires_at"`
+	// Token id (jti claim), generated on encoding if not set.
+	Id          string `json:"id,omitempty"`
+	SubjectId   string `json:"subject_id"`
+	SubjectType string `json:"subject_type"`
+	// Api key scopes granted to service account tokens,
+	// or OpenID Connect scopes granted to the client for tokens issued to OAuth clients.
+	Scopes []string `json:"scopes,omitempty"`
+	// Set for tokens issued to OAuth clients through the authorization code flow only.
+	ClientId string `json:"client_id,omitempty"`
+	// Granted by the account type, or equal to the scopes for service accounts.
+	Permissions []Permission `json:"permissions,omitempty"`
+	// Set for impersonation tokens only, the id of the admin acting on behalf of the subject.
+	ActorId   string    `json:"actor_id,omitempty"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (t AccessToken) Impersonated() bool {
+	return t.ActorId != ""
+}
+
+// Reports whether the token grants the permission. Resource servers check
+// permissions instead of the account type.
+func (t AccessToken) Has(permission Permission) bool {
+	return slices.Contains(t.Permissions, permission)
+}
+
+// Proof that the first authentication factor (password) has been verified.
+type MfaChallengeToken struct {
+	SubjectId string    `json:"subject_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OpenID Connect ID token asserting the account authentication to the client.
+type IdToken struct {
+	Issuer    string    `json:"issuer"`
+	SubjectId string    `json:"subject_id"`
+	Audience  string    `json:"audience"`
+	Nonce     string    `json:"nonce"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Set only if the profile scope is granted.
+	Name string `json:"name"`
+	// Set only if the email scope is granted.
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
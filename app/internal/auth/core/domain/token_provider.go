@@ -1,8 +1,15 @@
 package domain
 
+import "encoding/json"
+
 type TokenProvider interface {
 	EncodeRefresh(token RefreshToken) (tokenString string, err error)
 	DecodeRefresh(token string) (RefreshToken, error)
 	EncodeAccess(token AccessToken) (tokenString string, err error)
 	DecodeAccess(token string) (AccessToken, error)
+	EncodeMfaChallenge(token MfaChallengeToken) (tokenString string, err error)
+	DecodeMfaChallenge(token string) (MfaChallengeToken, error)
+	EncodeId(token IdToken) (tokenString string, err error)
+	// JSON Web Key Set (RFC 7517) of the keys the tokens signatures are verified with.
+	Jwks() (json.RawMessage, error)
 }
@@ -1,36 +1,83 @@
 package authn
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bratushkadan/floral/internal/auth/core/domain"
 	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/bratushkadan/floral/pkg/entity"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 const (
 	RefreshTokenIdPrefix = "ry"
+
+	// Clock skew tolerated between the issuing and the verifying hosts.
+	DefaultLeeway = 5 * time.Second
 )
 
+// End of the default migration window for tokens without registered claims: the refresh token lifetime
+// after the registered claims were released, by then every refresh token issued before has expired.
+// It's a fixed date so that restarting the service doesn't extend the window.
+var DefaultLegacyTokensAcceptedUntil = time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+
 type RefreshTokenJwtClaims struct {
-	TokenId   string           `json:"token_id"`
+	TokenId  string `json:"token_id"`
+	FamilyId string `json:"family_id,omitempty"`
+	// Duplicates "sub" for the verifiers not reading the registered claims yet.
 	SubjectId string           `json:"subject_id"`
 	TokenType domain.TokenType `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
 type AccessTokenJwtClaims struct {
-	TokenType   domain.TokenType `json:"token_type"`
-	SubjectId   string           `json:"subject_id"`
-	SubjectType string           `json:"subject_type"`
+	TokenType domain.TokenType `json:"token_type"`
+	// Duplicates "sub" for the verifiers not reading the registered claims yet.
+	SubjectId   string `json:"subject_id"`
+	SubjectType string `json:"subject_type"`
+	// Space separated scopes as in RFC 9068, set for service accounts and tokens issued to OAuth clients.
+	Scope string `json:"scope,omitempty"`
+	// OAuth client the token has been issued to as in RFC 9068.
+	ClientId    string   `json:"client_id,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Actor of impersonation tokens as in RFC 8693.
+	Act *ActorJwtClaims `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type ActorJwtClaims struct {
+	SubjectId string `json:"sub"`
+}
+
+type MfaChallengeTokenJwtClaims struct {
+	TokenType domain.TokenType `json:"token_type"`
+	SubjectId string           `json:"subject_id"`
 	jwt.RegisteredClaims
 }
 
+type IdTokenJwtClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+var ErrLegacyTokenRejected = errors.New("tokens without registered claims are no longer accepted")
+
 type TokenProvider struct {
 	jwt *auth.JwtProvider
+
+	issuer   string
+	audience []string
+	// Tokens issued before the registered claims were introduced are accepted until then.
+	legacyTokensAcceptedUntil time.Time
 }
 
 var _ domain.TokenProvider = (*TokenProvider)(nil)
@@ -41,12 +88,25 @@ type TokenProviderBuilder struct {
 
 	privateKey []byte
 	publicKey  []byte
+	keyId      string
+
+	retiredKeys []retiredKey
+
+	jwksUrl string
+
+	leeway time.Duration
 
 	p *TokenProvider
 }
 
+type retiredKey struct {
+	keyId     string
+	publicKey []byte
+	notAfter  time.Time
+}
+
 func NewTokenProviderBuilder() *TokenProviderBuilder {
-	return &TokenProviderBuilder{p: &TokenProvider{}}
+	return &TokenProviderBuilder{leeway: DefaultLeeway, p: &TokenProvider{}}
 }
 
 func (b *TokenProviderBuilder) PrivateKey(key []byte) *TokenProviderBuilder {
@@ -74,59 +134,160 @@ func (b *TokenProviderBuilder) PublicKeyPath(path string) *TokenProviderBuilder
 	return b
 }
 
+// Id of the signing key set in the "kid" header of issued tokens.
+// Defaults to the JWK thumbprint of the public key.
+func (b *TokenProviderBuilder) KeyId(keyId string) *TokenProviderBuilder {
+	b.keyId = keyId
+	return b
+}
+
+// Previous signing key that still verifies the tokens issued with it until notAfter
+// and is published in the JWKS. Zero notAfter keeps it until it's removed from the builder.
+func (b *TokenProviderBuilder) RetiredKey(keyId string, publicKey []byte, notAfter time.Time) *TokenProviderBuilder {
+	b.retiredKeys = append(b.retiredKeys, retiredKey{keyId: keyId, publicKey: publicKey, notAfter: notAfter})
+	return b
+}
+
+// "iss" claim of issued tokens, decoded tokens of other issuers are rejected.
+func (b *TokenProviderBuilder) Issuer(issuer string) *TokenProviderBuilder {
+	b.p.issuer = issuer
+	return b
+}
+
+// "aud" claim of issued tokens, decoded tokens must be intended for any of the audiences.
+func (b *TokenProviderBuilder) Audience(audience ...string) *TokenProviderBuilder {
+	b.p.audience = audience
+	return b
+}
+
+// End of the migration window tokens without registered claims issued
+// by previous versions are accepted in. DefaultLegacyTokensAcceptedUntil by default.
+func (b *TokenProviderBuilder) LegacyTokensAcceptedUntil(until time.Time) *TokenProviderBuilder {
+	b.p.legacyTokensAcceptedUntil = until
+	return b
+}
+
+// Clock skew tolerated validating "exp", "nbf" and "iat" claims, DefaultLeeway by default.
+func (b *TokenProviderBuilder) Leeway(leeway time.Duration) *TokenProviderBuilder {
+	b.leeway = leeway
+	return b
+}
+
+// Verify tokens with the JSON Web Key Set of the account service instead of a public key.
+// Meant for services only verifying tokens.
+func (b *TokenProviderBuilder) JwksUrl(url string) *TokenProviderBuilder {
+	b.jwksUrl = url
+	return b
+}
+
 func (b *TokenProviderBuilder) Build() (*TokenProvider, error) {
 	var (
 		privateKey, publicKey []byte
 		err                   error
 	)
 
+	// Tokens are only verified by providers built without a private key.
 	if len(b.privateKey) == 0 {
-		if b.privateKeyPath == "" {
-			return nil, errors.New("either a private key or a private key path must be provided to the token provider builder")
-		}
-		privateKey, err = os.ReadFile(b.privateKeyPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read private key from file: %w", err)
+		if b.privateKeyPath != "" {
+			privateKey, err = os.ReadFile(b.privateKeyPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read private key from file: %w", err)
+			}
 		}
 	} else {
 		privateKey = b.privateKey
 	}
 
 	if len(b.publicKey) == 0 {
-		if b.publicKeyPath == "" {
-			return nil, errors.New("either a public key or a public key path must be provided to the token provider builder")
+		if b.publicKeyPath == "" && b.jwksUrl == "" {
+			return nil, errors.New("either a public key, a public key path or a jwks url must be provided to the token provider builder")
 		}
-		publicKey, err = os.ReadFile(b.publicKeyPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read public key from file: %w", err)
+		if b.publicKeyPath != "" {
+			publicKey, err = os.ReadFile(b.publicKeyPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read public key from file: %w", err)
+			}
 		}
 	} else {
 		publicKey = b.publicKey
 	}
 
-	jwtProvider, err := auth.NewJwtProviderBuilder().
+	jwtBuilder := auth.NewJwtProviderBuilder().
 		WithPrivateKey(privateKey).
 		WithPublicKey(publicKey).
-		Build()
+		WithKeyId(b.keyId).
+		WithParserOptions(jwt.WithIssuedAt(), jwt.WithLeeway(b.leeway))
+	for _, key := range b.retiredKeys {
+		jwtBuilder = jwtBuilder.WithRetiredKey(key.keyId, key.publicKey, key.notAfter)
+	}
+	if b.jwksUrl != "" {
+		jwtBuilder = jwtBuilder.WithRemoteJwks(auth.NewRemoteJwks(auth.RemoteJwksConf{Url: b.jwksUrl}))
+	}
+
+	jwtProvider, err := jwtBuilder.Build()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build jwt provider: %w", err)
 	}
 
 	b.p.jwt = jwtProvider
+	if b.p.legacyTokensAcceptedUntil.IsZero() {
+		b.p.legacyTokensAcceptedUntil = DefaultLegacyTokensAcceptedUntil
+	}
 
 	return b.p, nil
 }
 
+func (p *TokenProvider) registeredClaims(id string, subject string, expiresAt time.Time) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		Issuer:    p.issuer,
+		Subject:   subject,
+		Audience:  p.audience,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        id,
+	}
+}
+
+// Expiration and not before claims are validated by the jwt parser.
+func (p *TokenProvider) validateRegisteredClaims(claims jwt.RegisteredClaims) error {
+	// Issued before the registered claims were introduced.
+	if claims.IssuedAt == nil {
+		if !time.Now().Before(p.legacyTokensAcceptedUntil) {
+			return ErrLegacyTokenRejected
+		}
+		return nil
+	}
+
+	if p.issuer != "" && claims.Issuer != p.issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", jwt.ErrTokenInvalidIssuer, claims.Issuer)
+	}
+	if len(p.audience) != 0 && !slices.ContainsFunc(p.audience, func(aud string) bool {
+		return slices.Contains(claims.Audience, aud)
+	}) {
+		return fmt.Errorf("%w: unexpected audience %q", jwt.ErrTokenInvalidAudience, claims.Audience)
+	}
+	return nil
+}
+
+// Subject of the token, read from the "subject_id" claim for legacy tokens.
+func subject(claims jwt.RegisteredClaims, subjectId string) string {
+	if claims.Subject != "" {
+		return claims.Subject
+	}
+	return subjectId
+}
+
 func (p *TokenProvider) EncodeRefresh(token domain.RefreshToken) (string, error) {
 	id := RefreshTokenIdPrefix + token.Id
 
 	claims := RefreshTokenJwtClaims{
-		TokenId:   id,
-		SubjectId: token.SubjectId,
-		TokenType: domain.TokenTypeRefresh,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
-		},
+		TokenId:          id,
+		FamilyId:         token.FamilyId,
+		SubjectId:        token.SubjectId,
+		TokenType:        domain.TokenTypeRefresh,
+		RegisteredClaims: p.registeredClaims(id, token.SubjectId, token.ExpiresAt),
 	}
 
 	tokenString, err := p.jwt.Create(claims)
@@ -142,12 +303,16 @@ func (p *TokenProvider) DecodeRefresh(tokenString string) (domain.RefreshToken,
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return domain.RefreshToken{
 				Id:        claims.TokenId,
-				SubjectId: claims.SubjectId,
+				FamilyId:  claims.FamilyId,
+				SubjectId: subject(claims.RegisteredClaims, claims.SubjectId),
 				ExpiresAt: claims.RegisteredClaims.ExpiresAt.Time,
 			}, domain.ErrTokenExpired
 		}
 		return domain.RefreshToken{}, fmt.Errorf("failed to parse jwt: %w: %w", err, domain.ErrTokenParseFailed)
 	}
+	if err := p.validateRegisteredClaims(claims.RegisteredClaims); err != nil {
+		return domain.RefreshToken{}, fmt.Errorf("failed to validate jwt claims: %w: %w", err, domain.ErrInvalidRefreshToken)
+	}
 
 	if claims.TokenType != domain.TokenTypeRefresh {
 		return domain.RefreshToken{}, fmt.Errorf(`expected token type to be "%s": %w`, domain.TokenTypeRefresh, domain.ErrInvalidTokenType)
@@ -160,19 +325,29 @@ func (p *TokenProvider) DecodeRefresh(tokenString string) (domain.RefreshToken,
 
 	return domain.RefreshToken{
 		Id:        id,
-		SubjectId: claims.SubjectId,
+		FamilyId:  claims.FamilyId,
+		SubjectId: subject(claims.RegisteredClaims, claims.SubjectId),
 		ExpiresAt: claims.RegisteredClaims.ExpiresAt.Time,
 	}, nil
 }
 
 func (p *TokenProvider) EncodeAccess(token domain.AccessToken) (string, error) {
+	id := token.Id
+	if id == "" {
+		id = entity.Id(20)
+	}
+
 	claims := AccessTokenJwtClaims{
-		SubjectId:   token.SubjectId,
-		SubjectType: token.SubjectType,
-		TokenType:   domain.TokenTypeAccess,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
-		},
+		SubjectId:        token.SubjectId,
+		SubjectType:      token.SubjectType,
+		Scope:            strings.Join(token.Scopes, " "),
+		ClientId:         token.ClientId,
+		Permissions:      token.Permissions,
+		TokenType:        domain.TokenTypeAccess,
+		RegisteredClaims: p.registeredClaims(id, token.SubjectId, token.ExpiresAt),
+	}
+	if token.ActorId != "" {
+		claims.Act = &ActorJwtClaims{SubjectId: token.ActorId}
 	}
 
 	tokenString, err := p.jwt.Create(claims)
@@ -191,13 +366,100 @@ func (p *TokenProvider) DecodeAccess(tokenString string) (domain.AccessToken, er
 		}
 		return domain.AccessToken{}, fmt.Errorf("failed to parse jwt: %w: %w", err, domain.ErrInvalidAccessToken)
 	}
+	if err := p.validateRegisteredClaims(claims.RegisteredClaims); err != nil {
+		return domain.AccessToken{}, fmt.Errorf("failed to validate jwt claims: %w: %w", err, domain.ErrInvalidAccessToken)
+	}
 
 	if claims.TokenType != domain.TokenTypeAccess {
 		return domain.AccessToken{}, fmt.Errorf(`expected token type to be "%s": %w`, domain.TokenTypeAccess, domain.ErrInvalidTokenType)
 	}
 
-	return domain.AccessToken{
-		SubjectId:   claims.SubjectId,
+	token := domain.AccessToken{
+		Id:          claims.ID,
+		SubjectId:   subject(claims.RegisteredClaims, claims.SubjectId),
 		SubjectType: claims.SubjectType,
+		Scopes:      strings.Fields(claims.Scope),
+		ClientId:    claims.ClientId,
+		Permissions: claims.Permissions,
+	}
+	if claims.IssuedAt != nil {
+		token.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		token.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if claims.Act != nil {
+		token.ActorId = claims.Act.SubjectId
+	}
+	return token, nil
+}
+
+func (p *TokenProvider) EncodeMfaChallenge(token domain.MfaChallengeToken) (string, error) {
+	claims := MfaChallengeTokenJwtClaims{
+		SubjectId:        token.SubjectId,
+		TokenType:        domain.TokenTypeMfaChallenge,
+		RegisteredClaims: p.registeredClaims(entity.Id(20), token.SubjectId, token.ExpiresAt),
+	}
+
+	tokenString, err := p.jwt.Create(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to create jwt mfa challenge token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+func (p *TokenProvider) DecodeMfaChallenge(tokenString string) (domain.MfaChallengeToken, error) {
+	var claims MfaChallengeTokenJwtClaims
+	if err := p.jwt.Parse(tokenString, &claims); err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return domain.MfaChallengeToken{}, domain.ErrTokenExpired
+		}
+		return domain.MfaChallengeToken{}, fmt.Errorf("failed to parse jwt: %w: %w", err, domain.ErrInvalidMfaChallengeToken)
+	}
+	if err := p.validateRegisteredClaims(claims.RegisteredClaims); err != nil {
+		return domain.MfaChallengeToken{}, fmt.Errorf("failed to validate jwt claims: %w: %w", err, domain.ErrInvalidMfaChallengeToken)
+	}
+
+	if claims.TokenType != domain.TokenTypeMfaChallenge {
+		return domain.MfaChallengeToken{}, fmt.Errorf(`expected token type to be "%s": %w`, domain.TokenTypeMfaChallenge, domain.ErrInvalidTokenType)
+	}
+
+	return domain.MfaChallengeToken{
+		SubjectId: subject(claims.RegisteredClaims, claims.SubjectId),
+		ExpiresAt: claims.RegisteredClaims.ExpiresAt.Time,
 	}, nil
 }
+
+func (p *TokenProvider) EncodeId(token domain.IdToken) (string, error) {
+	claims := IdTokenJwtClaims{
+		Nonce: token.Nonce,
+		Name:  token.Name,
+		Email: token.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    token.Issuer,
+			Subject:   token.SubjectId,
+			Audience:  jwt.ClaimStrings{token.Audience},
+			IssuedAt:  jwt.NewNumericDate(token.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
+		},
+	}
+	if token.Email != "" {
+		claims.EmailVerified = &token.EmailVerified
+	}
+
+	tokenString, err := p.jwt.Create(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to create jwt id token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+func (p *TokenProvider) Jwks() (json.RawMessage, error) {
+	jwks, err := json.Marshal(p.jwt.Jwks())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal jwks: %w", err)
+	}
+	return jwks, nil
+}
@@ -0,0 +1,205 @@
+package authn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/authn"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testKey struct {
+	key        *ecdsa.PrivateKey
+	privateKey []byte
+	publicKey  []byte
+}
+
+func newTestKey(t *testing.T) testKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privateDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	publicDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	return testKey{
+		key:        key,
+		privateKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDer}),
+		publicKey:  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer}),
+	}
+}
+
+func newBuilder(key testKey) *authn.TokenProviderBuilder {
+	return authn.NewTokenProviderBuilder().
+		PrivateKey(key.privateKey).
+		PublicKey(key.publicKey).
+		Issuer("https://auth.floral.example").
+		Audience("floral")
+}
+
+// Access token as issued before the registered claims were introduced.
+func legacyAccessToken(t *testing.T, key testKey) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"token_type":   domain.TokenTypeAccess,
+		"subject_id":   "user-id",
+		"subject_type": domain.AccountTypeUser,
+		"exp":          time.Now().Add(time.Minute).Unix(),
+	}).SignedString(key.key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAccessTokenRegisteredClaims(t *testing.T) {
+	key := newTestKey(t)
+	prov, err := newBuilder(key).Build()
+	require.NoError(t, err)
+
+	tokenString, err := prov.EncodeAccess(domain.AccessToken{
+		SubjectId:   "user-id",
+		SubjectType: domain.AccountTypeUser,
+		ExpiresAt:   time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	var claims authn.AccessTokenJwtClaims
+	_, _, err = jwt.NewParser().ParseUnverified(tokenString, &claims)
+	require.NoError(t, err)
+	assert.Equal(t, "https://auth.floral.example", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"floral"}, claims.Audience)
+	assert.Equal(t, "user-id", claims.Subject)
+	assert.Equal(t, "user-id", claims.SubjectId, "subject_id is kept for verifiers not reading sub yet")
+	assert.NotNil(t, claims.IssuedAt)
+	assert.NotNil(t, claims.NotBefore)
+	assert.NotEmpty(t, claims.ID)
+
+	token, err := prov.DecodeAccess(tokenString)
+	require.NoError(t, err)
+	assert.Equal(t, claims.ID, token.Id)
+	assert.Equal(t, "user-id", token.SubjectId)
+}
+
+// Access token issued by a host with the clock ahead of the verifier's one.
+func futureAccessToken(t *testing.T, key testKey, skew time.Duration) string {
+	t.Helper()
+
+	issuedAt := time.Now().Add(skew)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, authn.AccessTokenJwtClaims{
+		TokenType:   domain.TokenTypeAccess,
+		SubjectId:   "user-id",
+		SubjectType: domain.AccountTypeUser,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://auth.floral.example",
+			Subject:   "user-id",
+			Audience:  jwt.ClaimStrings{"floral"},
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Minute)),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+		},
+	}).SignedString(key.key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestDecodeAccessToleratesClockSkew(t *testing.T) {
+	key := newTestKey(t)
+	prov, err := newBuilder(key).Build()
+	require.NoError(t, err)
+
+	token, err := prov.DecodeAccess(futureAccessToken(t, key, 2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "user-id", token.SubjectId)
+
+	_, err = prov.DecodeAccess(futureAccessToken(t, key, time.Minute))
+	assert.ErrorIs(t, err, domain.ErrInvalidAccessToken, "tokens issued beyond the leeway are rejected")
+
+	strict, err := newBuilder(key).Leeway(0).Build()
+	require.NoError(t, err)
+	_, err = strict.DecodeAccess(futureAccessToken(t, key, 2*time.Second))
+	assert.ErrorIs(t, err, domain.ErrInvalidAccessToken)
+}
+
+func TestDecodeAccessRejectsForeignIssuerAndAudience(t *testing.T) {
+	key := newTestKey(t)
+	verifier, err := newBuilder(key).Build()
+	require.NoError(t, err)
+
+	for name, b := range map[string]*authn.TokenProviderBuilder{
+		"issuer":   newBuilder(key).Issuer("https://evil.example"),
+		"audience": newBuilder(key).Audience("other-service"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			prov, err := b.Build()
+			require.NoError(t, err)
+			tokenString, err := prov.EncodeAccess(domain.AccessToken{SubjectId: "user-id", ExpiresAt: time.Now().Add(time.Minute)})
+			require.NoError(t, err)
+
+			_, err = verifier.DecodeAccess(tokenString)
+			assert.ErrorIs(t, err, domain.ErrInvalidAccessToken)
+		})
+	}
+}
+
+func TestDecodeLegacyAccessToken(t *testing.T) {
+	key := newTestKey(t)
+
+	t.Run("within migration window", func(t *testing.T) {
+		prov, err := newBuilder(key).LegacyTokensAcceptedUntil(time.Now().Add(time.Hour)).Build()
+		require.NoError(t, err)
+
+		token, err := prov.DecodeAccess(legacyAccessToken(t, key))
+		require.NoError(t, err)
+		assert.Equal(t, "user-id", token.SubjectId)
+	})
+
+	t.Run("default migration window", func(t *testing.T) {
+		prov, err := newBuilder(key).Build()
+		require.NoError(t, err)
+
+		_, err = prov.DecodeAccess(legacyAccessToken(t, key))
+		if time.Now().Before(authn.DefaultLegacyTokensAcceptedUntil) {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, authn.ErrLegacyTokenRejected)
+		}
+	})
+
+	t.Run("after migration window", func(t *testing.T) {
+		prov, err := newBuilder(key).LegacyTokensAcceptedUntil(time.Now().Add(-time.Hour)).Build()
+		require.NoError(t, err)
+
+		_, err = prov.DecodeAccess(legacyAccessToken(t, key))
+		assert.ErrorIs(t, err, authn.ErrLegacyTokenRejected)
+		assert.ErrorIs(t, err, domain.ErrInvalidAccessToken)
+	})
+}
+
+func TestRefreshTokenRegisteredClaims(t *testing.T) {
+	prov, err := newBuilder(newTestKey(t)).Build()
+	require.NoError(t, err)
+
+	tokenString, err := prov.EncodeRefresh(domain.RefreshToken{Id: "token-id", FamilyId: "family-id", SubjectId: "user-id", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+
+	var claims authn.RefreshTokenJwtClaims
+	_, _, err = jwt.NewParser().ParseUnverified(tokenString, &claims)
+	require.NoError(t, err)
+	assert.Equal(t, authn.RefreshTokenIdPrefix+"token-id", claims.ID)
+	assert.Equal(t, "user-id", claims.Subject)
+
+	token, err := prov.DecodeRefresh(tokenString)
+	require.NoError(t, err)
+	assert.Equal(t, "token-id", token.Id)
+	assert.Equal(t, "user-id", token.SubjectId)
+}
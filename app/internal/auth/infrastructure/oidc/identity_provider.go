@@ -0,0 +1,124 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// External OpenID Connect provider users log in with using the authorization code flow with PKCE.
+// The provider metadata and signing keys are discovered from the issuer.
+type IdentityProvider struct {
+	config   oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+	client   *http.Client
+}
+
+var _ domain.IdentityProvider = (*IdentityProvider)(nil)
+
+type IdentityProviderConf struct {
+	// Issuer the provider metadata is discovered from, i.e. "https://accounts.google.com".
+	IssuerUrl    string
+	ClientId     string
+	ClientSecret string
+	// Page the provider redirects back to with the state and the authorization code.
+	RedirectUrl string
+	// Optional. http.DefaultClient is used if not set.
+	HttpClient *http.Client
+}
+
+// Discovers the provider metadata, so the provider must be reachable.
+func NewIdentityProvider(ctx context.Context, conf IdentityProviderConf) (*IdentityProvider, error) {
+	client := conf.HttpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	prov, err := gooidc.NewProvider(gooidc.ClientContext(ctx, client), conf.IssuerUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover openid connect provider %s: %w", conf.IssuerUrl, err)
+	}
+
+	return &IdentityProvider{
+		config: oauth2.Config{
+			ClientID:     conf.ClientId,
+			ClientSecret: conf.ClientSecret,
+			Endpoint:     prov.Endpoint(),
+			RedirectURL:  conf.RedirectUrl,
+			Scopes:       []string{gooidc.ScopeOpenID, "email", "profile"},
+		},
+		verifier: prov.Verifier(&gooidc.Config{ClientID: conf.ClientId}),
+		client:   client,
+	}, nil
+}
+
+func (p *IdentityProvider) AuthCodeUrl(state, nonce, codeVerifier string) string {
+	return p.config.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier))
+}
+
+func (p *IdentityProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (domain.ExternalIdentity, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.Response.StatusCode < http.StatusInternalServerError {
+			return domain.ExternalIdentity{}, fmt.Errorf("%w: %v", domain.ErrIdentityVerificationFailed, err)
+		}
+		return domain.ExternalIdentity{}, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return domain.ExternalIdentity{}, fmt.Errorf("%w: no id token in token response", domain.ErrIdentityVerificationFailed)
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIdToken)
+	if err != nil {
+		return domain.ExternalIdentity{}, fmt.Errorf("%w: %v", domain.ErrIdentityVerificationFailed, err)
+	}
+	if idToken.Nonce != nonce {
+		return domain.ExternalIdentity{}, fmt.Errorf("%w: id token nonce mismatch", domain.ErrIdentityVerificationFailed)
+	}
+
+	var claims struct {
+		Email         string       `json:"email"`
+		EmailVerified stringAsBool `json:"email_verified"`
+		Name          string       `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return domain.ExternalIdentity{}, fmt.Errorf("%w: %v", domain.ErrIdentityVerificationFailed, err)
+	}
+
+	return domain.ExternalIdentity{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// Some providers send email_verified as a string.
+type stringAsBool bool
+
+func (b *stringAsBool) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		data = []byte(s)
+	}
+	v, err := strconv.ParseBool(string(data))
+	if err != nil {
+		return err
+	}
+	*b = stringAsBool(v)
+	return nil
+}
@@ -0,0 +1,77 @@
+package oidc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/oidc"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nonce        = "nonce"
+	codeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+var identity = domain.ExternalIdentity{
+	Subject:       "10769150350006150715113082367",
+	Email:         "user@example.com",
+	EmailVerified: true,
+	Name:          "User",
+}
+
+func newIdentityProvider(t *testing.T) (*oidc.IdentityProvider, *oidctest.Provider) {
+	t.Helper()
+
+	idp, err := oidctest.NewProvider("floral", "secret")
+	require.NoError(t, err)
+	t.Cleanup(idp.Close)
+
+	prov, err := oidc.NewIdentityProvider(context.Background(), oidc.IdentityProviderConf{
+		IssuerUrl:    idp.Url(),
+		ClientId:     "floral",
+		ClientSecret: "secret",
+		RedirectUrl:  "https://floral.example.com/login/callback",
+	})
+	require.NoError(t, err)
+
+	return prov, idp
+}
+
+func TestIdentityProviderLogin(t *testing.T) {
+	ctx := context.Background()
+	prov, idp := newIdentityProvider(t)
+
+	code, err := idp.Authorize(prov.AuthCodeUrl("state", nonce, codeVerifier), identity)
+	require.NoError(t, err)
+
+	ext, err := prov.Exchange(ctx, code, codeVerifier, nonce)
+	require.NoError(t, err)
+	assert.Equal(t, identity, ext)
+
+	_, err = prov.Exchange(ctx, code, codeVerifier, nonce)
+	assert.ErrorIs(t, err, domain.ErrIdentityVerificationFailed, "authorization codes are single use")
+}
+
+func TestIdentityProviderRejectsInvalidCodeVerifier(t *testing.T) {
+	prov, idp := newIdentityProvider(t)
+
+	code, err := idp.Authorize(prov.AuthCodeUrl("state", nonce, codeVerifier), identity)
+	require.NoError(t, err)
+
+	_, err = prov.Exchange(context.Background(), code, "qBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", nonce)
+	assert.ErrorIs(t, err, domain.ErrIdentityVerificationFailed)
+}
+
+func TestIdentityProviderRejectsNonceMismatch(t *testing.T) {
+	prov, idp := newIdentityProvider(t)
+
+	code, err := idp.Authorize(prov.AuthCodeUrl("state", "another-nonce", codeVerifier), identity)
+	require.NoError(t, err)
+
+	_, err = prov.Exchange(context.Background(), code, codeVerifier, nonce)
+	assert.ErrorIs(t, err, domain.ErrIdentityVerificationFailed)
+}
@@ -0,0 +1,177 @@
+// Local OpenID Connect provider to run identity provider logins in tests without a real IdP.
+package oidctest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/bratushkadan/floral/pkg/entity"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type grant struct {
+	identity      domain.ExternalIdentity
+	redirectUri   string
+	nonce         string
+	codeChallenge string
+}
+
+// Provider serves discovery, JWKS and token endpoints of a single client.
+// The user signing in at the provider is simulated with Authorize.
+type Provider struct {
+	server       *httptest.Server
+	key          *ecdsa.PrivateKey
+	clientId     string
+	clientSecret string
+
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+func NewProvider(clientId, clientSecret string) (*Provider, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	p := &Provider{
+		key:          key,
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		grants:       make(map[string]grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.handleConfiguration)
+	mux.HandleFunc("GET /jwks", p.handleJwks)
+	mux.HandleFunc("POST /token", p.handleToken)
+	p.server = httptest.NewServer(mux)
+
+	return p, nil
+}
+
+// Issuer url of the provider.
+func (p *Provider) Url() string {
+	return p.server.URL
+}
+
+func (p *Provider) Close() {
+	p.server.Close()
+}
+
+// Sign the identity in with the authorization request the client redirected to
+// and return the authorization code the provider redirects back with.
+func (p *Provider) Authorize(authCodeUrl string, identity domain.ExternalIdentity) (string, error) {
+	u, err := url.Parse(authCodeUrl)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if query.Get("client_id") != p.clientId {
+		return "", errors.New("unknown client")
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		return "", errors.New("authorization code flow with S256 PKCE expected")
+	}
+
+	code := entity.Id(20)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.grants[code] = grant{
+		identity:      identity,
+		redirectUri:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	return code, nil
+}
+
+func (p *Provider) handleConfiguration(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, http.StatusOK, map[string]any{
+		"issuer":                                p.Url(),
+		"authorization_endpoint":                p.Url() + "/authorize",
+		"token_endpoint":                        p.Url() + "/token",
+		"jwks_uri":                              p.Url() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"ES256"},
+	})
+}
+
+func (p *Provider) handleJwks(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, http.StatusOK, auth.Jwks{Keys: []auth.Jwk{auth.NewEs256Jwk(&p.key.PublicKey)}})
+}
+
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientId, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientId, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientId != p.clientId || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(p.clientSecret)) != 1 {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	g, ok := p.grants[r.PostForm.Get("code")]
+	delete(p.grants, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok ||
+		r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != g.redirectUri ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != g.codeChallenge {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss":            p.Url(),
+		"sub":            g.identity.Subject,
+		"aud":            p.clientId,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.identity.Email,
+		"email_verified": g.identity.EmailVerified,
+		"name":           g.identity.Name,
+	}).SignedString(p.key)
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"access_token": entity.Id(20),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,205 @@
+// Software WebAuthn authenticator to run passkey ceremonies in tests without hardware.
+package passkeytest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+)
+
+const (
+	flagUserPresent      byte = 0x01
+	flagUserVerified     byte = 0x04
+	flagAttestedCredData byte = 0x40
+
+	coseKeyTypeEc2       = 2
+	coseAlgorithmEs256   = -7
+	coseCurveP256        = 1
+	credentialIdLength   = 32
+	clientDataTypeGet    = "webauthn.get"
+	clientDataTypeCreate = "webauthn.create"
+)
+
+// Authenticator holding discoverable ES256 credentials that always verifies the user.
+// Responses are the JSON serialized PublicKeyCredential objects browsers send to the relying party.
+type Authenticator struct {
+	// Origin reported in the client data.
+	Origin string
+
+	credentials []*credential
+}
+
+type credential struct {
+	id         []byte
+	rpId       string
+	userHandle []byte
+	key        *ecdsa.PrivateKey
+	signCount  uint32
+}
+
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin}
+}
+
+type creationOptions struct {
+	Challenge string `json:"challenge"`
+	Rp        struct {
+		Id string `json:"id"`
+	} `json:"rp"`
+	User struct {
+		Id string `json:"id"`
+	} `json:"user"`
+}
+
+// Create a credential for PublicKeyCredentialCreationOptions as navigator.credentials.create() does.
+func (a *Authenticator) Create(options json.RawMessage) (json.RawMessage, error) {
+	var opts creationOptions
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal creation options: %w", err)
+	}
+	userHandle, err := base64.RawURLEncoding.DecodeString(opts.User.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode user handle: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	cred := &credential{
+		id:         make([]byte, credentialIdLength),
+		rpId:       opts.Rp.Id,
+		userHandle: userHandle,
+		key:        key,
+	}
+	if _, err := rand.Read(cred.id); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := webauthncbor.Marshal(map[int]any{
+		1:  coseKeyTypeEc2,
+		3:  coseAlgorithmEs256,
+		-1: coseCurveP256,
+		-2: key.X.FillBytes(make([]byte, 32)),
+		-3: key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authData := cred.authData(flagUserPresent | flagUserVerified | flagAttestedCredData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(cred.id)))
+	authData = append(authData, cred.id...)
+	authData = append(authData, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clientData, err := a.clientData(clientDataTypeCreate, opts.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	a.credentials = append(a.credentials, cred)
+
+	return json.Marshal(map[string]any{
+		"id":    encode(cred.id),
+		"rawId": encode(cred.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientData),
+			"attestationObject": encode(attestationObject),
+		},
+	})
+}
+
+type requestOptions struct {
+	Challenge string `json:"challenge"`
+	RpId      string `json:"rpId"`
+}
+
+// Sign an assertion for PublicKeyCredentialRequestOptions as navigator.credentials.get() does.
+// The most recently created credential of the relying party is used.
+func (a *Authenticator) Get(options json.RawMessage) (json.RawMessage, error) {
+	var opts requestOptions
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request options: %w", err)
+	}
+
+	var cred *credential
+	for _, c := range a.credentials {
+		if c.rpId == opts.RpId {
+			cred = c
+		}
+	}
+	if cred == nil {
+		return nil, errors.New("no credentials for the relying party")
+	}
+
+	cred.signCount++
+	authData := cred.authData(flagUserPresent | flagUserVerified)
+
+	clientData, err := a.clientData(clientDataTypeGet, opts.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientData)
+
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, cred.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]any{
+		"id":    encode(cred.id),
+		"rawId": encode(cred.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientData),
+			"authenticatorData": encode(authData),
+			"signature":         encode(signature),
+			"userHandle":        encode(cred.userHandle),
+		},
+	})
+}
+
+// Roll the signature counter of every credential back as a cloned authenticator would.
+func (a *Authenticator) ResetSignCounts() {
+	for _, c := range a.credentials {
+		c.signCount = 0
+	}
+}
+
+func (c *credential) authData(flags byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(c.rpId))
+	authData := append(rpIdHash[:], flags)
+	return binary.BigEndian.AppendUint32(authData, c.signCount)
+}
+
+func (a *Authenticator) clientData(typ, challenge string) ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": challenge,
+		"origin":    a.Origin,
+	})
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,206 @@
+package passkey
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// WebAuthn relying party backed by go-webauthn.
+// Credentials are discoverable and require user verification,
+// so that a passkey alone is enough to sign in.
+type RelyingParty struct {
+	w *webauthn.WebAuthn
+}
+
+var _ domain.PasskeyRelyingParty = (*RelyingParty)(nil)
+
+type RelyingPartyConf struct {
+	// Domain the passkeys are scoped to, i.e. "floral.example.com".
+	Id          string
+	DisplayName string
+	// Fully qualified origins the ceremonies are allowed from, i.e. "https://floral.example.com".
+	Origins []string
+}
+
+func NewRelyingParty(conf RelyingPartyConf) (*RelyingParty, error) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          conf.Id,
+		RPDisplayName: conf.DisplayName,
+		RPOrigins:     conf.Origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.VerificationRequired,
+		},
+		AttestationPreference: protocol.PreferNoAttestation,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webauthn relying party: %w", err)
+	}
+	return &RelyingParty{w: w}, nil
+}
+
+func (rp *RelyingParty) BeginRegistration(user domain.PasskeyUser) (json.RawMessage, []byte, error) {
+	u, err := newUser(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+
+	creation, session, err := rp.w.BeginRegistration(u, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin webauthn registration: %w", err)
+	}
+
+	return marshalCeremony(creation.Response, session)
+}
+
+func (rp *RelyingParty) FinishRegistration(user domain.PasskeyUser, sessionData []byte, response json.RawMessage) (domain.PasskeyCredential, error) {
+	u, err := newUser(user)
+	if err != nil {
+		return domain.PasskeyCredential{}, err
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(sessionData, &session); err != nil {
+		return domain.PasskeyCredential{}, fmt.Errorf("failed to unmarshal webauthn session: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return domain.PasskeyCredential{}, verificationError(err)
+	}
+
+	cred, err := rp.w.CreateCredential(u, session, parsed)
+	if err != nil {
+		return domain.PasskeyCredential{}, verificationError(err)
+	}
+
+	return newCredential(user.AccountId, cred)
+}
+
+func (rp *RelyingParty) BeginLogin() (json.RawMessage, []byte, error) {
+	assertion, session, err := rp.w.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin webauthn login: %w", err)
+	}
+
+	return marshalCeremony(assertion.Response, session)
+}
+
+func (rp *RelyingParty) FinishLogin(sessionData []byte, response json.RawMessage, findUser func(accountId string) (domain.PasskeyUser, error)) (domain.PasskeyCredential, error) {
+	var session webauthn.SessionData
+	if err := json.Unmarshal(sessionData, &session); err != nil {
+		return domain.PasskeyCredential{}, fmt.Errorf("failed to unmarshal webauthn session: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return domain.PasskeyCredential{}, verificationError(err)
+	}
+
+	// The user handle is the account id the credential has been registered for.
+	accountId := string(parsed.Response.UserHandle)
+
+	var findUserErr error
+	cred, err := rp.w.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+		user, err := findUser(string(userHandle))
+		if err != nil {
+			findUserErr = err
+			return nil, err
+		}
+		return newUser(user)
+	}, session, parsed)
+	if findUserErr != nil {
+		return domain.PasskeyCredential{}, findUserErr
+	}
+	if err != nil {
+		return domain.PasskeyCredential{}, verificationError(err)
+	}
+
+	// Signature counter going backwards means the private key has been copied off the authenticator.
+	if cred.Authenticator.CloneWarning {
+		return domain.PasskeyCredential{}, fmt.Errorf("%w: signature counter did not increase", domain.ErrPasskeyVerificationFailed)
+	}
+
+	return newCredential(accountId, cred)
+}
+
+func marshalCeremony(options any, session *webauthn.SessionData) (json.RawMessage, []byte, error) {
+	optionsJson, err := json.Marshal(options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal webauthn options: %w", err)
+	}
+	sessionJson, err := json.Marshal(session)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal webauthn session: %w", err)
+	}
+	return optionsJson, sessionJson, nil
+}
+
+func verificationError(err error) error {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) {
+		return fmt.Errorf("%w: %s: %s", domain.ErrPasskeyVerificationFailed, protocolErr.Details, protocolErr.DevInfo)
+	}
+	return fmt.Errorf("%w: %w", domain.ErrPasskeyVerificationFailed, err)
+}
+
+func newCredential(accountId string, cred *webauthn.Credential) (domain.PasskeyCredential, error) {
+	data, err := json.Marshal(cred)
+	if err != nil {
+		return domain.PasskeyCredential{}, fmt.Errorf("failed to marshal webauthn credential: %w", err)
+	}
+	return domain.PasskeyCredential{
+		Id:        base64.RawURLEncoding.EncodeToString(cred.ID),
+		AccountId: accountId,
+		Data:      data,
+	}, nil
+}
+
+type user struct {
+	domain.PasskeyUser
+	credentials []webauthn.Credential
+}
+
+var _ webauthn.User = (*user)(nil)
+
+func newUser(u domain.PasskeyUser) (*user, error) {
+	credentials := make([]webauthn.Credential, 0, len(u.Credentials))
+	for _, c := range u.Credentials {
+		var cred webauthn.Credential
+		if err := json.Unmarshal(c.Data, &cred); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webauthn credential %s: %w", c.Id, err)
+		}
+		credentials = append(credentials, cred)
+	}
+	return &user{PasskeyUser: u, credentials: credentials}, nil
+}
+
+// Account ids are opaque hashes, so they are used as user handles as is.
+func (u *user) WebAuthnID() []byte {
+	return []byte(u.AccountId)
+}
+func (u *user) WebAuthnName() string {
+	return u.Email
+}
+func (u *user) WebAuthnDisplayName() string {
+	return u.Name
+}
+func (u *user) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+func (u *user) WebAuthnIcon() string {
+	return ""
+}
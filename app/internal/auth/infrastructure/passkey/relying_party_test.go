@@ -0,0 +1,80 @@
+package passkey_test
+
+import (
+	"testing"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/passkey"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/passkey/passkeytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRelyingParty(t *testing.T) *passkey.RelyingParty {
+	t.Helper()
+	rp, err := passkey.NewRelyingParty(passkey.RelyingPartyConf{
+		Id:          "floral.example.com",
+		DisplayName: "Floral",
+		Origins:     []string{"https://floral.example.com"},
+	})
+	require.NoError(t, err)
+	return rp
+}
+
+func TestRelyingPartyCeremonies(t *testing.T) {
+	rp := newRelyingParty(t)
+	authenticator := passkeytest.NewAuthenticator("https://floral.example.com")
+	user := domain.PasskeyUser{AccountId: "ie123", Name: "user", Email: "user@example.com"}
+
+	options, session, err := rp.BeginRegistration(user)
+	require.NoError(t, err)
+	response, err := authenticator.Create(options)
+	require.NoError(t, err)
+	cred, err := rp.FinishRegistration(user, session, response)
+	require.NoError(t, err)
+	assert.Equal(t, "ie123", cred.AccountId)
+	assert.NotEmpty(t, cred.Id)
+	user.Credentials = []domain.PasskeyCredential{cred}
+
+	options, session, err = rp.BeginLogin()
+	require.NoError(t, err)
+	response, err = authenticator.Get(options)
+	require.NoError(t, err)
+	used, err := rp.FinishLogin(session, response, func(accountId string) (domain.PasskeyUser, error) {
+		assert.Equal(t, "ie123", accountId)
+		return user, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, cred.Id, used.Id)
+	assert.NotEqual(t, cred.Data, used.Data, "signature counter must be updated")
+}
+
+func TestRelyingPartyRejectsForeignOrigin(t *testing.T) {
+	rp := newRelyingParty(t)
+	authenticator := passkeytest.NewAuthenticator("https://phishing.example.com")
+	user := domain.PasskeyUser{AccountId: "ie123", Name: "user", Email: "user@example.com"}
+
+	options, session, err := rp.BeginRegistration(user)
+	require.NoError(t, err)
+	response, err := authenticator.Create(options)
+	require.NoError(t, err)
+
+	_, err = rp.FinishRegistration(user, session, response)
+	assert.ErrorIs(t, err, domain.ErrPasskeyVerificationFailed)
+}
+
+func TestRelyingPartyRejectsForeignChallenge(t *testing.T) {
+	rp := newRelyingParty(t)
+	authenticator := passkeytest.NewAuthenticator("https://floral.example.com")
+	user := domain.PasskeyUser{AccountId: "ie123", Name: "user", Email: "user@example.com"}
+
+	options, _, err := rp.BeginRegistration(user)
+	require.NoError(t, err)
+	_, otherSession, err := rp.BeginRegistration(user)
+	require.NoError(t, err)
+	response, err := authenticator.Create(options)
+	require.NoError(t, err)
+
+	_, err = rp.FinishRegistration(user, otherSession, response)
+	assert.ErrorIs(t, err, domain.ErrPasskeyVerificationFailed)
+}
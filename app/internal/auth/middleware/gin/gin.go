@@ -0,0 +1,36 @@
+package gin_middleware
+
+import (
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Gin flavor of middleware.Auth.Middleware. The verified access token is put
+// into both the gin and the request contexts.
+func Middleware(a *middleware.Auth, req middleware.Requirement) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := a.Authorize(c.Request, req)
+		if err != nil {
+			status, res := middleware.ErrorResponse(err)
+			c.AbortWithStatusJSON(status, res)
+			return
+		}
+
+		c.Set(accessTokenKey, token)
+		c.Request = c.Request.WithContext(middleware.ContextWithAccessToken(c.Request.Context(), token))
+		c.Next()
+	}
+}
+
+const accessTokenKey = "auth.access_token"
+
+// Access token verified by the middleware.
+func AccessToken(c *gin.Context) (domain.AccessToken, bool) {
+	v, ok := c.Get(accessTokenKey)
+	if !ok {
+		return domain.AccessToken{}, false
+	}
+	token, ok := v.(domain.AccessToken)
+	return token, ok
+}
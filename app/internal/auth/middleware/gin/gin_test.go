@@ -0,0 +1,70 @@
+package gin_middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/middleware"
+	gin_middleware "github.com/bratushkadan/floral/internal/auth/middleware/gin"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Access token decoder accepting the "seller" token only.
+type fakeTokens struct{}
+
+func (fakeTokens) DecodeAccess(token string) (domain.AccessToken, error) {
+	if token != "seller" {
+		return domain.AccessToken{}, domain.ErrInvalidAccessToken
+	}
+	return domain.AccessToken{
+		SubjectId:   "seller-id",
+		SubjectType: domain.AccountTypeSeller,
+		Permissions: []domain.Permission{domain.PermissionProductsWrite},
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	a, err := middleware.NewAuth(middleware.AuthConf{Tokens: fakeTokens{}})
+	require.NoError(t, err)
+
+	r := gin.New()
+	handler := func(c *gin.Context) {
+		token, ok := gin_middleware.AccessToken(c)
+		require.True(t, ok)
+		fromCtx, ok := middleware.AccessTokenFromContext(c.Request.Context())
+		require.True(t, ok)
+		assert.Equal(t, token, fromCtx)
+		c.String(http.StatusOK, token.SubjectId)
+	}
+	r.POST("/products", gin_middleware.Middleware(a, middleware.Requirement{Permissions: []domain.Permission{domain.PermissionProductsWrite}}), handler)
+	r.DELETE("/products", gin_middleware.Middleware(a, middleware.Requirement{Roles: []domain.AccountType{domain.AccountTypeAdmin}}), handler)
+
+	for name, tc := range map[string]struct {
+		method        string
+		authorization string
+		status        int
+	}{
+		"allowed":          {http.MethodPost, "Bearer seller", http.StatusOK},
+		"invalid token":    {http.MethodPost, "Bearer user", http.StatusUnauthorized},
+		"missing token":    {http.MethodPost, "", http.StatusUnauthorized},
+		"role not allowed": {http.MethodDelete, "Bearer seller", http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/products", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Package middleware verifies access tokens issued by the account service
+// in downstream services of this module and enforces route level requirements.
+// It exposes the account service domain types, so it's kept internal.
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/authn"
+	"github.com/bratushkadan/floral/pkg/xhttp"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrMissingAuthorization = errors.New("authorization header must be provided")
+	ErrMissingBearerPrefix  = errors.New(`authorization header "Bearer " prefix must be provided`)
+	ErrInvalidAccessToken   = errors.New("invalid access token")
+	ErrAccessTokenExpired   = errors.New("access token expired")
+	ErrForbidden            = errors.New("access token does not meet the route requirements")
+)
+
+const (
+	ErrCodeMissingAuthorization = 121
+	ErrCodeMissingBearerPrefix  = 122
+	ErrCodeInvalidAccessToken   = 123
+	ErrCodeAccessTokenExpired   = 124
+	ErrCodeForbidden            = 125
+)
+
+// Satisfied by authn.TokenProvider. Refresh and other tokens are rejected by the token type.
+type AccessTokenDecoder interface {
+	DecodeAccess(token string) (domain.AccessToken, error)
+}
+
+type Auth struct {
+	tokens AccessTokenDecoder
+	l      *zap.Logger
+}
+
+type AuthConf struct {
+	// PEM encoded public key the tokens are verified with.
+	PublicKey []byte
+	// Account service JSON Web Key Set url the tokens are verified with, e.g.
+	// "https://auth.example.com/.well-known/jwks.json". Keys rotated in are picked up from it.
+	JwksUrl string
+	// Expected "iss" claim, not validated if empty.
+	Issuer string
+	// Tokens must be intended for any of the audiences, not validated if empty.
+	Audience []string
+	// Tokens without registered claims are rejected from then on,
+	// authn.DefaultLegacyTokensAcceptedUntil if zero.
+	LegacyTokensAcceptedUntil time.Time
+	// Decoder used instead of the public key or the JWKS url, e.g. in tests.
+	Tokens AccessTokenDecoder
+	Logger *zap.Logger
+}
+
+func NewAuth(conf AuthConf) (*Auth, error) {
+	a := &Auth{
+		tokens: conf.Tokens,
+		l:      conf.Logger,
+	}
+	if a.l == nil {
+		a.l = zap.NewNop()
+	}
+
+	if a.tokens == nil {
+		if len(conf.PublicKey) == 0 && conf.JwksUrl == "" {
+			return nil, errors.New("either a public key, a jwks url or an access token decoder must be provided to the auth middleware")
+		}
+		// Tokens are only verified, never signed.
+		b := authn.NewTokenProviderBuilder().
+			JwksUrl(conf.JwksUrl).
+			Issuer(conf.Issuer).
+			Audience(conf.Audience...).
+			LegacyTokensAcceptedUntil(conf.LegacyTokensAcceptedUntil)
+		if len(conf.PublicKey) != 0 {
+			b = b.PublicKey(conf.PublicKey)
+		}
+		tokens, err := b.Build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build token provider: %w", err)
+		}
+		a.tokens = tokens
+	}
+
+	return a, nil
+}
+
+// Route requirements the access token must meet. The zero value requires any valid access token.
+type Requirement struct {
+	// The token subject must be of any of the account types.
+	Roles []domain.AccountType
+	// The token must grant all of the permissions.
+	Permissions []domain.Permission
+}
+
+func (r Requirement) Check(token domain.AccessToken) error {
+	if len(r.Roles) != 0 && !slices.Contains(r.Roles, token.SubjectType) {
+		return fmt.Errorf("%w: account type %q is not allowed", ErrForbidden, token.SubjectType)
+	}
+	for _, permission := range r.Permissions {
+		if !token.Has(permission) {
+			return fmt.Errorf("%w: permission %q is not granted", ErrForbidden, permission)
+		}
+	}
+	return nil
+}
+
+// Verify the access token of the "Authorization: Bearer <token>" header.
+func (a *Auth) Authenticate(r *http.Request) (domain.AccessToken, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return domain.AccessToken{}, ErrMissingAuthorization
+	}
+	tokenString, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || tokenString == "" {
+		return domain.AccessToken{}, ErrMissingBearerPrefix
+	}
+
+	token, err := a.tokens.DecodeAccess(tokenString)
+	if err != nil {
+		if errors.Is(err, domain.ErrTokenExpired) {
+			return domain.AccessToken{}, ErrAccessTokenExpired
+		}
+		a.l.Info("failed to decode access token", zap.Error(err))
+		return domain.AccessToken{}, fmt.Errorf("%w: %w", ErrInvalidAccessToken, err)
+	}
+	return token, nil
+}
+
+// Authenticate the request and check the requirement.
+func (a *Auth) Authorize(r *http.Request, req Requirement) (domain.AccessToken, error) {
+	token, err := a.Authenticate(r)
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	if err := req.Check(token); err != nil {
+		return domain.AccessToken{}, err
+	}
+	return token, nil
+}
+
+// net/http (chi compatible) middleware putting the verified access token into the request context.
+func (a *Auth) Middleware(req Requirement) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := a.Authorize(r, req)
+			if err != nil {
+				status, res := ErrorResponse(err)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				if err := json.NewEncoder(w).Encode(res); err != nil {
+					a.l.Error("failed to encode auth middleware error response", zap.Error(err))
+				}
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ContextWithAccessToken(r.Context(), token)))
+		})
+	}
+}
+
+// Status code and body of the response to the authentication or authorization error.
+func ErrorResponse(err error) (int, xhttp.ErrorResponse) {
+	switch {
+	case errors.Is(err, ErrMissingAuthorization):
+		return http.StatusUnauthorized, xhttp.NewErrorResponse(xhttp.ErrorResponseErr{Code: ErrCodeMissingAuthorization, Message: ErrMissingAuthorization.Error()})
+	case errors.Is(err, ErrMissingBearerPrefix):
+		return http.StatusUnauthorized, xhttp.NewErrorResponse(xhttp.ErrorResponseErr{Code: ErrCodeMissingBearerPrefix, Message: ErrMissingBearerPrefix.Error()})
+	case errors.Is(err, ErrAccessTokenExpired):
+		return http.StatusUnauthorized, xhttp.NewErrorResponse(xhttp.ErrorResponseErr{Code: ErrCodeAccessTokenExpired, Message: ErrAccessTokenExpired.Error()})
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden, xhttp.NewErrorResponse(xhttp.ErrorResponseErr{Code: ErrCodeForbidden, Message: ErrForbidden.Error()})
+	default:
+		return http.StatusUnauthorized, xhttp.NewErrorResponse(xhttp.ErrorResponseErr{Code: ErrCodeInvalidAccessToken, Message: ErrInvalidAccessToken.Error()})
+	}
+}
+
+type accessTokenCtxKey struct{}
+
+func ContextWithAccessToken(ctx context.Context, token domain.AccessToken) context.Context {
+	return context.WithValue(ctx, accessTokenCtxKey{}, token)
+}
+
+// Access token verified by the middleware.
+func AccessTokenFromContext(ctx context.Context) (domain.AccessToken, bool) {
+	token, ok := ctx.Value(accessTokenCtxKey{}).(domain.AccessToken)
+	return token, ok
+}
@@ -0,0 +1,143 @@
+package middleware_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/authn"
+	"github.com/bratushkadan/floral/internal/auth/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenProvider(t *testing.T) (*authn.TokenProvider, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privateDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	publicDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer})
+
+	prov, err := authn.NewTokenProviderBuilder().
+		PrivateKey(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDer})).
+		PublicKey(publicKey).
+		Build()
+	require.NoError(t, err)
+	return prov, publicKey
+}
+
+func newSellerAccessToken(t *testing.T, prov *authn.TokenProvider) string {
+	t.Helper()
+
+	token, err := prov.EncodeAccess(domain.AccessToken{
+		SubjectId:   "seller-id",
+		SubjectType: domain.AccountTypeSeller,
+		Permissions: []domain.Permission{domain.PermissionProductsRead, domain.PermissionProductsWrite},
+		ExpiresAt:   time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	return token
+}
+
+// Serves the request through the middleware, responding with the subject id of the verified token.
+func serve(a *middleware.Auth, req middleware.Requirement, authorization string) *httptest.ResponseRecorder {
+	h := a.Middleware(req)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := middleware.AccessTokenFromContext(r.Context())
+		w.Write([]byte(token.SubjectId))
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/products", nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddlewarePublicKey(t *testing.T) {
+	prov, publicKey := newTokenProvider(t)
+	a, err := middleware.NewAuth(middleware.AuthConf{PublicKey: publicKey})
+	require.NoError(t, err)
+
+	w := serve(a, middleware.Requirement{}, "Bearer "+newSellerAccessToken(t, prov))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "seller-id", w.Body.String())
+}
+
+func TestMiddlewareJwksUrl(t *testing.T) {
+	prov, _ := newTokenProvider(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwks, err := prov.Jwks()
+		require.NoError(t, err)
+		w.Write(jwks)
+	}))
+	defer srv.Close()
+
+	a, err := middleware.NewAuth(middleware.AuthConf{JwksUrl: srv.URL})
+	require.NoError(t, err)
+
+	w := serve(a, middleware.Requirement{}, "Bearer "+newSellerAccessToken(t, prov))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "seller-id", w.Body.String())
+}
+
+func TestMiddlewareRejectsUnauthenticated(t *testing.T) {
+	prov, publicKey := newTokenProvider(t)
+	otherProv, _ := newTokenProvider(t)
+	a, err := middleware.NewAuth(middleware.AuthConf{PublicKey: publicKey})
+	require.NoError(t, err)
+
+	refreshToken, err := prov.EncodeRefresh(domain.RefreshToken{Id: "id", SubjectId: "seller-id", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	expiredToken, err := prov.EncodeAccess(domain.AccessToken{SubjectId: "seller-id", ExpiresAt: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+
+	for name, authorization := range map[string]string{
+		"missing header":     "",
+		"missing prefix":     newSellerAccessToken(t, prov),
+		"garbage":            "Bearer foo",
+		"refresh token":      "Bearer " + refreshToken,
+		"expired token":      "Bearer " + expiredToken,
+		"foreign key signed": "Bearer " + newSellerAccessToken(t, otherProv),
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(a, middleware.Requirement{}, authorization)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.NotContains(t, w.Body.String(), "seller-id")
+		})
+	}
+}
+
+func TestMiddlewareRequirements(t *testing.T) {
+	prov, publicKey := newTokenProvider(t)
+	a, err := middleware.NewAuth(middleware.AuthConf{PublicKey: publicKey})
+	require.NoError(t, err)
+	authorization := "Bearer " + newSellerAccessToken(t, prov)
+
+	for name, tc := range map[string]struct {
+		req    middleware.Requirement
+		status int
+	}{
+		"role allowed":           {middleware.Requirement{Roles: []domain.AccountType{domain.AccountTypeSeller, domain.AccountTypeAdmin}}, http.StatusOK},
+		"role denied":            {middleware.Requirement{Roles: []domain.AccountType{domain.AccountTypeAdmin}}, http.StatusForbidden},
+		"permission granted":     {middleware.Requirement{Permissions: []domain.Permission{domain.PermissionProductsWrite}}, http.StatusOK},
+		"permission not granted": {middleware.Requirement{Permissions: []domain.Permission{domain.PermissionProductsManage}}, http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(a, tc.req, authorization)
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"go.uber.org/zap"
+)
+
+var errAccessTokenRevocationsUnavailable = errors.New("access token revocation is unavailable: access token revocations not set")
+
+// Token provider rejecting revoked access tokens, so every decoding site checks the deny-list.
+type revocationCheckingTokenProvider struct {
+	domain.TokenProvider
+	revocations domain.AccessTokenRevocations
+}
+
+func (p *revocationCheckingTokenProvider) DecodeAccess(tokenString string) (domain.AccessToken, error) {
+	token, err := p.TokenProvider.DecodeAccess(tokenString)
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+
+	revoked, err := p.revocations.Revoked(context.Background(), token)
+	if err != nil {
+		return domain.AccessToken{}, fmt.Errorf("%w: %w", domain.ErrTokenRevocationCheckFailed, err)
+	}
+	if revoked {
+		return domain.AccessToken{}, fmt.Errorf("%w: %w", domain.ErrTokenRevoked, domain.ErrInvalidAccessToken)
+	}
+	return token, nil
+}
+
+func (svc *Auth) RevokeAccessTokens(ctx context.Context, req domain.RevokeAccessTokensReq) (domain.RevokeAccessTokensRes, error) {
+	if svc.accessTokenRevocations == nil {
+		return domain.RevokeAccessTokensRes{}, errAccessTokenRevocationsUnavailable
+	}
+
+	token, _, err := svc.prepareAccountManagement(ctx, req.AccessToken, req.AccountId, domain.ActionRevokeAccessTokens)
+	if err != nil {
+		return domain.RevokeAccessTokensRes{}, err
+	}
+
+	if err := svc.revokeAccountAccessTokens(ctx, req.AccountId, token.SubjectId); err != nil {
+		return domain.RevokeAccessTokensRes{}, err
+	}
+
+	return domain.RevokeAccessTokensRes{}, nil
+}
+
+// Revoke access tokens issued to the account so far if access token revocations are set.
+func (svc *Auth) revokeAccountAccessTokens(ctx context.Context, accountId string, actorId string) error {
+	if svc.accessTokenRevocations == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := svc.accessTokenRevocations.Revoke(ctx, domain.AccessTokenRevocation{
+		AccountId: accountId,
+		RevokedAt: now,
+		// Tokens issued before are expired by then.
+		ExpiresAt: now.Add(max(svc.accessTokenDuration, svc.impersonationTokenDuration)),
+	}); err != nil {
+		svc.l.Error("failed to revoke account access tokens", zap.String("account_id", accountId), zap.Error(err))
+		return err
+	}
+	svc.l.Info("revoked account access tokens", zap.String("account_id", accountId), zap.String("revoked_by", actorId))
+
+	event := newAuditEvent(domain.AuditEventAccessTokenRevocation, accountId, map[string]string{})
+	event.ActorId = actorId
+	svc.audit(ctx, event)
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRevocationTestAuth(t *testing.T) (*service.Auth, *fakeAuditLog) {
+	t.Helper()
+
+	auditLog := &fakeAuditLog{AuditLog: memory_adapter.NewAuditLog()}
+	svc, accProv := newTestAuthWith(t, service.NewAuthBuilder().
+		AuditLog(auditLog).
+		AccessTokenRevocations(memory_adapter.NewAccessTokenRevocations()))
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+	}
+
+	return svc, auditLog
+}
+
+func TestRevokeAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, auditLog := newRevocationTestAuth(t)
+
+	_, err := svc.RevokeAccessTokens(ctx, domain.RevokeAccessTokensReq{AccessToken: tokenAdmin, AccountId: "user-id"})
+	require.NoError(t, err)
+
+	_, err = svc.ListSessions(ctx, domain.ListSessionsReq{AccessToken: tokenUser})
+	assert.ErrorIs(t, err, domain.ErrInvalidAccessToken)
+	assert.ErrorIs(t, err, domain.ErrTokenRevoked)
+
+	require.Len(t, auditLog.events, 1)
+	assert.Equal(t, domain.AuditEventAccessTokenRevocation, auditLog.events[0].Type)
+	assert.Equal(t, "user-id", auditLog.events[0].AccountId)
+	assert.Equal(t, "admin-id", auditLog.events[0].ActorId)
+
+	// Access tokens of other accounts are still accepted.
+	_, err = svc.RevokeAccessTokens(ctx, domain.RevokeAccessTokensReq{AccessToken: tokenAdmin, AccountId: "user-id"})
+	assert.NoError(t, err)
+}
+
+func TestRevokeAccessTokensRejectsNonAdmins(t *testing.T) {
+	svc, _ := newRevocationTestAuth(t)
+
+	for _, token := range []string{tokenSeller, tokenUser} {
+		_, err := svc.RevokeAccessTokens(context.Background(), domain.RevokeAccessTokensReq{AccessToken: token, AccountId: "user-id"})
+		assert.ErrorIs(t, err, domain.ErrPermissionDenied, token)
+	}
+}
+
+func TestRevokeAccessTokensUnavailableWithoutRevocations(t *testing.T) {
+	svc, _ := newTestAuth(t)
+
+	_, err := svc.RevokeAccessTokens(context.Background(), domain.RevokeAccessTokensReq{AccessToken: tokenAdmin, AccountId: "user-id"})
+	assert.Error(t, err)
+}
+
+// Access token revocations failing every lookup.
+type unavailableAccessTokenRevocations struct {
+	*memory_adapter.AccessTokenRevocations
+}
+
+func (r *unavailableAccessTokenRevocations) Revoked(context.Context, domain.AccessToken) (bool, error) {
+	return false, errors.New("ydb is unavailable")
+}
+
+func TestAccessTokenRevocationCheckFailureIsNotUnauthorized(t *testing.T) {
+	svc, _ := newTestAuthWith(t, service.NewAuthBuilder().
+		AccessTokenRevocations(&unavailableAccessTokenRevocations{memory_adapter.NewAccessTokenRevocations()}))
+
+	_, err := svc.ListSessions(context.Background(), domain.ListSessionsReq{AccessToken: tokenUser})
+	assert.ErrorIs(t, err, domain.ErrTokenRevocationCheckFailed)
+	assert.NotErrorIs(t, err, domain.ErrInvalidAccessToken)
+}
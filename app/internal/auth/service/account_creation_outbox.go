@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"go.uber.org/zap"
+)
+
+var errAccountCreationOutboxUnavailable = errors.New("account creation relay is unavailable: account creation outbox not set")
+
+const (
+	relayAccountCreationNotificationsBatchSize = 100
+	// Failed publishing attempts are retried with exponential backoff.
+	accountCreationRelayBaseDelay = 10 * time.Second
+	accountCreationRelayMaxDelay  = time.Hour
+	// Attempts after which the relay starts to complain loudly, the message is still retried.
+	accountCreationRelayAlertAttempts = 10
+)
+
+func (svc *Auth) RelayAccountCreationNotifications(ctx context.Context, _ domain.RelayAccountCreationNotificationsReq) (domain.RelayAccountCreationNotificationsRes, error) {
+	if svc.accCreationOutbox == nil {
+		return domain.RelayAccountCreationNotificationsRes{}, errAccountCreationOutboxUnavailable
+	}
+
+	var res domain.RelayAccountCreationNotificationsRes
+	now := time.Now()
+	for {
+		messages, err := svc.accCreationOutbox.ListDue(ctx, domain.AccountCreationOutboxListDueDTOInput{
+			Now:   now,
+			Limit: relayAccountCreationNotificationsBatchSize,
+		})
+		if err != nil {
+			svc.l.Error("failed to list due account creation notifications", zap.Error(err))
+			return res, err
+		}
+
+		for _, msg := range messages {
+			if _, err := svc.accCreationNotificationProv.Send(ctx, domain.SendAccountCreationNotificationDTOInput{
+				Id:    msg.Id,
+				Email: msg.Email,
+			}); err != nil {
+				res.Failed++
+				if err := svc.retryAccountCreationNotification(ctx, msg, err); err != nil {
+					return res, err
+				}
+				continue
+			}
+
+			// The message is published again if deleting it fails, consumers deduplicate it by the id.
+			if err := svc.accCreationOutbox.Delete(ctx, msg.Id); err != nil {
+				svc.l.Error("failed to delete published account creation notification", zap.String("id", msg.Id), zap.Error(err))
+				return res, err
+			}
+			res.Published++
+		}
+
+		if len(messages) < relayAccountCreationNotificationsBatchSize {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+func (svc *Auth) retryAccountCreationNotification(ctx context.Context, msg domain.AccountCreationOutboxMessage, sendErr error) error {
+	attempts := msg.Attempts + 1
+	nextAttemptAt := time.Now().Add(accountCreationRelayDelay(attempts))
+
+	log := svc.l.Warn
+	if attempts >= accountCreationRelayAlertAttempts {
+		log = svc.l.Error
+	}
+	log(
+		"failed to publish account creation notification",
+		zap.String("id", msg.Id),
+		zap.Int("attempts", attempts),
+		zap.Time("next_attempt_at", nextAttemptAt),
+		zap.Error(sendErr),
+	)
+
+	if err := svc.accCreationOutbox.Retry(ctx, domain.AccountCreationOutboxRetryDTOInput{
+		Id:            msg.Id,
+		Attempts:      attempts,
+		NextAttemptAt: nextAttemptAt,
+		LastError:     sendErr.Error(),
+	}); err != nil {
+		svc.l.Error("failed to schedule account creation notification retry", zap.String("id", msg.Id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Delay before the next publishing attempt after the amount of failed attempts.
+func accountCreationRelayDelay(attempts int) time.Duration {
+	delay := accountCreationRelayBaseDelay
+	for i := 1; i < attempts && delay < accountCreationRelayMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, accountCreationRelayMaxDelay)
+}
@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Account creation notifications failing for the listed emails.
+type flakyAccountCreationNotifications struct {
+	failing map[string]bool
+	sent    []domain.SendAccountCreationNotificationDTOInput
+}
+
+func (n *flakyAccountCreationNotifications) Send(_ context.Context, in domain.SendAccountCreationNotificationDTOInput) (domain.SendAccountCreationNotificationDTOOutput, error) {
+	if n.failing[in.Email] {
+		return domain.SendAccountCreationNotificationDTOOutput{}, errors.New("queue is unavailable")
+	}
+	n.sent = append(n.sent, in)
+	return domain.SendAccountCreationNotificationDTOOutput{}, nil
+}
+
+func newRelayTestAuth(t *testing.T, outbox domain.AccountCreationOutbox, notifications domain.AccountCreationNotifications) *service.Auth {
+	t.Helper()
+
+	svc, err := service.NewAuthBuilder().
+		AccountCreationNotificationProvider(notifications).
+		AccountCreationOutbox(outbox).
+		Build()
+	require.NoError(t, err)
+	return svc
+}
+
+func TestCreateSellerEnqueuesCreationNotificationWithAccount(t *testing.T) {
+	svc, accProv := newTestAuthWith(t, service.NewAuthBuilder().AccountCreationOutbox(memory_adapter.NewAccountCreationOutbox()))
+
+	_, err := svc.CreateSeller(context.Background(), newCreateSellerReq(tokenAdmin))
+	require.NoError(t, err)
+
+	require.Len(t, accProv.created, 1)
+	assert.NotEmpty(t, accProv.created[0].CreationNotificationId, "notification must be written with the account")
+}
+
+func TestCreateSellerWithoutOutboxDoesNotEnqueue(t *testing.T) {
+	svc, accProv := newTestAuth(t)
+
+	_, err := svc.CreateSeller(context.Background(), newCreateSellerReq(tokenAdmin))
+	require.NoError(t, err)
+
+	require.Len(t, accProv.created, 1)
+	assert.Empty(t, accProv.created[0].CreationNotificationId)
+}
+
+func TestRelayAccountCreationNotifications(t *testing.T) {
+	ctx := context.Background()
+	outbox := memory_adapter.NewAccountCreationOutbox()
+	require.NoError(t, outbox.Enqueue(ctx, "notification-1", "ok@example.com"))
+	require.NoError(t, outbox.Enqueue(ctx, "notification-2", "failing@example.com"))
+	notifications := &flakyAccountCreationNotifications{failing: map[string]bool{"failing@example.com": true}}
+	svc := newRelayTestAuth(t, outbox, notifications)
+
+	res, err := svc.RelayAccountCreationNotifications(ctx, domain.RelayAccountCreationNotificationsReq{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, res.Published)
+	assert.Equal(t, 1, res.Failed)
+	assert.Equal(t, []domain.SendAccountCreationNotificationDTOInput{{Id: "notification-1", Email: "ok@example.com"}}, notifications.sent)
+
+	// The failed notification is retried later, the published one is gone.
+	due, err := outbox.ListDue(ctx, domain.AccountCreationOutboxListDueDTOInput{Now: time.Now()})
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	due, err = outbox.ListDue(ctx, domain.AccountCreationOutboxListDueDTOInput{Now: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "notification-2", due[0].Id)
+	assert.Equal(t, 1, due[0].Attempts)
+}
+
+func TestRelayAccountCreationNotificationsUnavailableWithoutOutbox(t *testing.T) {
+	svc, err := service.NewAuthBuilder().Build()
+	require.NoError(t, err)
+
+	_, err = svc.RelayAccountCreationNotifications(context.Background(), domain.RelayAccountCreationNotificationsReq{})
+	assert.Error(t, err)
+}
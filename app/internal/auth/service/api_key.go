@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/entity"
+	"go.uber.org/zap"
+)
+
+var errApiKeysUnavailable = errors.New("service accounts are unavailable: api keys not set")
+
+func (svc *Auth) CreateServiceAccount(ctx context.Context, req domain.CreateServiceAccountReq) (domain.CreateServiceAccountRes, error) {
+	if svc.apiKeys == nil {
+		return domain.CreateServiceAccountRes{}, errApiKeysUnavailable
+	}
+
+	token, err := svc.authorize(ctx, req.AccessToken, domain.ActionManageServiceAccounts)
+	if err != nil {
+		return domain.CreateServiceAccountRes{}, err
+	}
+
+	if err := domain.ValidateServiceAccountName(req.Name); err != nil {
+		return domain.CreateServiceAccountRes{}, err
+	}
+
+	res, err := svc.createAccount(ctx, createAccountReq{
+		Name:  req.Name,
+		Email: domain.ServiceAccountEmail(req.Name),
+		// Random password nobody knows, service accounts authenticate with api keys.
+		Password:  entity.Id(15),
+		Type:      domain.AccountTypeService,
+		Activated: true,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrEmailIsInUse) {
+			return domain.CreateServiceAccountRes{}, domain.ErrServiceAccountExists
+		}
+		return domain.CreateServiceAccountRes{}, err
+	}
+	svc.l.Info("created service account", zap.String("account_id", res.Id), zap.String("created_by", token.SubjectId))
+
+	return domain.CreateServiceAccountRes{
+		Id:   res.Id,
+		Name: res.Name,
+	}, nil
+}
+
+// Authorize managing service accounts and find the service account.
+func (svc *Auth) prepareServiceAccountManagement(ctx context.Context, accessToken, accountId string) (domain.AccessToken, error) {
+	token, acc, err := svc.prepareAccountManagement(ctx, accessToken, accountId, domain.ActionManageServiceAccounts)
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	if acc.Type != domain.AccountTypeService {
+		return domain.AccessToken{}, domain.ErrInvalidAccountType
+	}
+	return token, nil
+}
+
+func (svc *Auth) CreateApiKey(ctx context.Context, req domain.CreateApiKeyReq) (domain.CreateApiKeyRes, error) {
+	if svc.apiKeys == nil {
+		return domain.CreateApiKeyRes{}, errApiKeysUnavailable
+	}
+
+	token, err := svc.prepareServiceAccountManagement(ctx, req.AccessToken, req.AccountId)
+	if err != nil {
+		return domain.CreateApiKeyRes{}, err
+	}
+
+	if err := domain.ValidateScopes(req.Scopes); err != nil {
+		return domain.CreateApiKeyRes{}, err
+	}
+	scopes := normalizeScopes(req.Scopes)
+
+	key, err := svc.addApiKey(ctx, req.AccountId, strings.TrimSpace(req.Name), scopes)
+	if err != nil {
+		return domain.CreateApiKeyRes{}, err
+	}
+	svc.l.Info("created api key", zap.String("account_id", req.AccountId), zap.String("api_key_id", key.Id), zap.String("created_by", token.SubjectId))
+
+	return domain.CreateApiKeyRes{
+		Id:     key.Id,
+		ApiKey: key.ApiKey,
+		Scopes: scopes,
+	}, nil
+}
+
+func (svc *Auth) addApiKey(ctx context.Context, accountId, name string, scopes []string) (domain.CreateApiKeyRes, error) {
+	in := domain.ApiKeysAddDTOInput{
+		Id:        entity.Id(10),
+		AccountId: accountId,
+		Name:      name,
+		Secret:    entity.Id(32),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+	if err := svc.apiKeys.Add(ctx, in); err != nil {
+		svc.l.Error("failed to add api key", zap.String("account_id", accountId), zap.Error(err))
+		return domain.CreateApiKeyRes{}, err
+	}
+
+	return domain.CreateApiKeyRes{
+		Id:     in.Id,
+		ApiKey: domain.FormatApiKey(in.Id, in.Secret),
+		Scopes: scopes,
+	}, nil
+}
+
+func (svc *Auth) ListApiKeys(ctx context.Context, req domain.ListApiKeysReq) (domain.ListApiKeysRes, error) {
+	if svc.apiKeys == nil {
+		return domain.ListApiKeysRes{}, errApiKeysUnavailable
+	}
+
+	if _, err := svc.prepareServiceAccountManagement(ctx, req.AccessToken, req.AccountId); err != nil {
+		return domain.ListApiKeysRes{}, err
+	}
+
+	keys, err := svc.apiKeys.List(ctx, req.AccountId)
+	if err != nil {
+		svc.l.Error("failed to list api keys", zap.String("account_id", req.AccountId), zap.Error(err))
+		return domain.ListApiKeysRes{}, err
+	}
+
+	res := domain.ListApiKeysRes{
+		ApiKeys: make([]domain.ApiKeyInfo, 0, len(keys)),
+	}
+	for _, key := range keys {
+		info := domain.ApiKeyInfo{
+			Id:         key.Id,
+			Name:       key.Name,
+			Scopes:     key.Scopes,
+			CreatedAt:  key.CreatedAt,
+			LastUsedAt: key.LastUsedAt,
+		}
+		if !key.ExpiresAt.IsZero() {
+			info.ExpiresAt = &key.ExpiresAt
+		}
+		res.ApiKeys = append(res.ApiKeys, info)
+	}
+	return res, nil
+}
+
+// Returns domain.ErrApiKeyNotFound if there's no key with the id or the key has expired.
+func (svc *Auth) findApiKey(ctx context.Context, id string) (*domain.ApiKey, error) {
+	key, err := svc.apiKeys.Get(ctx, id)
+	if err != nil {
+		svc.l.Error("failed to get api key", zap.String("api_key_id", id), zap.Error(err))
+		return nil, err
+	}
+	if key == nil || key.Expired(time.Now()) {
+		return nil, domain.ErrApiKeyNotFound
+	}
+	return key, nil
+}
+
+func (svc *Auth) RotateApiKey(ctx context.Context, req domain.RotateApiKeyReq) (domain.RotateApiKeyRes, error) {
+	if svc.apiKeys == nil {
+		return domain.RotateApiKeyRes{}, errApiKeysUnavailable
+	}
+
+	token, err := svc.authorize(ctx, req.AccessToken, domain.ActionManageServiceAccounts)
+	if err != nil {
+		return domain.RotateApiKeyRes{}, err
+	}
+
+	old, err := svc.findApiKey(ctx, req.ApiKeyId)
+	if err != nil {
+		return domain.RotateApiKeyRes{}, err
+	}
+
+	key, err := svc.addApiKey(ctx, old.AccountId, old.Name, old.Scopes)
+	if err != nil {
+		return domain.RotateApiKeyRes{}, err
+	}
+
+	// Rotating a key again must not extend the life of the one being rotated out.
+	expiresAt := time.Now().Add(svc.apiKeyRotationGracePeriod)
+	if !old.ExpiresAt.IsZero() && old.ExpiresAt.Before(expiresAt) {
+		expiresAt = old.ExpiresAt
+	}
+	if err := svc.apiKeys.Expire(ctx, domain.ApiKeysExpireDTOInput{
+		Id:        old.Id,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		svc.l.Error("failed to expire rotated api key", zap.String("api_key_id", old.Id), zap.Error(err))
+		return domain.RotateApiKeyRes{}, err
+	}
+	svc.l.Info(
+		"rotated api key",
+		zap.String("account_id", old.AccountId),
+		zap.String("api_key_id", old.Id),
+		zap.String("new_api_key_id", key.Id),
+		zap.String("rotated_by", token.SubjectId),
+	)
+
+	return domain.RotateApiKeyRes{
+		Id:                key.Id,
+		ApiKey:            key.ApiKey,
+		PreviousExpiresAt: expiresAt,
+	}, nil
+}
+
+func (svc *Auth) RevokeApiKey(ctx context.Context, req domain.RevokeApiKeyReq) (domain.RevokeApiKeyRes, error) {
+	if svc.apiKeys == nil {
+		return domain.RevokeApiKeyRes{}, errApiKeysUnavailable
+	}
+
+	token, err := svc.authorize(ctx, req.AccessToken, domain.ActionManageServiceAccounts)
+	if err != nil {
+		return domain.RevokeApiKeyRes{}, err
+	}
+
+	deleted, err := svc.apiKeys.Delete(ctx, req.ApiKeyId)
+	if err != nil {
+		svc.l.Error("failed to delete api key", zap.String("api_key_id", req.ApiKeyId), zap.Error(err))
+		return domain.RevokeApiKeyRes{}, err
+	}
+	if !deleted {
+		return domain.RevokeApiKeyRes{}, domain.ErrApiKeyNotFound
+	}
+	svc.l.Info("revoked api key", zap.String("api_key_id", req.ApiKeyId), zap.String("revoked_by", token.SubjectId))
+
+	return domain.RevokeApiKeyRes{}, nil
+}
+
+// Access tokens of service accounts carry the requested scopes, there are no refresh tokens:
+// the service exchanges the api key again once the access token expires.
+func (svc *Auth) ExchangeApiKey(ctx context.Context, req domain.ExchangeApiKeyReq) (domain.ExchangeApiKeyRes, error) {
+	if svc.apiKeys == nil {
+		return domain.ExchangeApiKeyRes{}, errApiKeysUnavailable
+	}
+
+	loginAttemptsKeys := svc.loginAttemptsKeys("", req.Ip)
+	if _, err := svc.checkLoginThrottling(ctx, loginAttemptsKeys); err != nil {
+		return domain.ExchangeApiKeyRes{}, err
+	}
+
+	key, err := svc.checkApiKey(ctx, req.ApiKey)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidApiKey) {
+			if err := svc.registerLoginFailure(ctx, loginAttemptsKeys); err != nil {
+				return domain.ExchangeApiKeyRes{}, err
+			}
+		}
+		return domain.ExchangeApiKeyRes{}, err
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: key.AccountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account of api key", zap.String("account_id", key.AccountId), zap.Error(err))
+		return domain.ExchangeApiKeyRes{}, err
+	}
+	if acc == nil || acc.Type != domain.AccountTypeService {
+		// Account has been deleted or converted.
+		return domain.ExchangeApiKeyRes{}, domain.ErrInvalidApiKey
+	}
+	if !acc.SuspendedAt.IsZero() {
+		svc.l.Info("rejected creating access token for suspended account", zap.String("account_id", key.AccountId))
+		return domain.ExchangeApiKeyRes{}, domain.ErrAccountSuspended
+	}
+
+	scopes := key.Scopes
+	if requested := strings.Fields(req.Scope); len(requested) > 0 {
+		for _, scope := range requested {
+			if !slices.Contains(key.Scopes, scope) {
+				return domain.ExchangeApiKeyRes{}, domain.ErrInvalidScope
+			}
+		}
+		scopes = normalizeScopes(requested)
+	}
+
+	if err := svc.apiKeys.Touch(ctx, domain.ApiKeysTouchDTOInput{
+		Id:         key.Id,
+		LastUsedAt: time.Now(),
+	}); err != nil {
+		// Api key last usage time is informational only.
+		svc.l.Error("failed to touch api key", zap.String("api_key_id", key.Id), zap.Error(err))
+	}
+
+	accessToken := domain.AccessToken{
+		SubjectId:   key.AccountId,
+		SubjectType: domain.AccountTypeService,
+		Scopes:      scopes,
+		Permissions: scopes,
+		ExpiresAt:   time.Now().Add(svc.accessTokenDuration),
+	}
+	token, err := svc.tokenProv.EncodeAccess(accessToken)
+	if err != nil {
+		svc.l.Error("failed to encode access token", zap.Error(err))
+		return domain.ExchangeApiKeyRes{}, err
+	}
+
+	return domain.ExchangeApiKeyRes{
+		AccessToken: token,
+		Scope:       strings.Join(scopes, " "),
+		ExpiresAt:   accessToken.ExpiresAt,
+	}, nil
+}
+
+// Returns domain.ErrInvalidApiKey if the key is malformed, unknown, expired or its secret doesn't match.
+func (svc *Auth) checkApiKey(ctx context.Context, apiKey string) (*domain.ApiKey, error) {
+	id, secret, ok := domain.ParseApiKey(apiKey)
+	if !ok {
+		return nil, domain.ErrInvalidApiKey
+	}
+
+	key, err := svc.apiKeys.Get(ctx, id)
+	if err != nil {
+		svc.l.Error("failed to get api key", zap.String("api_key_id", id), zap.Error(err))
+		return nil, err
+	}
+	if key == nil || key.Expired(time.Now()) {
+		return nil, domain.ErrInvalidApiKey
+	}
+
+	ok, err = svc.apiKeys.CheckSecret(ctx, domain.ApiKeysCheckSecretDTOInput{
+		Id:     id,
+		Secret: secret,
+	})
+	if err != nil {
+		svc.l.Error("failed to check api key secret", zap.String("api_key_id", id), zap.Error(err))
+		return nil, err
+	}
+	if !ok {
+		svc.l.Info("rejected api key with invalid secret", zap.String("api_key_id", id))
+		return nil, domain.ErrInvalidApiKey
+	}
+
+	return key, nil
+}
+
+// Sorted scopes without duplicates.
+func normalizeScopes(scopes []string) []string {
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	return slices.Compact(scopes)
+}
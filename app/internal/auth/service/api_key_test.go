@@ -0,0 +1,181 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newApiKeyTestAuth(t *testing.T, b *service.AuthBuilder) (*service.Auth, *fakeAccountProvider, *fakeTokenProvider, string) {
+	t.Helper()
+
+	svc, accProv, tokenProv := newTestAuthWithTokens(t, b.ApiKeys(memory_adapter.NewApiKeys()))
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+	}
+
+	res, err := svc.CreateServiceAccount(context.Background(), domain.CreateServiceAccountReq{
+		AccessToken: tokenAdmin,
+		Name:        "products-service",
+	})
+	require.NoError(t, err)
+
+	return svc, accProv, tokenProv, res.Id
+}
+
+func createApiKey(t *testing.T, svc *service.Auth, accountId string, scopes ...string) domain.CreateApiKeyRes {
+	t.Helper()
+
+	res, err := svc.CreateApiKey(context.Background(), domain.CreateApiKeyReq{
+		AccessToken: tokenAdmin,
+		AccountId:   accountId,
+		Name:        "ci",
+		Scopes:      scopes,
+	})
+	require.NoError(t, err)
+	return res
+}
+
+func TestCreateServiceAccount(t *testing.T) {
+	_, accProv, _, _ := newApiKeyTestAuth(t, service.NewAuthBuilder())
+
+	require.Len(t, accProv.created, 1)
+	assert.Equal(t, domain.AccountTypeService, accProv.created[0].Type)
+	assert.Equal(t, "products-service@service.invalid", accProv.created[0].Email)
+	assert.True(t, accProv.created[0].Activated, "service accounts have no mailbox to confirm")
+}
+
+func TestCreateServiceAccountRejectsInvalidRequests(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, _ := newApiKeyTestAuth(t, service.NewAuthBuilder())
+
+	_, err := svc.CreateServiceAccount(ctx, domain.CreateServiceAccountReq{AccessToken: tokenUser, Name: "jobs"})
+	assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+
+	for _, name := range []string{"", "Products", "products service", "-products", "products@example.com"} {
+		_, err := svc.CreateServiceAccount(ctx, domain.CreateServiceAccountReq{AccessToken: tokenAdmin, Name: name})
+		assert.ErrorIs(t, err, domain.ErrInvalidServiceAccountName, name)
+	}
+}
+
+func TestCreateApiKeyRejectsNonServiceAccounts(t *testing.T) {
+	svc, _, _, _ := newApiKeyTestAuth(t, service.NewAuthBuilder())
+
+	_, err := svc.CreateApiKey(context.Background(), domain.CreateApiKeyReq{
+		AccessToken: tokenAdmin,
+		AccountId:   "user-id",
+		Name:        "ci",
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidAccountType)
+}
+
+func TestExchangeApiKeyIssuesScopedAccessToken(t *testing.T) {
+	ctx := context.Background()
+	svc, _, tokenProv, accountId := newApiKeyTestAuth(t, service.NewAuthBuilder())
+
+	key := createApiKey(t, svc, accountId, "products:write", "products:read", "products:read")
+	assert.True(t, strings.HasPrefix(key.ApiKey, "flk_"+key.Id+"_"))
+	assert.Equal(t, []string{"products:read", "products:write"}, key.Scopes)
+
+	res, err := svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: key.ApiKey})
+	require.NoError(t, err)
+	assert.Equal(t, "products:read products:write", res.Scope, "all key scopes are granted by default")
+
+	res, err = svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: key.ApiKey, Scope: "products:read"})
+	require.NoError(t, err)
+	assert.Equal(t, "products:read", res.Scope)
+
+	require.Len(t, tokenProv.encodedAccessTokens, 2)
+	token := tokenProv.encodedAccessTokens[1]
+	assert.Equal(t, accountId, token.SubjectId)
+	assert.Equal(t, domain.AccountTypeService, token.SubjectType)
+	assert.Equal(t, []string{"products:read"}, token.Scopes)
+
+	_, err = svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: key.ApiKey, Scope: "products:read orders:read"})
+	assert.ErrorIs(t, err, domain.ErrInvalidScope, "scopes beyond the key ones must not be granted")
+
+	keys, err := svc.ListApiKeys(ctx, domain.ListApiKeysReq{AccessToken: tokenAdmin, AccountId: accountId})
+	require.NoError(t, err)
+	require.Len(t, keys.ApiKeys, 1)
+	assert.False(t, keys.ApiKeys[0].LastUsedAt.IsZero())
+	assert.Nil(t, keys.ApiKeys[0].ExpiresAt)
+}
+
+func TestExchangeApiKeyRejectsInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	svc, accProv, _, accountId := newApiKeyTestAuth(t, service.NewAuthBuilder())
+	key := createApiKey(t, svc, accountId)
+
+	for _, apiKey := range []string{"", "garbage", "flk_" + key.Id, "flk_" + key.Id + "_wrongsecret", "flk_unknown_secret"} {
+		_, err := svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: apiKey})
+		assert.ErrorIs(t, err, domain.ErrInvalidApiKey, apiKey)
+	}
+
+	accProv.byId[accountId].SuspendedAt = time.Now()
+	_, err := svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: key.ApiKey})
+	assert.ErrorIs(t, err, domain.ErrAccountSuspended)
+	accProv.byId[accountId].SuspendedAt = time.Time{}
+
+	_, err = svc.RevokeApiKey(ctx, domain.RevokeApiKeyReq{AccessToken: tokenAdmin, ApiKeyId: key.Id})
+	require.NoError(t, err)
+	_, err = svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: key.ApiKey})
+	assert.ErrorIs(t, err, domain.ErrInvalidApiKey, "revoked keys must be rejected")
+
+	_, err = svc.RevokeApiKey(ctx, domain.RevokeApiKeyReq{AccessToken: tokenAdmin, ApiKeyId: key.Id})
+	assert.ErrorIs(t, err, domain.ErrApiKeyNotFound)
+}
+
+func TestRotateApiKey(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("old key works during grace period", func(t *testing.T) {
+		svc, _, _, accountId := newApiKeyTestAuth(t, service.NewAuthBuilder())
+		old := createApiKey(t, svc, accountId, "products:read")
+
+		res, err := svc.RotateApiKey(ctx, domain.RotateApiKeyReq{AccessToken: tokenAdmin, ApiKeyId: old.Id})
+		require.NoError(t, err)
+		assert.NotEqual(t, old.ApiKey, res.ApiKey)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), res.PreviousExpiresAt, time.Minute)
+
+		for _, apiKey := range []string{old.ApiKey, res.ApiKey} {
+			exchanged, err := svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: apiKey})
+			require.NoError(t, err)
+			assert.Equal(t, "products:read", exchanged.Scope, "rotated key keeps the scopes")
+		}
+	})
+
+	t.Run("old key expires after grace period", func(t *testing.T) {
+		svc, _, _, accountId := newApiKeyTestAuth(t, service.NewAuthBuilder().ApiKeyRotationGracePeriod(0))
+		old := createApiKey(t, svc, accountId)
+
+		res, err := svc.RotateApiKey(ctx, domain.RotateApiKeyReq{AccessToken: tokenAdmin, ApiKeyId: old.Id})
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+
+		_, err = svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: old.ApiKey})
+		assert.ErrorIs(t, err, domain.ErrInvalidApiKey)
+		_, err = svc.ExchangeApiKey(ctx, domain.ExchangeApiKeyReq{ApiKey: res.ApiKey})
+		require.NoError(t, err)
+
+		_, err = svc.RotateApiKey(ctx, domain.RotateApiKeyReq{AccessToken: tokenAdmin, ApiKeyId: old.Id})
+		assert.ErrorIs(t, err, domain.ErrApiKeyNotFound, "expired keys can't be rotated")
+	})
+}
+
+func TestUpdateAccountTypeRejectsServiceAccounts(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, accountId := newApiKeyTestAuth(t, service.NewAuthBuilder())
+
+	_, err := svc.UpdateAccountType(ctx, domain.UpdateAccountTypeReq{AccessToken: tokenAdmin, AccountId: accountId, Type: domain.AccountTypeAdmin})
+	assert.ErrorIs(t, err, domain.ErrInvalidAccountType)
+
+	_, err = svc.UpdateAccountType(ctx, domain.UpdateAccountTypeReq{AccessToken: tokenAdmin, AccountId: "user-id", Type: domain.AccountTypeService})
+	assert.ErrorIs(t, err, domain.ErrInvalidAccountType)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/entity"
+	"go.uber.org/zap"
+)
+
+var errAuditLogUnavailable = errors.New("audit log is unavailable: audit log not set")
+
+// Authentication methods recorded to the audit log.
+const (
+	authMethodPassword = "password"
+	// Password followed by the second factor.
+	authMethodMfa      = "mfa"
+	authMethodPasskey  = "passkey"
+	authMethodIdentity = "identity"
+)
+
+func newAuditEvent(eventType domain.AuditEventType, accountId string, details map[string]string) domain.AuditEvent {
+	return domain.AuditEvent{
+		Id:        entity.Id(20),
+		Type:      eventType,
+		AccountId: accountId,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Record the event to the audit log if it is set. Failures are only logged not to fail the audited operation.
+func (svc *Auth) audit(ctx context.Context, event domain.AuditEvent) {
+	if svc.auditLog == nil {
+		return
+	}
+	if err := svc.auditLog.Append(ctx, event); err != nil {
+		svc.l.Error("failed to append audit event", zap.String("type", event.Type), zap.String("account_id", event.AccountId), zap.Error(err))
+	}
+}
+
+// Accounts are activated by email, their ids are looked up for the audit log.
+func (svc *Auth) auditAccountActivations(ctx context.Context, emails []string) {
+	for _, email := range emails {
+		acc, err := svc.accProv.FindAccountByEmail(ctx, domain.FindAccountByEmailDTOInput{
+			Email: email,
+		})
+		if err != nil {
+			svc.l.Error("failed to find activated account for audit log", zap.String("email", email), zap.Error(err))
+			continue
+		}
+		if acc == nil {
+			continue
+		}
+		svc.audit(ctx, newAuditEvent(domain.AuditEventAccountActivation, acc.Id, map[string]string{
+			"email": email,
+		}))
+	}
+}
+
+const (
+	defaultListAuditEventsPageSize = 50
+	maxListAuditEventsPageSize     = 100
+)
+
+func (svc *Auth) ListAuditEvents(ctx context.Context, req domain.ListAuditEventsReq) (domain.ListAuditEventsRes, error) {
+	if svc.auditLog == nil {
+		return domain.ListAuditEventsRes{}, errAuditLogUnavailable
+	}
+
+	if _, err := svc.authorize(ctx, req.AccessToken, domain.ActionReadAuditLog); err != nil {
+		return domain.ListAuditEventsRes{}, err
+	}
+
+	if req.Type != "" {
+		if err := domain.ValidateAuditEventType(req.Type); err != nil {
+			return domain.ListAuditEventsRes{}, err
+		}
+	}
+	var before *domain.AuditLogCursor
+	if req.PageToken != "" {
+		cursor, err := decodeAuditLogPageToken(req.PageToken)
+		if err != nil {
+			return domain.ListAuditEventsRes{}, err
+		}
+		before = &cursor
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultListAuditEventsPageSize
+	}
+	pageSize = min(pageSize, maxListAuditEventsPageSize)
+
+	// One more event is listed to find out whether there is a next page.
+	events, err := svc.auditLog.List(ctx, domain.AuditLogListDTOInput{
+		AccountId: req.AccountId,
+		Type:      req.Type,
+		Since:     req.Since,
+		Until:     req.Until,
+		Before:    before,
+		Limit:     pageSize + 1,
+	})
+	if err != nil {
+		svc.l.Error("failed to list audit events", zap.Error(err))
+		return domain.ListAuditEventsRes{}, err
+	}
+
+	var nextPageToken string
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		last := events[len(events)-1]
+		nextPageToken = encodeAuditLogPageToken(domain.AuditLogCursor{CreatedAt: last.CreatedAt, Id: last.Id})
+	}
+
+	res := domain.ListAuditEventsRes{
+		Events:        make([]domain.AuditEventInfo, 0, len(events)),
+		NextPageToken: nextPageToken,
+	}
+	for _, event := range events {
+		details := event.Details
+		if details == nil {
+			details = map[string]string{}
+		}
+		res.Events = append(res.Events, domain.AuditEventInfo{
+			Id:        event.Id,
+			Type:      event.Type,
+			AccountId: event.AccountId,
+			ActorId:   event.ActorId,
+			Details:   details,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// Page token is "<creation time in unix microseconds>.<event id>" of the last event of the page.
+func encodeAuditLogPageToken(cursor domain.AuditLogCursor) string {
+	return strconv.FormatInt(cursor.CreatedAt.UnixMicro(), 10) + "." + cursor.Id
+}
+
+func decodeAuditLogPageToken(token string) (domain.AuditLogCursor, error) {
+	micros, id, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return domain.AuditLogCursor{}, domain.ErrInvalidPageToken
+	}
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return domain.AuditLogCursor{}, fmt.Errorf("%w: %w", domain.ErrInvalidPageToken, err)
+	}
+	return domain.AuditLogCursor{CreatedAt: time.UnixMicro(usec), Id: id}, nil
+}
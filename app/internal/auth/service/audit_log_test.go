@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditLogTestAuth(t *testing.T) (*service.Auth, *fakeAccountProvider, *fakeTokenProvider, *memory_adapter.AuditLog) {
+	t.Helper()
+
+	auditLog := memory_adapter.NewAuditLog()
+	svc, accProv, tokenProv := newTestAuthWithTokens(t, service.NewAuthBuilder().AuditLog(auditLog))
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+	}
+	accProv.byEmail = map[string]*domain.FindAccountByEmailDTOOutput{
+		"user@example.com": {Id: "user-id", Name: "user", Type: domain.AccountTypeUser, Activated: true},
+	}
+	accProv.passwords = map[string]string{"user-id": "password"}
+
+	return svc, accProv, tokenProv, auditLog
+}
+
+func listAuditEvents(t *testing.T, auditLog domain.AuditLog, in domain.AuditLogListDTOInput) []domain.AuditEvent {
+	t.Helper()
+	events, err := auditLog.List(context.Background(), in)
+	require.NoError(t, err)
+	return events
+}
+
+func TestAuditLogRecordsAuthentication(t *testing.T) {
+	ctx := context.Background()
+	svc, _, tokenProv, auditLog := newAuditLogTestAuth(t)
+
+	_, err := svc.Authenticate(ctx, domain.AuthenticateReq{Email: "user@example.com", Password: "wrong", Ip: "10.0.0.1"})
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	assert.Empty(t, listAuditEvents(t, auditLog, domain.AuditLogListDTOInput{}), "failed logins are not audited as authentications")
+
+	_, err = svc.Authenticate(ctx, domain.AuthenticateReq{Email: "user@example.com", Password: "password", Ip: "10.0.0.1", UserAgent: "curl"})
+	require.NoError(t, err)
+
+	tokenProv.refreshTokens = map[string]domain.RefreshToken{
+		"refresh-token-id": {Id: "token-id", SubjectId: "user-id"},
+	}
+	_, err = svc.CreateAccessToken(ctx, domain.CreateAccessTokenReq{RefreshToken: "refresh-token-id"})
+	require.NoError(t, err)
+
+	events := listAuditEvents(t, auditLog, domain.AuditLogListDTOInput{AccountId: "user-id"})
+	require.Len(t, events, 2)
+	assert.Equal(t, domain.AuditEventAccessTokenCreation, events[0].Type, "newest events are listed first")
+	assert.Equal(t, "token-id", events[0].Details["refresh_token_id"])
+
+	assert.Equal(t, domain.AuditEventAuthentication, events[1].Type)
+	assert.Equal(t, "password", events[1].Details["method"])
+	assert.Equal(t, "10.0.0.1", events[1].Details["ip"])
+	assert.Equal(t, "curl", events[1].Details["user_agent"])
+}
+
+func TestAuditLogRecordsAccountCreation(t *testing.T) {
+	ctx := context.Background()
+	svc, accProv, _, auditLog := newAuditLogTestAuth(t)
+
+	seller, err := svc.CreateSeller(ctx, newCreateSellerReq(tokenAdmin))
+	require.NoError(t, err)
+
+	_, err = svc.CreateAdmin(ctx, domain.CreateAdminReq{Name: "admin", Email: "admin@example.com", Password: "password"})
+	require.NoError(t, err)
+
+	accProv.byEmail["seller@example.com"] = &domain.FindAccountByEmailDTOOutput{Id: seller.Id, Type: domain.AccountTypeSeller}
+	_, err = svc.ActivateAccounts(ctx, domain.ActivateAccountsReq{Emails: []string{"seller@example.com", "unknown@example.com"}})
+	require.NoError(t, err)
+
+	events := listAuditEvents(t, auditLog, domain.AuditLogListDTOInput{Type: domain.AuditEventSellerCreation})
+	require.Len(t, events, 1)
+	assert.Equal(t, seller.Id, events[0].AccountId)
+	assert.Equal(t, "admin-id", events[0].ActorId)
+
+	assert.Len(t, listAuditEvents(t, auditLog, domain.AuditLogListDTOInput{Type: domain.AuditEventAdminCreation}), 1)
+
+	events = listAuditEvents(t, auditLog, domain.AuditLogListDTOInput{Type: domain.AuditEventAccountActivation})
+	require.Len(t, events, 1, "accounts that are not found are not audited")
+	assert.Equal(t, seller.Id, events[0].AccountId)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, auditLog := newAuditLogTestAuth(t)
+
+	start := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	for i, eventType := range []domain.AuditEventType{
+		domain.AuditEventAuthentication,
+		domain.AuditEventAccessTokenCreation,
+		domain.AuditEventAuthentication,
+		domain.AuditEventRefreshTokenRotation,
+		domain.AuditEventAuthentication,
+	} {
+		require.NoError(t, auditLog.Append(ctx, domain.AuditEvent{
+			Id:        string(rune('a' + i)),
+			Type:      eventType,
+			AccountId: "user-id",
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+	require.NoError(t, auditLog.Append(ctx, domain.AuditEvent{Id: "z", Type: domain.AuditEventAuthentication, AccountId: "other-id", CreatedAt: start}))
+
+	req := domain.ListAuditEventsReq{
+		AccessToken: tokenAdmin,
+		AccountId:   "user-id",
+		Type:        domain.AuditEventAuthentication,
+		PageSize:    2,
+	}
+	res, err := svc.ListAuditEvents(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, res.Events, 2)
+	assert.Equal(t, "e", res.Events[0].Id)
+	assert.Equal(t, "c", res.Events[1].Id)
+	assert.NotNil(t, res.Events[0].Details)
+	require.NotEmpty(t, res.NextPageToken)
+
+	req.PageToken = res.NextPageToken
+	res, err = svc.ListAuditEvents(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, res.Events, 1)
+	assert.Equal(t, "a", res.Events[0].Id)
+	assert.Empty(t, res.NextPageToken)
+
+	res, err = svc.ListAuditEvents(ctx, domain.ListAuditEventsReq{
+		AccessToken: tokenAdmin,
+		Since:       start.Add(time.Hour),
+		Until:       start.Add(3 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Events, 2, "until is exclusive")
+	assert.Equal(t, "c", res.Events[0].Id)
+	assert.Equal(t, "b", res.Events[1].Id)
+}
+
+func TestListAuditEventsRejectsInvalidRequests(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, _ := newAuditLogTestAuth(t)
+
+	_, err := svc.ListAuditEvents(ctx, domain.ListAuditEventsReq{AccessToken: tokenUser})
+	assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+
+	_, err = svc.ListAuditEvents(ctx, domain.ListAuditEventsReq{AccessToken: tokenAdmin, Type: "unknown"})
+	assert.ErrorIs(t, err, domain.ErrInvalidAuditEventType)
+
+	for _, pageToken := range []string{"garbage", "123.", "abc.id"} {
+		_, err = svc.ListAuditEvents(ctx, domain.ListAuditEventsReq{AccessToken: tokenAdmin, PageToken: pageToken})
+		assert.ErrorIs(t, err, domain.ErrInvalidPageToken, pageToken)
+	}
+
+	svc, _ = newTestAuth(t)
+	_, err = svc.ListAuditEvents(ctx, domain.ListAuditEventsReq{AccessToken: tokenAdmin})
+	assert.Error(t, err, "audit log must be unavailable if not set")
+}
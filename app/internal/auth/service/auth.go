@@ -5,21 +5,74 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/bratushkadan/floral/pkg/entity"
 	"go.uber.org/zap"
 )
 
 type Auth struct {
 	accProv                     domain.AccountProvider
 	accCreationNotificationProv domain.AccountCreationNotifications
+	accCreationOutbox           domain.AccountCreationOutbox
+	accessTokenRevocations      domain.AccessTokenRevocations
+	securityEventProv           domain.SecurityEventNotifications
 	refreshTokenProv            domain.RefreshTokenProvider
 	tokenProv                   domain.TokenProvider
+	authz                       domain.Authorizer
+	rolePermissions             domain.RolePermissions
+	passwordResetTokens         domain.PasswordResetTokens
+	passwordResetSender         domain.PasswordResetSender
+	emailConfirmationTokens     domain.EmailConfirmationTokens
+	loginAttempts               domain.LoginAttempts
+	totp                        auth.Totp
+	passkeyCredentials          domain.PasskeyCredentials
+	passkeyCeremonies           domain.PasskeyCeremonies
+	passkeyRelyingParty         domain.PasskeyRelyingParty
+	oauthClients                domain.OAuthClients
+	authorizationCodes          domain.AuthorizationCodes
+	identities                  domain.Identities
+	identityLogins              domain.IdentityLogins
+	// identity providers by name
+	identityProviders map[string]domain.IdentityProvider
+	apiKeys           domain.ApiKeys
+	auditLog          domain.AuditLog
 
 	// token TTL for rows is also applied to the provider's refresh_tokens YDB table
 	refreshTokenDuration time.Duration
 	accessTokenDuration  time.Duration
+	// password reset token TTL, the link sent to the account email expires after it
+	passwordResetTokenDuration time.Duration
+	// deleted accounts are purged after the grace period
+	accountDeletionGracePeriod time.Duration
+	// time given to enter the MFA code after the password is verified
+	mfaChallengeTokenDuration time.Duration
+	// issuer displayed by authenticator apps
+	mfaIssuer string
+	// time given to finish passkey registration or login once started
+	passkeyCeremonyDuration time.Duration
+	// OpenID Connect issuer identifier, the url the provider metadata is served under
+	oidcIssuer string
+	// login page OpenID Connect clients redirect to, it calls Authorize once the user is signed in
+	oidcAuthorizationUrl string
+	// time given to the client to exchange the authorization code
+	authorizationCodeDuration time.Duration
+	// time given to log in with the identity provider once started
+	identityLoginDuration time.Duration
+	// time clients are given to switch to the new api key once the old one is rotated
+	apiKeyRotationGracePeriod time.Duration
+	// impersonation access token TTL, kept short since no refresh token is issued for support sessions
+	impersonationTokenDuration time.Duration
+
+	// amount of refresh tokens (sessions) an account of the type may hold at once
+	refreshTokensLimits         map[domain.AccountType]int
+	refreshTokensEvictionPolicy domain.RefreshTokenEvictionPolicy
+
+	// throttling of failed authentication attempts per email and per client ip
+	loginThrottlingPolicies map[domain.LoginAttemptsKeyKind]domain.LoginThrottlingPolicy
 
 	l *zap.Logger
 }
@@ -38,6 +91,27 @@ func (b *AuthBuilder) AccountCreationNotificationProvider(prov domain.AccountCre
 	b.auth.accCreationNotificationProv = prov
 	return b
 }
+
+// Account creation notifications are enqueued in the account creation transaction
+// and published by RelayAccountCreationNotifications instead of being sent right away.
+// The account provider must support enqueueing them.
+func (b *AuthBuilder) AccountCreationOutbox(outbox domain.AccountCreationOutbox) *AuthBuilder {
+	b.auth.accCreationOutbox = outbox
+	return b
+}
+
+// Optional. Access tokens are rejected once revoked, e.g. on account suspension,
+// instead of being valid until they expire.
+func (b *AuthBuilder) AccessTokenRevocations(revocations domain.AccessTokenRevocations) *AuthBuilder {
+	b.auth.accessTokenRevocations = revocations
+	return b
+}
+
+// Optional. Security events are only logged if the provider is not set.
+func (b *AuthBuilder) SecurityEventNotificationProvider(prov domain.SecurityEventNotifications) *AuthBuilder {
+	b.auth.securityEventProv = prov
+	return b
+}
 func (b *AuthBuilder) RefreshTokenProvider(prov domain.RefreshTokenProvider) *AuthBuilder {
 	b.auth.refreshTokenProv = prov
 	return b
@@ -47,6 +121,102 @@ func (b *AuthBuilder) TokenProvider(prov domain.TokenProvider) *AuthBuilder {
 	return b
 }
 
+// Optional. Password reset is unavailable if either of password reset tokens or sender is not set.
+func (b *AuthBuilder) PasswordResetTokens(prov domain.PasswordResetTokens) *AuthBuilder {
+	b.auth.passwordResetTokens = prov
+	return b
+}
+func (b *AuthBuilder) PasswordResetSender(prov domain.PasswordResetSender) *AuthBuilder {
+	b.auth.passwordResetSender = prov
+	return b
+}
+
+// Optional. Used to export account email confirmations and purge them along with deleted accounts.
+func (b *AuthBuilder) EmailConfirmationTokens(prov domain.EmailConfirmationTokens) *AuthBuilder {
+	b.auth.emailConfirmationTokens = prov
+	return b
+}
+
+// Optional. Failed authentication attempts are not throttled if not set.
+func (b *AuthBuilder) LoginAttempts(prov domain.LoginAttempts) *AuthBuilder {
+	b.auth.loginAttempts = prov
+	return b
+}
+
+// Optional. Passkeys are unavailable if any of passkey credentials, ceremonies or relying party is not set.
+func (b *AuthBuilder) PasskeyCredentials(prov domain.PasskeyCredentials) *AuthBuilder {
+	b.auth.passkeyCredentials = prov
+	return b
+}
+func (b *AuthBuilder) PasskeyCeremonies(prov domain.PasskeyCeremonies) *AuthBuilder {
+	b.auth.passkeyCeremonies = prov
+	return b
+}
+func (b *AuthBuilder) PasskeyRelyingParty(rp domain.PasskeyRelyingParty) *AuthBuilder {
+	b.auth.passkeyRelyingParty = rp
+	return b
+}
+
+// Optional. OpenID Connect provider is unavailable if any of OAuth clients, authorization codes,
+// issuer or authorization url is not set.
+func (b *AuthBuilder) OAuthClients(prov domain.OAuthClients) *AuthBuilder {
+	b.auth.oauthClients = prov
+	return b
+}
+func (b *AuthBuilder) AuthorizationCodes(prov domain.AuthorizationCodes) *AuthBuilder {
+	b.auth.authorizationCodes = prov
+	return b
+}
+func (b *AuthBuilder) OidcIssuer(issuer string) *AuthBuilder {
+	b.auth.oidcIssuer = issuer
+	return b
+}
+func (b *AuthBuilder) OidcAuthorizationUrl(url string) *AuthBuilder {
+	b.auth.oidcAuthorizationUrl = url
+	return b
+}
+
+// Optional. Login with identity providers is unavailable if either of identities or identity logins
+// is not set or no identity provider is registered.
+func (b *AuthBuilder) Identities(prov domain.Identities) *AuthBuilder {
+	b.auth.identities = prov
+	return b
+}
+func (b *AuthBuilder) IdentityLogins(prov domain.IdentityLogins) *AuthBuilder {
+	b.auth.identityLogins = prov
+	return b
+}
+
+// Register the identity provider under the name users pick it by, e.g. "google".
+func (b *AuthBuilder) IdentityProvider(name string, prov domain.IdentityProvider) *AuthBuilder {
+	b.auth.identityProviders[name] = prov
+	return b
+}
+
+// Optional. Service accounts are unavailable if not set.
+func (b *AuthBuilder) ApiKeys(prov domain.ApiKeys) *AuthBuilder {
+	b.auth.apiKeys = prov
+	return b
+}
+
+// Audit trail of security relevant events. Impersonation is unavailable without it.
+func (b *AuthBuilder) AuditLog(prov domain.AuditLog) *AuthBuilder {
+	b.auth.auditLog = prov
+	return b
+}
+
+// Authorizer of privileged operations. Only admins are authorized to perform them by default.
+func (b *AuthBuilder) Authorizer(authz domain.Authorizer) *AuthBuilder {
+	b.auth.authz = authz
+	return b
+}
+
+// Permission sets put into access tokens. Permissions of DefaultRolePermissions are granted by default.
+func (b *AuthBuilder) RolePermissions(prov domain.RolePermissions) *AuthBuilder {
+	b.auth.rolePermissions = prov
+	return b
+}
+
 func (b *AuthBuilder) RefreshTokenDuration(dur time.Duration) *AuthBuilder {
 	b.auth.refreshTokenDuration = dur
 	return b
@@ -56,19 +226,154 @@ func (b *AuthBuilder) AccessTokenDuration(dur time.Duration) *AuthBuilder {
 	return b
 }
 
+func (b *AuthBuilder) PasswordResetTokenDuration(dur time.Duration) *AuthBuilder {
+	b.auth.passwordResetTokenDuration = dur
+	return b
+}
+
+func (b *AuthBuilder) AccountDeletionGracePeriod(dur time.Duration) *AuthBuilder {
+	b.auth.accountDeletionGracePeriod = dur
+	return b
+}
+
+func (b *AuthBuilder) MfaChallengeTokenDuration(dur time.Duration) *AuthBuilder {
+	b.auth.mfaChallengeTokenDuration = dur
+	return b
+}
+
+// Issuer of TOTP keys displayed by authenticator apps.
+func (b *AuthBuilder) MfaIssuer(issuer string) *AuthBuilder {
+	b.auth.mfaIssuer = issuer
+	return b
+}
+
+func (b *AuthBuilder) PasskeyCeremonyDuration(dur time.Duration) *AuthBuilder {
+	b.auth.passkeyCeremonyDuration = dur
+	return b
+}
+
+func (b *AuthBuilder) AuthorizationCodeDuration(dur time.Duration) *AuthBuilder {
+	b.auth.authorizationCodeDuration = dur
+	return b
+}
+
+func (b *AuthBuilder) IdentityLoginDuration(dur time.Duration) *AuthBuilder {
+	b.auth.identityLoginDuration = dur
+	return b
+}
+
+func (b *AuthBuilder) ApiKeyRotationGracePeriod(dur time.Duration) *AuthBuilder {
+	b.auth.apiKeyRotationGracePeriod = dur
+	return b
+}
+
+func (b *AuthBuilder) ImpersonationTokenDuration(dur time.Duration) *AuthBuilder {
+	b.auth.impersonationTokenDuration = dur
+	return b
+}
+
+// Amount of refresh tokens accounts of the type may hold, admins 2, sellers 10 and users 5 by default.
+// Accounts of types without a limit, i.e. service accounts, fall back to the refresh token provider's default limit.
+func (b *AuthBuilder) RefreshTokensLimit(accountType domain.AccountType, limit int) *AuthBuilder {
+	b.auth.refreshTokensLimits[accountType] = limit
+	return b
+}
+
+// Policy applied on authentication once the account holds the limit of refresh tokens.
+// Oldest refresh tokens are evicted by default.
+func (b *AuthBuilder) RefreshTokensEvictionPolicy(policy domain.RefreshTokenEvictionPolicy) *AuthBuilder {
+	b.auth.refreshTokensEvictionPolicy = policy
+	return b
+}
+
+func (b *AuthBuilder) LoginThrottlingPolicy(kind domain.LoginAttemptsKeyKind, policy domain.LoginThrottlingPolicy) *AuthBuilder {
+	b.auth.loginThrottlingPolicies[kind] = policy
+	return b
+}
+
 func (b *AuthBuilder) Logger(l *zap.Logger) *AuthBuilder {
 	b.auth.l = l
 	return b
 }
 
 func (b *AuthBuilder) Build() (*Auth, error) {
+	for accountType, limit := range b.auth.refreshTokensLimits {
+		if limit <= 0 {
+			return nil, fmt.Errorf("refresh tokens limit for account type %q must be positive, got %d", accountType, limit)
+		}
+	}
+	switch b.auth.refreshTokensEvictionPolicy {
+	case domain.RefreshTokenEvictionPolicyEvictOldest, domain.RefreshTokenEvictionPolicyRejectNew:
+	default:
+		return nil, fmt.Errorf("unknown refresh tokens eviction policy %q", b.auth.refreshTokensEvictionPolicy)
+	}
+	for kind, policy := range b.auth.loginThrottlingPolicies {
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s login throttling policy: %w", kind, err)
+		}
+	}
+	if b.auth.authz == nil {
+		return nil, errors.New("authorizer must be set")
+	}
+	if b.auth.rolePermissions == nil {
+		return nil, errors.New("role permissions must be set")
+	}
+	if b.auth.l == nil {
+		b.auth.l = zap.NewNop()
+	}
+	if b.auth.accessTokenRevocations != nil && b.auth.tokenProv != nil {
+		b.auth.tokenProv = &revocationCheckingTokenProvider{
+			TokenProvider: b.auth.tokenProv,
+			revocations:   b.auth.accessTokenRevocations,
+		}
+	}
 	return b.auth, nil
 }
 
 func NewAuthBuilder() *AuthBuilder {
 	auth := Auth{
+		authz:           NewRoleAuthorizer(DefaultAuthorizationPolicy()),
+		rolePermissions: NewStaticRolePermissions(DefaultRolePermissions()),
+
 		refreshTokenDuration: 30 * 24 * time.Hour,
 		accessTokenDuration:  30 * time.Minute,
+
+		passwordResetTokenDuration: 30 * time.Minute,
+		accountDeletionGracePeriod: 30 * 24 * time.Hour,
+		mfaChallengeTokenDuration:  5 * time.Minute,
+		mfaIssuer:                  "Floral",
+		passkeyCeremonyDuration:    5 * time.Minute,
+		authorizationCodeDuration:  time.Minute,
+		identityLoginDuration:      10 * time.Minute,
+		identityProviders:          make(map[string]domain.IdentityProvider),
+		apiKeyRotationGracePeriod:  24 * time.Hour,
+		impersonationTokenDuration: 15 * time.Minute,
+		totp:                       auth.DefaultTotp(),
+		refreshTokensLimits: map[domain.AccountType]int{
+			domain.AccountTypeAdmin:  2,
+			domain.AccountTypeSeller: 10,
+			domain.AccountTypeUser:   5,
+		},
+		refreshTokensEvictionPolicy: domain.RefreshTokenEvictionPolicyEvictOldest,
+		loginThrottlingPolicies: map[domain.LoginAttemptsKeyKind]domain.LoginThrottlingPolicy{
+			domain.LoginAttemptsKeyKindEmail: {
+				FreeAttempts:     3,
+				BaseDelay:        time.Second,
+				MaxDelay:         time.Minute,
+				LockoutThreshold: 10,
+				LockoutDuration:  15 * time.Minute,
+				ResetAfter:       time.Hour,
+			},
+			// Clients behind NAT share an ip, hence the higher thresholds.
+			domain.LoginAttemptsKeyKindIp: {
+				FreeAttempts:     20,
+				BaseDelay:        time.Second,
+				MaxDelay:         time.Minute,
+				LockoutThreshold: 100,
+				LockoutDuration:  15 * time.Minute,
+				ResetAfter:       time.Hour,
+			},
+		},
 	}
 	return &AuthBuilder{auth: &auth}
 }
@@ -79,6 +384,8 @@ type createAccountReq struct {
 	Email    string
 	Password string
 	Type     domain.AccountType
+	// Activate the account right away instead of sending an email confirmation.
+	Activated bool
 }
 
 func (svc *Auth) createAccount(ctx context.Context, req createAccountReq) (domain.CreateUserRes, error) {
@@ -88,12 +395,19 @@ func (svc *Auth) createAccount(ctx context.Context, req createAccountReq) (domai
 		return domain.CreateUserRes{}, err
 	}
 
-	out, err := svc.accProv.CreateAccount(ctx, domain.CreateAccountDTOInput{
-		Name:     acc.Name(),
-		Password: acc.Password(),
-		Email:    acc.Email(),
-		Type:     acc.Type(),
-	})
+	in := domain.CreateAccountDTOInput{
+		Name:      acc.Name(),
+		Password:  acc.Password(),
+		Email:     acc.Email(),
+		Type:      acc.Type(),
+		Activated: req.Activated,
+	}
+	enqueued := svc.accCreationOutbox != nil && !req.Activated
+	if enqueued {
+		in.CreationNotificationId = entity.Id(20)
+	}
+
+	out, err := svc.accProv.CreateAccount(ctx, in)
 	if err != nil {
 		svc.l.Error("failed to create account via account provider", zap.Error(err))
 		return domain.CreateUserRes{}, err
@@ -105,6 +419,10 @@ func (svc *Auth) createAccount(ctx context.Context, req createAccountReq) (domai
 		Email: out.Email,
 	}
 
+	if req.Activated || enqueued {
+		return accountRes, nil
+	}
+
 	_, err = svc.accCreationNotificationProv.Send(ctx, domain.SendAccountCreationNotificationDTOInput{
 		Email: accountRes.Email,
 	})
@@ -135,20 +453,9 @@ func (svc *Auth) CreateUser(ctx context.Context, req domain.CreateUserReq) (doma
 }
 
 func (svc *Auth) CreateSeller(ctx context.Context, req domain.CreateSellerReq) (domain.CreateSellerRes, error) {
-	token, err := svc.tokenProv.DecodeAccess(req.AccessToken)
+	token, err := svc.authorize(ctx, req.AccessToken, domain.ActionCreateSeller)
 	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrInvalidRefreshToken):
-			svc.l.Info("invalid refresh token", zap.Error(err))
-			return domain.CreateSellerRes{}, err
-		case errors.Is(err, domain.ErrTokenExpired):
-			svc.l.Info("refresh token expired", zap.Any("token", token))
-			return domain.CreateSellerRes{}, err
-		case errors.Is(err, domain.ErrTokenParseFailed):
-		default:
-			svc.l.Error("failed to decode refresh token: %w", zap.Error(err))
-			return domain.CreateSellerRes{}, err
-		}
+		return domain.CreateSellerRes{}, err
 	}
 
 	res, err := svc.createAccount(ctx, createAccountReq{
@@ -160,6 +467,13 @@ func (svc *Auth) CreateSeller(ctx context.Context, req domain.CreateSellerReq) (
 	if err != nil {
 		return domain.CreateSellerRes{}, err
 	}
+
+	event := newAuditEvent(domain.AuditEventSellerCreation, res.Id, map[string]string{
+		"email": res.Email,
+	})
+	event.ActorId = token.SubjectId
+	svc.audit(ctx, event)
+
 	return domain.CreateSellerRes{
 		Name:  res.Name,
 		Email: res.Email,
@@ -167,20 +481,83 @@ func (svc *Auth) CreateSeller(ctx context.Context, req domain.CreateSellerReq) (
 	}, nil
 }
 
+// Create an activated admin account or, if an admin with the email already
+// exists, rotate its password and revoke its sessions.
 func (svc *Auth) CreateAdmin(ctx context.Context, req domain.CreateAdminReq) (domain.CreateAdminRes, error) {
-	res, err := svc.createAccount(ctx, createAccountReq{
-		Name:     req.Name,
-		Email:    req.Email,
-		Password: req.Password,
-		Type:     domain.AccountTypeAdmin,
+	acc, err := svc.accProv.FindAccountByEmail(ctx, domain.FindAccountByEmailDTOInput{
+		Email: req.Email,
 	})
 	if err != nil {
+		svc.l.Error("failed to find account by email for admin creation", zap.Error(err))
+		return domain.CreateAdminRes{}, err
+	}
+
+	if acc == nil {
+		res, err := svc.createAccount(ctx, createAccountReq{
+			Name:      req.Name,
+			Email:     req.Email,
+			Password:  req.Password,
+			Type:      domain.AccountTypeAdmin,
+			Activated: true,
+		})
+		if err != nil {
+			return domain.CreateAdminRes{}, err
+		}
+		svc.audit(ctx, newAuditEvent(domain.AuditEventAdminCreation, res.Id, map[string]string{
+			"email": res.Email,
+		}))
+		return domain.CreateAdminRes{
+			Name:    res.Name,
+			Email:   res.Email,
+			Id:      res.Id,
+			Created: true,
+		}, nil
+	}
+
+	if acc.Type != domain.AccountTypeAdmin {
+		return domain.CreateAdminRes{}, domain.ErrEmailIsInUse
+	}
+
+	if err := domain.ValidatePassword(req.Password); err != nil {
+		return domain.CreateAdminRes{}, err
+	}
+
+	if err := svc.accProv.UpdatePassword(ctx, domain.UpdatePasswordDTOInput{
+		Id:       acc.Id,
+		Password: req.Password,
+	}); err != nil {
+		svc.l.Error("failed to rotate admin account password", zap.String("account_id", acc.Id), zap.Error(err))
 		return domain.CreateAdminRes{}, err
 	}
+
+	if !acc.Activated {
+		if err := svc.accProv.ActivateAccountsByEmail(ctx, domain.ActivateAccountsByEmailDTOInput{
+			Emails: []string{req.Email},
+		}); err != nil {
+			svc.l.Error("failed to activate admin account", zap.String("account_id", acc.Id), zap.Error(err))
+			return domain.CreateAdminRes{}, err
+		}
+	}
+
+	if svc.refreshTokenProv != nil {
+		out, err := svc.refreshTokenProv.DeleteByAccountId(ctx, domain.RefreshTokenDeleteByAccountIdDTOInput{
+			Id: acc.Id,
+		})
+		if err != nil {
+			svc.l.Error("failed to revoke admin refresh tokens after password rotation", zap.String("account_id", acc.Id), zap.Error(err))
+			return domain.CreateAdminRes{}, err
+		}
+		svc.l.Info("rotated admin account password", zap.String("account_id", acc.Id), zap.Int("revoked_sessions", len(out.Ids)))
+	}
+
+	svc.audit(ctx, newAuditEvent(domain.AuditEventAdminPasswordRotation, acc.Id, map[string]string{
+		"email": req.Email,
+	}))
+
 	return domain.CreateAdminRes{
-		Name:  res.Name,
-		Email: res.Email,
-		Id:    res.Id,
+		Id:    acc.Id,
+		Name:  acc.Name,
+		Email: req.Email,
 	}, nil
 }
 
@@ -192,10 +569,27 @@ func (svc *Auth) ActivateAccounts(ctx context.Context, req domain.ActivateAccoun
 		return domain.ActivateAccountsRes{}, err
 	}
 
+	if svc.auditLog != nil {
+		svc.auditAccountActivations(ctx, req.Emails)
+	}
+
 	return domain.ActivateAccountsRes{}, nil
 }
 
 func (svc *Auth) Authenticate(ctx context.Context, req domain.AuthenticateReq) (domain.AuthenticateRes, error) {
+	if req.Passkey != nil {
+		return svc.authenticateWithPasskey(ctx, req)
+	}
+	if req.Identity != nil {
+		return svc.authenticateWithIdentity(ctx, req)
+	}
+
+	loginAttemptsKeys := svc.loginAttemptsKeys(req.Email, req.Ip)
+	emailFailed, err := svc.checkLoginThrottling(ctx, loginAttemptsKeys)
+	if err != nil {
+		return domain.AuthenticateRes{}, err
+	}
+
 	out, err := svc.accProv.CheckAccountCredentials(ctx, domain.CheckAccountCredentialsDTOInput{
 		Email:    req.Email,
 		Password: req.Password,
@@ -206,25 +600,61 @@ func (svc *Auth) Authenticate(ctx context.Context, req domain.AuthenticateReq) (
 	}
 
 	if !out.Ok {
+		if err := svc.registerLoginFailure(ctx, loginAttemptsKeys); err != nil {
+			return domain.AuthenticateRes{}, err
+		}
 		return domain.AuthenticateRes{}, domain.ErrInvalidCredentials
 	}
 
+	// Failed attempts of accounts with MFA enabled are reset once the second factor is verified.
+	if emailFailed && !out.MfaEnabled {
+		if err := svc.loginAttempts.Reset(ctx, loginAttemptsKeys[domain.LoginAttemptsKeyKindEmail]); err != nil {
+			svc.l.Error("failed to reset failed login attempts", zap.String("account_id", out.AccountId), zap.Error(err))
+			return domain.AuthenticateRes{}, err
+		}
+	}
+
 	if !out.Activated {
 		svc.l.Info("rejected creating refresh token for account that has not been activated")
 		return domain.AuthenticateRes{}, domain.ErrAccountNotActivated
 	}
 
+	if out.Suspended {
+		svc.l.Info("rejected creating refresh token for suspended account", zap.String("account_id", out.AccountId))
+		return domain.AuthenticateRes{}, domain.ErrAccountSuspended
+	}
+
+	if out.MfaEnabled {
+		return svc.createMfaChallenge(out.AccountId)
+	}
+
+	return svc.issueRefreshToken(ctx, out.AccountId, out.AccountType, authMethodPassword, req.UserAgent, req.Ip)
+}
+
+// Start a new session (refresh token family) for the authenticated account.
+func (svc *Auth) issueRefreshToken(ctx context.Context, accountId string, accountType domain.AccountType, authMethod, userAgent, ip string) (domain.AuthenticateRes, error) {
 	token := domain.RefreshToken{
-		SubjectId: out.AccountId,
+		SubjectId: accountId,
+		FamilyId:  entity.Id(16),
 	}
 
 	// FIXME: clean Go transactions
 	outToken, err := svc.refreshTokenProv.Add(ctx, domain.RefreshTokenAddDTOInput{
-		AccountId: out.AccountId,
+		AccountId: accountId,
+		FamilyId:  token.FamilyId,
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(svc.refreshTokenDuration),
+		UserAgent: userAgent,
+		Ip:        ip,
+
+		Limit:          svc.refreshTokensLimit(accountType),
+		EvictionPolicy: svc.refreshTokensEvictionPolicy,
 	})
 	if err != nil {
+		if errors.Is(err, domain.ErrRefreshTokensLimitReached) {
+			svc.l.Info("rejected creating refresh token for account that reached refresh tokens limit", zap.String("account_id", accountId))
+			return domain.AuthenticateRes{}, err
+		}
 		svc.l.Error("failed to add data on refresh token", zap.Error(err))
 		return domain.AuthenticateRes{}, err
 	}
@@ -237,12 +667,88 @@ func (svc *Auth) Authenticate(ctx context.Context, req domain.AuthenticateReq) (
 		return domain.AuthenticateRes{}, err
 	}
 
+	svc.audit(ctx, newAuditEvent(domain.AuditEventAuthentication, accountId, map[string]string{
+		"method":     authMethod,
+		"token_id":   token.Id,
+		"family_id":  token.FamilyId,
+		"user_agent": userAgent,
+		"ip":         ip,
+	}))
+
 	return domain.AuthenticateRes{
 		RefreshToken: tokenStr,
 		ExpiresAt:    token.ExpiresAt,
 	}, nil
 }
 
+func (svc *Auth) loginAttemptsKeys(email, ip string) map[domain.LoginAttemptsKeyKind]string {
+	if svc.loginAttempts == nil {
+		return nil
+	}
+	keys := make(map[domain.LoginAttemptsKeyKind]string, 2)
+	if email != "" {
+		keys[domain.LoginAttemptsKeyKindEmail] = domain.LoginAttemptsKey(domain.LoginAttemptsKeyKindEmail, strings.ToLower(email))
+	}
+	if ip != "" {
+		keys[domain.LoginAttemptsKeyKindIp] = domain.LoginAttemptsKey(domain.LoginAttemptsKeyKindIp, ip)
+	}
+	return keys
+}
+
+// Reject the login attempt if any of the keys is throttled.
+// Reports whether failed attempts are registered for the email key, so that they are reset on success.
+func (svc *Auth) checkLoginThrottling(ctx context.Context, keys map[domain.LoginAttemptsKeyKind]string) (bool, error) {
+	var emailFailed bool
+	now := time.Now()
+	for kind, key := range keys {
+		record, err := svc.loginAttempts.Get(ctx, key)
+		if err != nil {
+			svc.l.Error("failed to get failed login attempts", zap.String("key", key), zap.Error(err))
+			return false, err
+		}
+		if record == nil {
+			continue
+		}
+
+		policy := svc.loginThrottlingPolicies[kind]
+		if record.LastFailureAt.Before(now.Add(-policy.ResetAfter)) {
+			continue
+		}
+		if kind == domain.LoginAttemptsKeyKindEmail {
+			emailFailed = true
+		}
+		if blockedUntil := policy.BlockedUntil(*record); now.Before(blockedUntil) {
+			svc.l.Info("rejected throttled login attempt", zap.String("key", key), zap.Int("failures", record.Failures), zap.Time("blocked_until", blockedUntil))
+			return false, fmt.Errorf("%w: retry after %s", domain.ErrTooManyLoginAttempts, blockedUntil.Format(time.RFC3339))
+		}
+	}
+	return emailFailed, nil
+}
+
+func (svc *Auth) registerLoginFailure(ctx context.Context, keys map[domain.LoginAttemptsKeyKind]string) error {
+	now := time.Now()
+	for kind, key := range keys {
+		record, err := svc.loginAttempts.RegisterFailure(ctx, domain.LoginAttemptsRegisterFailureDTOInput{
+			Key:        key,
+			At:         now,
+			ResetAfter: svc.loginThrottlingPolicies[kind].ResetAfter,
+		})
+		if err != nil {
+			svc.l.Error("failed to register failed login attempt", zap.String("key", key), zap.Error(err))
+			return err
+		}
+		if record.Failures == svc.loginThrottlingPolicies[kind].LockoutThreshold {
+			svc.l.Warn("locked out login attempts", zap.String("key", key), zap.Int("failures", record.Failures))
+		}
+	}
+	return nil
+}
+
+// Amount of refresh tokens an account of the type may hold, zero for the refresh token provider's default limit.
+func (svc *Auth) refreshTokensLimit(accountType domain.AccountType) int {
+	return svc.refreshTokensLimits[accountType]
+}
+
 func (svc *Auth) ReplaceRefreshToken(ctx context.Context, req domain.ReplaceRefreshTokenReq) (domain.ReplaceRefreshTokenRes, error) {
 	token, err := svc.tokenProv.DecodeRefresh(req.RefreshToken)
 	if err != nil {
@@ -264,17 +770,26 @@ func (svc *Auth) ReplaceRefreshToken(ctx context.Context, req domain.ReplaceRefr
 		Id:        token.Id,
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(svc.refreshTokenDuration),
+		UserAgent: req.UserAgent,
+		Ip:        req.Ip,
 	})
 	if err != nil {
 		svc.l.Error("failed to replace refresh token: %w", zap.Error(err))
 		return domain.ReplaceRefreshTokenRes{}, err
 	}
 	if out.Id == "" {
-		return domain.ReplaceRefreshTokenRes{}, domain.ErrRefreshTokenToReplaceNotFound
+		// Tokens revoked by logging out, session revocation, eviction or account deletion are gone,
+		// only replaced tokens are kept. Tokens issued before rotation families were introduced can't be traced back.
+		if out.ReplacedAt.IsZero() || token.FamilyId == "" {
+			svc.l.Info("refresh token to replace not found", zap.String("token_id", token.Id))
+			return domain.ReplaceRefreshTokenRes{}, domain.ErrRefreshTokenToReplaceNotFound
+		}
+		return domain.ReplaceRefreshTokenRes{}, svc.revokeRefreshTokenFamily(ctx, token, out.ReplacedAt)
 	}
 
 	newToken := domain.RefreshToken{
 		Id:        out.Id,
+		FamilyId:  token.FamilyId,
 		SubjectId: token.SubjectId,
 		ExpiresAt: out.ExpiresAt,
 	}
@@ -285,12 +800,62 @@ func (svc *Auth) ReplaceRefreshToken(ctx context.Context, req domain.ReplaceRefr
 		return domain.ReplaceRefreshTokenRes{}, err
 	}
 
+	svc.audit(ctx, newAuditEvent(domain.AuditEventRefreshTokenRotation, token.SubjectId, map[string]string{
+		"token_id":          newToken.Id,
+		"replaced_token_id": token.Id,
+		"family_id":         token.FamilyId,
+		"user_agent":        req.UserAgent,
+		"ip":                req.Ip,
+	}))
+
 	return domain.ReplaceRefreshTokenRes{
 		RefreshToken: newTokenEncoded,
 		ExpiresAt:    newToken.ExpiresAt,
 	}, nil
 }
 
+// Presenting a refresh token that has already been rotated means
+// that either the client or an attacker holds a stolen copy of it,
+// so every token of the rotation family is revoked.
+func (svc *Auth) revokeRefreshTokenFamily(ctx context.Context, token domain.RefreshToken, replacedAt time.Time) error {
+	svc.l.Warn(
+		"refresh token reuse detected, revoking refresh token family",
+		zap.String("account_id", token.SubjectId),
+		zap.String("token_id", token.Id),
+		zap.String("family_id", token.FamilyId),
+		zap.Time("replaced_at", replacedAt),
+	)
+
+	out, err := svc.refreshTokenProv.DeleteByFamilyId(ctx, domain.RefreshTokenDeleteByFamilyIdDTOInput{
+		FamilyId: token.FamilyId,
+	})
+	if err != nil {
+		svc.l.Error("failed to revoke refresh token family", zap.String("family_id", token.FamilyId), zap.Error(err))
+		return err
+	}
+
+	svc.audit(ctx, newAuditEvent(domain.AuditEventRefreshTokenReuse, token.SubjectId, map[string]string{
+		"token_id":  token.Id,
+		"family_id": token.FamilyId,
+	}))
+
+	if svc.securityEventProv != nil {
+		if _, err := svc.securityEventProv.Send(ctx, domain.SendSecurityEventNotificationDTOInput{
+			Type:      domain.SecurityEventRefreshTokenReuse,
+			AccountId: token.SubjectId,
+			Details: map[string]string{
+				"token_id":  token.Id,
+				"family_id": token.FamilyId,
+			},
+		}); err != nil {
+			svc.l.Error("failed to send refresh token reuse security event", zap.Error(err))
+		}
+	}
+
+	svc.l.Info("revoked refresh token family", zap.String("family_id", token.FamilyId), zap.Strings("revoked_token_ids", out.Ids))
+	return domain.ErrRefreshTokenReused
+}
+
 func (svc *Auth) CreateAccessToken(ctx context.Context, req domain.CreateAccessTokenReq) (domain.CreateAccessTokenRes, error) {
 	refreshToken, err := svc.tokenProv.DecodeRefresh(req.RefreshToken)
 	if err != nil {
@@ -308,8 +873,25 @@ func (svc *Auth) CreateAccessToken(ctx context.Context, req domain.CreateAccessT
 		}
 	}
 
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: refreshToken.SubjectId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for creating access token: %v", zap.Error(err))
+		return domain.CreateAccessTokenRes{}, err
+	}
+	if acc == nil {
+		// Account has been deleted.
+		return domain.CreateAccessTokenRes{}, domain.ErrTokenRevoked
+	}
+	if !acc.SuspendedAt.IsZero() {
+		svc.l.Info("rejected creating access token for suspended account", zap.String("account_id", refreshToken.SubjectId))
+		return domain.CreateAccessTokenRes{}, domain.ErrAccountSuspended
+	}
+
 	out, err := svc.refreshTokenProv.List(ctx, domain.RefreshTokenListDTOInput{
 		AccountId: refreshToken.SubjectId,
+		Limit:     svc.refreshTokensLimit(acc.Type),
 	})
 	if err != nil {
 		svc.l.Error("failed to list refresh tokens for creating access token", zap.Error(err))
@@ -322,17 +904,23 @@ func (svc *Auth) CreateAccessToken(ctx context.Context, req domain.CreateAccessT
 		return domain.CreateAccessTokenRes{}, domain.ErrTokenRevoked
 	}
 
-	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
-		Id: refreshToken.SubjectId,
-	})
+	if err := svc.refreshTokenProv.Touch(ctx, domain.RefreshTokenTouchDTOInput{
+		Id:         refreshToken.Id,
+		LastUsedAt: time.Now(),
+	}); err != nil {
+		// Session last usage time is informational only.
+		svc.l.Error("failed to touch refresh token", zap.Error(err))
+	}
+
+	permissions, err := svc.permissions(ctx, acc.Type)
 	if err != nil {
-		svc.l.Error("failed to find account for creating access token: %v", zap.Error(err))
 		return domain.CreateAccessTokenRes{}, err
 	}
 
 	accessToken := domain.AccessToken{
 		SubjectId:   refreshToken.SubjectId,
 		SubjectType: acc.Type,
+		Permissions: permissions,
 		ExpiresAt:   time.Now().Add(svc.accessTokenDuration),
 	}
 	token, err := svc.tokenProv.EncodeAccess(accessToken)
@@ -341,8 +929,816 @@ func (svc *Auth) CreateAccessToken(ctx context.Context, req domain.CreateAccessT
 		return domain.CreateAccessTokenRes{}, err
 	}
 
+	svc.audit(ctx, newAuditEvent(domain.AuditEventAccessTokenCreation, refreshToken.SubjectId, map[string]string{
+		"refresh_token_id": refreshToken.Id,
+	}))
+
 	return domain.CreateAccessTokenRes{
 		AccessToken: token,
 		ExpiresAt:   accessToken.ExpiresAt,
 	}, nil
 }
+
+func (svc *Auth) permissions(ctx context.Context, accountType domain.AccountType) ([]domain.Permission, error) {
+	permissions, err := svc.rolePermissions.Permissions(ctx, accountType)
+	if err != nil {
+		svc.l.Error("failed to get role permissions", zap.String("account_type", accountType), zap.Error(err))
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// Decode refresh token reporting any decoding failure as domain.ErrInvalidRefreshToken.
+func (svc *Auth) decodeRefreshToken(tokenString string) (domain.RefreshToken, error) {
+	token, err := svc.tokenProv.DecodeRefresh(tokenString)
+	if err != nil {
+		if errors.Is(err, domain.ErrTokenExpired) {
+			svc.l.Info("refresh token expired", zap.Any("token", token))
+		} else {
+			svc.l.Info("failed to decode refresh token", zap.Error(err))
+		}
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			return domain.RefreshToken{}, err
+		}
+		return domain.RefreshToken{}, fmt.Errorf("%w: %w", domain.ErrInvalidRefreshToken, err)
+	}
+	return token, nil
+}
+
+// Decode access token reporting any decoding failure as domain.ErrInvalidAccessToken
+// except for failing to check the deny-list.
+func (svc *Auth) decodeAccessToken(tokenString string) (domain.AccessToken, error) {
+	token, err := svc.tokenProv.DecodeAccess(tokenString)
+	if err != nil {
+		// Handlers must not answer unauthorized while the deny-list is unavailable.
+		if errors.Is(err, domain.ErrTokenRevocationCheckFailed) {
+			svc.l.Error("failed to decode access token", zap.Error(err))
+			return domain.AccessToken{}, err
+		}
+		if errors.Is(err, domain.ErrTokenExpired) {
+			svc.l.Info("access token expired")
+		} else {
+			svc.l.Info("failed to decode access token", zap.Error(err))
+		}
+		if errors.Is(err, domain.ErrInvalidAccessToken) {
+			return domain.AccessToken{}, err
+		}
+		return domain.AccessToken{}, fmt.Errorf("%w: %w", domain.ErrInvalidAccessToken, err)
+	}
+	return token, nil
+}
+
+func (svc *Auth) Logout(ctx context.Context, req domain.LogoutReq) (domain.LogoutRes, error) {
+	token, err := svc.decodeRefreshToken(req.RefreshToken)
+	if err != nil {
+		return domain.LogoutRes{}, err
+	}
+
+	out, err := svc.refreshTokenProv.Delete(ctx, domain.RefreshTokenDeleteDTOInput{
+		Id: token.Id,
+	})
+	if err != nil {
+		svc.l.Error("failed to delete refresh token", zap.Error(err))
+		return domain.LogoutRes{}, err
+	}
+	// Logging out of an already revoked session is not an error.
+	if out.Id == "" {
+		svc.l.Info("refresh token to log out has already been revoked", zap.String("token_id", token.Id))
+	}
+
+	return domain.LogoutRes{}, nil
+}
+
+func (svc *Auth) LogoutAll(ctx context.Context, req domain.LogoutAllReq) (domain.LogoutAllRes, error) {
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.LogoutAllRes{}, err
+	}
+
+	out, err := svc.refreshTokenProv.DeleteByAccountId(ctx, domain.RefreshTokenDeleteByAccountIdDTOInput{
+		Id: token.SubjectId,
+	})
+	if err != nil {
+		svc.l.Error("failed to delete refresh tokens by account id", zap.Error(err))
+		return domain.LogoutAllRes{}, err
+	}
+	svc.l.Info("revoked all account refresh tokens", zap.String("account_id", token.SubjectId), zap.Int("count", len(out.Ids)))
+
+	return domain.LogoutAllRes{
+		RevokedSessions: len(out.Ids),
+	}, nil
+}
+
+func (svc *Auth) ListSessions(ctx context.Context, req domain.ListSessionsReq) (domain.ListSessionsRes, error) {
+	token, err := svc.decodeAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.ListSessionsRes{}, err
+	}
+
+	out, err := svc.refreshTokenProv.List(ctx, domain.RefreshTokenListDTOInput{
+		AccountId: token.SubjectId,
+		Limit:     svc.refreshTokensLimit(token.SubjectType),
+	})
+	if err != nil {
+		svc.l.Error("failed to list refresh tokens for listing sessions", zap.Error(err))
+		return domain.ListSessionsRes{}, err
+	}
+
+	return domain.ListSessionsRes{
+		Sessions: sessionsFromRefreshTokens(out.Tokens),
+	}, nil
+}
+
+func sessionsFromRefreshTokens(tokens []domain.RefreshTokenListDTOOutputToken) []domain.Session {
+	sessions := make([]domain.Session, 0, len(tokens))
+	for _, t := range tokens {
+		sessions = append(sessions, domain.Session{
+			Id:         t.Id,
+			UserAgent:  t.UserAgent,
+			Ip:         t.Ip,
+			CreatedAt:  t.CreatedAt,
+			ExpiresAt:  t.ExpiresAt,
+			LastUsedAt: t.LastUsedAt,
+		})
+	}
+	return sessions
+}
+
+func (svc *Auth) RevokeSession(ctx context.Context, req domain.RevokeSessionReq) (domain.RevokeSessionRes, error) {
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.RevokeSessionRes{}, err
+	}
+
+	out, err := svc.refreshTokenProv.List(ctx, domain.RefreshTokenListDTOInput{
+		AccountId: token.SubjectId,
+		Limit:     svc.refreshTokensLimit(token.SubjectType),
+	})
+	if err != nil {
+		svc.l.Error("failed to list refresh tokens for revoking session", zap.Error(err))
+		return domain.RevokeSessionRes{}, err
+	}
+
+	// Accounts may only revoke their own sessions.
+	if !slices.ContainsFunc(out.Tokens, func(v domain.RefreshTokenListDTOOutputToken) bool {
+		return v.Id == req.SessionId
+	}) {
+		return domain.RevokeSessionRes{}, domain.ErrSessionNotFound
+	}
+
+	if _, err := svc.refreshTokenProv.Delete(ctx, domain.RefreshTokenDeleteDTOInput{
+		Id: req.SessionId,
+	}); err != nil {
+		svc.l.Error("failed to delete refresh token for revoking session", zap.Error(err))
+		return domain.RevokeSessionRes{}, err
+	}
+	svc.l.Info("revoked session", zap.String("account_id", token.SubjectId), zap.String("session_id", req.SessionId))
+
+	return domain.RevokeSessionRes{}, nil
+}
+
+var errPasswordResetUnavailable = errors.New("password reset is unavailable: password reset tokens or sender not set")
+
+func (svc *Auth) RequestPasswordReset(ctx context.Context, req domain.RequestPasswordResetReq) (domain.RequestPasswordResetRes, error) {
+	if svc.passwordResetTokens == nil || svc.passwordResetSender == nil {
+		return domain.RequestPasswordResetRes{}, errPasswordResetUnavailable
+	}
+
+	acc, err := svc.accProv.FindAccountByEmail(ctx, domain.FindAccountByEmailDTOInput{
+		Email: req.Email,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account by email for password reset", zap.Error(err))
+		return domain.RequestPasswordResetRes{}, err
+	}
+	if acc == nil {
+		svc.l.Info("skip password reset for email that does not belong to any account")
+		return domain.RequestPasswordResetRes{}, nil
+	}
+	if acc.Type == domain.AccountTypeService {
+		svc.l.Info("skip password reset for service account", zap.String("account_id", acc.Id))
+		return domain.RequestPasswordResetRes{}, nil
+	}
+
+	record := domain.PasswordResetRecord{
+		Email:     req.Email,
+		Token:     entity.Id(64),
+		Purpose:   domain.OneTimeTokenPurposePasswordReset,
+		ExpiresAt: time.Now().Add(svc.passwordResetTokenDuration),
+	}
+	if err := svc.passwordResetTokens.InsertToken(ctx, record); err != nil {
+		svc.l.Error("failed to insert password reset token", zap.Error(err))
+		return domain.RequestPasswordResetRes{}, err
+	}
+
+	if err := svc.passwordResetSender.Send(ctx, domain.PasswordResetSenderSendDTOInput{
+		RecipientEmail: record.Email,
+		ResetToken:     record.Token,
+	}); err != nil {
+		svc.l.Error("failed to send password reset email", zap.Error(err))
+		return domain.RequestPasswordResetRes{}, err
+	}
+	svc.l.Info("sent password reset email", zap.String("account_id", acc.Id))
+
+	return domain.RequestPasswordResetRes{}, nil
+}
+
+func (svc *Auth) ResetPassword(ctx context.Context, req domain.ResetPasswordReq) (domain.ResetPasswordRes, error) {
+	if svc.passwordResetTokens == nil {
+		return domain.ResetPasswordRes{}, errPasswordResetUnavailable
+	}
+
+	if err := domain.ValidatePassword(req.NewPassword); err != nil {
+		return domain.ResetPasswordRes{}, err
+	}
+
+	record, err := svc.passwordResetTokens.ConsumeToken(ctx, req.Token)
+	if err != nil {
+		svc.l.Error("failed to consume password reset token", zap.Error(err))
+		return domain.ResetPasswordRes{}, err
+	}
+	if record == nil || record.Purpose != domain.OneTimeTokenPurposePasswordReset {
+		return domain.ResetPasswordRes{}, domain.ErrInvalidPasswordResetToken
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return domain.ResetPasswordRes{}, domain.ErrPasswordResetTokenExpired
+	}
+
+	acc, err := svc.accProv.FindAccountByEmail(ctx, domain.FindAccountByEmailDTOInput{
+		Email: record.Email,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account by email for password reset", zap.Error(err))
+		return domain.ResetPasswordRes{}, err
+	}
+	if acc == nil {
+		return domain.ResetPasswordRes{}, domain.ErrInvalidPasswordResetToken
+	}
+
+	if err := svc.accProv.UpdatePassword(ctx, domain.UpdatePasswordDTOInput{
+		Id:       acc.Id,
+		Password: req.NewPassword,
+	}); err != nil {
+		svc.l.Error("failed to update account password", zap.String("account_id", acc.Id), zap.Error(err))
+		return domain.ResetPasswordRes{}, err
+	}
+
+	// Whoever knew the old password must not stay signed in.
+	out, err := svc.refreshTokenProv.DeleteByAccountId(ctx, domain.RefreshTokenDeleteByAccountIdDTOInput{
+		Id: acc.Id,
+	})
+	if err != nil {
+		svc.l.Error("failed to revoke account refresh tokens after password reset", zap.String("account_id", acc.Id), zap.Error(err))
+		return domain.ResetPasswordRes{}, err
+	}
+	svc.l.Info("reset account password", zap.String("account_id", acc.Id), zap.Int("revoked_sessions", len(out.Ids)))
+
+	return domain.ResetPasswordRes{}, nil
+}
+
+// Check the password of the access token owner and return the account email.
+func (svc *Auth) checkAccountPassword(ctx context.Context, accountId, password string) (string, error) {
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for checking password", zap.Error(err))
+		return "", err
+	}
+	if acc == nil {
+		return "", domain.ErrUserNotFound
+	}
+
+	out, err := svc.accProv.CheckAccountCredentials(ctx, domain.CheckAccountCredentialsDTOInput{
+		Email:    acc.Email,
+		Password: password,
+	})
+	if err != nil {
+		svc.l.Error("failed to check account credentials", zap.String("account_id", accountId), zap.Error(err))
+		return "", err
+	}
+	if !out.Ok {
+		return "", domain.ErrInvalidCredentials
+	}
+
+	return acc.Email, nil
+}
+
+func (svc *Auth) UpdatePassword(ctx context.Context, req domain.UpdatePasswordReq) (domain.UpdatePasswordRes, error) {
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.UpdatePasswordRes{}, err
+	}
+
+	var currentSessionId string
+	if req.RefreshToken != "" {
+		refreshToken, err := svc.decodeRefreshToken(req.RefreshToken)
+		if err != nil {
+			return domain.UpdatePasswordRes{}, err
+		}
+		if refreshToken.SubjectId != token.SubjectId {
+			return domain.UpdatePasswordRes{}, fmt.Errorf("%w: refresh token belongs to another account", domain.ErrInvalidRefreshToken)
+		}
+		currentSessionId = refreshToken.Id
+	}
+
+	if err := domain.ValidatePassword(req.NewPassword); err != nil {
+		return domain.UpdatePasswordRes{}, err
+	}
+
+	if _, err := svc.checkAccountPassword(ctx, token.SubjectId, req.Password); err != nil {
+		return domain.UpdatePasswordRes{}, err
+	}
+
+	if err := svc.accProv.UpdatePassword(ctx, domain.UpdatePasswordDTOInput{
+		Id:       token.SubjectId,
+		Password: req.NewPassword,
+	}); err != nil {
+		svc.l.Error("failed to update account password", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.UpdatePasswordRes{}, err
+	}
+
+	out, err := svc.refreshTokenProv.DeleteByAccountId(ctx, domain.RefreshTokenDeleteByAccountIdDTOInput{
+		Id:       token.SubjectId,
+		ExceptId: currentSessionId,
+	})
+	if err != nil {
+		svc.l.Error("failed to revoke account refresh tokens after password change", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.UpdatePasswordRes{}, err
+	}
+	svc.l.Info("updated account password", zap.String("account_id", token.SubjectId), zap.Int("revoked_sessions", len(out.Ids)))
+
+	return domain.UpdatePasswordRes{
+		RevokedSessions: len(out.Ids),
+	}, nil
+}
+
+func (svc *Auth) UpdateEmail(ctx context.Context, req domain.UpdateEmailReq) (domain.UpdateEmailRes, error) {
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.UpdateEmailRes{}, err
+	}
+
+	if err := domain.ValidateEmail(req.NewEmail); err != nil {
+		return domain.UpdateEmailRes{}, err
+	}
+
+	if _, err := svc.checkAccountPassword(ctx, token.SubjectId, req.Password); err != nil {
+		return domain.UpdateEmailRes{}, err
+	}
+
+	acc, err := svc.accProv.FindAccountByEmail(ctx, domain.FindAccountByEmailDTOInput{
+		Email: req.NewEmail,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account by email for updating email", zap.Error(err))
+		return domain.UpdateEmailRes{}, err
+	}
+	if acc != nil {
+		return domain.UpdateEmailRes{}, domain.ErrEmailIsInUse
+	}
+
+	if err := svc.accProv.UpdateEmail(ctx, domain.UpdateEmailDTOInput{
+		Id:    token.SubjectId,
+		Email: req.NewEmail,
+	}); err != nil {
+		if errors.Is(err, domain.ErrEmailIsInUse) {
+			return domain.UpdateEmailRes{}, err
+		}
+		svc.l.Error("failed to update account email", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.UpdateEmailRes{}, err
+	}
+
+	// New email address is confirmed the same way as the email of a newly created account.
+	if _, err := svc.accCreationNotificationProv.Send(ctx, domain.SendAccountCreationNotificationDTOInput{
+		Email: req.NewEmail,
+	}); err != nil {
+		err = fmt.Errorf("%w: %v", domain.ErrSendAccountConfirmationFailed, err)
+		svc.l.Error("failed to send new account email confirmation message", zap.Error(err))
+		return domain.UpdateEmailRes{}, err
+	}
+	svc.l.Info("requested account email update", zap.String("account_id", token.SubjectId))
+
+	return domain.UpdateEmailRes{}, nil
+}
+
+// Resolve id of the account the access token owner acts upon.
+// Accounts act upon themselves, while acting upon other accounts requires the action to be authorized.
+func (svc *Auth) resolveAccountId(ctx context.Context, token domain.AccessToken, accountId string, action domain.Action) (string, error) {
+	if accountId == "" || accountId == token.SubjectId {
+		return token.SubjectId, nil
+	}
+	if err := svc.authz.Authorize(ctx, token, action); err != nil {
+		return "", err
+	}
+	return accountId, nil
+}
+
+func (svc *Auth) DeleteAccount(ctx context.Context, req domain.DeleteAccountReq) (domain.DeleteAccountRes, error) {
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.DeleteAccountRes{}, err
+	}
+
+	accountId, err := svc.resolveAccountId(ctx, token, req.AccountId, domain.ActionDeleteAnyAccount)
+	if err != nil {
+		svc.l.Info("account is not permitted to delete another account", zap.String("account_id", token.SubjectId), zap.String("target_account_id", req.AccountId))
+		return domain.DeleteAccountRes{}, err
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for deletion", zap.Error(err))
+		return domain.DeleteAccountRes{}, err
+	}
+	if acc == nil {
+		return domain.DeleteAccountRes{}, domain.ErrUserNotFound
+	}
+
+	deletedAt := time.Now()
+	if err := svc.accProv.DeleteAccount(ctx, domain.DeleteAccountDTOInput{
+		Id:        accountId,
+		DeletedAt: deletedAt,
+	}); err != nil {
+		svc.l.Error("failed to delete account", zap.String("account_id", accountId), zap.Error(err))
+		return domain.DeleteAccountRes{}, err
+	}
+
+	out, err := svc.refreshTokenProv.DeleteByAccountId(ctx, domain.RefreshTokenDeleteByAccountIdDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to revoke refresh tokens of deleted account", zap.String("account_id", accountId), zap.Error(err))
+		return domain.DeleteAccountRes{}, err
+	}
+	svc.l.Info(
+		"deleted account",
+		zap.String("account_id", accountId),
+		zap.String("deleted_by", token.SubjectId),
+		zap.Int("revoked_sessions", len(out.Ids)),
+	)
+
+	return domain.DeleteAccountRes{
+		PurgeAt: deletedAt.Add(svc.accountDeletionGracePeriod),
+	}, nil
+}
+
+const purgeDeletedAccountsBatchSize = 100
+
+func (svc *Auth) PurgeDeletedAccounts(ctx context.Context, _ domain.PurgeDeletedAccountsReq) (domain.PurgeDeletedAccountsRes, error) {
+	deletedBefore := time.Now().Add(-svc.accountDeletionGracePeriod)
+
+	var purged int
+	for {
+		out, err := svc.accProv.ListDeletedAccounts(ctx, domain.ListDeletedAccountsDTOInput{
+			DeletedBefore: deletedBefore,
+			Limit:         purgeDeletedAccountsBatchSize,
+		})
+		if err != nil {
+			svc.l.Error("failed to list deleted accounts to purge", zap.Error(err))
+			return domain.PurgeDeletedAccountsRes{PurgedAccounts: purged}, err
+		}
+
+		for _, acc := range out.Accounts {
+			if svc.emailConfirmationTokens != nil {
+				if err := svc.emailConfirmationTokens.DeleteTokensEmail(ctx, acc.Email); err != nil {
+					svc.l.Error("failed to delete email confirmation tokens of deleted account", zap.String("account_id", acc.Id), zap.Error(err))
+					return domain.PurgeDeletedAccountsRes{PurgedAccounts: purged}, err
+				}
+			}
+
+			if err := svc.accProv.PurgeAccount(ctx, domain.PurgeAccountDTOInput{
+				Id: acc.Id,
+			}); err != nil {
+				svc.l.Error("failed to purge deleted account", zap.String("account_id", acc.Id), zap.Error(err))
+				return domain.PurgeDeletedAccountsRes{PurgedAccounts: purged}, err
+			}
+			purged++
+			svc.l.Info("purged deleted account", zap.String("account_id", acc.Id), zap.Time("deleted_at", acc.DeletedAt))
+		}
+
+		if len(out.Accounts) < purgeDeletedAccountsBatchSize {
+			break
+		}
+	}
+
+	return domain.PurgeDeletedAccountsRes{
+		PurgedAccounts: purged,
+	}, nil
+}
+
+func (svc *Auth) ExportAccountData(ctx context.Context, req domain.ExportAccountDataReq) (domain.ExportAccountDataRes, error) {
+	token, err := svc.decodeAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.ExportAccountDataRes{}, err
+	}
+
+	accountId, err := svc.resolveAccountId(ctx, token, req.AccountId, domain.ActionExportAnyAccountData)
+	if err != nil {
+		svc.l.Info("account is not permitted to export data of another account", zap.String("account_id", token.SubjectId), zap.String("target_account_id", req.AccountId))
+		return domain.ExportAccountDataRes{}, err
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for exporting data", zap.Error(err))
+		return domain.ExportAccountDataRes{}, err
+	}
+	if acc == nil {
+		return domain.ExportAccountDataRes{}, domain.ErrUserNotFound
+	}
+
+	res := domain.ExportAccountDataRes{
+		Account: domain.ExportedAccount{
+			Id:           accountId,
+			Name:         acc.Name,
+			Email:        acc.Email,
+			Type:         acc.Type,
+			CreatedAt:    acc.CreatedAt,
+			PendingEmail: acc.PendingEmail,
+		},
+		EmailConfirmations: make([]domain.ExportedEmailConfirmation, 0),
+	}
+	if !acc.ActivatedAt.IsZero() {
+		res.Account.ActivatedAt = &acc.ActivatedAt
+	}
+
+	out, err := svc.refreshTokenProv.List(ctx, domain.RefreshTokenListDTOInput{
+		AccountId: accountId,
+		Limit:     svc.refreshTokensLimit(acc.Type),
+	})
+	if err != nil {
+		svc.l.Error("failed to list refresh tokens for exporting account data", zap.Error(err))
+		return domain.ExportAccountDataRes{}, err
+	}
+	res.Sessions = sessionsFromRefreshTokens(out.Tokens)
+
+	if svc.emailConfirmationTokens != nil {
+		emails := []string{acc.Email}
+		if acc.PendingEmail != "" {
+			emails = append(emails, acc.PendingEmail)
+		}
+		for _, email := range emails {
+			records, err := svc.emailConfirmationTokens.ListTokensEmail(ctx, email)
+			if err != nil {
+				svc.l.Error("failed to list email confirmation tokens for exporting account data", zap.Error(err))
+				return domain.ExportAccountDataRes{}, err
+			}
+			for _, record := range records {
+				res.EmailConfirmations = append(res.EmailConfirmations, domain.ExportedEmailConfirmation{
+					Email:     record.Email,
+					ExpiresAt: record.ExpiresAt,
+				})
+			}
+		}
+	}
+
+	if svc.passkeysAvailable() {
+		res.Passkeys, err = svc.listPasskeys(ctx, accountId)
+		if err != nil {
+			return domain.ExportAccountDataRes{}, err
+		}
+	}
+
+	if svc.identitiesAvailable() {
+		res.Identities, err = svc.listIdentities(ctx, accountId)
+		if err != nil {
+			return domain.ExportAccountDataRes{}, err
+		}
+	}
+
+	svc.l.Info("exported account data", zap.String("account_id", accountId), zap.String("exported_by", token.SubjectId))
+	return res, nil
+}
+
+// Decode access token and authorize its owner to perform the action.
+func (svc *Auth) authorize(ctx context.Context, tokenString string, action domain.Action) (domain.AccessToken, error) {
+	token, err := svc.decodeAccessToken(tokenString)
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	if err := svc.authz.Authorize(ctx, token, action); err != nil {
+		svc.l.Info(
+			"rejected unauthorized action",
+			zap.String("account_id", token.SubjectId),
+			zap.String("account_type", token.SubjectType),
+			zap.String("action", string(action)),
+			zap.Error(err),
+		)
+		return domain.AccessToken{}, err
+	}
+	if token.Impersonated() {
+		svc.l.Info("rejected privileged action with impersonation token", zap.String("account_id", token.SubjectId), zap.String("actor_id", token.ActorId), zap.String("action", string(action)))
+		return domain.AccessToken{}, fmt.Errorf("%w: %w", domain.ErrPermissionDenied, domain.ErrImpersonationRefused)
+	}
+	return token, nil
+}
+
+func newAccountInfo(id string, name, email, accountType string, createdAt, activatedAt, suspendedAt time.Time) domain.AccountInfo {
+	info := domain.AccountInfo{
+		Id:        id,
+		Name:      name,
+		Email:     email,
+		Type:      accountType,
+		CreatedAt: createdAt,
+	}
+	if !activatedAt.IsZero() {
+		info.ActivatedAt = &activatedAt
+	}
+	if !suspendedAt.IsZero() {
+		info.SuspendedAt = &suspendedAt
+	}
+	return info
+}
+
+const (
+	defaultListAccountsPageSize = 50
+	maxListAccountsPageSize     = 100
+)
+
+func (svc *Auth) ListAccounts(ctx context.Context, req domain.ListAccountsReq) (domain.ListAccountsRes, error) {
+	if _, err := svc.authorize(ctx, req.AccessToken, domain.ActionListAccounts); err != nil {
+		return domain.ListAccountsRes{}, err
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultListAccountsPageSize
+	}
+	pageSize = min(pageSize, maxListAccountsPageSize)
+
+	// One more account is listed to find out whether there is a next page.
+	out, err := svc.accProv.ListAccounts(ctx, domain.ListAccountsDTOInput{
+		Type:          req.Type,
+		Activated:     req.Activated,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		AfterId:       req.PageToken,
+		Limit:         pageSize + 1,
+	})
+	if err != nil {
+		svc.l.Error("failed to list accounts", zap.Error(err))
+		return domain.ListAccountsRes{}, err
+	}
+
+	accounts := out.Accounts
+	var nextPageToken string
+	if len(accounts) > pageSize {
+		accounts = accounts[:pageSize]
+		nextPageToken = accounts[len(accounts)-1].Id
+	}
+
+	res := domain.ListAccountsRes{
+		Accounts:      make([]domain.AccountInfo, 0, len(accounts)),
+		NextPageToken: nextPageToken,
+	}
+	for _, acc := range accounts {
+		res.Accounts = append(res.Accounts, newAccountInfo(acc.Id, acc.Name, acc.Email, acc.Type, acc.CreatedAt, acc.ActivatedAt, acc.SuspendedAt))
+	}
+
+	return res, nil
+}
+
+func (svc *Auth) GetAccount(ctx context.Context, req domain.GetAccountReq) (domain.GetAccountRes, error) {
+	if _, err := svc.authorize(ctx, req.AccessToken, domain.ActionGetAccount); err != nil {
+		return domain.GetAccountRes{}, err
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: req.AccountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account", zap.String("account_id", req.AccountId), zap.Error(err))
+		return domain.GetAccountRes{}, err
+	}
+	if acc == nil {
+		return domain.GetAccountRes{}, domain.ErrUserNotFound
+	}
+
+	return domain.GetAccountRes{
+		Account: newAccountInfo(req.AccountId, acc.Name, acc.Email, acc.Type, acc.CreatedAt, acc.ActivatedAt, acc.SuspendedAt),
+	}, nil
+}
+
+// Authorize the access token owner to manage the account and make sure the account exists
+// and does not belong to the access token owner, so that admins can't lock themselves out.
+func (svc *Auth) prepareAccountManagement(ctx context.Context, accessToken, accountId string, action domain.Action) (domain.AccessToken, *domain.FindAccountDTOOutput, error) {
+	token, err := svc.authorize(ctx, accessToken, action)
+	if err != nil {
+		return domain.AccessToken{}, nil, err
+	}
+	if token.SubjectId == accountId {
+		svc.l.Info("rejected admin operation on admin's own account", zap.String("account_id", token.SubjectId))
+		return domain.AccessToken{}, nil, domain.ErrPermissionDenied
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account to manage", zap.String("account_id", accountId), zap.Error(err))
+		return domain.AccessToken{}, nil, err
+	}
+	if acc == nil {
+		return domain.AccessToken{}, nil, domain.ErrUserNotFound
+	}
+
+	return token, acc, nil
+}
+
+func (svc *Auth) SuspendAccount(ctx context.Context, req domain.SuspendAccountReq) (domain.SuspendAccountRes, error) {
+	token, _, err := svc.prepareAccountManagement(ctx, req.AccessToken, req.AccountId, domain.ActionSuspendAccount)
+	if err != nil {
+		return domain.SuspendAccountRes{}, err
+	}
+
+	if err := svc.accProv.SuspendAccount(ctx, domain.SuspendAccountDTOInput{
+		Id:          req.AccountId,
+		SuspendedAt: time.Now(),
+	}); err != nil {
+		svc.l.Error("failed to suspend account", zap.String("account_id", req.AccountId), zap.Error(err))
+		return domain.SuspendAccountRes{}, err
+	}
+	svc.l.Info("suspended account", zap.String("account_id", req.AccountId), zap.String("suspended_by", token.SubjectId))
+
+	if err := svc.revokeAccountAccessTokens(ctx, req.AccountId, token.SubjectId); err != nil {
+		return domain.SuspendAccountRes{}, err
+	}
+
+	return domain.SuspendAccountRes{}, nil
+}
+
+func (svc *Auth) UnsuspendAccount(ctx context.Context, req domain.UnsuspendAccountReq) (domain.UnsuspendAccountRes, error) {
+	token, _, err := svc.prepareAccountManagement(ctx, req.AccessToken, req.AccountId, domain.ActionSuspendAccount)
+	if err != nil {
+		return domain.UnsuspendAccountRes{}, err
+	}
+
+	if err := svc.accProv.UnsuspendAccount(ctx, domain.UnsuspendAccountDTOInput{
+		Id: req.AccountId,
+	}); err != nil {
+		svc.l.Error("failed to unsuspend account", zap.String("account_id", req.AccountId), zap.Error(err))
+		return domain.UnsuspendAccountRes{}, err
+	}
+	svc.l.Info("unsuspended account", zap.String("account_id", req.AccountId), zap.String("unsuspended_by", token.SubjectId))
+
+	return domain.UnsuspendAccountRes{}, nil
+}
+
+func (svc *Auth) UpdateAccountType(ctx context.Context, req domain.UpdateAccountTypeReq) (domain.UpdateAccountTypeRes, error) {
+	if err := domain.ValidateAccountType(req.Type); err != nil {
+		return domain.UpdateAccountTypeRes{}, err
+	}
+
+	token, acc, err := svc.prepareAccountManagement(ctx, req.AccessToken, req.AccountId, domain.ActionUpdateAccountType)
+	if err != nil {
+		return domain.UpdateAccountTypeRes{}, err
+	}
+	// Service accounts have no password anybody knows and people's accounts have no api keys.
+	if (req.Type == domain.AccountTypeService) != (acc.Type == domain.AccountTypeService) {
+		svc.l.Info("rejected converting account to or from service account", zap.String("account_id", req.AccountId))
+		return domain.UpdateAccountTypeRes{}, domain.ErrInvalidAccountType
+	}
+
+	if err := svc.accProv.UpdateAccountType(ctx, domain.UpdateAccountTypeDTOInput{
+		Id:   req.AccountId,
+		Type: req.Type,
+	}); err != nil {
+		svc.l.Error("failed to update account type", zap.String("account_id", req.AccountId), zap.Error(err))
+		return domain.UpdateAccountTypeRes{}, err
+	}
+	svc.l.Info("updated account type", zap.String("account_id", req.AccountId), zap.String("type", req.Type), zap.String("updated_by", token.SubjectId))
+
+	// Tokens carry the permissions of the previous account type.
+	if err := svc.revokeAccountAccessTokens(ctx, req.AccountId, token.SubjectId); err != nil {
+		return domain.UpdateAccountTypeRes{}, err
+	}
+
+	return domain.UpdateAccountTypeRes{}, nil
+}
+
+var errLoginThrottlingUnavailable = errors.New("login throttling is unavailable: login attempts provider not set")
+
+func (svc *Auth) UnlockAccount(ctx context.Context, req domain.UnlockAccountReq) (domain.UnlockAccountRes, error) {
+	if svc.loginAttempts == nil {
+		return domain.UnlockAccountRes{}, errLoginThrottlingUnavailable
+	}
+
+	token, acc, err := svc.prepareAccountManagement(ctx, req.AccessToken, req.AccountId, domain.ActionUnlockAccount)
+	if err != nil {
+		return domain.UnlockAccountRes{}, err
+	}
+
+	key := domain.LoginAttemptsKey(domain.LoginAttemptsKeyKindEmail, strings.ToLower(acc.Email))
+	if err := svc.loginAttempts.Reset(ctx, key); err != nil {
+		svc.l.Error("failed to reset failed login attempts", zap.String("account_id", req.AccountId), zap.Error(err))
+		return domain.UnlockAccountRes{}, err
+	}
+	svc.l.Info("unlocked account", zap.String("account_id", req.AccountId), zap.String("unlocked_by", token.SubjectId))
+
+	return domain.UnlockAccountRes{}, nil
+}
@@ -0,0 +1,371 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Token provider decoding access tokens from a fixed set of token strings.
+type fakeTokenProvider struct {
+	domain.TokenProvider
+	accessTokens map[string]domain.AccessToken
+	accessErrs   map[string]error
+	// refresh tokens decoded by the token string
+	refreshTokens map[string]domain.RefreshToken
+	idTokens      []domain.IdToken
+	// access tokens encoded by the service
+	encodedAccessTokens []domain.AccessToken
+}
+
+// Encoded refresh tokens can be decoded afterwards.
+func (p *fakeTokenProvider) EncodeRefresh(token domain.RefreshToken) (string, error) {
+	if p.refreshTokens == nil {
+		p.refreshTokens = make(map[string]domain.RefreshToken)
+	}
+	p.refreshTokens["refresh-"+token.Id] = token
+	return "refresh-" + token.Id, nil
+}
+
+func (p *fakeTokenProvider) DecodeRefresh(token string) (domain.RefreshToken, error) {
+	if t, ok := p.refreshTokens[token]; ok {
+		return t, nil
+	}
+	return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+}
+
+func (p *fakeTokenProvider) EncodeMfaChallenge(token domain.MfaChallengeToken) (string, error) {
+	return "mfa-" + token.SubjectId, nil
+}
+
+func (p *fakeTokenProvider) DecodeMfaChallenge(token string) (domain.MfaChallengeToken, error) {
+	subjectId, ok := strings.CutPrefix(token, "mfa-")
+	if !ok {
+		return domain.MfaChallengeToken{}, domain.ErrInvalidMfaChallengeToken
+	}
+	return domain.MfaChallengeToken{SubjectId: subjectId}, nil
+}
+
+func (p *fakeTokenProvider) EncodeAccess(token domain.AccessToken) (string, error) {
+	p.encodedAccessTokens = append(p.encodedAccessTokens, token)
+	return "access-" + token.SubjectId, nil
+}
+
+func (p *fakeTokenProvider) EncodeId(token domain.IdToken) (string, error) {
+	p.idTokens = append(p.idTokens, token)
+	return fmt.Sprintf("id-%d", len(p.idTokens)), nil
+}
+
+func (p *fakeTokenProvider) DecodeAccess(token string) (domain.AccessToken, error) {
+	if err, ok := p.accessErrs[token]; ok {
+		return domain.AccessToken{}, err
+	}
+	if t, ok := p.accessTokens[token]; ok {
+		return t, nil
+	}
+	return domain.AccessToken{}, domain.ErrTokenParseFailed
+}
+
+type fakeAccountProvider struct {
+	domain.AccountProvider
+	created   []domain.CreateAccountDTOInput
+	byId      map[string]*domain.FindAccountDTOOutput
+	byEmail   map[string]*domain.FindAccountByEmailDTOOutput
+	passwords map[string]string
+	activated []string
+
+	mfa           map[string]*domain.FindAccountMfaDTOOutput
+	recoveryCodes map[string][]string
+}
+
+func (p *fakeAccountProvider) FindAccount(_ context.Context, in domain.FindAccountDTOInput) (*domain.FindAccountDTOOutput, error) {
+	return p.byId[in.Id], nil
+}
+
+// Credentials are valid for accounts found by email whose password is set.
+func (p *fakeAccountProvider) CheckAccountCredentials(_ context.Context, in domain.CheckAccountCredentialsDTOInput) (domain.CheckAccountCredentialsDTOOutput, error) {
+	acc, ok := p.byEmail[in.Email]
+	if !ok || p.passwords[acc.Id] != in.Password {
+		return domain.CheckAccountCredentialsDTOOutput{}, nil
+	}
+	mfa := p.mfa[acc.Id]
+	return domain.CheckAccountCredentialsDTOOutput{
+		Ok:          true,
+		Activated:   acc.Activated,
+		MfaEnabled:  mfa != nil && !mfa.EnabledAt.IsZero(),
+		AccountId:   acc.Id,
+		AccountType: acc.Type,
+	}, nil
+}
+
+func (p *fakeAccountProvider) FindAccountMfa(_ context.Context, in domain.FindAccountMfaDTOInput) (*domain.FindAccountMfaDTOOutput, error) {
+	if _, ok := p.byId[in.Id]; !ok {
+		return nil, nil
+	}
+	if mfa, ok := p.mfa[in.Id]; ok {
+		out := *mfa
+		out.RecoveryCodesLeft = len(p.recoveryCodes[in.Id])
+		return &out, nil
+	}
+	return &domain.FindAccountMfaDTOOutput{}, nil
+}
+
+func (p *fakeAccountProvider) SetMfaTotpSecret(_ context.Context, in domain.SetMfaTotpSecretDTOInput) error {
+	if p.mfa == nil {
+		p.mfa = make(map[string]*domain.FindAccountMfaDTOOutput)
+	}
+	p.mfa[in.Id] = &domain.FindAccountMfaDTOOutput{TotpSecret: in.TotpSecret}
+	return nil
+}
+
+func (p *fakeAccountProvider) EnableMfa(_ context.Context, in domain.EnableMfaDTOInput) error {
+	if p.recoveryCodes == nil {
+		p.recoveryCodes = make(map[string][]string)
+	}
+	p.mfa[in.Id].EnabledAt = in.EnabledAt
+	p.mfa[in.Id].TotpLastCounter = in.TotpLastCounter
+	p.recoveryCodes[in.Id] = slices.Clone(in.RecoveryCodes)
+	return nil
+}
+
+func (p *fakeAccountProvider) DisableMfa(_ context.Context, in domain.DisableMfaDTOInput) error {
+	delete(p.mfa, in.Id)
+	delete(p.recoveryCodes, in.Id)
+	return nil
+}
+
+func (p *fakeAccountProvider) UseMfaTotpCounter(_ context.Context, in domain.UseMfaTotpCounterDTOInput) (bool, error) {
+	mfa := p.mfa[in.Id]
+	if mfa == nil || mfa.TotpLastCounter >= in.Counter {
+		return false, nil
+	}
+	mfa.TotpLastCounter = in.Counter
+	return true, nil
+}
+
+func (p *fakeAccountProvider) UseMfaRecoveryCode(_ context.Context, in domain.UseMfaRecoveryCodeDTOInput) (bool, error) {
+	codes := p.recoveryCodes[in.Id]
+	i := slices.Index(codes, in.Code)
+	if i == -1 {
+		return false, nil
+	}
+	p.recoveryCodes[in.Id] = slices.Delete(codes, i, i+1)
+	return true, nil
+}
+
+func (p *fakeAccountProvider) FindAccountByEmail(_ context.Context, in domain.FindAccountByEmailDTOInput) (*domain.FindAccountByEmailDTOOutput, error) {
+	return p.byEmail[in.Email], nil
+}
+
+func (p *fakeAccountProvider) UpdatePassword(_ context.Context, in domain.UpdatePasswordDTOInput) error {
+	if p.passwords == nil {
+		p.passwords = make(map[string]string)
+	}
+	p.passwords[in.Id] = in.Password
+	return nil
+}
+
+func (p *fakeAccountProvider) ActivateAccountsByEmail(_ context.Context, in domain.ActivateAccountsByEmailDTOInput) error {
+	p.activated = append(p.activated, in.Emails...)
+	return nil
+}
+
+// Created accounts can be found by id afterwards.
+func (p *fakeAccountProvider) CreateAccount(_ context.Context, in domain.CreateAccountDTOInput) (domain.CreateAccountDTOOutput, error) {
+	p.created = append(p.created, in)
+	id := fmt.Sprintf("account-%d", len(p.created))
+	if p.byId == nil {
+		p.byId = make(map[string]*domain.FindAccountDTOOutput)
+	}
+	p.byId[id] = &domain.FindAccountDTOOutput{Name: in.Name, Email: in.Email, Type: in.Type, Activated: in.Activated}
+	return domain.CreateAccountDTOOutput{
+		Id:    id,
+		Name:  in.Name,
+		Email: in.Email,
+		Type:  in.Type,
+	}, nil
+}
+
+type fakeRefreshTokenProvider struct {
+	domain.RefreshTokenProvider
+	revokedAccountIds []string
+}
+
+func (p *fakeRefreshTokenProvider) DeleteByAccountId(_ context.Context, in domain.RefreshTokenDeleteByAccountIdDTOInput) (domain.RefreshTokenDeleteByAccountIdDTOOutput, error) {
+	p.revokedAccountIds = append(p.revokedAccountIds, in.Id)
+	return domain.RefreshTokenDeleteByAccountIdDTOOutput{}, nil
+}
+
+func (p *fakeRefreshTokenProvider) Add(_ context.Context, in domain.RefreshTokenAddDTOInput) (domain.RefreshTokenAddDTOOutput, error) {
+	return domain.RefreshTokenAddDTOOutput{
+		Id:        "token-id",
+		CreatedAt: in.CreatedAt,
+		ExpiresAt: in.ExpiresAt,
+	}, nil
+}
+
+// Lists the only refresh token ever added.
+func (p *fakeRefreshTokenProvider) List(_ context.Context, _ domain.RefreshTokenListDTOInput) (domain.RefreshTokenListDTOOutput, error) {
+	return domain.RefreshTokenListDTOOutput{Tokens: []domain.RefreshTokenListDTOOutputToken{{Id: "token-id"}}}, nil
+}
+
+func (p *fakeRefreshTokenProvider) Touch(_ context.Context, _ domain.RefreshTokenTouchDTOInput) error {
+	return nil
+}
+
+type fakeAccountCreationNotifications struct {
+	sent []string
+}
+
+func (n *fakeAccountCreationNotifications) Send(_ context.Context, in domain.SendAccountCreationNotificationDTOInput) (domain.SendAccountCreationNotificationDTOOutput, error) {
+	n.sent = append(n.sent, in.Email)
+	return domain.SendAccountCreationNotificationDTOOutput{}, nil
+}
+
+const (
+	tokenAdmin   = "admin"
+	tokenSeller  = "seller"
+	tokenUser    = "user"
+	tokenExpired = "expired"
+	tokenInvalid = "invalid"
+)
+
+func newTestAuth(t *testing.T) (*service.Auth, *fakeAccountProvider) {
+	t.Helper()
+	return newTestAuthWith(t, service.NewAuthBuilder())
+}
+
+func newTestAuthWith(t *testing.T, b *service.AuthBuilder) (*service.Auth, *fakeAccountProvider) {
+	t.Helper()
+	svc, accProv, _ := newTestAuthWithTokens(t, b)
+	return svc, accProv
+}
+
+func newTestAuthWithTokens(t *testing.T, b *service.AuthBuilder) (*service.Auth, *fakeAccountProvider, *fakeTokenProvider) {
+	t.Helper()
+
+	accProv := &fakeAccountProvider{}
+	tokenProv := &fakeTokenProvider{
+		accessTokens: map[string]domain.AccessToken{
+			tokenAdmin:  {SubjectId: "admin-id", SubjectType: domain.AccountTypeAdmin},
+			tokenSeller: {SubjectId: "seller-id", SubjectType: domain.AccountTypeSeller},
+			tokenUser:   {SubjectId: "user-id", SubjectType: domain.AccountTypeUser},
+		},
+		accessErrs: map[string]error{
+			tokenExpired: domain.ErrTokenExpired,
+			tokenInvalid: domain.ErrInvalidAccessToken,
+		},
+	}
+
+	svc, err := b.
+		AccountProvider(accProv).
+		AccountCreationNotificationProvider(&fakeAccountCreationNotifications{}).
+		RefreshTokenProvider(&fakeRefreshTokenProvider{}).
+		TokenProvider(tokenProv).
+		Build()
+	require.NoError(t, err)
+
+	return svc, accProv, tokenProv
+}
+
+func newCreateSellerReq(accessToken string) domain.CreateSellerReq {
+	return domain.CreateSellerReq{
+		Name:        "seller",
+		Password:    "password",
+		Email:       "seller@example.com",
+		AccessToken: accessToken,
+	}
+}
+
+func TestCreateSellerByAdmin(t *testing.T) {
+	svc, accProv := newTestAuth(t)
+
+	res, err := svc.CreateSeller(context.Background(), newCreateSellerReq(tokenAdmin))
+	require.NoError(t, err)
+
+	assert.Equal(t, "seller@example.com", res.Email)
+	require.Len(t, accProv.created, 1)
+	assert.Equal(t, domain.AccountTypeSeller, accProv.created[0].Type)
+}
+
+func TestCreateSellerRejectsNonAdmins(t *testing.T) {
+	for _, token := range []string{tokenSeller, tokenUser} {
+		t.Run(token, func(t *testing.T) {
+			svc, accProv := newTestAuth(t)
+
+			_, err := svc.CreateSeller(context.Background(), newCreateSellerReq(token))
+			assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+			assert.Empty(t, accProv.created, "no account should be created for a non-admin")
+		})
+	}
+}
+
+func TestCreateSellerRejectsBadTokens(t *testing.T) {
+	for _, token := range []string{"garbage", "", tokenExpired, tokenInvalid} {
+		t.Run(token, func(t *testing.T) {
+			svc, accProv := newTestAuth(t)
+
+			_, err := svc.CreateSeller(context.Background(), newCreateSellerReq(token))
+			assert.ErrorIs(t, err, domain.ErrInvalidAccessToken)
+			assert.Empty(t, accProv.created, "no account should be created for a bad access token")
+		})
+	}
+}
+
+func TestCreateAdminCreatesActivatedAccount(t *testing.T) {
+	svc, accProv := newTestAuth(t)
+
+	res, err := svc.CreateAdmin(context.Background(), domain.CreateAdminReq{
+		Name:     "admin",
+		Password: "password",
+		Email:    "admin@example.com",
+	})
+	require.NoError(t, err)
+
+	assert.True(t, res.Created)
+	require.Len(t, accProv.created, 1)
+	assert.Equal(t, domain.AccountTypeAdmin, accProv.created[0].Type)
+	assert.True(t, accProv.created[0].Activated)
+}
+
+func TestCreateAdminRotatesPassword(t *testing.T) {
+	svc, accProv := newTestAuth(t)
+	accProv.byEmail = map[string]*domain.FindAccountByEmailDTOOutput{
+		"admin@example.com": {Id: "admin-id", Name: "admin", Type: domain.AccountTypeAdmin},
+	}
+
+	res, err := svc.CreateAdmin(context.Background(), domain.CreateAdminReq{
+		Name:     "admin",
+		Password: "new-password",
+		Email:    "admin@example.com",
+	})
+	require.NoError(t, err)
+
+	assert.False(t, res.Created)
+	assert.Equal(t, "admin-id", res.Id)
+	assert.Empty(t, accProv.created)
+	assert.Equal(t, "new-password", accProv.passwords["admin-id"])
+	assert.Equal(t, []string{"admin@example.com"}, accProv.activated)
+}
+
+func TestCreateAdminRejectsNonAdminEmail(t *testing.T) {
+	svc, accProv := newTestAuth(t)
+	accProv.byEmail = map[string]*domain.FindAccountByEmailDTOOutput{
+		"user@example.com": {Id: "user-id", Name: "user", Type: domain.AccountTypeUser, Activated: true},
+	}
+
+	_, err := svc.CreateAdmin(context.Background(), domain.CreateAdminReq{
+		Name:     "admin",
+		Password: "password",
+		Email:    "user@example.com",
+	})
+	assert.ErrorIs(t, err, domain.ErrEmailIsInUse)
+	assert.Empty(t, accProv.passwords)
+}
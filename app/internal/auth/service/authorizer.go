@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// Authorizer granting actions by the access token owner account type.
+type RoleAuthorizer struct {
+	policy map[domain.Action][]domain.AccountType
+}
+
+var _ domain.Authorizer = (*RoleAuthorizer)(nil)
+
+// Actions not present in the policy are denied.
+func NewRoleAuthorizer(policy map[domain.Action][]domain.AccountType) *RoleAuthorizer {
+	return &RoleAuthorizer{policy: policy}
+}
+
+// Every privileged action is granted to admins only.
+func DefaultAuthorizationPolicy() map[domain.Action][]domain.AccountType {
+	admin := []domain.AccountType{domain.AccountTypeAdmin}
+	return map[domain.Action][]domain.AccountType{
+		domain.ActionCreateSeller:          admin,
+		domain.ActionListAccounts:          admin,
+		domain.ActionGetAccount:            admin,
+		domain.ActionSuspendAccount:        admin,
+		domain.ActionUnlockAccount:         admin,
+		domain.ActionUpdateAccountType:     admin,
+		domain.ActionDeleteAnyAccount:      admin,
+		domain.ActionExportAnyAccountData:  admin,
+		domain.ActionManageOAuthClients:    admin,
+		domain.ActionManageServiceAccounts: admin,
+		domain.ActionImpersonate:           admin,
+		domain.ActionReadAuditLog:          admin,
+		domain.ActionRevokeAccessTokens:    admin,
+	}
+}
+
+func (a *RoleAuthorizer) Authorize(_ context.Context, token domain.AccessToken, action domain.Action) error {
+	if !slices.Contains(a.policy[action], token.SubjectType) {
+		return fmt.Errorf("%w: account type %q may not perform action %q", domain.ErrPermissionDenied, token.SubjectType, action)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAuthorizerDefaultPolicy(t *testing.T) {
+	authz := service.NewRoleAuthorizer(service.DefaultAuthorizationPolicy())
+
+	for action := range service.DefaultAuthorizationPolicy() {
+		assert.NoError(t, authz.Authorize(context.Background(), domain.AccessToken{SubjectType: domain.AccountTypeAdmin}, action), action)
+
+		for _, accountType := range []domain.AccountType{domain.AccountTypeSeller, domain.AccountTypeUser, ""} {
+			err := authz.Authorize(context.Background(), domain.AccessToken{SubjectType: accountType}, action)
+			assert.ErrorIs(t, err, domain.ErrPermissionDenied, "%s must not be allowed to %s", accountType, action)
+		}
+	}
+}
+
+func TestRoleAuthorizerDeniesUnknownActions(t *testing.T) {
+	authz := service.NewRoleAuthorizer(service.DefaultAuthorizationPolicy())
+
+	err := authz.Authorize(context.Background(), domain.AccessToken{SubjectType: domain.AccountTypeAdmin}, domain.Action("unknown"))
+	assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/entity"
+	"go.uber.org/zap"
+)
+
+var errIdentitiesUnavailable = errors.New("login with identity providers is unavailable: identities, identity logins or identity providers not set")
+
+func (svc *Auth) identitiesAvailable() bool {
+	return svc.identities != nil && svc.identityLogins != nil && len(svc.identityProviders) > 0
+}
+
+func (svc *Auth) BeginIdentityLogin(ctx context.Context, req domain.BeginIdentityLoginReq) (domain.BeginIdentityLoginRes, error) {
+	if !svc.identitiesAvailable() {
+		return domain.BeginIdentityLoginRes{}, errIdentitiesUnavailable
+	}
+
+	prov, ok := svc.identityProviders[req.Provider]
+	if !ok {
+		return domain.BeginIdentityLoginRes{}, domain.ErrIdentityProviderNotFound
+	}
+
+	login := domain.IdentityLogin{
+		State:        entity.Id(32),
+		Provider:     req.Provider,
+		Nonce:        entity.Id(32),
+		CodeVerifier: entity.Id(32),
+		ExpiresAt:    time.Now().Add(svc.identityLoginDuration),
+	}
+	if err := svc.identityLogins.Insert(ctx, login); err != nil {
+		svc.l.Error("failed to insert identity login", zap.String("provider", req.Provider), zap.Error(err))
+		return domain.BeginIdentityLoginRes{}, err
+	}
+
+	return domain.BeginIdentityLoginRes{
+		AuthorizationUrl: prov.AuthCodeUrl(login.State, login.Nonce, login.CodeVerifier),
+		State:            login.State,
+		ExpiresAt:        login.ExpiresAt,
+	}, nil
+}
+
+// Verify the identity asserted by the provider and start a new session of the account
+// the identity is linked to. Unknown identities are linked to the account with the same
+// provider-verified email, or a new activated account is created for them.
+func (svc *Auth) authenticateWithIdentity(ctx context.Context, req domain.AuthenticateReq) (domain.AuthenticateRes, error) {
+	if !svc.identitiesAvailable() {
+		return domain.AuthenticateRes{}, errIdentitiesUnavailable
+	}
+
+	// The account is unknown until the identity is verified, so failures are only throttled per client ip.
+	loginAttemptsKeys := svc.loginAttemptsKeys("", req.Ip)
+	if _, err := svc.checkLoginThrottling(ctx, loginAttemptsKeys); err != nil {
+		return domain.AuthenticateRes{}, err
+	}
+
+	// Logins are single use: a finished or failed login has to be started over.
+	login, err := svc.identityLogins.Consume(ctx, req.Identity.State)
+	if err != nil {
+		svc.l.Error("failed to consume identity login", zap.Error(err))
+		return domain.AuthenticateRes{}, err
+	}
+	if login == nil || time.Now().After(login.ExpiresAt) {
+		return domain.AuthenticateRes{}, domain.ErrInvalidIdentityLogin
+	}
+	prov, ok := svc.identityProviders[login.Provider]
+	if !ok {
+		return domain.AuthenticateRes{}, domain.ErrInvalidIdentityLogin
+	}
+
+	ext, err := prov.Exchange(ctx, req.Identity.Code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		if !errors.Is(err, domain.ErrIdentityVerificationFailed) {
+			svc.l.Error("failed to exchange identity provider authorization code", zap.String("provider", login.Provider), zap.Error(err))
+			return domain.AuthenticateRes{}, err
+		}
+		svc.l.Info("rejected identity provider assertion", zap.String("provider", login.Provider), zap.Error(err))
+		if err := svc.registerLoginFailure(ctx, loginAttemptsKeys); err != nil {
+			return domain.AuthenticateRes{}, err
+		}
+		return domain.AuthenticateRes{}, err
+	}
+
+	accountId, err := svc.resolveIdentityAccount(ctx, login.Provider, ext)
+	if err != nil {
+		return domain.AuthenticateRes{}, err
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account linked to identity", zap.String("account_id", accountId), zap.Error(err))
+		return domain.AuthenticateRes{}, err
+	}
+	if acc == nil {
+		return domain.AuthenticateRes{}, domain.ErrUserNotFound
+	}
+	if !acc.SuspendedAt.IsZero() {
+		svc.l.Info("rejected creating refresh token for suspended account", zap.String("account_id", accountId))
+		return domain.AuthenticateRes{}, domain.ErrAccountSuspended
+	}
+
+	// Unlike passkeys, identity providers are just another first factor.
+	mfa, err := svc.accProv.FindAccountMfa(ctx, domain.FindAccountMfaDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account mfa", zap.String("account_id", accountId), zap.Error(err))
+		return domain.AuthenticateRes{}, err
+	}
+	if mfa != nil && !mfa.EnabledAt.IsZero() {
+		return svc.createMfaChallenge(accountId)
+	}
+
+	return svc.issueRefreshToken(ctx, accountId, acc.Type, authMethodIdentity, req.UserAgent, req.Ip)
+}
+
+// Returns id of the account the external identity is linked to, linking or creating one if needed.
+func (svc *Auth) resolveIdentityAccount(ctx context.Context, provider string, ext domain.ExternalIdentity) (string, error) {
+	identity, err := svc.identities.Find(ctx, provider, ext.Subject)
+	if err != nil {
+		svc.l.Error("failed to find identity", zap.String("provider", provider), zap.Error(err))
+		return "", err
+	}
+	if identity != nil {
+		return identity.AccountId, nil
+	}
+
+	// Unverified emails could be used to take over accounts registered with them.
+	if !ext.EmailVerified {
+		svc.l.Info("rejected identity with unverified email", zap.String("provider", provider))
+		return "", domain.ErrIdentityEmailNotVerified
+	}
+
+	acc, err := svc.accProv.FindAccountByEmail(ctx, domain.FindAccountByEmailDTOInput{
+		Email: ext.Email,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account by identity email", zap.String("provider", provider), zap.Error(err))
+		return "", err
+	}
+
+	var accountId string
+	if acc != nil {
+		// Whoever registered the account has not proven they own the email,
+		// the password they set must not grant access to the linked identity.
+		if !acc.Activated {
+			svc.l.Info("rejected linking identity to account that has not been activated", zap.String("account_id", acc.Id))
+			return "", domain.ErrAccountNotActivated
+		}
+		accountId = acc.Id
+	} else {
+		name := strings.TrimSpace(ext.Name)
+		if name == "" {
+			name, _, _ = strings.Cut(ext.Email, "@")
+		}
+		res, err := svc.createAccount(ctx, createAccountReq{
+			Name:  name,
+			Email: ext.Email,
+			// Random password nobody knows, it may be set with the password reset.
+			Password: entity.Id(15),
+			Type:     domain.AccountTypeUser,
+			// The provider has verified the email.
+			Activated: true,
+		})
+		if err != nil {
+			return "", err
+		}
+		accountId = res.Id
+		svc.l.Info("created account for identity", zap.String("account_id", accountId), zap.String("provider", provider))
+	}
+
+	if err := svc.identities.Add(ctx, domain.Identity{
+		Provider:  provider,
+		Subject:   ext.Subject,
+		AccountId: accountId,
+		Email:     ext.Email,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		svc.l.Error("failed to add identity", zap.String("account_id", accountId), zap.String("provider", provider), zap.Error(err))
+		return "", err
+	}
+	svc.l.Info("linked identity", zap.String("account_id", accountId), zap.String("provider", provider))
+
+	return accountId, nil
+}
+
+func (svc *Auth) ListIdentities(ctx context.Context, req domain.ListIdentitiesReq) (domain.ListIdentitiesRes, error) {
+	if !svc.identitiesAvailable() {
+		return domain.ListIdentitiesRes{}, errIdentitiesUnavailable
+	}
+
+	token, err := svc.decodeAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.ListIdentitiesRes{}, err
+	}
+
+	identities, err := svc.listIdentities(ctx, token.SubjectId)
+	if err != nil {
+		return domain.ListIdentitiesRes{}, err
+	}
+
+	return domain.ListIdentitiesRes{
+		Identities: identities,
+	}, nil
+}
+
+func (svc *Auth) listIdentities(ctx context.Context, accountId string) ([]domain.LinkedIdentity, error) {
+	identities, err := svc.identities.List(ctx, accountId)
+	if err != nil {
+		svc.l.Error("failed to list identities", zap.String("account_id", accountId), zap.Error(err))
+		return nil, err
+	}
+
+	linked := make([]domain.LinkedIdentity, 0, len(identities))
+	for _, identity := range identities {
+		linked = append(linked, domain.LinkedIdentity{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	return linked, nil
+}
@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Identity provider asserting the identity stored under the authorization code.
+type fakeIdentityProvider struct {
+	identities map[string]domain.ExternalIdentity
+}
+
+func (p *fakeIdentityProvider) AuthCodeUrl(state, nonce, _ string) string {
+	return "https://idp.example.com/authorize?" + url.Values{"state": {state}, "nonce": {nonce}}.Encode()
+}
+
+func (p *fakeIdentityProvider) Exchange(_ context.Context, code, _, _ string) (domain.ExternalIdentity, error) {
+	identity, ok := p.identities[code]
+	if !ok {
+		return domain.ExternalIdentity{}, domain.ErrIdentityVerificationFailed
+	}
+	return identity, nil
+}
+
+func newIdentityTestAuth(t *testing.T) (*service.Auth, *fakeAccountProvider, *fakeIdentityProvider) {
+	t.Helper()
+
+	idp := &fakeIdentityProvider{identities: map[string]domain.ExternalIdentity{
+		"new":         {Subject: "new-subject", Email: "new@example.com", EmailVerified: true, Name: "New User"},
+		"existing":    {Subject: "existing-subject", Email: "user@example.com", EmailVerified: true},
+		"unverified":  {Subject: "unverified-subject", Email: "user@example.com"},
+		"unactivated": {Subject: "unactivated-subject", Email: "unactivated@example.com", EmailVerified: true},
+	}}
+	svc, accProv := newTestAuthWith(t, service.NewAuthBuilder().
+		Identities(memory_adapter.NewIdentities()).
+		IdentityLogins(memory_adapter.NewIdentityLogins()).
+		IdentityProvider("google", idp),
+	)
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+	}
+	accProv.byEmail = map[string]*domain.FindAccountByEmailDTOOutput{
+		"user@example.com":        {Id: "user-id", Name: "user", Type: domain.AccountTypeUser, Activated: true},
+		"unactivated@example.com": {Id: "unactivated-id", Name: "unactivated", Type: domain.AccountTypeUser},
+	}
+
+	return svc, accProv, idp
+}
+
+func authenticateWithIdentity(t *testing.T, svc *service.Auth, code string) (domain.AuthenticateRes, error) {
+	t.Helper()
+
+	login, err := svc.BeginIdentityLogin(context.Background(), domain.BeginIdentityLoginReq{Provider: "google"})
+	require.NoError(t, err)
+
+	authorizationUrl, err := url.Parse(login.AuthorizationUrl)
+	require.NoError(t, err)
+	assert.Equal(t, login.State, authorizationUrl.Query().Get("state"))
+
+	return svc.Authenticate(context.Background(), domain.AuthenticateReq{
+		Identity: &domain.IdentityAssertion{State: login.State, Code: code},
+	})
+}
+
+func TestAuthenticateWithIdentityCreatesActivatedAccount(t *testing.T) {
+	svc, accProv, _ := newIdentityTestAuth(t)
+
+	res, err := authenticateWithIdentity(t, svc, "new")
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.RefreshToken)
+
+	require.Len(t, accProv.created, 1)
+	assert.Equal(t, "new@example.com", accProv.created[0].Email)
+	assert.Equal(t, "New User", accProv.created[0].Name)
+	assert.Equal(t, domain.AccountTypeUser, accProv.created[0].Type)
+	assert.True(t, accProv.created[0].Activated, "provider verified emails need no confirmation")
+
+	_, err = authenticateWithIdentity(t, svc, "new")
+	require.NoError(t, err)
+	assert.Len(t, accProv.created, 1, "linked identity should log in to the same account")
+
+	res2, err := svc.ListIdentities(context.Background(), domain.ListIdentitiesReq{AccessToken: tokenUser})
+	require.NoError(t, err)
+	assert.Empty(t, res2.Identities, "identity is linked to the created account only")
+}
+
+func TestAuthenticateWithIdentityLinksExistingAccount(t *testing.T) {
+	svc, accProv, _ := newIdentityTestAuth(t)
+
+	_, err := authenticateWithIdentity(t, svc, "existing")
+	require.NoError(t, err)
+	assert.Empty(t, accProv.created)
+
+	res, err := svc.ListIdentities(context.Background(), domain.ListIdentitiesReq{AccessToken: tokenUser})
+	require.NoError(t, err)
+	require.Len(t, res.Identities, 1)
+	assert.Equal(t, "google", res.Identities[0].Provider)
+	assert.Equal(t, "user@example.com", res.Identities[0].Email)
+}
+
+func TestAuthenticateWithIdentityRejectsUnverifiedEmails(t *testing.T) {
+	svc, accProv, _ := newIdentityTestAuth(t)
+
+	_, err := authenticateWithIdentity(t, svc, "unverified")
+	assert.ErrorIs(t, err, domain.ErrIdentityEmailNotVerified)
+	assert.Empty(t, accProv.created)
+}
+
+func TestAuthenticateWithIdentityDoesNotLinkUnactivatedAccounts(t *testing.T) {
+	svc, _, _ := newIdentityTestAuth(t)
+
+	_, err := authenticateWithIdentity(t, svc, "unactivated")
+	assert.ErrorIs(t, err, domain.ErrAccountNotActivated)
+}
+
+func TestAuthenticateWithIdentityPromptsForMfa(t *testing.T) {
+	svc, accProv, _ := newIdentityTestAuth(t)
+	accProv.mfa = map[string]*domain.FindAccountMfaDTOOutput{
+		"user-id": {EnabledAt: time.Now()},
+	}
+
+	res, err := authenticateWithIdentity(t, svc, "existing")
+	require.NoError(t, err)
+	assert.Empty(t, res.RefreshToken)
+	assert.Equal(t, "mfa-user-id", res.MfaChallengeToken)
+}
+
+func TestAuthenticateWithIdentityRejectsInvalidLogins(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := newIdentityTestAuth(t)
+
+	_, err := authenticateWithIdentity(t, svc, "unknown-code")
+	assert.ErrorIs(t, err, domain.ErrIdentityVerificationFailed)
+
+	_, err = svc.Authenticate(ctx, domain.AuthenticateReq{
+		Identity: &domain.IdentityAssertion{State: "unknown-state", Code: "new"},
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidIdentityLogin)
+
+	login, err := svc.BeginIdentityLogin(ctx, domain.BeginIdentityLoginReq{Provider: "google"})
+	require.NoError(t, err)
+	_, err = svc.Authenticate(ctx, domain.AuthenticateReq{Identity: &domain.IdentityAssertion{State: login.State, Code: "new"}})
+	require.NoError(t, err)
+	_, err = svc.Authenticate(ctx, domain.AuthenticateReq{Identity: &domain.IdentityAssertion{State: login.State, Code: "new"}})
+	assert.ErrorIs(t, err, domain.ErrInvalidIdentityLogin, "logins are single use")
+
+	_, err = svc.BeginIdentityLogin(ctx, domain.BeginIdentityLoginReq{Provider: "unknown"})
+	assert.ErrorIs(t, err, domain.ErrIdentityProviderNotFound)
+}
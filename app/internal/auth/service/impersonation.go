@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"go.uber.org/zap"
+)
+
+var errImpersonationUnavailable = errors.New("impersonation is unavailable: audit log not set")
+
+func (svc *Auth) Impersonate(ctx context.Context, req domain.ImpersonateReq) (domain.ImpersonateRes, error) {
+	if svc.auditLog == nil {
+		return domain.ImpersonateRes{}, errImpersonationUnavailable
+	}
+
+	token, acc, err := svc.prepareAccountManagement(ctx, req.AccessToken, req.AccountId, domain.ActionImpersonate)
+	if err != nil {
+		return domain.ImpersonateRes{}, err
+	}
+	// Acting as another admin would grant privileged actions on behalf of them.
+	if acc.Type == domain.AccountTypeAdmin || acc.Type == domain.AccountTypeService {
+		svc.l.Info("rejected impersonation", zap.String("account_id", req.AccountId), zap.String("account_type", acc.Type), zap.String("actor_id", token.SubjectId))
+		return domain.ImpersonateRes{}, domain.ErrImpersonationNotAllowed
+	}
+	if !acc.SuspendedAt.IsZero() {
+		return domain.ImpersonateRes{}, domain.ErrAccountSuspended
+	}
+
+	permissions, err := svc.permissions(ctx, acc.Type)
+	if err != nil {
+		return domain.ImpersonateRes{}, err
+	}
+
+	now := time.Now()
+	accessToken := domain.AccessToken{
+		SubjectId:   req.AccountId,
+		SubjectType: acc.Type,
+		Permissions: permissions,
+		ActorId:     token.SubjectId,
+		ExpiresAt:   now.Add(svc.impersonationTokenDuration),
+	}
+
+	// The token is not handed out unless the impersonation is recorded.
+	event := newAuditEvent(domain.AuditEventImpersonation, req.AccountId, map[string]string{
+		"reason":     req.Reason,
+		"expires_at": accessToken.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+	event.ActorId = token.SubjectId
+	if err := svc.auditLog.Append(ctx, event); err != nil {
+		svc.l.Error("failed to append impersonation audit event", zap.String("account_id", req.AccountId), zap.Error(err))
+		return domain.ImpersonateRes{}, err
+	}
+
+	accessTokenString, err := svc.tokenProv.EncodeAccess(accessToken)
+	if err != nil {
+		svc.l.Error("failed to encode impersonation access token", zap.Error(err))
+		return domain.ImpersonateRes{}, err
+	}
+	svc.l.Info("impersonated account", zap.String("account_id", req.AccountId), zap.String("actor_id", token.SubjectId))
+
+	return domain.ImpersonateRes{
+		AccessToken: accessTokenString,
+		ExpiresAt:   accessToken.ExpiresAt,
+	}, nil
+}
+
+// Decode access token of the account owner. Impersonation tokens are refused:
+// support staff may see what the owner sees but not change credentials, sessions
+// or the account itself, nor obtain refresh tokens through OpenID Connect.
+func (svc *Auth) decodeOwnerAccessToken(tokenString string) (domain.AccessToken, error) {
+	token, err := svc.decodeAccessToken(tokenString)
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	if token.Impersonated() {
+		svc.l.Info("rejected operation with impersonation token", zap.String("account_id", token.SubjectId), zap.String("actor_id", token.ActorId))
+		return domain.AccessToken{}, domain.ErrImpersonationRefused
+	}
+	return token, nil
+}
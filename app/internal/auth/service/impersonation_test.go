@@ -0,0 +1,136 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// In-memory audit log recording appended events, appending fails with err if it is set.
+type fakeAuditLog struct {
+	*memory_adapter.AuditLog
+	events []domain.AuditEvent
+	err    error
+}
+
+func (l *fakeAuditLog) Append(ctx context.Context, event domain.AuditEvent) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.events = append(l.events, event)
+	return l.AuditLog.Append(ctx, event)
+}
+
+const tokenImpersonation = "impersonation"
+
+func newImpersonationTestAuth(t *testing.T) (*service.Auth, *fakeAccountProvider, *fakeTokenProvider, *fakeAuditLog) {
+	t.Helper()
+
+	auditLog := &fakeAuditLog{AuditLog: memory_adapter.NewAuditLog()}
+	svc, accProv, tokenProv := newTestAuthWithTokens(t, service.NewAuthBuilder().AuditLog(auditLog))
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id":    {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+		"admin2-id":  {Name: "admin2", Email: "admin2@example.com", Type: domain.AccountTypeAdmin, Activated: true},
+		"service-id": {Name: "jobs", Email: "jobs@service.invalid", Type: domain.AccountTypeService, Activated: true},
+	}
+	tokenProv.accessTokens[tokenImpersonation] = domain.AccessToken{
+		SubjectId:   "user-id",
+		SubjectType: domain.AccountTypeUser,
+		ActorId:     "admin-id",
+	}
+
+	return svc, accProv, tokenProv, auditLog
+}
+
+func TestImpersonate(t *testing.T) {
+	svc, _, tokenProv, auditLog := newImpersonationTestAuth(t)
+
+	res, err := svc.Impersonate(context.Background(), domain.ImpersonateReq{
+		AccessToken: tokenAdmin,
+		AccountId:   "user-id",
+		Reason:      "ticket 42",
+	})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), res.ExpiresAt, time.Minute)
+
+	require.Len(t, tokenProv.encodedAccessTokens, 1)
+	token := tokenProv.encodedAccessTokens[0]
+	assert.Equal(t, "user-id", token.SubjectId)
+	assert.Equal(t, domain.AccountTypeUser, token.SubjectType)
+	assert.Equal(t, "admin-id", token.ActorId)
+	assert.True(t, token.Impersonated())
+	assert.True(t, token.Has(domain.PermissionOrdersRead), "impersonation token grants the account permissions")
+
+	require.Len(t, auditLog.events, 1)
+	event := auditLog.events[0]
+	assert.Equal(t, domain.AuditEventImpersonation, event.Type)
+	assert.Equal(t, "user-id", event.AccountId)
+	assert.Equal(t, "admin-id", event.ActorId)
+	assert.Equal(t, "ticket 42", event.Details["reason"])
+}
+
+func TestImpersonateRejectsInvalidRequests(t *testing.T) {
+	ctx := context.Background()
+	svc, _, tokenProv, auditLog := newImpersonationTestAuth(t)
+
+	for _, tc := range []struct {
+		accessToken string
+		accountId   string
+		err         error
+	}{
+		{tokenUser, "user-id", domain.ErrPermissionDenied},
+		{tokenSeller, "user-id", domain.ErrPermissionDenied},
+		{tokenImpersonation, "user-id", domain.ErrPermissionDenied},
+		{tokenAdmin, "admin-id", domain.ErrPermissionDenied},
+		{tokenAdmin, "admin2-id", domain.ErrImpersonationNotAllowed},
+		{tokenAdmin, "service-id", domain.ErrImpersonationNotAllowed},
+		{tokenAdmin, "unknown-id", domain.ErrUserNotFound},
+	} {
+		_, err := svc.Impersonate(ctx, domain.ImpersonateReq{AccessToken: tc.accessToken, AccountId: tc.accountId})
+		assert.ErrorIs(t, err, tc.err, tc.accessToken+" impersonating "+tc.accountId)
+	}
+
+	assert.Empty(t, tokenProv.encodedAccessTokens)
+	assert.Empty(t, auditLog.events)
+}
+
+func TestImpersonateRequiresAuditTrail(t *testing.T) {
+	ctx := context.Background()
+
+	svc, accProv := newTestAuth(t)
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+	}
+	_, err := svc.Impersonate(ctx, domain.ImpersonateReq{AccessToken: tokenAdmin, AccountId: "user-id"})
+	assert.Error(t, err, "impersonation must be unavailable without audit log")
+
+	svc, _, tokenProv, auditLog := newImpersonationTestAuth(t)
+	auditLog.err = errors.New("audit log is down")
+	_, err = svc.Impersonate(ctx, domain.ImpersonateReq{AccessToken: tokenAdmin, AccountId: "user-id"})
+	assert.ErrorIs(t, err, auditLog.err)
+	assert.Empty(t, tokenProv.encodedAccessTokens, "unrecorded impersonation must not issue a token")
+}
+
+func TestImpersonationTokenIsRefusedForAccountChanges(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, _ := newImpersonationTestAuth(t)
+
+	_, err := svc.UpdatePassword(ctx, domain.UpdatePasswordReq{AccessToken: tokenImpersonation, Password: "password", NewPassword: "new-password"})
+	assert.ErrorIs(t, err, domain.ErrImpersonationRefused)
+
+	_, err = svc.DeleteAccount(ctx, domain.DeleteAccountReq{AccessToken: tokenImpersonation})
+	assert.ErrorIs(t, err, domain.ErrImpersonationRefused)
+
+	_, err = svc.EnrollMfa(ctx, domain.EnrollMfaReq{AccessToken: tokenImpersonation})
+	assert.ErrorIs(t, err, domain.ErrImpersonationRefused)
+
+	_, err = svc.LogoutAll(ctx, domain.LogoutAllReq{AccessToken: tokenImpersonation})
+	assert.ErrorIs(t, err, domain.ErrImpersonationRefused)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"go.uber.org/zap"
+)
+
+func (svc *Auth) IntrospectToken(ctx context.Context, req domain.IntrospectTokenReq) (domain.IntrospectTokenRes, error) {
+	caller, err := svc.decodeAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.IntrospectTokenRes{}, err
+	}
+	if caller.SubjectType != domain.AccountTypeService || !caller.Has(domain.PermissionTokensIntrospect) {
+		return domain.IntrospectTokenRes{}, fmt.Errorf("%w: introspecting tokens requires a service account granted %q", domain.ErrPermissionDenied, domain.PermissionTokensIntrospect)
+	}
+
+	introspect := []func(context.Context, string) (domain.IntrospectTokenRes, error){
+		svc.introspectAccessToken,
+		svc.introspectRefreshToken,
+	}
+	if req.TokenTypeHint == domain.TokenTypeHintRefreshToken {
+		slices.Reverse(introspect)
+	}
+
+	for _, f := range introspect {
+		res, err := f(ctx, req.Token)
+		if err != nil {
+			return domain.IntrospectTokenRes{}, err
+		}
+		if res.Active {
+			return res, nil
+		}
+	}
+	return domain.IntrospectTokenRes{Active: false}, nil
+}
+
+// Access tokens on the revocation list are rejected by the token provider.
+func (svc *Auth) introspectAccessToken(ctx context.Context, tokenString string) (domain.IntrospectTokenRes, error) {
+	token, err := svc.tokenProv.DecodeAccess(tokenString)
+	if err != nil {
+		if isInvalidTokenErr(err) {
+			return domain.IntrospectTokenRes{Active: false}, nil
+		}
+		svc.l.Error("failed to decode access token for introspection", zap.Error(err))
+		return domain.IntrospectTokenRes{}, err
+	}
+
+	acc, err := svc.findIntrospectedAccount(ctx, token.SubjectId)
+	if err != nil {
+		return domain.IntrospectTokenRes{}, err
+	}
+	if acc == nil {
+		return domain.IntrospectTokenRes{Active: false}, nil
+	}
+
+	return domain.IntrospectTokenRes{
+		Active:      true,
+		TokenType:   domain.TokenTypeHintAccessToken,
+		Id:          token.Id,
+		SubjectId:   token.SubjectId,
+		SubjectType: token.SubjectType,
+		Permissions: token.Permissions,
+		ActorId:     token.ActorId,
+		IssuedAt:    token.IssuedAt,
+		ExpiresAt:   token.ExpiresAt,
+	}, nil
+}
+
+// Refresh tokens are revoked by deleting them, so the token must still be listed for the account.
+func (svc *Auth) introspectRefreshToken(ctx context.Context, tokenString string) (domain.IntrospectTokenRes, error) {
+	token, err := svc.tokenProv.DecodeRefresh(tokenString)
+	if err != nil {
+		if isInvalidTokenErr(err) {
+			return domain.IntrospectTokenRes{Active: false}, nil
+		}
+		svc.l.Error("failed to decode refresh token for introspection", zap.Error(err))
+		return domain.IntrospectTokenRes{}, err
+	}
+
+	acc, err := svc.findIntrospectedAccount(ctx, token.SubjectId)
+	if err != nil {
+		return domain.IntrospectTokenRes{}, err
+	}
+	if acc == nil {
+		return domain.IntrospectTokenRes{Active: false}, nil
+	}
+
+	out, err := svc.refreshTokenProv.List(ctx, domain.RefreshTokenListDTOInput{
+		AccountId: token.SubjectId,
+		Limit:     svc.refreshTokensLimit(acc.Type),
+	})
+	if err != nil {
+		svc.l.Error("failed to list refresh tokens for introspection", zap.Error(err))
+		return domain.IntrospectTokenRes{}, err
+	}
+
+	i := slices.IndexFunc(out.Tokens, func(t domain.RefreshTokenListDTOOutputToken) bool {
+		return t.Id == token.Id
+	})
+	if i == -1 {
+		return domain.IntrospectTokenRes{Active: false}, nil
+	}
+
+	return domain.IntrospectTokenRes{
+		Active:    true,
+		TokenType: domain.TokenTypeHintRefreshToken,
+		Id:        token.Id,
+		SubjectId: token.SubjectId,
+		IssuedAt:  out.Tokens[i].CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// Tokens of deleted and suspended accounts are inactive as CreateAccessToken refuses them,
+// no account is returned for them.
+func (svc *Auth) findIntrospectedAccount(ctx context.Context, accountId string) (*domain.FindAccountDTOOutput, error) {
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for introspection", zap.Error(err))
+		return nil, err
+	}
+	if acc == nil || !acc.SuspendedAt.IsZero() {
+		return nil, nil
+	}
+	return acc, nil
+}
+
+// Reports whether the decoding error means the token isn't valid rather than it couldn't be checked.
+func isInvalidTokenErr(err error) bool {
+	for _, target := range []error{
+		domain.ErrInvalidAccessToken,
+		domain.ErrInvalidRefreshToken,
+		domain.ErrInvalidTokenType,
+		domain.ErrTokenParseFailed,
+		domain.ErrTokenExpired,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	tokenIntrospector = "introspector"
+	tokenJobs         = "jobs"
+	tokenRefresh      = "refresh"
+	tokenRefreshGone  = "refresh-gone"
+
+	tokenSuspended        = "suspended"
+	tokenDeleted          = "deleted"
+	tokenRefreshSuspended = "refresh-suspended"
+	tokenRefreshDeleted   = "refresh-deleted"
+)
+
+func newIntrospectionTestAuth(t *testing.T, b *service.AuthBuilder) *service.Auth {
+	t.Helper()
+
+	svc, accProv, tokenProv := newTestAuthWithTokens(t, b)
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id":      {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+		"seller-id":    {Name: "seller", Email: "seller@example.com", Type: domain.AccountTypeSeller, Activated: true},
+		"suspended-id": {Name: "suspended", Email: "suspended@example.com", Type: domain.AccountTypeUser, Activated: true, SuspendedAt: time.Now()},
+	}
+	tokenProv.accessTokens[tokenSuspended] = domain.AccessToken{SubjectId: "suspended-id", SubjectType: domain.AccountTypeUser}
+	tokenProv.accessTokens[tokenDeleted] = domain.AccessToken{SubjectId: "deleted-id", SubjectType: domain.AccountTypeUser}
+	tokenProv.accessTokens[tokenIntrospector] = domain.AccessToken{
+		SubjectId:   "introspector-id",
+		SubjectType: domain.AccountTypeService,
+		Permissions: []domain.Permission{domain.PermissionTokensIntrospect},
+	}
+	tokenProv.accessTokens[tokenJobs] = domain.AccessToken{
+		SubjectId:   "jobs-id",
+		SubjectType: domain.AccountTypeService,
+		Permissions: []domain.Permission{domain.PermissionProductsRead},
+	}
+	// The fake refresh token provider lists "token-id" only.
+	tokenProv.refreshTokens = map[string]domain.RefreshToken{
+		tokenRefresh:     {Id: "token-id", SubjectId: "user-id", ExpiresAt: time.Now().Add(time.Hour)},
+		tokenRefreshGone: {Id: "deleted-token-id", SubjectId: "user-id", ExpiresAt: time.Now().Add(time.Hour)},
+		// Still listed, but the accounts can't use them.
+		tokenRefreshSuspended: {Id: "token-id", SubjectId: "suspended-id", ExpiresAt: time.Now().Add(time.Hour)},
+		tokenRefreshDeleted:   {Id: "token-id", SubjectId: "deleted-id", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+
+	return svc
+}
+
+func TestIntrospectAccessToken(t *testing.T) {
+	svc := newIntrospectionTestAuth(t, service.NewAuthBuilder())
+
+	res, err := svc.IntrospectToken(context.Background(), domain.IntrospectTokenReq{AccessToken: tokenIntrospector, Token: tokenUser})
+	require.NoError(t, err)
+
+	assert.True(t, res.Active)
+	assert.Equal(t, domain.TokenTypeHintAccessToken, res.TokenType)
+	assert.Equal(t, "user-id", res.SubjectId)
+	assert.Equal(t, domain.AccountTypeUser, res.SubjectType)
+}
+
+func TestIntrospectRefreshToken(t *testing.T) {
+	svc := newIntrospectionTestAuth(t, service.NewAuthBuilder())
+
+	for _, hint := range []string{"", domain.TokenTypeHintAccessToken, domain.TokenTypeHintRefreshToken} {
+		res, err := svc.IntrospectToken(context.Background(), domain.IntrospectTokenReq{AccessToken: tokenIntrospector, Token: tokenRefresh, TokenTypeHint: hint})
+		require.NoError(t, err)
+
+		assert.True(t, res.Active, hint)
+		assert.Equal(t, domain.TokenTypeHintRefreshToken, res.TokenType, hint)
+		assert.Equal(t, "token-id", res.Id, hint)
+		assert.Equal(t, "user-id", res.SubjectId, hint)
+	}
+}
+
+func TestIntrospectInactiveTokens(t *testing.T) {
+	revocations := memory_adapter.NewAccessTokenRevocations()
+	require.NoError(t, revocations.Revoke(context.Background(), domain.AccessTokenRevocation{
+		AccountId: "seller-id",
+		RevokedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	svc := newIntrospectionTestAuth(t, service.NewAuthBuilder().AccessTokenRevocations(revocations))
+
+	for name, token := range map[string]string{
+		"expired":         tokenExpired,
+		"invalid":         tokenInvalid,
+		"malformed":       "garbage",
+		"revoked access":  tokenSeller,
+		"deleted refresh": tokenRefreshGone,
+		// Inactive even without the revocation list.
+		"suspended account access":  tokenSuspended,
+		"deleted account access":    tokenDeleted,
+		"suspended account refresh": tokenRefreshSuspended,
+		"deleted account refresh":   tokenRefreshDeleted,
+	} {
+		res, err := svc.IntrospectToken(context.Background(), domain.IntrospectTokenReq{AccessToken: tokenIntrospector, Token: token})
+		require.NoError(t, err, name)
+
+		assert.Equal(t, domain.IntrospectTokenRes{Active: false}, res, name)
+	}
+}
+
+func TestIntrospectTokenRequiresIntrospectionScope(t *testing.T) {
+	svc := newIntrospectionTestAuth(t, service.NewAuthBuilder())
+
+	for _, token := range []string{tokenJobs, tokenAdmin, tokenUser} {
+		_, err := svc.IntrospectToken(context.Background(), domain.IntrospectTokenReq{AccessToken: token, Token: tokenUser})
+		assert.ErrorIs(t, err, domain.ErrPermissionDenied, token)
+	}
+
+	_, err := svc.IntrospectToken(context.Background(), domain.IntrospectTokenReq{AccessToken: tokenInvalid, Token: tokenUser})
+	assert.ErrorIs(t, err, domain.ErrInvalidAccessToken)
+}
@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLoginThrottlingPolicy = domain.LoginThrottlingPolicy{
+	FreeAttempts:     2,
+	BaseDelay:        time.Second,
+	MaxDelay:         4 * time.Second,
+	LockoutThreshold: 5,
+	LockoutDuration:  time.Hour,
+	ResetAfter:       2 * time.Hour,
+}
+
+func TestLoginThrottlingPolicyBlockedUntil(t *testing.T) {
+	lastFailureAt := time.Date(2025, 3, 18, 12, 0, 0, 0, time.UTC)
+
+	for failures, delay := range map[int]time.Duration{
+		0: 0,
+		2: 0,
+		3: time.Second,
+		4: 2 * time.Second,
+		5: time.Hour,
+		6: time.Hour,
+	} {
+		blockedUntil := testLoginThrottlingPolicy.BlockedUntil(domain.LoginAttemptsRecord{
+			Failures:      failures,
+			LastFailureAt: lastFailureAt,
+		})
+		if delay == 0 {
+			assert.True(t, blockedUntil.IsZero(), "%d failures must not be throttled", failures)
+			continue
+		}
+		assert.Equal(t, lastFailureAt.Add(delay), blockedUntil, "%d failures", failures)
+	}
+
+	policy := testLoginThrottlingPolicy
+	policy.LockoutThreshold = 10
+	blockedUntil := policy.BlockedUntil(domain.LoginAttemptsRecord{Failures: 9, LastFailureAt: lastFailureAt})
+	assert.Equal(t, lastFailureAt.Add(policy.MaxDelay), blockedUntil, "delay must be capped")
+}
+
+func newThrottledTestAuth(t *testing.T) (*service.Auth, *fakeAccountProvider) {
+	t.Helper()
+
+	svc, accProv := newTestAuthWith(t, service.NewAuthBuilder().
+		LoginAttempts(memory_adapter.NewLoginAttempts()).
+		LoginThrottlingPolicy(domain.LoginAttemptsKeyKindEmail, testLoginThrottlingPolicy).
+		LoginThrottlingPolicy(domain.LoginAttemptsKeyKindIp, testLoginThrottlingPolicy))
+
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+	}
+	accProv.byEmail = map[string]*domain.FindAccountByEmailDTOOutput{
+		"user@example.com": {Id: "user-id", Name: "user", Type: domain.AccountTypeUser, Activated: true},
+	}
+	accProv.passwords = map[string]string{"user-id": "password"}
+
+	return svc, accProv
+}
+
+func authenticate(svc *service.Auth, email, password, ip string) error {
+	_, err := svc.Authenticate(context.Background(), domain.AuthenticateReq{
+		Email:    email,
+		Password: password,
+		Ip:       ip,
+	})
+	return err
+}
+
+func TestAuthenticateThrottlesFailedAttemptsPerEmail(t *testing.T) {
+	svc, _ := newThrottledTestAuth(t)
+
+	for i := 0; i < testLoginThrottlingPolicy.FreeAttempts; i++ {
+		assert.ErrorIs(t, authenticate(svc, "user@example.com", "wrong", "10.0.0.1"), domain.ErrInvalidCredentials)
+	}
+	assert.ErrorIs(t, authenticate(svc, "user@example.com", "wrong", "10.0.0.2"), domain.ErrInvalidCredentials)
+
+	// Correct password is rejected as well from any ip until the delay passes.
+	assert.ErrorIs(t, authenticate(svc, "user@example.com", "password", "10.0.0.3"), domain.ErrTooManyLoginAttempts)
+
+	// Other emails are not affected.
+	assert.ErrorIs(t, authenticate(svc, "other@example.com", "wrong", "10.0.0.3"), domain.ErrInvalidCredentials)
+}
+
+func TestAuthenticateThrottlesFailedAttemptsPerIp(t *testing.T) {
+	svc, _ := newThrottledTestAuth(t)
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		assert.ErrorIs(t, authenticate(svc, email, "wrong", "10.0.0.1"), domain.ErrInvalidCredentials)
+	}
+
+	assert.ErrorIs(t, authenticate(svc, "user@example.com", "password", "10.0.0.1"), domain.ErrTooManyLoginAttempts)
+	assert.NoError(t, authenticate(svc, "user@example.com", "password", "10.0.0.2"))
+}
+
+func TestAuthenticateResetsFailedAttemptsOnSuccess(t *testing.T) {
+	svc, _ := newThrottledTestAuth(t)
+
+	for i := 0; i < testLoginThrottlingPolicy.FreeAttempts; i++ {
+		assert.ErrorIs(t, authenticate(svc, "user@example.com", "wrong", ""), domain.ErrInvalidCredentials)
+	}
+	require.NoError(t, authenticate(svc, "user@example.com", "password", ""))
+
+	for i := 0; i < testLoginThrottlingPolicy.FreeAttempts; i++ {
+		assert.ErrorIs(t, authenticate(svc, "user@example.com", "wrong", ""), domain.ErrInvalidCredentials)
+	}
+}
+
+func TestUnlockAccount(t *testing.T) {
+	svc, _ := newThrottledTestAuth(t)
+
+	for i := 0; i < testLoginThrottlingPolicy.LockoutThreshold; i++ {
+		_ = authenticate(svc, "user@example.com", "wrong", "")
+	}
+	require.ErrorIs(t, authenticate(svc, "user@example.com", "password", ""), domain.ErrTooManyLoginAttempts)
+
+	_, err := svc.UnlockAccount(context.Background(), domain.UnlockAccountReq{AccessToken: tokenUser, AccountId: "user-id"})
+	assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+
+	_, err = svc.UnlockAccount(context.Background(), domain.UnlockAccountReq{AccessToken: tokenAdmin, AccountId: "user-id"})
+	require.NoError(t, err)
+
+	assert.NoError(t, authenticate(svc, "user@example.com", "password", ""))
+}
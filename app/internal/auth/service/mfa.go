@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/bratushkadan/floral/pkg/entity"
+	"go.uber.org/zap"
+)
+
+func (svc *Auth) createMfaChallenge(accountId string) (domain.AuthenticateRes, error) {
+	token := domain.MfaChallengeToken{
+		SubjectId: accountId,
+		ExpiresAt: time.Now().Add(svc.mfaChallengeTokenDuration),
+	}
+
+	tokenStr, err := svc.tokenProv.EncodeMfaChallenge(token)
+	if err != nil {
+		svc.l.Error("failed to encode mfa challenge token", zap.String("account_id", accountId), zap.Error(err))
+		return domain.AuthenticateRes{}, err
+	}
+
+	return domain.AuthenticateRes{
+		MfaChallengeToken: tokenStr,
+		ExpiresAt:         token.ExpiresAt,
+	}, nil
+}
+
+func (svc *Auth) VerifyMfa(ctx context.Context, req domain.VerifyMfaReq) (domain.VerifyMfaRes, error) {
+	challenge, err := svc.tokenProv.DecodeMfaChallenge(req.MfaChallengeToken)
+	if err != nil {
+		svc.l.Info("failed to decode mfa challenge token", zap.Error(err))
+		if errors.Is(err, domain.ErrInvalidMfaChallengeToken) {
+			return domain.VerifyMfaRes{}, err
+		}
+		return domain.VerifyMfaRes{}, fmt.Errorf("%w: %w", domain.ErrInvalidMfaChallengeToken, err)
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: challenge.SubjectId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for mfa verification", zap.String("account_id", challenge.SubjectId), zap.Error(err))
+		return domain.VerifyMfaRes{}, err
+	}
+	if acc == nil {
+		return domain.VerifyMfaRes{}, domain.ErrInvalidMfaChallengeToken
+	}
+	if !acc.SuspendedAt.IsZero() {
+		svc.l.Info("rejected mfa verification for suspended account", zap.String("account_id", challenge.SubjectId))
+		return domain.VerifyMfaRes{}, domain.ErrAccountSuspended
+	}
+
+	// MFA codes are guessed far easier than passwords, so failures are throttled the same way.
+	loginAttemptsKeys := svc.loginAttemptsKeys(acc.Email, req.Ip)
+	emailFailed, err := svc.checkLoginThrottling(ctx, loginAttemptsKeys)
+	if err != nil {
+		return domain.VerifyMfaRes{}, err
+	}
+
+	ok, err := svc.verifyMfaCode(ctx, challenge.SubjectId, req.Code, req.RecoveryCode)
+	if err != nil {
+		return domain.VerifyMfaRes{}, err
+	}
+	if !ok {
+		if err := svc.registerLoginFailure(ctx, loginAttemptsKeys); err != nil {
+			return domain.VerifyMfaRes{}, err
+		}
+		return domain.VerifyMfaRes{}, domain.ErrInvalidMfaCode
+	}
+
+	if emailFailed {
+		if err := svc.loginAttempts.Reset(ctx, loginAttemptsKeys[domain.LoginAttemptsKeyKindEmail]); err != nil {
+			svc.l.Error("failed to reset failed login attempts", zap.String("account_id", challenge.SubjectId), zap.Error(err))
+			return domain.VerifyMfaRes{}, err
+		}
+	}
+
+	res, err := svc.issueRefreshToken(ctx, challenge.SubjectId, acc.Type, authMethodMfa, req.UserAgent, req.Ip)
+	if err != nil {
+		return domain.VerifyMfaRes{}, err
+	}
+
+	return domain.VerifyMfaRes{
+		RefreshToken: res.RefreshToken,
+		ExpiresAt:    res.ExpiresAt,
+	}, nil
+}
+
+// Check the TOTP code or, if set, the recovery code of the account with MFA enabled.
+// Accepted codes can't be used again.
+func (svc *Auth) verifyMfaCode(ctx context.Context, accountId, code, recoveryCode string) (bool, error) {
+	mfa, err := svc.accProv.FindAccountMfa(ctx, domain.FindAccountMfaDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account mfa", zap.String("account_id", accountId), zap.Error(err))
+		return false, err
+	}
+	if mfa == nil || mfa.EnabledAt.IsZero() {
+		return false, domain.ErrMfaNotEnabled
+	}
+
+	if recoveryCode != "" {
+		used, err := svc.accProv.UseMfaRecoveryCode(ctx, domain.UseMfaRecoveryCodeDTOInput{
+			Id:   accountId,
+			Code: normalizeRecoveryCode(recoveryCode),
+		})
+		if err != nil {
+			svc.l.Error("failed to use mfa recovery code", zap.String("account_id", accountId), zap.Error(err))
+			return false, err
+		}
+		if used {
+			svc.l.Info("used mfa recovery code", zap.String("account_id", accountId), zap.Int("recovery_codes_left", mfa.RecoveryCodesLeft-1))
+		}
+		return used, nil
+	}
+
+	counter, ok, err := svc.totp.Verify(mfa.TotpSecret, code, time.Now())
+	if err != nil {
+		svc.l.Error("failed to verify totp code", zap.String("account_id", accountId), zap.Error(err))
+		return false, err
+	}
+	if !ok {
+		return false, nil
+	}
+
+	used, err := svc.accProv.UseMfaTotpCounter(ctx, domain.UseMfaTotpCounterDTOInput{
+		Id:      accountId,
+		Counter: counter,
+	})
+	if err != nil {
+		svc.l.Error("failed to use totp code", zap.String("account_id", accountId), zap.Error(err))
+		return false, err
+	}
+	if !used {
+		svc.l.Info("rejected reused totp code", zap.String("account_id", accountId))
+	}
+	return used, nil
+}
+
+func (svc *Auth) EnrollMfa(ctx context.Context, req domain.EnrollMfaReq) (domain.EnrollMfaRes, error) {
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.EnrollMfaRes{}, err
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: token.SubjectId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for mfa enrollment", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.EnrollMfaRes{}, err
+	}
+	if acc == nil {
+		return domain.EnrollMfaRes{}, domain.ErrUserNotFound
+	}
+
+	mfa, err := svc.accProv.FindAccountMfa(ctx, domain.FindAccountMfaDTOInput{
+		Id: token.SubjectId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account mfa", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.EnrollMfaRes{}, err
+	}
+	if mfa != nil && !mfa.EnabledAt.IsZero() {
+		return domain.EnrollMfaRes{}, domain.ErrMfaAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTotpSecret()
+	if err != nil {
+		svc.l.Error("failed to generate totp secret", zap.Error(err))
+		return domain.EnrollMfaRes{}, err
+	}
+
+	if err := svc.accProv.SetMfaTotpSecret(ctx, domain.SetMfaTotpSecretDTOInput{
+		Id:         token.SubjectId,
+		TotpSecret: secret,
+	}); err != nil {
+		svc.l.Error("failed to set account totp secret", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.EnrollMfaRes{}, err
+	}
+	svc.l.Info("started mfa enrollment", zap.String("account_id", token.SubjectId))
+
+	return domain.EnrollMfaRes{
+		TotpSecret: secret,
+		TotpUri:    svc.totp.Uri(svc.mfaIssuer, acc.Email, secret),
+	}, nil
+}
+
+func (svc *Auth) ConfirmMfa(ctx context.Context, req domain.ConfirmMfaReq) (domain.ConfirmMfaRes, error) {
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.ConfirmMfaRes{}, err
+	}
+
+	mfa, err := svc.accProv.FindAccountMfa(ctx, domain.FindAccountMfaDTOInput{
+		Id: token.SubjectId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account mfa", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.ConfirmMfaRes{}, err
+	}
+	if mfa == nil {
+		return domain.ConfirmMfaRes{}, domain.ErrUserNotFound
+	}
+	if !mfa.EnabledAt.IsZero() {
+		return domain.ConfirmMfaRes{}, domain.ErrMfaAlreadyEnabled
+	}
+	if mfa.TotpSecret == "" {
+		return domain.ConfirmMfaRes{}, domain.ErrMfaNotEnrolled
+	}
+
+	counter, ok, err := svc.totp.Verify(mfa.TotpSecret, req.Code, time.Now())
+	if err != nil {
+		svc.l.Error("failed to verify totp code", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.ConfirmMfaRes{}, err
+	}
+	if !ok {
+		return domain.ConfirmMfaRes{}, domain.ErrInvalidMfaCode
+	}
+
+	recoveryCodes := make([]string, 0, domain.MfaRecoveryCodesCount)
+	normalizedRecoveryCodes := make([]string, 0, domain.MfaRecoveryCodesCount)
+	for range domain.MfaRecoveryCodesCount {
+		code := newRecoveryCode()
+		recoveryCodes = append(recoveryCodes, code)
+		normalizedRecoveryCodes = append(normalizedRecoveryCodes, normalizeRecoveryCode(code))
+	}
+
+	if err := svc.accProv.EnableMfa(ctx, domain.EnableMfaDTOInput{
+		Id:              token.SubjectId,
+		EnabledAt:       time.Now(),
+		TotpLastCounter: counter,
+		RecoveryCodes:   normalizedRecoveryCodes,
+	}); err != nil {
+		svc.l.Error("failed to enable account mfa", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.ConfirmMfaRes{}, err
+	}
+	svc.l.Info("enabled mfa", zap.String("account_id", token.SubjectId))
+
+	return domain.ConfirmMfaRes{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (svc *Auth) DisableMfa(ctx context.Context, req domain.DisableMfaReq) (domain.DisableMfaRes, error) {
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.DisableMfaRes{}, err
+	}
+
+	if _, err := svc.checkAccountPassword(ctx, token.SubjectId, req.Password); err != nil {
+		return domain.DisableMfaRes{}, err
+	}
+
+	ok, err := svc.verifyMfaCode(ctx, token.SubjectId, req.Code, "")
+	if err != nil {
+		return domain.DisableMfaRes{}, err
+	}
+	if !ok {
+		return domain.DisableMfaRes{}, domain.ErrInvalidMfaCode
+	}
+
+	if err := svc.accProv.DisableMfa(ctx, domain.DisableMfaDTOInput{
+		Id: token.SubjectId,
+	}); err != nil {
+		svc.l.Error("failed to disable account mfa", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.DisableMfaRes{}, err
+	}
+	svc.l.Info("disabled mfa", zap.String("account_id", token.SubjectId))
+
+	return domain.DisableMfaRes{}, nil
+}
+
+// Recovery code formatted as "xxxxx-xxxxx" to be easy to type in.
+func newRecoveryCode() string {
+	code := entity.Id(10)[:10]
+	return code[:5] + "-" + code[5:]
+}
+
+// Recovery codes are stored and compared without separators and whitespace,
+// so "ABCDE-12345", "abcde 12345" and "abcde12345" are the same code.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, code)
+}
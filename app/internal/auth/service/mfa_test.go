@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMfaTestAuth(t *testing.T) (*service.Auth, *fakeAccountProvider) {
+	t.Helper()
+
+	svc, accProv := newTestAuth(t)
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+	}
+	accProv.byEmail = map[string]*domain.FindAccountByEmailDTOOutput{
+		"user@example.com": {Id: "user-id", Name: "user", Type: domain.AccountTypeUser, Activated: true},
+	}
+	accProv.passwords = map[string]string{"user-id": "password"}
+
+	return svc, accProv
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := auth.DefaultTotp().Code(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+// Enroll MFA for the test user and return the TOTP secret and recovery codes.
+func enableMfa(t *testing.T, svc *service.Auth) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollRes, err := svc.EnrollMfa(ctx, domain.EnrollMfaReq{AccessToken: tokenUser})
+	require.NoError(t, err)
+	assert.Contains(t, enrollRes.TotpUri, "otpauth://totp/")
+	assert.Contains(t, enrollRes.TotpUri, enrollRes.TotpSecret)
+
+	confirmRes, err := svc.ConfirmMfa(ctx, domain.ConfirmMfaReq{
+		AccessToken: tokenUser,
+		Code:        totpCode(t, enrollRes.TotpSecret, time.Now()),
+	})
+	require.NoError(t, err)
+	assert.Len(t, confirmRes.RecoveryCodes, domain.MfaRecoveryCodesCount)
+
+	return enrollRes.TotpSecret, confirmRes.RecoveryCodes
+}
+
+func TestAuthenticateWithMfa(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newMfaTestAuth(t)
+	secret, _ := enableMfa(t, svc)
+
+	_, err := svc.EnrollMfa(ctx, domain.EnrollMfaReq{AccessToken: tokenUser})
+	assert.ErrorIs(t, err, domain.ErrMfaAlreadyEnabled)
+
+	authRes, err := svc.Authenticate(ctx, domain.AuthenticateReq{Email: "user@example.com", Password: "password"})
+	require.NoError(t, err)
+	assert.Empty(t, authRes.RefreshToken, "refresh token must not be issued before mfa is verified")
+	require.NotEmpty(t, authRes.MfaChallengeToken)
+
+	_, err = svc.VerifyMfa(ctx, domain.VerifyMfaReq{MfaChallengeToken: "garbage", Code: "000000"})
+	assert.ErrorIs(t, err, domain.ErrInvalidMfaChallengeToken)
+
+	// The code used for confirmation must not be accepted again.
+	_, err = svc.VerifyMfa(ctx, domain.VerifyMfaReq{
+		MfaChallengeToken: authRes.MfaChallengeToken,
+		Code:              totpCode(t, secret, time.Now()),
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidMfaCode)
+
+	nextCode := totpCode(t, secret, time.Now().Add(auth.DefaultTotp().Period))
+	verifyRes, err := svc.VerifyMfa(ctx, domain.VerifyMfaReq{
+		MfaChallengeToken: authRes.MfaChallengeToken,
+		Code:              nextCode,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, verifyRes.RefreshToken)
+
+	_, err = svc.VerifyMfa(ctx, domain.VerifyMfaReq{
+		MfaChallengeToken: authRes.MfaChallengeToken,
+		Code:              nextCode,
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidMfaCode, "totp code must not be accepted twice")
+}
+
+func TestVerifyMfaWithRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newMfaTestAuth(t)
+	_, recoveryCodes := enableMfa(t, svc)
+
+	authRes, err := svc.Authenticate(ctx, domain.AuthenticateReq{Email: "user@example.com", Password: "password"})
+	require.NoError(t, err)
+
+	_, err = svc.VerifyMfa(ctx, domain.VerifyMfaReq{MfaChallengeToken: authRes.MfaChallengeToken, RecoveryCode: "wrong-code"})
+	assert.ErrorIs(t, err, domain.ErrInvalidMfaCode)
+
+	_, err = svc.VerifyMfa(ctx, domain.VerifyMfaReq{MfaChallengeToken: authRes.MfaChallengeToken, RecoveryCode: recoveryCodes[0]})
+	require.NoError(t, err)
+
+	_, err = svc.VerifyMfa(ctx, domain.VerifyMfaReq{MfaChallengeToken: authRes.MfaChallengeToken, RecoveryCode: recoveryCodes[0]})
+	assert.ErrorIs(t, err, domain.ErrInvalidMfaCode, "recovery code must not be accepted twice")
+}
+
+func TestVerifyMfaWithRecoveryCodeIgnoresSeparators(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newMfaTestAuth(t)
+	_, recoveryCodes := enableMfa(t, svc)
+
+	for _, code := range []string{
+		strings.ReplaceAll(recoveryCodes[0], "-", ""),
+		" " + strings.ToUpper(strings.ReplaceAll(recoveryCodes[1], "-", " ")) + "\n",
+		strings.ReplaceAll(recoveryCodes[2], "-", "--"),
+	} {
+		authRes, err := svc.Authenticate(ctx, domain.AuthenticateReq{Email: "user@example.com", Password: "password"})
+		require.NoError(t, err)
+
+		_, err = svc.VerifyMfa(ctx, domain.VerifyMfaReq{MfaChallengeToken: authRes.MfaChallengeToken, RecoveryCode: code})
+		assert.NoError(t, err, "recovery code %q", code)
+	}
+}
+
+func TestDisableMfa(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newMfaTestAuth(t)
+	secret, _ := enableMfa(t, svc)
+
+	code := totpCode(t, secret, time.Now().Add(auth.DefaultTotp().Period))
+
+	_, err := svc.DisableMfa(ctx, domain.DisableMfaReq{AccessToken: tokenUser, Password: "wrong", Code: code})
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+
+	_, err = svc.DisableMfa(ctx, domain.DisableMfaReq{AccessToken: tokenUser, Password: "password", Code: code})
+	require.NoError(t, err)
+
+	authRes, err := svc.Authenticate(ctx, domain.AuthenticateReq{Email: "user@example.com", Password: "password"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, authRes.RefreshToken)
+	assert.Empty(t, authRes.MfaChallengeToken)
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/entity"
+	"go.uber.org/zap"
+)
+
+var errOidcUnavailable = errors.New("openid connect provider is unavailable: oauth clients, authorization codes, issuer or authorization url not set")
+
+// Scopes granted to clients, unknown requested scopes are ignored.
+var oidcScopesSupported = []string{domain.OidcScopeOpenId, domain.OidcScopeProfile, domain.OidcScopeEmail}
+
+const (
+	codeVerifierMinLength = 43
+	codeVerifierMaxLength = 128
+)
+
+func (svc *Auth) oidcAvailable() bool {
+	return svc.oauthClients != nil && svc.authorizationCodes != nil && svc.oidcIssuer != "" && svc.oidcAuthorizationUrl != ""
+}
+
+func (svc *Auth) GetOpenIdConfiguration(_ context.Context, _ domain.GetOpenIdConfigurationReq) (domain.GetOpenIdConfigurationRes, error) {
+	if !svc.oidcAvailable() {
+		return domain.GetOpenIdConfigurationRes{}, errOidcUnavailable
+	}
+
+	return domain.GetOpenIdConfigurationRes{
+		Issuer:                svc.oidcIssuer,
+		AuthorizationEndpoint: svc.oidcAuthorizationUrl,
+		ScopesSupported:       slices.Clone(oidcScopesSupported),
+	}, nil
+}
+
+func (svc *Auth) GetJwks(_ context.Context, _ domain.GetJwksReq) (domain.GetJwksRes, error) {
+	jwks, err := svc.tokenProv.Jwks()
+	if err != nil {
+		svc.l.Error("failed to get jwks", zap.Error(err))
+		return domain.GetJwksRes{}, err
+	}
+
+	return domain.GetJwksRes{
+		Jwks: jwks,
+	}, nil
+}
+
+func (svc *Auth) Authorize(ctx context.Context, req domain.AuthorizeReq) (domain.AuthorizeRes, error) {
+	if !svc.oidcAvailable() {
+		return domain.AuthorizeRes{}, errOidcUnavailable
+	}
+
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.AuthorizeRes{}, err
+	}
+
+	client, err := svc.findOAuthClient(ctx, req.ClientId)
+	if err != nil {
+		return domain.AuthorizeRes{}, err
+	}
+	// Redirect uris are compared exactly not to leak codes to open redirects.
+	if !slices.Contains(client.RedirectUris, req.RedirectUri) {
+		return domain.AuthorizeRes{}, fmt.Errorf("%w: redirect uri is not registered for the client", domain.ErrInvalidRedirectUri)
+	}
+
+	if req.ResponseType != domain.OidcResponseTypeCode {
+		return domain.AuthorizeRes{}, fmt.Errorf("%w: unsupported response type %q", domain.ErrInvalidAuthorizationRequest, req.ResponseType)
+	}
+	scopes := strings.Fields(req.Scope)
+	if !slices.Contains(scopes, domain.OidcScopeOpenId) {
+		return domain.AuthorizeRes{}, fmt.Errorf("%w: %q scope is required", domain.ErrInvalidAuthorizationRequest, domain.OidcScopeOpenId)
+	}
+	// PKCE is required for confidential clients as well.
+	if req.CodeChallengeMethod != domain.OidcCodeChallengeMethodS256 {
+		return domain.AuthorizeRes{}, fmt.Errorf("%w: code challenge method must be %q", domain.ErrInvalidAuthorizationRequest, domain.OidcCodeChallengeMethodS256)
+	}
+	if challenge, err := base64.RawURLEncoding.DecodeString(req.CodeChallenge); err != nil || len(challenge) != sha256.Size {
+		return domain.AuthorizeRes{}, fmt.Errorf("%w: code challenge must be base64url encoded SHA-256 of the code verifier", domain.ErrInvalidAuthorizationRequest)
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: token.SubjectId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for authorization", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.AuthorizeRes{}, err
+	}
+	if acc == nil {
+		return domain.AuthorizeRes{}, fmt.Errorf("%w: account has been deleted", domain.ErrInvalidAccessToken)
+	}
+	if !acc.SuspendedAt.IsZero() {
+		svc.l.Info("rejected authorization for suspended account", zap.String("account_id", token.SubjectId))
+		return domain.AuthorizeRes{}, domain.ErrAccountSuspended
+	}
+
+	code := domain.AuthorizationCode{
+		Code:          entity.Id(32),
+		ClientId:      client.Id,
+		AccountId:     token.SubjectId,
+		RedirectUri:   req.RedirectUri,
+		Scope:         grantedScope(scopes),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(svc.authorizationCodeDuration),
+	}
+	if err := svc.authorizationCodes.Insert(ctx, code); err != nil {
+		svc.l.Error("failed to insert authorization code", zap.String("client_id", client.Id), zap.Error(err))
+		return domain.AuthorizeRes{}, err
+	}
+	svc.l.Info("granted authorization code", zap.String("account_id", token.SubjectId), zap.String("client_id", client.Id))
+
+	redirectUri, err := url.Parse(req.RedirectUri)
+	if err != nil {
+		return domain.AuthorizeRes{}, fmt.Errorf("failed to parse redirect uri: %w", err)
+	}
+	query := redirectUri.Query()
+	query.Set("code", code.Code)
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	redirectUri.RawQuery = query.Encode()
+
+	return domain.AuthorizeRes{
+		RedirectUri: redirectUri.String(),
+	}, nil
+}
+
+func (svc *Auth) ExchangeAuthorizationCode(ctx context.Context, req domain.ExchangeAuthorizationCodeReq) (domain.ExchangeAuthorizationCodeRes, error) {
+	if !svc.oidcAvailable() {
+		return domain.ExchangeAuthorizationCodeRes{}, errOidcUnavailable
+	}
+
+	if req.GrantType != domain.OidcGrantTypeAuthorizationCode {
+		return domain.ExchangeAuthorizationCodeRes{}, fmt.Errorf("%w: %q", domain.ErrUnsupportedGrantType, req.GrantType)
+	}
+
+	client, err := svc.authenticateOAuthClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		return domain.ExchangeAuthorizationCodeRes{}, err
+	}
+
+	code, err := svc.authorizationCodes.Consume(ctx, req.Code)
+	if err != nil {
+		svc.l.Error("failed to consume authorization code", zap.String("client_id", client.Id), zap.Error(err))
+		return domain.ExchangeAuthorizationCodeRes{}, err
+	}
+	if code == nil || time.Now().After(code.ExpiresAt) || code.ClientId != client.Id || code.RedirectUri != req.RedirectUri {
+		return domain.ExchangeAuthorizationCodeRes{}, domain.ErrInvalidGrant
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		svc.l.Info("rejected authorization code with invalid code verifier", zap.String("client_id", client.Id))
+		return domain.ExchangeAuthorizationCodeRes{}, fmt.Errorf("%w: code verifier does not match the code challenge", domain.ErrInvalidGrant)
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: code.AccountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for exchanging authorization code", zap.String("account_id", code.AccountId), zap.Error(err))
+		return domain.ExchangeAuthorizationCodeRes{}, err
+	}
+	if acc == nil {
+		return domain.ExchangeAuthorizationCodeRes{}, fmt.Errorf("%w: account has been deleted", domain.ErrInvalidGrant)
+	}
+	if !acc.SuspendedAt.IsZero() {
+		svc.l.Info("rejected exchanging authorization code for suspended account", zap.String("account_id", code.AccountId))
+		return domain.ExchangeAuthorizationCodeRes{}, fmt.Errorf("%w: %w", domain.ErrInvalidGrant, domain.ErrAccountSuspended)
+	}
+
+	now := time.Now()
+	permissions, err := svc.permissions(ctx, acc.Type)
+	if err != nil {
+		return domain.ExchangeAuthorizationCodeRes{}, err
+	}
+
+	scopes := strings.Fields(code.Scope)
+	accessToken := domain.AccessToken{
+		SubjectId:   code.AccountId,
+		SubjectType: acc.Type,
+		Scopes:      scopes,
+		ClientId:    client.Id,
+		Permissions: permissions,
+		ExpiresAt:   now.Add(svc.accessTokenDuration),
+	}
+	accessTokenString, err := svc.tokenProv.EncodeAccess(accessToken)
+	if err != nil {
+		svc.l.Error("failed to encode access token", zap.Error(err))
+		return domain.ExchangeAuthorizationCodeRes{}, err
+	}
+
+	idToken := domain.IdToken{
+		Issuer:    svc.oidcIssuer,
+		SubjectId: code.AccountId,
+		Audience:  client.Id,
+		Nonce:     code.Nonce,
+		IssuedAt:  now,
+		ExpiresAt: accessToken.ExpiresAt,
+	}
+	claims := userInfoClaims(code.AccountId, acc, scopes)
+	idToken.Name = claims.Name
+	idToken.Email = claims.Email
+	idToken.EmailVerified = claims.EmailVerified
+	idTokenString, err := svc.tokenProv.EncodeId(idToken)
+	if err != nil {
+		svc.l.Error("failed to encode id token", zap.Error(err))
+		return domain.ExchangeAuthorizationCodeRes{}, err
+	}
+
+	return domain.ExchangeAuthorizationCodeRes{
+		AccessToken: accessTokenString,
+		IdToken:     idTokenString,
+		Scope:       code.Scope,
+		ExpiresAt:   accessToken.ExpiresAt,
+	}, nil
+}
+
+func (svc *Auth) GetUserInfo(ctx context.Context, req domain.GetUserInfoReq) (domain.GetUserInfoRes, error) {
+	if !svc.oidcAvailable() {
+		return domain.GetUserInfoRes{}, errOidcUnavailable
+	}
+
+	token, err := svc.decodeAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.GetUserInfoRes{}, err
+	}
+	// Only tokens issued to OAuth clients are accepted, other tokens carry no consent to share the claims.
+	if token.ClientId == "" || !slices.Contains(token.Scopes, domain.OidcScopeOpenId) {
+		return domain.GetUserInfoRes{}, fmt.Errorf("%w: user info requires a token issued to an oauth client granted %q scope", domain.ErrPermissionDenied, domain.OidcScopeOpenId)
+	}
+
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: token.SubjectId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for user info", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.GetUserInfoRes{}, err
+	}
+	if acc == nil {
+		return domain.GetUserInfoRes{}, fmt.Errorf("%w: account has been deleted", domain.ErrInvalidAccessToken)
+	}
+
+	return userInfoClaims(token.SubjectId, acc, token.Scopes), nil
+}
+
+// Claims about the account shared with the client limited to the granted scopes.
+func userInfoClaims(subjectId string, acc *domain.FindAccountDTOOutput, scopes []string) domain.GetUserInfoRes {
+	res := domain.GetUserInfoRes{SubjectId: subjectId}
+	if slices.Contains(scopes, domain.OidcScopeProfile) {
+		res.Name = acc.Name
+	}
+	if slices.Contains(scopes, domain.OidcScopeEmail) {
+		res.Email = acc.Email
+		res.EmailVerified = acc.Activated
+	}
+	return res
+}
+
+func (svc *Auth) CreateOAuthClient(ctx context.Context, req domain.CreateOAuthClientReq) (domain.CreateOAuthClientRes, error) {
+	if !svc.oidcAvailable() {
+		return domain.CreateOAuthClientRes{}, errOidcUnavailable
+	}
+
+	if _, err := svc.authorize(ctx, req.AccessToken, domain.ActionManageOAuthClients); err != nil {
+		return domain.CreateOAuthClientRes{}, err
+	}
+
+	if len(req.RedirectUris) == 0 {
+		return domain.CreateOAuthClientRes{}, fmt.Errorf("%w: at least one redirect uri is required", domain.ErrInvalidRedirectUri)
+	}
+	for _, uri := range req.RedirectUris {
+		if err := validateRedirectUri(uri); err != nil {
+			return domain.CreateOAuthClientRes{}, err
+		}
+	}
+
+	in := domain.OAuthClientsAddDTOInput{
+		Id:           entity.Id(16),
+		Name:         strings.TrimSpace(req.Name),
+		RedirectUris: req.RedirectUris,
+		CreatedAt:    time.Now(),
+	}
+	if req.Confidential {
+		in.Secret = entity.Id(32)
+	}
+	if err := svc.oauthClients.Add(ctx, in); err != nil {
+		svc.l.Error("failed to add oauth client", zap.Error(err))
+		return domain.CreateOAuthClientRes{}, err
+	}
+	svc.l.Info("created oauth client", zap.String("client_id", in.Id), zap.Bool("confidential", req.Confidential))
+
+	return domain.CreateOAuthClientRes{
+		ClientId:     in.Id,
+		ClientSecret: in.Secret,
+	}, nil
+}
+
+func (svc *Auth) DeleteOAuthClient(ctx context.Context, req domain.DeleteOAuthClientReq) (domain.DeleteOAuthClientRes, error) {
+	if !svc.oidcAvailable() {
+		return domain.DeleteOAuthClientRes{}, errOidcUnavailable
+	}
+
+	if _, err := svc.authorize(ctx, req.AccessToken, domain.ActionManageOAuthClients); err != nil {
+		return domain.DeleteOAuthClientRes{}, err
+	}
+
+	deleted, err := svc.oauthClients.Delete(ctx, req.ClientId)
+	if err != nil {
+		svc.l.Error("failed to delete oauth client", zap.String("client_id", req.ClientId), zap.Error(err))
+		return domain.DeleteOAuthClientRes{}, err
+	}
+	if !deleted {
+		return domain.DeleteOAuthClientRes{}, domain.ErrOAuthClientNotFound
+	}
+	svc.l.Info("deleted oauth client", zap.String("client_id", req.ClientId))
+
+	return domain.DeleteOAuthClientRes{}, nil
+}
+
+// Returns domain.ErrInvalidOAuthClient if there's no client with the id.
+func (svc *Auth) findOAuthClient(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	client, err := svc.oauthClients.Get(ctx, id)
+	if err != nil {
+		svc.l.Error("failed to get oauth client", zap.String("client_id", id), zap.Error(err))
+		return nil, err
+	}
+	if client == nil {
+		return nil, fmt.Errorf("%w: unknown client %q", domain.ErrInvalidOAuthClient, id)
+	}
+	return client, nil
+}
+
+// Confidential clients must present their secret, public ones are identified by the client id only.
+func (svc *Auth) authenticateOAuthClient(ctx context.Context, id, secret string) (*domain.OAuthClient, error) {
+	client, err := svc.findOAuthClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential {
+		return client, nil
+	}
+
+	if secret == "" {
+		return nil, fmt.Errorf("%w: client secret is required", domain.ErrInvalidOAuthClient)
+	}
+	ok, err := svc.oauthClients.CheckSecret(ctx, domain.OAuthClientsCheckSecretDTOInput{
+		Id:     id,
+		Secret: secret,
+	})
+	if err != nil {
+		svc.l.Error("failed to check oauth client secret", zap.String("client_id", id), zap.Error(err))
+		return nil, err
+	}
+	if !ok {
+		svc.l.Info("rejected invalid oauth client secret", zap.String("client_id", id))
+		return nil, fmt.Errorf("%w: invalid client secret", domain.ErrInvalidOAuthClient)
+	}
+	return client, nil
+}
+
+func grantedScope(requested []string) string {
+	granted := make([]string, 0, len(oidcScopesSupported))
+	for _, scope := range oidcScopesSupported {
+		if slices.Contains(requested, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	return strings.Join(granted, " ")
+}
+
+// Check the code verifier against the S256 code challenge (RFC 7636).
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < codeVerifierMinLength || len(verifier) > codeVerifierMaxLength {
+		return false
+	}
+	digest := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(digest[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// Redirect uris must be absolute https urls without fragment. Plain http is allowed for loopback native clients.
+func validateRedirectUri(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an absolute url", domain.ErrInvalidRedirectUri, uri)
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("%w: %q must not contain a fragment", domain.ErrInvalidRedirectUri, uri)
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if host := u.Hostname(); host != "localhost" && !net.ParseIP(host).IsLoopback() {
+			return fmt.Errorf("%w: %q must use https", domain.ErrInvalidRedirectUri, uri)
+		}
+	default:
+		return fmt.Errorf("%w: %q must use https", domain.ErrInvalidRedirectUri, uri)
+	}
+	return nil
+}
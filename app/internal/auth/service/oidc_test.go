@@ -0,0 +1,256 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	oidcIssuer      = "https://auth.floral.example.com"
+	oidcRedirectUri = "https://catalog.floral.example.com/callback"
+	codeVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+func newOidcTestAuth(t *testing.T) (*service.Auth, *fakeAccountProvider, *fakeTokenProvider) {
+	t.Helper()
+
+	svc, accProv, tokenProv := newTestAuthWithTokens(t, service.NewAuthBuilder().
+		OAuthClients(memory_adapter.NewOAuthClients()).
+		AuthorizationCodes(memory_adapter.NewAuthorizationCodes()).
+		OidcIssuer(oidcIssuer).
+		OidcAuthorizationUrl("https://floral.example.com/authorize"),
+	)
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+	}
+
+	return svc, accProv, tokenProv
+}
+
+func createOAuthClient(t *testing.T, svc *service.Auth, confidential bool) domain.CreateOAuthClientRes {
+	t.Helper()
+
+	res, err := svc.CreateOAuthClient(context.Background(), domain.CreateOAuthClientReq{
+		AccessToken:  tokenAdmin,
+		Name:         "catalog",
+		RedirectUris: []string{oidcRedirectUri},
+		Confidential: confidential,
+	})
+	require.NoError(t, err)
+
+	return res
+}
+
+func newAuthorizeReq(clientId string) domain.AuthorizeReq {
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	return domain.AuthorizeReq{
+		AccessToken:         tokenUser,
+		ClientId:            clientId,
+		RedirectUri:         oidcRedirectUri,
+		ResponseType:        "code",
+		Scope:               "openid email unknown",
+		State:               "state",
+		Nonce:               "nonce",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(challenge[:]),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func authorize(t *testing.T, svc *service.Auth, clientId string) string {
+	t.Helper()
+
+	res, err := svc.Authorize(context.Background(), newAuthorizeReq(clientId))
+	require.NoError(t, err)
+
+	redirectUri, err := url.Parse(res.RedirectUri)
+	require.NoError(t, err)
+	assert.Equal(t, "state", redirectUri.Query().Get("state"))
+
+	return redirectUri.Query().Get("code")
+}
+
+func newExchangeReq(clientId, code string) domain.ExchangeAuthorizationCodeReq {
+	return domain.ExchangeAuthorizationCodeReq{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectUri:  oidcRedirectUri,
+		ClientId:     clientId,
+		CodeVerifier: codeVerifier,
+	}
+}
+
+func TestOidcAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	svc, _, tokenProv := newOidcTestAuth(t)
+	client := createOAuthClient(t, svc, false)
+	assert.Empty(t, client.ClientSecret, "public clients have no secret")
+
+	code := authorize(t, svc, client.ClientId)
+	require.NotEmpty(t, code)
+
+	res, err := svc.ExchangeAuthorizationCode(ctx, newExchangeReq(client.ClientId, code))
+	require.NoError(t, err)
+	assert.Equal(t, "access-user-id", res.AccessToken)
+	assert.Equal(t, "openid email", res.Scope)
+
+	require.Len(t, tokenProv.idTokens, 1)
+	idToken := tokenProv.idTokens[0]
+	assert.Equal(t, oidcIssuer, idToken.Issuer)
+	assert.Equal(t, "user-id", idToken.SubjectId)
+	assert.Equal(t, client.ClientId, idToken.Audience)
+	assert.Equal(t, "nonce", idToken.Nonce)
+	assert.Equal(t, "user@example.com", idToken.Email)
+	assert.True(t, idToken.EmailVerified)
+	assert.Empty(t, idToken.Name, "name is only granted with the profile scope")
+
+	_, err = svc.ExchangeAuthorizationCode(ctx, newExchangeReq(client.ClientId, code))
+	assert.ErrorIs(t, err, domain.ErrInvalidGrant, "authorization codes are single use")
+}
+
+func TestExchangeAuthorizationCodeRejectsInvalidCodeVerifier(t *testing.T) {
+	svc, _, _ := newOidcTestAuth(t)
+	client := createOAuthClient(t, svc, false)
+
+	req := newExchangeReq(client.ClientId, authorize(t, svc, client.ClientId))
+	req.CodeVerifier = "qBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	_, err := svc.ExchangeAuthorizationCode(context.Background(), req)
+	assert.ErrorIs(t, err, domain.ErrInvalidGrant)
+}
+
+func TestExchangeAuthorizationCodeAuthenticatesConfidentialClients(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := newOidcTestAuth(t)
+	client := createOAuthClient(t, svc, true)
+	require.NotEmpty(t, client.ClientSecret)
+
+	req := newExchangeReq(client.ClientId, authorize(t, svc, client.ClientId))
+	_, err := svc.ExchangeAuthorizationCode(ctx, req)
+	assert.ErrorIs(t, err, domain.ErrInvalidOAuthClient)
+
+	req = newExchangeReq(client.ClientId, authorize(t, svc, client.ClientId))
+	req.ClientSecret = client.ClientSecret
+	_, err = svc.ExchangeAuthorizationCode(ctx, req)
+	assert.NoError(t, err)
+}
+
+func TestAuthorizeRejectsInvalidRequests(t *testing.T) {
+	svc, _, _ := newOidcTestAuth(t)
+	client := createOAuthClient(t, svc, false)
+
+	for name, tc := range map[string]struct {
+		modify func(*domain.AuthorizeReq)
+		err    error
+	}{
+		"unknown client": {
+			modify: func(req *domain.AuthorizeReq) { req.ClientId = "unknown" },
+			err:    domain.ErrInvalidOAuthClient,
+		},
+		"unregistered redirect uri": {
+			modify: func(req *domain.AuthorizeReq) { req.RedirectUri = "https://evil.example.com/callback" },
+			err:    domain.ErrInvalidRedirectUri,
+		},
+		"no openid scope": {
+			modify: func(req *domain.AuthorizeReq) { req.Scope = "email" },
+			err:    domain.ErrInvalidAuthorizationRequest,
+		},
+		"plain code challenge": {
+			modify: func(req *domain.AuthorizeReq) { req.CodeChallengeMethod = "plain" },
+			err:    domain.ErrInvalidAuthorizationRequest,
+		},
+		"implicit flow": {
+			modify: func(req *domain.AuthorizeReq) { req.ResponseType = "token" },
+			err:    domain.ErrInvalidAuthorizationRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := newAuthorizeReq(client.ClientId)
+			tc.modify(&req)
+			_, err := svc.Authorize(context.Background(), req)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestCreateOAuthClientValidatesRedirectUris(t *testing.T) {
+	svc, _, _ := newOidcTestAuth(t)
+
+	for _, uri := range []string{"http://catalog.floral.example.com/callback", "https://catalog.floral.example.com/callback#fragment", "/callback"} {
+		_, err := svc.CreateOAuthClient(context.Background(), domain.CreateOAuthClientReq{
+			AccessToken:  tokenAdmin,
+			Name:         "catalog",
+			RedirectUris: []string{uri},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidRedirectUri, uri)
+	}
+
+	_, err := svc.CreateOAuthClient(context.Background(), domain.CreateOAuthClientReq{
+		AccessToken:  tokenAdmin,
+		Name:         "cli",
+		RedirectUris: []string{"http://127.0.0.1:8400/callback"},
+	})
+	assert.NoError(t, err, "loopback redirect uris of native clients may use http")
+}
+
+func TestCreateOAuthClientRejectsNonAdmins(t *testing.T) {
+	svc, _, _ := newOidcTestAuth(t)
+
+	_, err := svc.CreateOAuthClient(context.Background(), domain.CreateOAuthClientReq{
+		AccessToken:  tokenUser,
+		Name:         "catalog",
+		RedirectUris: []string{oidcRedirectUri},
+	})
+	assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+}
+
+func TestGetUserInfoReturnsGrantedClaimsOnly(t *testing.T) {
+	ctx := context.Background()
+	svc, _, tokenProv := newOidcTestAuth(t)
+	client := createOAuthClient(t, svc, false)
+
+	for scope, want := range map[string]domain.GetUserInfoRes{
+		"openid":         {SubjectId: "user-id"},
+		"openid email":   {SubjectId: "user-id", Email: "user@example.com", EmailVerified: true},
+		"openid profile": {SubjectId: "user-id", Name: "user"},
+	} {
+		t.Run(scope, func(t *testing.T) {
+			authorizeReq := newAuthorizeReq(client.ClientId)
+			authorizeReq.Scope = scope
+			authorizeRes, err := svc.Authorize(ctx, authorizeReq)
+			require.NoError(t, err)
+			redirectUri, err := url.Parse(authorizeRes.RedirectUri)
+			require.NoError(t, err)
+
+			_, err = svc.ExchangeAuthorizationCode(ctx, newExchangeReq(client.ClientId, redirectUri.Query().Get("code")))
+			require.NoError(t, err)
+
+			idToken := tokenProv.idTokens[len(tokenProv.idTokens)-1]
+			assert.Equal(t, want.Name, idToken.Name)
+			assert.Equal(t, want.Email, idToken.Email)
+			assert.Equal(t, want.EmailVerified, idToken.EmailVerified)
+
+			accessToken := tokenProv.encodedAccessTokens[len(tokenProv.encodedAccessTokens)-1]
+			assert.Equal(t, client.ClientId, accessToken.ClientId)
+			tokenProv.accessTokens["oidc-"+scope] = accessToken
+
+			res, err := svc.GetUserInfo(ctx, domain.GetUserInfoReq{AccessToken: "oidc-" + scope})
+			require.NoError(t, err)
+			assert.Equal(t, want, res)
+		})
+	}
+}
+
+func TestGetUserInfoRejectsTokensNotIssuedToClients(t *testing.T) {
+	svc, _, _ := newOidcTestAuth(t)
+
+	_, err := svc.GetUserInfo(context.Background(), domain.GetUserInfoReq{AccessToken: tokenUser})
+	assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+}
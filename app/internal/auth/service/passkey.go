@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/pkg/entity"
+	"go.uber.org/zap"
+)
+
+var errPasskeysUnavailable = errors.New("passkeys are unavailable: passkey credentials, ceremonies or relying party not set")
+
+func (svc *Auth) passkeysAvailable() bool {
+	return svc.passkeyCredentials != nil && svc.passkeyCeremonies != nil && svc.passkeyRelyingParty != nil
+}
+
+func (svc *Auth) BeginPasskeyRegistration(ctx context.Context, req domain.BeginPasskeyRegistrationReq) (domain.BeginPasskeyRegistrationRes, error) {
+	if !svc.passkeysAvailable() {
+		return domain.BeginPasskeyRegistrationRes{}, errPasskeysUnavailable
+	}
+
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.BeginPasskeyRegistrationRes{}, err
+	}
+
+	user, err := svc.passkeyUser(ctx, token.SubjectId)
+	if err != nil {
+		return domain.BeginPasskeyRegistrationRes{}, err
+	}
+
+	options, session, err := svc.passkeyRelyingParty.BeginRegistration(user)
+	if err != nil {
+		svc.l.Error("failed to begin passkey registration", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.BeginPasskeyRegistrationRes{}, err
+	}
+
+	ceremony, err := svc.insertPasskeyCeremony(ctx, domain.PasskeyCeremonyKindRegistration, token.SubjectId, session)
+	if err != nil {
+		return domain.BeginPasskeyRegistrationRes{}, err
+	}
+
+	return domain.BeginPasskeyRegistrationRes{
+		CeremonyId: ceremony.Id,
+		Options:    options,
+		ExpiresAt:  ceremony.ExpiresAt,
+	}, nil
+}
+
+func (svc *Auth) FinishPasskeyRegistration(ctx context.Context, req domain.FinishPasskeyRegistrationReq) (domain.FinishPasskeyRegistrationRes, error) {
+	if !svc.passkeysAvailable() {
+		return domain.FinishPasskeyRegistrationRes{}, errPasskeysUnavailable
+	}
+
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.FinishPasskeyRegistrationRes{}, err
+	}
+
+	ceremony, err := svc.consumePasskeyCeremony(ctx, req.CeremonyId, domain.PasskeyCeremonyKindRegistration)
+	if err != nil {
+		return domain.FinishPasskeyRegistrationRes{}, err
+	}
+	// Ceremonies may only be finished by the account that started them.
+	if ceremony.AccountId != token.SubjectId {
+		return domain.FinishPasskeyRegistrationRes{}, domain.ErrInvalidPasskeyCeremony
+	}
+
+	user, err := svc.passkeyUser(ctx, token.SubjectId)
+	if err != nil {
+		return domain.FinishPasskeyRegistrationRes{}, err
+	}
+
+	cred, err := svc.passkeyRelyingParty.FinishRegistration(user, ceremony.Session, req.Credential)
+	if err != nil {
+		if errors.Is(err, domain.ErrPasskeyVerificationFailed) {
+			svc.l.Info("rejected passkey registration", zap.String("account_id", token.SubjectId), zap.Error(err))
+			return domain.FinishPasskeyRegistrationRes{}, err
+		}
+		svc.l.Error("failed to finish passkey registration", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.FinishPasskeyRegistrationRes{}, err
+	}
+
+	cred.Name = strings.TrimSpace(req.Name)
+	cred.CreatedAt = time.Now()
+	if err := svc.passkeyCredentials.Add(ctx, cred); err != nil {
+		svc.l.Error("failed to add passkey credential", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.FinishPasskeyRegistrationRes{}, err
+	}
+	svc.l.Info("registered passkey", zap.String("account_id", token.SubjectId), zap.String("passkey_id", cred.Id))
+
+	return domain.FinishPasskeyRegistrationRes{
+		Passkey: newPasskey(cred),
+	}, nil
+}
+
+func (svc *Auth) BeginPasskeyLogin(ctx context.Context, _ domain.BeginPasskeyLoginReq) (domain.BeginPasskeyLoginRes, error) {
+	if !svc.passkeysAvailable() {
+		return domain.BeginPasskeyLoginRes{}, errPasskeysUnavailable
+	}
+
+	options, session, err := svc.passkeyRelyingParty.BeginLogin()
+	if err != nil {
+		svc.l.Error("failed to begin passkey login", zap.Error(err))
+		return domain.BeginPasskeyLoginRes{}, err
+	}
+
+	ceremony, err := svc.insertPasskeyCeremony(ctx, domain.PasskeyCeremonyKindLogin, "", session)
+	if err != nil {
+		return domain.BeginPasskeyLoginRes{}, err
+	}
+
+	return domain.BeginPasskeyLoginRes{
+		CeremonyId: ceremony.Id,
+		Options:    options,
+		ExpiresAt:  ceremony.ExpiresAt,
+	}, nil
+}
+
+// Verify the passkey assertion and start a new session of the account the passkey belongs to.
+// Passkeys require user verification, so MFA is not prompted for.
+func (svc *Auth) authenticateWithPasskey(ctx context.Context, req domain.AuthenticateReq) (domain.AuthenticateRes, error) {
+	if !svc.passkeysAvailable() {
+		return domain.AuthenticateRes{}, errPasskeysUnavailable
+	}
+
+	// The account is unknown until the assertion is verified, so failures are only throttled per client ip.
+	loginAttemptsKeys := svc.loginAttemptsKeys("", req.Ip)
+	if _, err := svc.checkLoginThrottling(ctx, loginAttemptsKeys); err != nil {
+		return domain.AuthenticateRes{}, err
+	}
+
+	ceremony, err := svc.consumePasskeyCeremony(ctx, req.Passkey.CeremonyId, domain.PasskeyCeremonyKindLogin)
+	if err != nil {
+		return domain.AuthenticateRes{}, err
+	}
+
+	var acc *domain.FindAccountDTOOutput
+	cred, err := svc.passkeyRelyingParty.FinishLogin(ceremony.Session, req.Passkey.Credential, func(accountId string) (domain.PasskeyUser, error) {
+		user, foundAcc, err := svc.findPasskeyUser(ctx, accountId)
+		acc = foundAcc
+		return user, err
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			err = fmt.Errorf("%w: credential of unknown account", domain.ErrPasskeyVerificationFailed)
+		}
+		if !errors.Is(err, domain.ErrPasskeyVerificationFailed) {
+			svc.l.Error("failed to finish passkey login", zap.Error(err))
+			return domain.AuthenticateRes{}, err
+		}
+		svc.l.Info("rejected passkey assertion", zap.Error(err))
+		if err := svc.registerLoginFailure(ctx, loginAttemptsKeys); err != nil {
+			return domain.AuthenticateRes{}, err
+		}
+		return domain.AuthenticateRes{}, err
+	}
+
+	if !acc.SuspendedAt.IsZero() {
+		svc.l.Info("rejected creating refresh token for suspended account", zap.String("account_id", cred.AccountId))
+		return domain.AuthenticateRes{}, domain.ErrAccountSuspended
+	}
+
+	if err := svc.passkeyCredentials.Touch(ctx, domain.PasskeyCredentialsTouchDTOInput{
+		AccountId:  cred.AccountId,
+		Id:         cred.Id,
+		Data:       cred.Data,
+		LastUsedAt: time.Now(),
+	}); err != nil {
+		// The signature counter must be stored for cloned authenticators to be detected.
+		svc.l.Error("failed to touch passkey credential", zap.String("account_id", cred.AccountId), zap.Error(err))
+		return domain.AuthenticateRes{}, err
+	}
+
+	return svc.issueRefreshToken(ctx, cred.AccountId, acc.Type, authMethodPasskey, req.UserAgent, req.Ip)
+}
+
+func (svc *Auth) ListPasskeys(ctx context.Context, req domain.ListPasskeysReq) (domain.ListPasskeysRes, error) {
+	if !svc.passkeysAvailable() {
+		return domain.ListPasskeysRes{}, errPasskeysUnavailable
+	}
+
+	token, err := svc.decodeAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.ListPasskeysRes{}, err
+	}
+
+	passkeys, err := svc.listPasskeys(ctx, token.SubjectId)
+	if err != nil {
+		return domain.ListPasskeysRes{}, err
+	}
+
+	return domain.ListPasskeysRes{
+		Passkeys: passkeys,
+	}, nil
+}
+
+func (svc *Auth) listPasskeys(ctx context.Context, accountId string) ([]domain.Passkey, error) {
+	creds, err := svc.passkeyCredentials.List(ctx, domain.PasskeyCredentialsListDTOInput{
+		AccountId: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to list passkey credentials", zap.String("account_id", accountId), zap.Error(err))
+		return nil, err
+	}
+
+	passkeys := make([]domain.Passkey, 0, len(creds))
+	for _, cred := range creds {
+		passkeys = append(passkeys, newPasskey(cred))
+	}
+	return passkeys, nil
+}
+
+func (svc *Auth) DeletePasskey(ctx context.Context, req domain.DeletePasskeyReq) (domain.DeletePasskeyRes, error) {
+	if !svc.passkeysAvailable() {
+		return domain.DeletePasskeyRes{}, errPasskeysUnavailable
+	}
+
+	token, err := svc.decodeOwnerAccessToken(req.AccessToken)
+	if err != nil {
+		return domain.DeletePasskeyRes{}, err
+	}
+
+	deleted, err := svc.passkeyCredentials.Delete(ctx, domain.PasskeyCredentialsDeleteDTOInput{
+		AccountId: token.SubjectId,
+		Id:        req.PasskeyId,
+	})
+	if err != nil {
+		svc.l.Error("failed to delete passkey credential", zap.String("account_id", token.SubjectId), zap.Error(err))
+		return domain.DeletePasskeyRes{}, err
+	}
+	if !deleted {
+		return domain.DeletePasskeyRes{}, domain.ErrPasskeyNotFound
+	}
+	svc.l.Info("deleted passkey", zap.String("account_id", token.SubjectId), zap.String("passkey_id", req.PasskeyId))
+
+	return domain.DeletePasskeyRes{}, nil
+}
+
+func (svc *Auth) passkeyUser(ctx context.Context, accountId string) (domain.PasskeyUser, error) {
+	user, _, err := svc.findPasskeyUser(ctx, accountId)
+	return user, err
+}
+
+// Returns domain.ErrUserNotFound if the account does not exist.
+func (svc *Auth) findPasskeyUser(ctx context.Context, accountId string) (domain.PasskeyUser, *domain.FindAccountDTOOutput, error) {
+	acc, err := svc.accProv.FindAccount(ctx, domain.FindAccountDTOInput{
+		Id: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to find account for passkey ceremony", zap.String("account_id", accountId), zap.Error(err))
+		return domain.PasskeyUser{}, nil, err
+	}
+	if acc == nil {
+		return domain.PasskeyUser{}, nil, domain.ErrUserNotFound
+	}
+
+	creds, err := svc.passkeyCredentials.List(ctx, domain.PasskeyCredentialsListDTOInput{
+		AccountId: accountId,
+	})
+	if err != nil {
+		svc.l.Error("failed to list passkey credentials", zap.String("account_id", accountId), zap.Error(err))
+		return domain.PasskeyUser{}, nil, err
+	}
+
+	return domain.PasskeyUser{
+		AccountId:   accountId,
+		Name:        acc.Name,
+		Email:       acc.Email,
+		Credentials: creds,
+	}, acc, nil
+}
+
+func (svc *Auth) insertPasskeyCeremony(ctx context.Context, kind domain.PasskeyCeremonyKind, accountId string, session []byte) (domain.PasskeyCeremony, error) {
+	ceremony := domain.PasskeyCeremony{
+		Id:        entity.Id(32),
+		Kind:      kind,
+		AccountId: accountId,
+		Session:   session,
+		ExpiresAt: time.Now().Add(svc.passkeyCeremonyDuration),
+	}
+	if err := svc.passkeyCeremonies.Insert(ctx, ceremony); err != nil {
+		svc.l.Error("failed to insert passkey ceremony", zap.String("kind", kind), zap.Error(err))
+		return domain.PasskeyCeremony{}, err
+	}
+	return ceremony, nil
+}
+
+// Ceremonies are single use: a finished or failed ceremony has to be started over.
+func (svc *Auth) consumePasskeyCeremony(ctx context.Context, id string, kind domain.PasskeyCeremonyKind) (*domain.PasskeyCeremony, error) {
+	ceremony, err := svc.passkeyCeremonies.Consume(ctx, id)
+	if err != nil {
+		svc.l.Error("failed to consume passkey ceremony", zap.String("kind", kind), zap.Error(err))
+		return nil, err
+	}
+	if ceremony == nil || ceremony.Kind != kind || time.Now().After(ceremony.ExpiresAt) {
+		return nil, domain.ErrInvalidPasskeyCeremony
+	}
+	return ceremony, nil
+}
+
+func newPasskey(cred domain.PasskeyCredential) domain.Passkey {
+	return domain.Passkey{
+		Id:         cred.Id,
+		Name:       cred.Name,
+		CreatedAt:  cred.CreatedAt,
+		LastUsedAt: cred.LastUsedAt,
+	}
+}
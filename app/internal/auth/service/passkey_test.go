@@ -0,0 +1,172 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/passkey"
+	"github.com/bratushkadan/floral/internal/auth/infrastructure/passkey/passkeytest"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const passkeyOrigin = "https://floral.example.com"
+
+func newPasskeyTestAuth(t *testing.T) (*service.Auth, *fakeAccountProvider, *passkeytest.Authenticator) {
+	t.Helper()
+
+	rp, err := passkey.NewRelyingParty(passkey.RelyingPartyConf{
+		Id:          "floral.example.com",
+		DisplayName: "Floral",
+		Origins:     []string{passkeyOrigin},
+	})
+	require.NoError(t, err)
+
+	svc, accProv := newTestAuthWith(t, service.NewAuthBuilder().
+		PasskeyCredentials(memory_adapter.NewPasskeyCredentials()).
+		PasskeyCeremonies(memory_adapter.NewPasskeyCeremonies()).
+		PasskeyRelyingParty(rp),
+	)
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"user-id":   {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+		"seller-id": {Name: "seller", Email: "seller@example.com", Type: domain.AccountTypeSeller, Activated: true},
+	}
+
+	return svc, accProv, passkeytest.NewAuthenticator(passkeyOrigin)
+}
+
+func registerPasskey(t *testing.T, svc *service.Auth, authenticator *passkeytest.Authenticator, accessToken string) domain.Passkey {
+	t.Helper()
+	ctx := context.Background()
+
+	beginRes, err := svc.BeginPasskeyRegistration(ctx, domain.BeginPasskeyRegistrationReq{AccessToken: accessToken})
+	require.NoError(t, err)
+
+	credential, err := authenticator.Create(beginRes.Options)
+	require.NoError(t, err)
+
+	finishRes, err := svc.FinishPasskeyRegistration(ctx, domain.FinishPasskeyRegistrationReq{
+		AccessToken: accessToken,
+		CeremonyId:  beginRes.CeremonyId,
+		Name:        " Laptop ",
+		Credential:  credential,
+	})
+	require.NoError(t, err)
+
+	return finishRes.Passkey
+}
+
+func passkeyAssertion(t *testing.T, svc *service.Auth, authenticator *passkeytest.Authenticator) *domain.PasskeyAssertion {
+	t.Helper()
+
+	beginRes, err := svc.BeginPasskeyLogin(context.Background(), domain.BeginPasskeyLoginReq{})
+	require.NoError(t, err)
+
+	credential, err := authenticator.Get(beginRes.Options)
+	require.NoError(t, err)
+
+	return &domain.PasskeyAssertion{
+		CeremonyId: beginRes.CeremonyId,
+		Credential: credential,
+	}
+}
+
+func TestAuthenticateWithPasskey(t *testing.T) {
+	ctx := context.Background()
+	svc, _, authenticator := newPasskeyTestAuth(t)
+
+	registered := registerPasskey(t, svc, authenticator, tokenUser)
+	assert.NotEmpty(t, registered.Id)
+	assert.Equal(t, "Laptop", registered.Name)
+
+	for range 2 {
+		res, err := svc.Authenticate(ctx, domain.AuthenticateReq{
+			Passkey: passkeyAssertion(t, svc, authenticator),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "refresh-token-id", res.RefreshToken)
+	}
+
+	listRes, err := svc.ListPasskeys(ctx, domain.ListPasskeysReq{AccessToken: tokenUser})
+	require.NoError(t, err)
+	require.Len(t, listRes.Passkeys, 1)
+	assert.Equal(t, registered.Id, listRes.Passkeys[0].Id)
+	assert.WithinDuration(t, time.Now(), listRes.Passkeys[0].LastUsedAt, time.Minute)
+}
+
+func TestAuthenticateWithPasskeyRejectsReusedCeremony(t *testing.T) {
+	ctx := context.Background()
+	svc, _, authenticator := newPasskeyTestAuth(t)
+	registerPasskey(t, svc, authenticator, tokenUser)
+
+	assertion := passkeyAssertion(t, svc, authenticator)
+	_, err := svc.Authenticate(ctx, domain.AuthenticateReq{Passkey: assertion})
+	require.NoError(t, err)
+
+	_, err = svc.Authenticate(ctx, domain.AuthenticateReq{Passkey: assertion})
+	assert.ErrorIs(t, err, domain.ErrInvalidPasskeyCeremony)
+}
+
+func TestAuthenticateWithPasskeyRejectsClonedAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	svc, _, authenticator := newPasskeyTestAuth(t)
+	registerPasskey(t, svc, authenticator, tokenUser)
+
+	_, err := svc.Authenticate(ctx, domain.AuthenticateReq{Passkey: passkeyAssertion(t, svc, authenticator)})
+	require.NoError(t, err)
+
+	authenticator.ResetSignCounts()
+	_, err = svc.Authenticate(ctx, domain.AuthenticateReq{Passkey: passkeyAssertion(t, svc, authenticator)})
+	assert.ErrorIs(t, err, domain.ErrPasskeyVerificationFailed)
+}
+
+func TestAuthenticateWithPasskeyRejectsSuspendedAccount(t *testing.T) {
+	svc, accProv, authenticator := newPasskeyTestAuth(t)
+	registerPasskey(t, svc, authenticator, tokenUser)
+
+	accProv.byId["user-id"].SuspendedAt = time.Now()
+	_, err := svc.Authenticate(context.Background(), domain.AuthenticateReq{Passkey: passkeyAssertion(t, svc, authenticator)})
+	assert.ErrorIs(t, err, domain.ErrAccountSuspended)
+}
+
+func TestAuthenticateWithDeletedPasskey(t *testing.T) {
+	ctx := context.Background()
+	svc, _, authenticator := newPasskeyTestAuth(t)
+	registered := registerPasskey(t, svc, authenticator, tokenUser)
+
+	_, err := svc.DeletePasskey(ctx, domain.DeletePasskeyReq{AccessToken: tokenUser, PasskeyId: registered.Id})
+	require.NoError(t, err)
+	_, err = svc.DeletePasskey(ctx, domain.DeletePasskeyReq{AccessToken: tokenUser, PasskeyId: registered.Id})
+	assert.ErrorIs(t, err, domain.ErrPasskeyNotFound)
+
+	_, err = svc.Authenticate(ctx, domain.AuthenticateReq{Passkey: passkeyAssertion(t, svc, authenticator)})
+	assert.ErrorIs(t, err, domain.ErrPasskeyVerificationFailed)
+}
+
+func TestFinishPasskeyRegistrationRejectsCeremonyOfAnotherAccount(t *testing.T) {
+	ctx := context.Background()
+	svc, _, authenticator := newPasskeyTestAuth(t)
+
+	beginRes, err := svc.BeginPasskeyRegistration(ctx, domain.BeginPasskeyRegistrationReq{AccessToken: tokenUser})
+	require.NoError(t, err)
+	credential, err := authenticator.Create(beginRes.Options)
+	require.NoError(t, err)
+
+	_, err = svc.FinishPasskeyRegistration(ctx, domain.FinishPasskeyRegistrationReq{
+		AccessToken: tokenSeller,
+		CeremonyId:  beginRes.CeremonyId,
+		Credential:  credential,
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidPasskeyCeremony)
+}
+
+func TestPasskeysUnavailable(t *testing.T) {
+	svc, _ := newTestAuth(t)
+
+	_, err := svc.BeginPasskeyLogin(context.Background(), domain.BeginPasskeyLoginReq{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+)
+
+// Role permissions defined in code.
+type StaticRolePermissions struct {
+	permissions map[domain.AccountType][]domain.Permission
+}
+
+var _ domain.RolePermissions = (*StaticRolePermissions)(nil)
+
+func NewStaticRolePermissions(permissions map[domain.AccountType][]domain.Permission) *StaticRolePermissions {
+	return &StaticRolePermissions{permissions: permissions}
+}
+
+// Users buy, sellers sell and admins manage everyone's products and orders.
+// Service accounts are granted the scopes of their api keys instead.
+func DefaultRolePermissions() map[domain.AccountType][]domain.Permission {
+	return map[domain.AccountType][]domain.Permission{
+		domain.AccountTypeUser: {
+			domain.PermissionProductsRead,
+			domain.PermissionOrdersRead,
+			domain.PermissionOrdersWrite,
+		},
+		domain.AccountTypeSeller: {
+			domain.PermissionProductsRead,
+			domain.PermissionProductsWrite,
+			domain.PermissionOrdersRead,
+		},
+		domain.AccountTypeAdmin: {
+			domain.PermissionProductsRead,
+			domain.PermissionProductsWrite,
+			domain.PermissionProductsManage,
+			domain.PermissionOrdersRead,
+			domain.PermissionOrdersWrite,
+			domain.PermissionOrdersManage,
+		},
+	}
+}
+
+func (p *StaticRolePermissions) Permissions(_ context.Context, accountType domain.AccountType) ([]domain.Permission, error) {
+	return slices.Clone(p.permissions[accountType]), nil
+}
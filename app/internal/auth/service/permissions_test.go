@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createAccessToken(t *testing.T, b *service.AuthBuilder, accountType domain.AccountType) domain.AccessToken {
+	t.Helper()
+
+	svc, accProv, tokenProv := newTestAuthWithTokens(t, b)
+	accProv.byId = map[string]*domain.FindAccountDTOOutput{
+		"account-id": {Name: "account", Email: "account@example.com", Type: accountType, Activated: true},
+	}
+	tokenProv.refreshTokens = map[string]domain.RefreshToken{
+		"refresh-token-id": {Id: "token-id", SubjectId: "account-id"},
+	}
+
+	_, err := svc.CreateAccessToken(context.Background(), domain.CreateAccessTokenReq{RefreshToken: "refresh-token-id"})
+	require.NoError(t, err)
+	require.Len(t, tokenProv.encodedAccessTokens, 1)
+	return tokenProv.encodedAccessTokens[0]
+}
+
+func TestCreateAccessTokenGrantsRolePermissions(t *testing.T) {
+	for accountType, permissions := range service.DefaultRolePermissions() {
+		token := createAccessToken(t, service.NewAuthBuilder(), accountType)
+		assert.Equal(t, permissions, token.Permissions, accountType)
+	}
+
+	token := createAccessToken(t, service.NewAuthBuilder(), domain.AccountTypeUser)
+	assert.True(t, token.Has(domain.PermissionOrdersWrite))
+	assert.False(t, token.Has(domain.PermissionProductsWrite), "users must not sell")
+	assert.False(t, token.Has(domain.PermissionOrdersManage))
+}
+
+func TestCreateAccessTokenGrantsCustomRolePermissions(t *testing.T) {
+	b := service.NewAuthBuilder().RolePermissions(service.NewStaticRolePermissions(map[domain.AccountType][]domain.Permission{
+		domain.AccountTypeSeller: {"reports:read"},
+	}))
+
+	token := createAccessToken(t, b, domain.AccountTypeSeller)
+	assert.Equal(t, []domain.Permission{"reports:read"}, token.Permissions)
+	assert.False(t, token.Has(domain.PermissionProductsWrite))
+
+	token = createAccessToken(t, b, domain.AccountTypeUser)
+	assert.Empty(t, token.Permissions, "account types without a permission set are granted nothing")
+}
+
+func TestExchangeApiKeyGrantsScopesAsPermissions(t *testing.T) {
+	svc, _, tokenProv, accountId := newApiKeyTestAuth(t, service.NewAuthBuilder())
+	key := createApiKey(t, svc, accountId, domain.PermissionProductsRead, domain.PermissionProductsWrite)
+
+	_, err := svc.ExchangeApiKey(context.Background(), domain.ExchangeApiKeyReq{ApiKey: key.ApiKey, Scope: domain.PermissionProductsRead})
+	require.NoError(t, err)
+
+	require.Len(t, tokenProv.encodedAccessTokens, 1)
+	token := tokenProv.encodedAccessTokens[0]
+	assert.True(t, token.Has(domain.PermissionProductsRead))
+	assert.False(t, token.Has(domain.PermissionProductsWrite), "only requested scopes are granted")
+}
@@ -0,0 +1,109 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	memory_adapter "github.com/bratushkadan/floral/internal/auth/adapters/secondary/memory"
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSecurityEvents struct {
+	sent []domain.SendSecurityEventNotificationDTOInput
+}
+
+func (n *fakeSecurityEvents) Send(_ context.Context, in domain.SendSecurityEventNotificationDTOInput) (domain.SendSecurityEventNotificationDTOOutput, error) {
+	n.sent = append(n.sent, in)
+	return domain.SendSecurityEventNotificationDTOOutput{}, nil
+}
+
+// User accounts may hold 2 refresh tokens, the oldest ones are evicted.
+func newRefreshTokenTestAuth(t *testing.T) (*service.Auth, *fakeSecurityEvents) {
+	t.Helper()
+
+	accProv := &fakeAccountProvider{
+		byId: map[string]*domain.FindAccountDTOOutput{
+			"user-id": {Name: "user", Email: "user@example.com", Type: domain.AccountTypeUser, Activated: true},
+		},
+		byEmail: map[string]*domain.FindAccountByEmailDTOOutput{
+			"user@example.com": {Id: "user-id", Name: "user", Type: domain.AccountTypeUser, Activated: true},
+		},
+		passwords: map[string]string{"user-id": "password"},
+	}
+	securityEvents := &fakeSecurityEvents{}
+
+	svc, err := service.NewAuthBuilder().
+		AccountProvider(accProv).
+		AccountCreationNotificationProvider(&fakeAccountCreationNotifications{}).
+		SecurityEventNotificationProvider(securityEvents).
+		RefreshTokenProvider(memory_adapter.NewRefreshTokens(0, "")).
+		RefreshTokensLimit(domain.AccountTypeUser, 2).
+		TokenProvider(&fakeTokenProvider{}).
+		Build()
+	require.NoError(t, err)
+
+	return svc, securityEvents
+}
+
+func login(t *testing.T, svc *service.Auth) string {
+	t.Helper()
+
+	res, err := svc.Authenticate(context.Background(), domain.AuthenticateReq{Email: "user@example.com", Password: "password"})
+	require.NoError(t, err)
+	return res.RefreshToken
+}
+
+func replaceRefreshToken(svc *service.Auth, refreshToken string) (string, error) {
+	res, err := svc.ReplaceRefreshToken(context.Background(), domain.ReplaceRefreshTokenReq{RefreshToken: refreshToken})
+	return res.RefreshToken, err
+}
+
+func TestReplaceRotatedRefreshTokenRevokesFamily(t *testing.T) {
+	svc, securityEvents := newRefreshTokenTestAuth(t)
+
+	sibling := login(t, svc)
+	rotated := login(t, svc)
+	current, err := replaceRefreshToken(svc, rotated)
+	require.NoError(t, err)
+
+	_, err = replaceRefreshToken(svc, rotated)
+	assert.ErrorIs(t, err, domain.ErrRefreshTokenReused)
+	require.Len(t, securityEvents.sent, 1)
+	assert.Equal(t, domain.SecurityEventRefreshTokenReuse, securityEvents.sent[0].Type)
+
+	_, err = replaceRefreshToken(svc, current)
+	assert.ErrorIs(t, err, domain.ErrRefreshTokenToReplaceNotFound, "every token of the family must be revoked")
+
+	_, err = replaceRefreshToken(svc, sibling)
+	assert.NoError(t, err, "sessions of other families must survive")
+}
+
+func TestReplaceRevokedRefreshTokenIsNotReuse(t *testing.T) {
+	for name, revoke := range map[string]func(t *testing.T, svc *service.Auth, refreshToken string){
+		"evicted token presented": func(t *testing.T, svc *service.Auth, _ string) {
+			login(t, svc)
+		},
+		"logged-out token presented": func(t *testing.T, svc *service.Auth, refreshToken string) {
+			_, err := svc.Logout(context.Background(), domain.LogoutReq{RefreshToken: refreshToken})
+			require.NoError(t, err)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc, securityEvents := newRefreshTokenTestAuth(t)
+
+			refreshToken := login(t, svc)
+			sibling := login(t, svc)
+			revoke(t, svc, refreshToken)
+
+			_, err := replaceRefreshToken(svc, refreshToken)
+			assert.ErrorIs(t, err, domain.ErrRefreshTokenToReplaceNotFound)
+			assert.Empty(t, securityEvents.sent)
+
+			_, err = replaceRefreshToken(svc, sibling)
+			assert.NoError(t, err, "sibling sessions must survive")
+		})
+	}
+}
@@ -11,11 +11,13 @@ const (
 
 	EnvKeySqsQueueUrlEmailConfirmations = "SQS_QUEUE_URL_EMAIL_CONFIRMATIONS"
 	EnvKeySqsQueueUrlAccountCreations   = "SQS_QUEUE_URL_ACCOUNT_CREATIONS"
+	EnvKeySqsQueueUrlSecurityEvents     = "SQS_QUEUE_URL_SECURITY_EVENTS"
 
 	EnvKeySenderEmail                  = "SENDER_EMAIL"
 	EnvKeySenderPassword               = "SENDER_PASSWORD"
 	EnvKeyEmailConfirmationApiEndpoint = "EMAIL_CONFIRMATION_API_ENDPOINT"
 	EnvKeyEmailConfirmationOrigin      = "EMAIL_CONFIRMATION_ORIGIN"
+	EnvKeyPasswordResetUrl             = "PASSWORD_RESET_URL"
 
 	EnvKeyAccountIdHashSalt = "APP_ID_ACCOUNT_HASH_SALT"
 	EnvKeyTokenIdHashSalt   = "APP_ID_TOKEN_HASH_SALT"
@@ -26,6 +28,65 @@ const (
 	EnvKeyAuthTokenPublicKey      = "APP_AUTH_TOKEN_PUBLIC_KEY"
 	EnvKeyAuthTokenPrivateKeyPath = "APP_AUTH_TOKEN_PRIVATE_KEY_PATH"
 	EnvKeyAuthTokenPublicKeyPath  = "APP_AUTH_TOKEN_PUBLIC_KEY_PATH"
+	// Id of the signing key, the JWK thumbprint of the public key by default.
+	EnvKeyAuthTokenKeyId = "APP_AUTH_TOKEN_KEY_ID"
+	// "iss" claim of issued tokens, tokens of other issuers are rejected if set.
+	EnvKeyAuthTokenIssuer = "APP_AUTH_TOKEN_ISSUER"
+	// Comma separated "aud" claim of issued tokens, tokens for other audiences are rejected if set.
+	EnvKeyAuthTokenAudience = "APP_AUTH_TOKEN_AUDIENCE"
+	// RFC 3339 time tokens issued without the registered claims stop being accepted at,
+	// authn.DefaultLegacyTokensAcceptedUntil by default.
+	EnvKeyAuthTokenLegacyAcceptedUntil = "APP_AUTH_TOKEN_LEGACY_ACCEPTED_UNTIL"
+	// Comma separated ids of previous signing keys still verifying the tokens they've signed.
+	EnvKeyAuthTokenRetiredKeyIds          = "APP_AUTH_TOKEN_RETIRED_KEY_IDS"
+	EnvKeyAuthTokenRetiredKeyPublicKeyFmt = "APP_AUTH_TOKEN_RETIRED_KEY_%s_PUBLIC_KEY"
+	// Optional RFC 3339 time the retired key stops verifying tokens at.
+	EnvKeyAuthTokenRetiredKeyNotAfterFmt = "APP_AUTH_TOKEN_RETIRED_KEY_%s_NOT_AFTER"
+
+	// Policy applied once the account holds the limit of refresh tokens: "evict_oldest" (default) or "reject_new".
+	EnvKeyRefreshTokensEvictionPolicy = "APP_REFRESH_TOKENS_EVICTION_POLICY"
+	// Amount of refresh tokens accounts of the type may hold, formatted with the upper cased account type,
+	// i.e. "APP_REFRESH_TOKENS_LIMIT_SELLER". Admins 2, sellers 10 and users 5 by default.
+	EnvKeyRefreshTokensLimitFmt = "APP_REFRESH_TOKENS_LIMIT_%s"
+	// Amount of refresh tokens accounts of types without a limit may hold, 5 by default.
+	EnvKeyRefreshTokensDefaultLimit = "APP_REFRESH_TOKENS_DEFAULT_LIMIT"
+
+	EnvKeyAccountDeletionGracePeriod = "APP_ACCOUNT_DELETION_GRACE_PERIOD"
+
+	// Account creation notifications are written to the outbox and published by the relay when set to "true".
+	EnvKeyAccountCreationOutboxEnabled = "APP_ACCOUNT_CREATION_OUTBOX_ENABLED"
+	// How often the relay publishes pending account creation notifications, 5s by default.
+	EnvKeyAccountCreationRelayInterval = "APP_ACCOUNT_CREATION_RELAY_INTERVAL"
+
+	// How often revoked access tokens are reloaded from the database, 10s by default.
+	EnvKeyAccessTokenRevocationsRefreshInterval = "APP_ACCESS_TOKEN_REVOCATIONS_REFRESH_INTERVAL"
+
+	// Where access token permission sets are read from: "code" (default) or "ydb".
+	EnvKeyRolePermissionsSource = "APP_ROLE_PERMISSIONS_SOURCE"
+
+	// Passkeys are enabled once the relying party id is set.
+	EnvKeyPasskeyRpId = "APP_PASSKEY_RP_ID"
+	// Comma separated origins passkey ceremonies are allowed from.
+	EnvKeyPasskeyRpOrigins = "APP_PASSKEY_RP_ORIGINS"
+
+	// OpenID Connect provider is enabled once the issuer is set.
+	EnvKeyOidcIssuer = "APP_OIDC_ISSUER"
+	// Login page OpenID Connect clients redirect users to for authorization.
+	EnvKeyOidcAuthorizationUrl = "APP_OIDC_AUTHORIZATION_URL"
+
+	// Comma separated names of external identity providers users may log in with, i.e. "google,yandex".
+	// Login with identity providers is enabled once set.
+	EnvKeyIdentityProviders = "APP_IDENTITY_PROVIDERS"
+	// Login page identity providers redirect back to.
+	EnvKeyIdentityProviderRedirectUrl = "APP_IDENTITY_PROVIDER_REDIRECT_URL"
+	// Per provider settings, formatted with the upper cased provider name.
+	EnvKeyIdentityProviderIssuerUrlFmt    = "APP_IDENTITY_PROVIDER_%s_ISSUER_URL"
+	EnvKeyIdentityProviderClientIdFmt     = "APP_IDENTITY_PROVIDER_%s_CLIENT_ID"
+	EnvKeyIdentityProviderClientSecretFmt = "APP_IDENTITY_PROVIDER_%s_CLIENT_SECRET"
+
+	EnvKeyAdminName     = "ADMIN_NAME"
+	EnvKeyAdminEmail    = "ADMIN_EMAIL"
+	EnvKeyAdminPassword = "ADMIN_PASSWORD"
 )
 
 // Yandex Cloud Serverless
@@ -3,8 +3,10 @@ package presentation
 import (
 	"net/http"
 	"slices"
-	"strings"
 
+	"github.com/bratushkadan/floral/internal/auth/core/domain"
+	"github.com/bratushkadan/floral/internal/auth/middleware"
+	gin_middleware "github.com/bratushkadan/floral/internal/auth/middleware/gin"
 	oapi_codegen "github.com/bratushkadan/floral/internal/products/presentation/generated"
 	"github.com/bratushkadan/floral/pkg/xhttp"
 	"github.com/gin-gonic/gin"
@@ -17,6 +19,7 @@ var _ oapi_codegen.ServerInterface = (*ApiImpl)(nil)
 
 type ApiImpl struct {
 	Logger *zap.Logger
+	Auth   *middleware.Auth
 }
 
 var _ oapi_codegen.ServerInterface = (*ApiImpl)(nil)
@@ -36,63 +39,47 @@ func (*ApiImpl) ProductsList(c *gin.Context, params oapi_codegen.ProductsListPar
 }
 
 type authRequiredRecord struct {
-	Method string
-	Path   string
+	Method      string
+	Path        string
+	Requirement middleware.Requirement
 }
 
 var authRequired = []authRequiredRecord{
 	{
-		Method: oapi_codegen.ProductsCreateMethod,
-		Path:   oapi_codegen.ProductsCreatePath,
+		Method:      oapi_codegen.ProductsCreateMethod,
+		Path:        oapi_codegen.ProductsCreatePath,
+		Requirement: middleware.Requirement{Permissions: []domain.Permission{domain.PermissionProductsWrite}},
 	},
 	{
-		Method: oapi_codegen.ProductsUpdateMethod,
-		Path:   oapi_codegen.ProductsUpdatePath,
+		Method:      oapi_codegen.ProductsUpdateMethod,
+		Path:        oapi_codegen.ProductsUpdatePath,
+		Requirement: middleware.Requirement{Permissions: []domain.Permission{domain.PermissionProductsWrite}},
 	},
 	{
-		Method: oapi_codegen.ProductsDeleteMethod,
-		Path:   oapi_codegen.ProductsDeletePath,
+		Method:      oapi_codegen.ProductsDeleteMethod,
+		Path:        oapi_codegen.ProductsDeletePath,
+		Requirement: middleware.Requirement{Permissions: []domain.Permission{domain.PermissionProductsWrite}},
 	},
 }
 
-func checkAuthRequired(c *gin.Context) bool {
-	return slices.ContainsFunc(authRequired, func(r authRequiredRecord) bool {
+func findAuthRequired(c *gin.Context) (authRequiredRecord, bool) {
+	i := slices.IndexFunc(authRequired, func(r authRequiredRecord) bool {
 		return c.FullPath() == r.Path && c.Request.Method == r.Method
 	})
+	if i == -1 {
+		return authRequiredRecord{}, false
+	}
+	return authRequired[i], true
 }
 
 func (a *ApiImpl) AuthMiddleware(c *gin.Context) {
-	if checkAuthRequired(c) {
-		// do check
-
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			a.Logger.Info("did not find authorization header value", zap.String("value", c.GetHeader("Authorization")))
-			c.AbortWithStatusJSON(http.StatusUnauthorized, oapi_codegen.Error{
-				Errors: []oapi_codegen.Err{{Code: 121, Message: "Authorization header must be provided"}},
-			})
-			return
-		}
-		token, stripped := strings.CutPrefix(authHeader, "Bearer ")
-		if !stripped {
-			a.Logger.Info("did not find authorization bearer header", zap.String("value", c.GetHeader("Authorization")))
-			c.AbortWithStatusJSON(http.StatusUnauthorized, oapi_codegen.Error{
-				Errors: []oapi_codegen.Err{{Code: 122, Message: `authorization header "Bearer " prefix must be provided`}},
-			})
-			return
-		}
-
-		a.Logger.Info("token", zap.String("token", token))
-		// process token here
-		var err error
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{})
-			return
-		}
+	record, ok := findAuthRequired(c)
+	if !ok {
+		c.Next()
+		return
 	}
 
-	a.Logger.Info("authorization header", zap.String("value", c.GetHeader("Authorization")))
-	c.Next()
+	gin_middleware.Middleware(a.Auth, record.Requirement)(c)
 }
 
 func (*ApiImpl) ProductsGet(c *gin.Context, id string) {
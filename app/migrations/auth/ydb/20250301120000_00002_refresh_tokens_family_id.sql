@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens ADD COLUMN family_id Utf8;
+ALTER TABLE refresh_tokens ADD INDEX idx_family_id GLOBAL SYNC ON (family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP INDEX idx_family_id;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
+-- +goose StatementEnd
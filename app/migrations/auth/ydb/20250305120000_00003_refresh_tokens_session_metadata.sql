@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens
+    ADD COLUMN user_agent Utf8,
+    ADD COLUMN ip Utf8,
+    ADD COLUMN last_used_at Datetime;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens
+    DROP COLUMN user_agent,
+    DROP COLUMN ip,
+    DROP COLUMN last_used_at;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE accounts ADD COLUMN pending_email Utf8;
+ALTER TABLE accounts ADD INDEX idx_pending_email GLOBAL SYNC ON (pending_email);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE accounts DROP INDEX idx_pending_email;
+ALTER TABLE accounts DROP COLUMN pending_email;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE accounts ADD COLUMN deleted_at Datetime;
+ALTER TABLE accounts ADD INDEX idx_deleted_at GLOBAL SYNC ON (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE accounts DROP INDEX idx_deleted_at;
+ALTER TABLE accounts DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE login_attempts (
+    key Utf8 NOT NULL,
+    failures Uint32 NOT NULL,
+    last_failure_at Timestamp NOT NULL,
+    PRIMARY KEY (key)
+) WITH (
+    TTL = Interval("P1D") ON last_failure_at
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE login_attempts;
+-- +goose StatementEnd
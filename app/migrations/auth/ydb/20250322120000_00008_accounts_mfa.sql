@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE accounts
+    ADD COLUMN mfa_totp_secret Utf8,
+    ADD COLUMN mfa_enabled_at Datetime,
+    ADD COLUMN mfa_totp_last_counter Uint64,
+    ADD COLUMN mfa_recovery_codes Json;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE accounts
+    DROP COLUMN mfa_totp_secret,
+    DROP COLUMN mfa_enabled_at,
+    DROP COLUMN mfa_totp_last_counter,
+    DROP COLUMN mfa_recovery_codes;
+-- +goose StatementEnd
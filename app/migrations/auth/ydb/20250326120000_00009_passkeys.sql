@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE passkey_credentials (
+    account_id Utf8 NOT NULL,
+    id Utf8 NOT NULL,
+    name Utf8 NOT NULL,
+    data Json NOT NULL,
+    created_at Timestamp NOT NULL,
+    last_used_at Timestamp,
+    PRIMARY KEY (account_id, id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE passkey_ceremonies (
+    id Utf8 NOT NULL,
+    kind Utf8 NOT NULL,
+    account_id Utf8,
+    session Json NOT NULL,
+    expires_at Timestamp NOT NULL,
+    PRIMARY KEY (id)
+) WITH (
+    TTL = Interval("PT0S") ON expires_at
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE passkey_ceremonies;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE passkey_credentials;
+-- +goose StatementEnd
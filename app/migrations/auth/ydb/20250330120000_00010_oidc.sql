@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE oauth_clients (
+    id Utf8 NOT NULL,
+    name Utf8 NOT NULL,
+    secret_hash Utf8,
+    redirect_uris Json NOT NULL,
+    created_at Timestamp NOT NULL,
+    PRIMARY KEY (id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE authorization_codes (
+    code Utf8 NOT NULL,
+    client_id Utf8 NOT NULL,
+    account_id Utf8 NOT NULL,
+    redirect_uri Utf8 NOT NULL,
+    scope Utf8 NOT NULL,
+    nonce Utf8,
+    code_challenge Utf8 NOT NULL,
+    expires_at Timestamp NOT NULL,
+    PRIMARY KEY (code)
+) WITH (
+    TTL = Interval("PT0S") ON expires_at
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE authorization_codes;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE oauth_clients;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE identities (
+    provider Utf8 NOT NULL,
+    subject Utf8 NOT NULL,
+    account_id Utf8 NOT NULL,
+    email Utf8 NOT NULL,
+    created_at Timestamp NOT NULL,
+    PRIMARY KEY (provider, subject),
+    INDEX idx_account_id GLOBAL SYNC on(account_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE identity_logins (
+    state Utf8 NOT NULL,
+    provider Utf8 NOT NULL,
+    nonce Utf8 NOT NULL,
+    code_verifier Utf8 NOT NULL,
+    expires_at Timestamp NOT NULL,
+    PRIMARY KEY (state)
+) WITH (
+    TTL = Interval("PT0S") ON expires_at
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE identity_logins;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE identities;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE api_keys (
+    id Utf8 NOT NULL,
+    account_id Utf8 NOT NULL,
+    name Utf8 NOT NULL,
+    secret_hash Utf8 NOT NULL,
+    scopes Json NOT NULL,
+    created_at Timestamp NOT NULL,
+    expires_at Timestamp,
+    last_used_at Timestamp,
+    PRIMARY KEY (id),
+    INDEX idx_account_id GLOBAL SYNC on(account_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE api_keys;
+-- +goose StatementEnd
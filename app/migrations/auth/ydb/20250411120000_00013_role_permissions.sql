@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE role_permissions (
+    account_type Utf8 NOT NULL,
+    permission Utf8 NOT NULL,
+    PRIMARY KEY (account_type, permission)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPSERT INTO role_permissions ( account_type, permission ) VALUES
+    ( "user", "products:read" ),
+    ( "user", "orders:read" ),
+    ( "user", "orders:write" ),
+    ( "seller", "products:read" ),
+    ( "seller", "products:write" ),
+    ( "seller", "orders:read" ),
+    ( "admin", "products:read" ),
+    ( "admin", "products:write" ),
+    ( "admin", "products:manage" ),
+    ( "admin", "orders:read" ),
+    ( "admin", "orders:write" ),
+    ( "admin", "orders:manage" );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE role_permissions;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_log (
+    id Utf8 NOT NULL,
+    type Utf8 NOT NULL,
+    account_id Utf8 NOT NULL,
+    actor_id Utf8,
+    details Json NOT NULL,
+    created_at Timestamp NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_account_id GLOBAL SYNC on(account_id, created_at),
+    INDEX idx_created_at GLOBAL SYNC on(created_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_log;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE account_creation_outbox (
+    id Utf8 NOT NULL,
+    email Utf8 NOT NULL,
+    attempts Uint32 NOT NULL,
+    created_at Timestamp NOT NULL,
+    next_attempt_at Timestamp NOT NULL,
+    last_error Utf8,
+    PRIMARY KEY (id),
+    INDEX idx_next_attempt_at GLOBAL SYNC on(next_attempt_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE account_creation_outbox;
+-- +goose StatementEnd
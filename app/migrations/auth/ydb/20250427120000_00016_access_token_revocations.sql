@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE access_token_revocations (
+    id Utf8 NOT NULL,
+    token_id Utf8,
+    account_id Utf8,
+    revoked_at Timestamp NOT NULL,
+    expires_at Timestamp NOT NULL,
+    PRIMARY KEY (id)
+) WITH (
+    TTL = Interval("PT0S") ON expires_at
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE access_token_revocations;
+-- +goose StatementEnd
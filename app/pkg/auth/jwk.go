@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// JSON Web Key (RFC 7517) of an ECDSA P-256 public key.
+type Jwk struct {
+	Kid string `json:"kid,omitempty"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
+
+func NewEs256Jwk(key *ecdsa.PublicKey) Jwk {
+	return Jwk{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		Use: "sig",
+		Alg: "ES256",
+	}
+}
+
+// JWK thumbprint (RFC 7638) of an ECDSA P-256 public key.
+func Es256JwkThumbprint(key *ecdsa.PublicKey) string {
+	jwk := NewEs256Jwk(key)
+	// Required members in lexicographic order without whitespace.
+	sum := sha256.Sum256([]byte(fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`, jwk.Crv, jwk.Kty, jwk.X, jwk.Y)))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Key set the tokens created by the provider are verified with,
+// the signing key first followed by the retired keys still in their grace window.
+func (p *JwtProvider) Jwks() Jwks {
+	ids := p.keyIds()
+	jwks := Jwks{Keys: make([]Jwk, 0, len(ids))}
+	for _, kid := range ids {
+		jwk := NewEs256Jwk(p.keys[kid].publicKey)
+		jwk.Kid = kid
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// Public key of an ECDSA P-256 JSON Web Key.
+func (k Jwk) Es256PublicKey() (*ecdsa.PublicKey, error) {
+	if k.Kty != "EC" || k.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported jwk key type %q curve %q", k.Kty, k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode jwk x coordinate: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode jwk y coordinate: %w", err)
+	}
+
+	key := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !key.Curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("jwk point is not on the P-256 curve")
+	}
+	return key, nil
+}
@@ -1,25 +1,57 @@
 package auth
 
 import (
+	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var ErrUnknownKeyId = errors.New("unknown jwt key id")
+
+// How long token verification waits for the remote key set to be fetched.
+const remoteJwksLookupTimeout = 3 * time.Second
+
 type JwtProvider struct {
 	privateKey *ecdsa.PrivateKey
-	publicKey  *ecdsa.PublicKey
+	// Id of the key tokens are signed with, set as the "kid" header of every token.
+	keyId string
+	// Keys tokens are verified with by key id, the signing key included.
+	keys map[string]jwtVerificationKey
+	// Keys not configured locally are looked up in the remote key set if set.
+	remote     *RemoteJwks
 	parserOpts []jwt.ParserOption
 }
 
+type jwtVerificationKey struct {
+	publicKey *ecdsa.PublicKey
+	// Zero for keys verifying tokens for as long as they are configured.
+	notAfter time.Time
+}
+
+func (k jwtVerificationKey) active(now time.Time) bool {
+	return k.notAfter.IsZero() || now.Before(k.notAfter)
+}
+
 type JwtProviderBuilder struct {
 	privateKey []byte
 	publicKey  []byte
+	keyId      string
+	retired    []jwtRetiredKey
 	prov       JwtProvider
 }
 
+type jwtRetiredKey struct {
+	keyId     string
+	publicKey []byte
+	notAfter  time.Time
+}
+
 func NewJwtProviderBuilder() *JwtProviderBuilder {
 	return &JwtProviderBuilder{prov: JwtProvider{}}
 }
@@ -33,6 +65,27 @@ func (b *JwtProviderBuilder) WithPublicKey(publicKey []byte) *JwtProviderBuilder
 	return b
 }
 
+// Id of the key pair set with WithPrivateKey and WithPublicKey.
+// Defaults to the JWK thumbprint (RFC 7638) of the public key.
+func (b *JwtProviderBuilder) WithKeyId(keyId string) *JwtProviderBuilder {
+	b.keyId = keyId
+	return b
+}
+
+// Previously active key still verifying the tokens it has signed until notAfter.
+// Zero notAfter keeps the key for as long as it's configured.
+func (b *JwtProviderBuilder) WithRetiredKey(keyId string, publicKey []byte, notAfter time.Time) *JwtProviderBuilder {
+	b.retired = append(b.retired, jwtRetiredKey{keyId: keyId, publicKey: publicKey, notAfter: notAfter})
+	return b
+}
+
+// Verify tokens with the keys of a remote key set, e.g. in services
+// not issuing tokens themselves. The public key is optional then.
+func (b *JwtProviderBuilder) WithRemoteJwks(jwks *RemoteJwks) *JwtProviderBuilder {
+	b.prov.remote = jwks
+	return b
+}
+
 // Example: jwt.WithIssuer("foo")
 func (b *JwtProviderBuilder) WithParserOptions(opts ...jwt.ParserOption) *JwtProviderBuilder {
 	b.prov.parserOpts = opts
@@ -40,12 +93,22 @@ func (b *JwtProviderBuilder) WithParserOptions(opts ...jwt.ParserOption) *JwtPro
 }
 
 func (b *JwtProviderBuilder) Build() (*JwtProvider, error) {
+	keys := make(map[string]jwtVerificationKey)
+
+	keyId := b.keyId
 	if len(b.publicKey) == 0 {
-		return nil, fmt.Errorf("public key can't be empty")
-	}
-	publicKey, err := jwt.ParseECPublicKeyFromPEM(b.publicKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse ECC public key from PEM for asymmetric JWT validation: %w", err)
+		if b.prov.remote == nil {
+			return nil, fmt.Errorf("public key can't be empty")
+		}
+	} else {
+		publicKey, err := jwt.ParseECPublicKeyFromPEM(b.publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ECC public key from PEM for asymmetric JWT validation: %w", err)
+		}
+		if keyId == "" {
+			keyId = Es256JwkThumbprint(publicKey)
+		}
+		keys[keyId] = jwtVerificationKey{publicKey: publicKey}
 	}
 
 	var privateKey *ecdsa.PrivateKey
@@ -60,16 +123,35 @@ func (b *JwtProviderBuilder) Build() (*JwtProvider, error) {
 		}
 	}
 
-	b.prov.publicKey = publicKey
+	for _, retired := range b.retired {
+		if retired.keyId == "" {
+			return nil, fmt.Errorf("retired key id can't be empty")
+		}
+		if _, ok := keys[retired.keyId]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", retired.keyId)
+		}
+		key, err := jwt.ParseECPublicKeyFromPEM(retired.publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ECC public key %q from PEM for asymmetric JWT validation: %w", retired.keyId, err)
+		}
+		keys[retired.keyId] = jwtVerificationKey{publicKey: key, notAfter: retired.notAfter}
+	}
+
 	b.prov.privateKey = privateKey
+	b.prov.keyId = keyId
+	b.prov.keys = keys
 
 	return &b.prov, nil
 }
 
 func (p *JwtProvider) Create(claims jwt.Claims) (string, error) {
+	if p.privateKey == nil {
+		return "", errors.New("failed to create token: provider without a private key only verifies tokens")
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = p.keyId
 
-	log.Print("private key", p.privateKey)
 	tokenString, err := token.SignedString(p.privateKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to create refresh token: %w", err)
@@ -83,7 +165,7 @@ func (p *JwtProvider) Parse(token string, claims jwt.Claims) error {
 		if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
 			return nil, fmt.Errorf("unexpected signing method for token")
 		}
-		return p.publicKey, nil
+		return p.verificationKey(token)
 	}, p.parserOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to parse jwt token: %w", err)
@@ -94,3 +176,60 @@ func (p *JwtProvider) Parse(token string, claims jwt.Claims) error {
 
 	return nil
 }
+
+func (p *JwtProvider) verificationKey(token *jwt.Token) (any, error) {
+	now := time.Now()
+
+	// Verification isn't held up by a slow remote key set for longer than that.
+	ctx, cancel := context.WithTimeout(context.Background(), remoteJwksLookupTimeout)
+	defer cancel()
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// Tokens created before key ids were introduced are tried against every active key.
+		var set jwt.VerificationKeySet
+		for _, key := range p.keys {
+			if key.active(now) {
+				set.Keys = append(set.Keys, key.publicKey)
+			}
+		}
+		if p.remote != nil {
+			keys, err := p.remote.PublicKeys(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				set.Keys = append(set.Keys, key)
+			}
+		}
+		return set, nil
+	}
+
+	if key, ok := p.keys[kid]; ok {
+		if !key.active(now) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKeyId, kid)
+		}
+		return key.publicKey, nil
+	}
+	if p.remote != nil {
+		return p.remote.PublicKey(ctx, kid)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKeyId, kid)
+}
+
+// Active local key ids, the signing key first.
+func (p *JwtProvider) keyIds() []string {
+	now := time.Now()
+
+	ids := make([]string, 0, len(p.keys))
+	for kid, key := range p.keys {
+		if kid != p.keyId && key.active(now) {
+			ids = append(ids, kid)
+		}
+	}
+	slices.SortFunc(ids, strings.Compare)
+	if _, ok := p.keys[p.keyId]; ok {
+		ids = append([]string{p.keyId}, ids...)
+	}
+	return ids
+}
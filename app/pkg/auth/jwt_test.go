@@ -1,13 +1,21 @@
 package auth_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	_ "embed"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/bratushkadan/floral/pkg/auth"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //go:embed test_fixtures/private_key.pem
@@ -66,3 +74,158 @@ func TestJwtDecodeExpired(t *testing.T) {
 		assert.ErrorIs(t, err, jwt.ErrTokenExpired, "unexpected token provider decoding error")
 	}
 }
+
+func TestJwks(t *testing.T) {
+	tokenProv, err := getJwtProvider()
+	assert.NoError(t, err)
+
+	key, err := jwt.ParseECPublicKeyFromPEM(publicKey)
+	assert.NoError(t, err)
+
+	jwks := tokenProv.Jwks()
+	if assert.Len(t, jwks.Keys, 1) {
+		jwk := jwks.Keys[0]
+		assert.Equal(t, "EC", jwk.Kty)
+		assert.Equal(t, "ES256", jwk.Alg)
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		assert.NoError(t, err)
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		assert.NoError(t, err)
+		assert.Equal(t, key.X.Bytes(), new(big.Int).SetBytes(x).Bytes())
+		assert.Equal(t, key.Y.Bytes(), new(big.Int).SetBytes(y).Bytes())
+	}
+}
+
+// PEM encoded P-256 key pair generated for the test.
+func generateKeyPair(t *testing.T) (privateKeyPem []byte, publicKeyPem []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privateDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	publicDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDer}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer})
+}
+
+func TestJwtKeyId(t *testing.T) {
+	tokenProv, err := auth.NewJwtProviderBuilder().
+		WithPublicKey(publicKey).
+		WithPrivateKey(privateKey).
+		WithKeyId("key-1").
+		Build()
+	require.NoError(t, err)
+
+	tokenString, err := tokenProv.Create(testClaims{SubjectId: "dan"})
+	require.NoError(t, err)
+
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &testClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", token.Header["kid"])
+
+	jwks := tokenProv.Jwks()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "key-1", jwks.Keys[0].Kid)
+}
+
+func TestJwtDefaultKeyIdIsThumbprint(t *testing.T) {
+	tokenProv, err := getJwtProvider()
+	require.NoError(t, err)
+
+	key, err := jwt.ParseECPublicKeyFromPEM(publicKey)
+	require.NoError(t, err)
+
+	jwks := tokenProv.Jwks()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, auth.Es256JwkThumbprint(key), jwks.Keys[0].Kid)
+}
+
+func TestJwtKeyRotation(t *testing.T) {
+	newPrivateKey, newPublicKey := generateKeyPair(t)
+
+	oldProv, err := auth.NewJwtProviderBuilder().
+		WithPublicKey(publicKey).
+		WithPrivateKey(privateKey).
+		WithKeyId("old").
+		Build()
+	require.NoError(t, err)
+	oldToken, err := oldProv.Create(testClaims{SubjectId: "dan"})
+	require.NoError(t, err)
+
+	newProv, err := auth.NewJwtProviderBuilder().
+		WithPublicKey(newPublicKey).
+		WithPrivateKey(newPrivateKey).
+		WithKeyId("new").
+		WithRetiredKey("old", publicKey, time.Now().Add(time.Hour)).
+		Build()
+	require.NoError(t, err)
+
+	var claims testClaims
+	require.NoError(t, newProv.Parse(oldToken, &claims), "tokens of the retired key must verify within the grace window")
+	assert.Equal(t, "dan", claims.SubjectId)
+
+	newToken, err := newProv.Create(testClaims{SubjectId: "dan"})
+	require.NoError(t, err)
+	assert.Error(t, oldProv.Parse(newToken, &testClaims{}), "tokens of the new key must not verify with the old key only")
+
+	jwks := newProv.Jwks()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "new", jwks.Keys[0].Kid, "the signing key is listed first")
+	assert.Equal(t, "old", jwks.Keys[1].Kid)
+}
+
+func TestJwtRetiredKeyAfterGraceWindow(t *testing.T) {
+	newPrivateKey, newPublicKey := generateKeyPair(t)
+
+	oldProv, err := auth.NewJwtProviderBuilder().
+		WithPublicKey(publicKey).
+		WithPrivateKey(privateKey).
+		WithKeyId("old").
+		Build()
+	require.NoError(t, err)
+	oldToken, err := oldProv.Create(testClaims{SubjectId: "dan"})
+	require.NoError(t, err)
+
+	newProv, err := auth.NewJwtProviderBuilder().
+		WithPublicKey(newPublicKey).
+		WithPrivateKey(newPrivateKey).
+		WithKeyId("new").
+		WithRetiredKey("old", publicKey, time.Now().Add(-time.Minute)).
+		Build()
+	require.NoError(t, err)
+
+	err = newProv.Parse(oldToken, &testClaims{})
+	assert.ErrorIs(t, err, auth.ErrUnknownKeyId)
+	assert.Len(t, newProv.Jwks().Keys, 1, "retired keys past the grace window are not published")
+}
+
+func TestJwtWithoutKeyId(t *testing.T) {
+	newPrivateKey, newPublicKey := generateKeyPair(t)
+
+	// Tokens created before key ids were introduced.
+	legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodES256, testClaims{SubjectId: "dan"}).
+		SignedString(mustParsePrivateKey(t, privateKey))
+	require.NoError(t, err)
+
+	tokenProv, err := auth.NewJwtProviderBuilder().
+		WithPublicKey(newPublicKey).
+		WithPrivateKey(newPrivateKey).
+		WithRetiredKey("old", publicKey, time.Time{}).
+		Build()
+	require.NoError(t, err)
+
+	var claims testClaims
+	require.NoError(t, tokenProv.Parse(legacyToken, &claims))
+	assert.Equal(t, "dan", claims.SubjectId)
+}
+
+func mustParsePrivateKey(t *testing.T, key []byte) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := jwt.ParseECPrivateKeyFromPEM(key)
+	require.NoError(t, err)
+	return k
+}
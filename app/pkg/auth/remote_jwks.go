@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRemoteJwksCacheDuration = 5 * time.Minute
+	// The key set is refetched at most this often, be it stale or a token signed with an unknown key seen.
+	remoteJwksMinRefreshInterval = 30 * time.Second
+	remoteJwksFetchTimeout       = 10 * time.Second
+)
+
+// Verification keys fetched from a JSON Web Key Set url, e.g. the account service
+// "/.well-known/jwks.json". The key set is cached and refetched once a token
+// signed with a new key is seen, so signing key rotations are picked up right away.
+// A single fetch is in flight at a time and the cached keys are served meanwhile,
+// so a slow key set url doesn't hold up verification of tokens signed with known keys.
+type RemoteJwks struct {
+	url           string
+	client        *http.Client
+	cacheDuration time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]*ecdsa.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetchErr    error
+	// Closed once the fetch in flight completes, nil if there is none.
+	fetching chan struct{}
+}
+
+type RemoteJwksConf struct {
+	Url string
+	// Defaults to a client with a 10 seconds timeout.
+	HttpClient *http.Client
+	// Defaults to 5 minutes.
+	CacheDuration time.Duration
+}
+
+func NewRemoteJwks(conf RemoteJwksConf) *RemoteJwks {
+	j := &RemoteJwks{
+		url:           conf.Url,
+		client:        conf.HttpClient,
+		cacheDuration: conf.CacheDuration,
+	}
+	if j.client == nil {
+		j.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if j.cacheDuration <= 0 {
+		j.cacheDuration = defaultRemoteJwksCacheDuration
+	}
+	return j
+}
+
+// Key the token with the key id is verified with.
+func (j *RemoteJwks) PublicKey(ctx context.Context, kid string) (*ecdsa.PublicKey, error) {
+	keys, err := j.keySet(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyId, kid)
+	}
+	return key, nil
+}
+
+// All keys of the set, for tokens created without a key id.
+func (j *RemoteJwks) PublicKeys(ctx context.Context) ([]*ecdsa.PublicKey, error) {
+	keys, err := j.keySet(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*ecdsa.PublicKey, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, key)
+	}
+	return out, nil
+}
+
+// Waits for the key set to be fetched until the context is done only if there are no cached keys
+// or the key id is unknown, otherwise the cached keys are returned while the key set is refetched.
+func (j *RemoteJwks) keySet(ctx context.Context, kid string) (map[string]*ecdsa.PublicKey, error) {
+	j.mu.Lock()
+	now := time.Now()
+	_, known := j.keys[kid]
+	unknown := kid != "" && !known
+	refresh := j.keys == nil ||
+		(now.Sub(j.attemptedAt) >= remoteJwksMinRefreshInterval && (unknown || now.Sub(j.fetchedAt) >= j.cacheDuration))
+	if refresh && j.fetching == nil {
+		j.fetching = make(chan struct{})
+		j.attemptedAt = now
+		go j.refresh(j.fetching)
+	}
+	keys, fetching := j.keys, j.fetching
+	j.mu.Unlock()
+
+	if keys != nil && (!unknown || fetching == nil) {
+		return keys, nil
+	}
+
+	select {
+	case <-fetching:
+	case <-ctx.Done():
+		if keys != nil {
+			return keys, nil
+		}
+		return nil, fmt.Errorf("failed to fetch jwks: %w", ctx.Err())
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.keys == nil {
+		return nil, j.fetchErr
+	}
+	// Keep verifying tokens with the cached keys while the key set is unavailable.
+	return j.keys, nil
+}
+
+func (j *RemoteJwks) refresh(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteJwksFetchTimeout)
+	defer cancel()
+
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		j.fetchErr = err
+	} else {
+		j.keys = keys
+		j.fetchedAt = time.Now()
+		j.fetchErr = nil
+	}
+	j.fetching = nil
+	close(done)
+}
+
+func (j *RemoteJwks) fetch(ctx context.Context) (map[string]*ecdsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwks request: %w", err)
+	}
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch jwks: unexpected status code %d", res.StatusCode)
+	}
+
+	var jwks Jwks
+	if err := json.NewDecoder(res.Body).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*ecdsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		// Keys of other types are not used to sign tokens.
+		key, err := jwk.Es256PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
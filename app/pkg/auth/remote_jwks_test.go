@@ -0,0 +1,85 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJwksServer(t *testing.T, delay time.Duration, fetches *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	tokenProv, err := getJwtProvider()
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(tokenProv.Jwks()))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRemoteJwksFetchesOnceForConcurrentLookups(t *testing.T) {
+	var fetches atomic.Int32
+	srv := newJwksServer(t, 50*time.Millisecond, &fetches)
+	jwks := auth.NewRemoteJwks(auth.RemoteJwksConf{Url: srv.URL})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys, err := jwks.PublicKeys(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, keys, 1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fetches.Load())
+}
+
+func TestRemoteJwksLookupIsBoundedByContext(t *testing.T) {
+	var fetches atomic.Int32
+	srv := newJwksServer(t, 500*time.Millisecond, &fetches)
+	jwks := auth.NewRemoteJwks(auth.RemoteJwksConf{Url: srv.URL})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := jwks.PublicKey(ctx, "key-id")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second, "lookup must not wait for the fetch in flight")
+}
+
+func TestRemoteJwksServesCachedKeysForUnknownKeyIds(t *testing.T) {
+	var fetches atomic.Int32
+	srv := newJwksServer(t, 0, &fetches)
+	jwks := auth.NewRemoteJwks(auth.RemoteJwksConf{Url: srv.URL})
+
+	_, err := jwks.PublicKeys(context.Background())
+	require.NoError(t, err)
+
+	// Refetching for unknown key ids is rate limited.
+	for range 5 {
+		_, err := jwks.PublicKey(context.Background(), "random-kid")
+		assert.ErrorIs(t, err, auth.ErrUnknownKeyId)
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Time-based one-time passwords as specified by RFC 6238 with HMAC-SHA1,
+// compatible with common authenticator apps.
+type Totp struct {
+	Digits int
+	Period time.Duration
+	// Amount of periods before and after the current one codes are accepted for
+	// to compensate clock drift between the server and the authenticator app.
+	Skew int
+}
+
+func DefaultTotp() Totp {
+	return Totp{
+		Digits: 6,
+		Period: 30 * time.Second,
+		Skew:   1,
+	}
+}
+
+// Generate random base32 encoded secret shared with the authenticator app.
+func GenerateTotpSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return totpSecretEncoding.EncodeToString(secret), nil
+}
+
+// Key URI to be rendered as a QR code for the authenticator app,
+// see https://github.com/google/google-authenticator/wiki/Key-Uri-Format.
+func (t Totp) Uri(issuer, accountName, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(t.Digits))
+	params.Set("period", fmt.Sprint(int(t.Period.Seconds())))
+
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: params.Encode(),
+	}).String()
+}
+
+// Counter of the period the time belongs to.
+func (t Totp) Counter(at time.Time) uint64 {
+	return uint64(at.Unix() / int64(t.Period.Seconds()))
+}
+
+func (t Totp) Code(secret string, at time.Time) (string, error) {
+	key, err := decodeTotpSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, t.Counter(at), t.Digits), nil
+}
+
+// Verify the code against the periods around the time.
+// Returns the counter of the matched period, so that callers can reject reused codes.
+func (t Totp) Verify(secret, code string, at time.Time) (uint64, bool, error) {
+	key, err := decodeTotpSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(code) != t.Digits {
+		return 0, false, nil
+	}
+
+	current := t.Counter(at)
+	for i := -t.Skew; i <= t.Skew; i++ {
+		counter := current + uint64(i)
+		if subtle.ConstantTimeCompare([]byte(hotp(key, counter, t.Digits)), []byte(code)) == 1 {
+			return counter, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func decodeTotpSecret(secret string) ([]byte, error) {
+	key, err := totpSecretEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode totp secret: %w", err)
+	}
+	return key, nil
+}
+
+// HMAC-based one-time password as specified by RFC 4226.
+func hotp(key []byte, counter uint64, digits int) string {
+	mac := hmac.New(sha1.New, key)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
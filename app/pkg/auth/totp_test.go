@@ -0,0 +1,72 @@
+package auth_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bratushkadan/floral/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 Appendix B test vectors for HMAC-SHA1.
+func TestTotpCodeRfc6238(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	totp := auth.Totp{Digits: 8, Period: 30 * time.Second}
+
+	for unix, code := range map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	} {
+		got, err := totp.Code(secret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, code, got, "time %d", unix)
+	}
+}
+
+func TestTotpVerify(t *testing.T) {
+	secret, err := auth.GenerateTotpSecret()
+	require.NoError(t, err)
+
+	totp := auth.DefaultTotp()
+	now := time.Unix(1742299200, 0)
+
+	code, err := totp.Code(secret, now)
+	require.NoError(t, err)
+
+	counter, ok, err := totp.Verify(secret, code, now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Counter(now), counter)
+
+	_, ok, err = totp.Verify(secret, code, now.Add(totp.Period))
+	require.NoError(t, err)
+	assert.True(t, ok, "code of the previous period must be accepted")
+
+	_, ok, err = totp.Verify(secret, code, now.Add(2*totp.Period))
+	require.NoError(t, err)
+	assert.False(t, ok, "code older than skew must be rejected")
+
+	_, ok, err = totp.Verify(secret, "12345", now)
+	require.NoError(t, err)
+	assert.False(t, ok, "code of wrong length must be rejected")
+}
+
+func TestTotpUri(t *testing.T) {
+	uri, err := url.Parse(auth.DefaultTotp().Uri("Floral", "user@example.com", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Floral:user@example.com", uri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal(t, "Floral", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+	assert.Equal(t, "30", uri.Query().Get("period"))
+}
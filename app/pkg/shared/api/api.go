@@ -10,3 +10,9 @@ type AccountConfirmationMessage struct {
 	Id    string `json:"id"`
 	Email string `json:"email"`
 }
+
+type SecurityEventMessage struct {
+	Type      string            `json:"type"`
+	AccountId string            `json:"account_id"`
+	Details   map[string]string `json:"details,omitempty"`
+}
@@ -2,7 +2,9 @@ package xhttp
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"strings"
 )
 
 func HandleReadiness(ctx context.Context) http.HandlerFunc {
@@ -38,3 +40,43 @@ type ErrorResponseErr struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
+
+// Reverse proxies in front of the services appending to X-Forwarded-For, the API gateway only.
+const TrustedProxies = 1
+
+// Resolve client ip address from the X-Forwarded-For hop appended by the API gateway.
+func ClientIp(r *http.Request) string {
+	return ClientIpBehindProxies(r, TrustedProxies)
+}
+
+// Resolve client ip address from the hop appended by the outermost of the trusted proxies.
+// Hops before it are set by the client and can't be trusted. Falls back to the remote address
+// if there are fewer hops than trusted proxies.
+func ClientIpBehindProxies(r *http.Request, trustedProxies int) string {
+	var hops []string
+	for _, forwardedFor := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(forwardedFor, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	if trustedProxies > 0 && len(hops) >= trustedProxies {
+		if ip := hops[len(hops)-trustedProxies]; ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Extract token from the "Authorization: Bearer <token>" header.
+func BearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	return token, true
+}
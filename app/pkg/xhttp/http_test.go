@@ -0,0 +1,38 @@
+package xhttp_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bratushkadan/floral/pkg/xhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIpIgnoresSpoofedHops(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/v1/users/:authenticate", nil)
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	assert.Equal(t, "203.0.113.7", xhttp.ClientIp(r))
+
+	for _, spoofed := range []string{"10.0.0.1", "198.51.100.1, 10.0.0.2", "garbage"} {
+		r.Header.Set("X-Forwarded-For", spoofed+", 203.0.113.7")
+		assert.Equal(t, "203.0.113.7", xhttp.ClientIp(r), spoofed)
+	}
+}
+
+func TestClientIpBehindProxies(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/v1/users/:authenticate", nil)
+	r.Header.Add("X-Forwarded-For", "10.0.0.1, 203.0.113.7")
+	r.Header.Add("X-Forwarded-For", "192.0.2.10")
+
+	assert.Equal(t, "192.0.2.10", xhttp.ClientIpBehindProxies(r, 1))
+	assert.Equal(t, "203.0.113.7", xhttp.ClientIpBehindProxies(r, 2))
+}
+
+func TestClientIpFallsBackToRemoteAddr(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/v1/users/:authenticate", nil)
+	r.RemoteAddr = "192.0.2.1:43210"
+	assert.Equal(t, "192.0.2.1", xhttp.ClientIp(r))
+
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	assert.Equal(t, "192.0.2.1", xhttp.ClientIpBehindProxies(r, 2), "fewer hops than trusted proxies")
+}